@@ -0,0 +1,38 @@
+"""
+This script compares the running times of the regular (gopls-based) and the fast (locally parsed) symbol overview
+for some files of the Go test repository and checks that both yield the same result.
+Requires gopls to be installed.
+"""
+
+import os
+import time
+
+from serena.agent import SerenaAgent
+from serena.config.serena_config import SerenaConfig
+from serena.constants import REPO_ROOT
+from serena.tools import GetSymbolsOverviewTool
+
+RELATIVE_PATHS = ["base.go", "child.go", "processor.go"]
+NUM_REPETITIONS = 20
+
+if __name__ == "__main__":
+    project_root = os.path.join(REPO_ROOT, "test", "resources", "repos", "go", "test_repo")
+    agent = SerenaAgent(project=project_root, serena_config=SerenaConfig(gui_log_window_enabled=False, web_dashboard=False))
+    overview_tool = agent.get_tool(GetSymbolsOverviewTool)
+
+    def measure(relative_path: str, fast: bool) -> tuple[float, str]:
+        start_time = time.perf_counter()
+        result = ""
+        for _ in range(NUM_REPETITIONS):
+            result = agent.execute_task(lambda: overview_tool.apply(relative_path, fast=fast))
+        return (time.perf_counter() - start_time) / NUM_REPETITIONS, result
+
+    for path in RELATIVE_PATHS:
+        # warm up (starting the language server, populating caches)
+        measure(path, fast=False)
+        regular_time, regular_result = measure(path, fast=False)
+        fast_time, fast_result = measure(path, fast=True)
+        print(
+            f"{path}: regular {regular_time * 1000:.2f} ms, fast {fast_time * 1000:.2f} ms "
+            f"(speedup {regular_time / fast_time:.1f}x), identical results: {regular_result == fast_result}"
+        )
@@ -7,6 +7,7 @@ import os
 import platform
 import sys
 import threading
+import time
 import webbrowser
 from collections import defaultdict
 from collections.abc import Callable
@@ -24,6 +25,7 @@ from serena.analytics import RegisteredTokenCountEstimator, ToolUsageStats
 from serena.config.context_mode import RegisteredContext, SerenaAgentContext, SerenaAgentMode
 from serena.config.serena_config import SerenaConfig, ToolInclusionDefinition, ToolSet, get_serena_managed_in_project_dir
 from serena.dashboard import SerenaDashboardAPI
+from serena.indexing import IndexingProgress, IndexingStats, SymbolIndexer
 from serena.project import Project
 from serena.prompt_factory import SerenaPromptFactory
 from serena.tools import ActivateProjectTool, GetCurrentConfigTool, Tool, ToolMarker, ToolRegistry
@@ -140,6 +142,14 @@ class SerenaAgent:
         self.language_server: SolidLanguageServer | None = None
         self.memories_manager: MemoriesManager | None = None
         self.lines_read: LinesRead | None = None
+        self._edited_files: set[str] = set()
+        """
+        the relative paths of the files of the active project which were edited via the tools
+        """
+        self._symbol_indexer: SymbolIndexer | None = None
+        """
+        the indexer of the reindexing run in progress (if any)
+        """
 
         # adjust log level
         serena_log_level = self.serena_config.log_level
@@ -445,6 +455,7 @@ class SerenaAgent:
         # initialize project-specific instances which do not depend on the language server
         self.memories_manager = MemoriesManager(project.project_root)
         self.lines_read = LinesRead()
+        self._edited_files = set()
 
         def init_language_server() -> None:
             # start the language server
@@ -598,6 +609,69 @@ class SerenaAgent:
                 f"Failed to start the language server for {self._active_project.project_name} at {self._active_project.project_root}"
             )
 
+    def reindex(
+        self,
+        relative_paths: list[str],
+        force: bool = False,
+        max_duration_seconds: float | None = None,
+        progress_callback: Callable[[IndexingProgress], None] | None = None,
+    ) -> IndexingStats:
+        """
+        Indexes the symbols of the given files with the current language server, reusing its cache for unchanged files.
+        The progress is logged and can be queried via `get_reindexing_progress` while the run is in progress;
+        the run can be cancelled via `cancel_reindexing`.
+
+        :param relative_paths: the relative paths of the files to index
+        :param force: whether to reparse all files, even if their cached symbols are up to date
+        :param max_duration_seconds: the duration after which the run is cancelled (checked after each file); None for no limit
+        :param progress_callback: a function to call with the progress after each processed file
+        :return: the summary of the run
+        """
+        assert self.language_server is not None
+        indexer = SymbolIndexer(self.language_server)
+        self._symbol_indexer = indexer
+        last_log_time = 0.0
+        deadline = time.time() + max_duration_seconds if max_duration_seconds is not None else None
+
+        def log_progress(progress: IndexingProgress) -> None:
+            nonlocal last_log_time
+            now = time.time()
+            if deadline is not None and now >= deadline and not indexer.is_cancelled():
+                log.info(f"Reindexing exceeded the maximum duration of {max_duration_seconds}s; cancelling")
+                indexer.cancel()
+            # limit the number of log messages for large projects
+            if now - last_log_time >= 1.0 or progress.num_files_processed == progress.num_files_total:
+                last_log_time = now
+                log.info(f"Reindexing: {progress.num_files_processed}/{progress.num_files_total} files processed ({progress.current_file})")
+            if progress_callback is not None:
+                progress_callback(progress)
+
+        try:
+            return indexer.index(relative_paths, progress_callback=log_progress, force=force)
+        finally:
+            self._symbol_indexer = None
+
+    def get_reindexing_progress(self) -> IndexingProgress | None:
+        """
+        :return: the progress of the reindexing run in progress or None if there is no such run
+        """
+        indexer = self._symbol_indexer
+        return indexer.progress if indexer is not None else None
+
+    def cancel_reindexing(self) -> bool:
+        """
+        Requests the cancellation of the reindexing run in progress (which can be called from any thread,
+        as reindexing itself blocks the agent's task executor).
+
+        :return: whether there was a run to cancel
+        """
+        indexer = self._symbol_indexer
+        if indexer is None:
+            return False
+        log.info("Cancelling the reindexing run ...")
+        indexer.cancel()
+        return True
+
     def get_tool(self, tool_class: type[TTool]) -> TTool:
         return self._all_tools[tool_class]  # type: ignore
 
@@ -607,6 +681,13 @@ class SerenaAgent:
     def mark_file_modified(self, relative_path: str) -> None:
         assert self.lines_read is not None
         self.lines_read.invalidate_lines_read(relative_path)
+        self._edited_files.add(relative_path)
+
+    def get_edited_files(self) -> set[str]:
+        """
+        :return: the relative paths of the files of the active project which were edited via the tools
+        """
+        return set(self._edited_files)
 
     def __del__(self) -> None:
         """
@@ -28,6 +28,7 @@ from serena.constants import (
     USER_CONTEXT_YAMLS_DIR,
     USER_MODE_YAMLS_DIR,
 )
+from serena.indexing import SymbolIndexer
 from serena.mcp import SerenaMCPFactory, SerenaMCPFactorySingleProcess
 from serena.project import Project
 from serena.tools import FindReferencingSymbolsTool, FindSymbolTool, GetSymbolsOverviewTool, SearchForPatternTool, ToolRegistry
@@ -463,29 +464,18 @@ class ProjectCommands(AutoRegisteringGroup):
         ls = proj.create_language_server(log_level=lvl, ls_timeout=timeout, ls_specific_settings=serena_config.ls_specific_settings)
         log_file = os.path.join(project, ".serena", "logs", "indexing.txt")
 
-        collected_exceptions: list[Exception] = []
-        files_failed = []
         with ls.start_server():
             files = proj.gather_source_files()
-            for i, f in enumerate(tqdm(files, desc="Indexing")):
-                try:
-                    ls.request_document_symbols(f, include_body=False)
-                    ls.request_document_symbols(f, include_body=True)
-                except Exception as e:
-                    log.error(f"Failed to index {f}, continuing.")
-                    collected_exceptions.append(e)
-                    files_failed.append(f)
-                if (i + 1) % 10 == 0:
-                    ls.save_cache()
-            ls.save_cache()
+            with tqdm(total=len(files), desc="Indexing") as progress_bar:
+                stats = SymbolIndexer(ls).index(files, progress_callback=lambda progress: progress_bar.update(1))
         click.echo(f"Symbols saved to {ls.cache_path}")
-        if len(files_failed) > 0:
+        if len(stats.failed_files) > 0:
             os.makedirs(os.path.dirname(log_file), exist_ok=True)
             with open(log_file, "w") as f:
-                for file, exception in zip(files_failed, collected_exceptions, strict=True):
+                for file, error in stats.failed_files.items():
                     f.write(f"{file}\n")
-                    f.write(f"{exception}\n")
-            click.echo(f"Failed to index {len(files_failed)} files, see:\n{log_file}")
+                    f.write(f"{error}\n")
+            click.echo(f"Failed to index {len(stats.failed_files)} files, see:\n{log_file}")
 
     @staticmethod
     @click.command("is_ignored_path", help="Check if a path is ignored by the project configuration.")
@@ -626,7 +616,7 @@ class ProjectCommands(AutoRegisteringGroup):
                 find_symbol_result = agent.execute_task(
                     lambda: find_symbol_tool.apply(symbol_name, relative_path=target_file, include_body=True)
                 )
-                find_symbol_data = json.loads(find_symbol_result)
+                find_symbol_data = json.loads(find_symbol_result)["symbols"]
                 log.info("FindSymbolTool found %d matches for symbol %s", len(find_symbol_data), symbol_name)
 
                 # Test 3: FindReferencingSymbolsTool
@@ -1,14 +1,16 @@
+import difflib
 import json
 import logging
 import os
 from abc import ABC, abstractmethod
 from collections.abc import Iterable, Iterator, Reversible
-from contextlib import contextmanager
+from contextlib import ExitStack, contextmanager
 from typing import TYPE_CHECKING, Generic, Optional, TypeVar
 
 from serena.symbol import JetBrainsSymbol, LanguageServerSymbol, LanguageServerSymbolRetriever, PositionInFile, Symbol
-from solidlsp import SolidLanguageServer
+from solidlsp import SolidLanguageServer, ls_types
 from solidlsp.ls import LSPFileBuffer
+from solidlsp.ls_config import Language
 from solidlsp.ls_utils import TextUtils
 
 from .project import Project
@@ -21,11 +23,146 @@ if TYPE_CHECKING:
 log = logging.getLogger(__name__)
 TSymbol = TypeVar("TSymbol", bound=Symbol)
 
+_LINE_COMMENT_PREFIXES: dict[Language, str] = {
+    Language.GO: "//",
+    Language.JAVA: "//",
+    Language.KOTLIN: "//",
+    Language.CSHARP: "//",
+    Language.RUST: "//",
+    Language.CPP: "//",
+    Language.TYPESCRIPT: "//",
+    Language.DART: "//",
+    Language.PHP: "//",
+    Language.SWIFT: "//",
+    Language.ZIG: "//",
+    Language.AL: "//",
+    Language.PYTHON: "#",
+    Language.RUBY: "#",
+    Language.R: "#",
+    Language.ELIXIR: "#",
+    Language.TERRAFORM: "#",
+    Language.BASH: "#",
+    Language.NIX: "#",
+    Language.JULIA: "#",
+    Language.LUA: "--",
+    Language.CLOJURE: ";;",
+    Language.ERLANG: "%",
+}
+"""
+the prefixes of line comments (which are used for doc comments) in the files of the respective languages
+"""
+
+
+def get_unified_diff(original_contents: dict[str, str], new_contents: dict[str, str]) -> str:
+    """
+    :param original_contents: maps the relative paths of files to their original contents
+    :param new_contents: maps the relative paths of (some of) these files to their new contents
+    :return: the unified diff of the changes, ordered by path
+    """
+    diff_lines: list[str] = []
+    for relative_path in sorted(new_contents):
+        diff_lines.extend(
+            difflib.unified_diff(
+                original_contents[relative_path].splitlines(keepends=True),
+                new_contents[relative_path].splitlines(keepends=True),
+                fromfile=f"a/{relative_path}",
+                tofile=f"b/{relative_path}",
+            )
+        )
+    return "".join(diff_lines)
+
 
 class CodeEditor(Generic[TSymbol], ABC):
-    def __init__(self, project_root: str, agent: Optional["SerenaAgent"] = None) -> None:
+    def __init__(self, project_root: str, agent: Optional["SerenaAgent"] = None, encoding: str = "utf-8") -> None:
         self.project_root = project_root
         self.agent = agent
+        self.encoding = encoding
+        """
+        the encoding of the project's files
+        """
+        self._transaction: CodeEditor.EditTransaction | None = None
+        """
+        the active edit transaction (if any)
+        """
+
+    class EditTransaction:
+        """
+        Handle of an active edit transaction (see `edit_transaction`)
+        """
+
+        def __init__(self, code_editor: "CodeEditor") -> None:
+            self._code_editor = code_editor
+            self.original_contents: dict[str, str] = {}
+            """
+            the original contents of the files edited within the transaction
+            """
+            self.pending_contents: dict[str, str] = {}
+            """
+            the contents of the files edited within the transaction including the edits, which are only written to disk
+            when the transaction is completed
+            """
+            self._edited_files: dict[str, CodeEditor.EditedFile] = {}
+            self._open_files = ExitStack()
+            """
+            keeps the edited files open (e.g. in the language server) until the transaction ends, such that subsequent
+            edits within the transaction build upon the pending contents
+            """
+
+        def _get_edited_file(self, relative_path: str) -> "CodeEditor.EditedFile":
+            edited_file = self._edited_files.get(relative_path)
+            if edited_file is None:
+                edited_file = self._open_files.enter_context(self._code_editor._open_file_context(relative_path))
+                self._edited_files[relative_path] = edited_file
+                self.original_contents[relative_path] = edited_file.get_contents()
+                self.pending_contents[relative_path] = edited_file.get_contents()
+            return edited_file
+
+        def _commit(self) -> None:
+            for relative_path in self.get_edited_files():
+                self._code_editor._write_file(relative_path, self.pending_contents[relative_path])
+
+        def _discard(self) -> None:
+            for relative_path in self.get_edited_files():
+                log.info(f"Discarding the pending edits of {relative_path}")
+                edited_file = self._edited_files[relative_path]
+                content = edited_file.get_contents()
+                end_line = content.count("\n")
+                end_column = len(content) - (content.rfind("\n") + 1)
+                edited_file.delete_text_between_positions(PositionInFile(line=0, col=0), PositionInFile(line=end_line, col=end_column))
+                edited_file.insert_text_at_position(PositionInFile(line=0, col=0), self.original_contents[relative_path])
+
+        def _close(self) -> None:
+            self._open_files.close()
+
+        def get_edited_files(self) -> list[str]:
+            """
+            :return: the relative paths of the files whose pending contents differ from their original contents
+            """
+            return [p for p, content in self.pending_contents.items() if self.original_contents[p] != content]
+
+        def get_unified_diff(self) -> str:
+            """
+            :return: the unified diff of the (pending) changes made to the edited files so far
+            """
+            return get_unified_diff(self.original_contents, {p: self.pending_contents[p] for p in self.get_edited_files()})
+
+        def preview(self) -> dict[str, LanguageServerSymbolRetriever.SymbolDiff]:
+            """
+            Determines the symbol-level changes the transaction makes, i.e. the symbols added, removed and modified by
+            the edits so far, such that they can be reviewed before the transaction is completed (and the edits are
+            written to disk).
+
+            :return: a mapping from the relative paths of the edited files to the symbol differences between their
+                original and pending contents (omitting files without symbol-level changes)
+            """
+            result = {}
+            for relative_path in self.get_edited_files():
+                diff = self._code_editor._diff_symbols(
+                    relative_path, self.original_contents[relative_path], self.pending_contents[relative_path]
+                )
+                if not diff.is_empty():
+                    result[relative_path] = diff
+            return result
 
     class EditedFile(ABC):
         @abstractmethod
@@ -53,16 +190,64 @@ class CodeEditor(Generic[TSymbol], ABC):
     def _edited_file_context(self, relative_path: str) -> Iterator["CodeEditor.EditedFile"]:
         """
         Context manager for editing a file.
+        Within a transaction, the edits are kept pending until the transaction is completed; otherwise, the file is
+        saved right away.
         """
+        if self._transaction is not None:
+            edited_file = self._transaction._get_edited_file(relative_path)
+            yield edited_file
+            self._transaction.pending_contents[relative_path] = edited_file.get_contents()
+            return
         with self._open_file_context(relative_path) as edited_file:
             yield edited_file
-            # save the file
-            abs_path = os.path.join(self.project_root, relative_path)
-            with open(abs_path, "w", encoding="utf-8") as f:
-                f.write(edited_file.get_contents())
-            # notify agent (if provided)
-            if self.agent is not None:
-                self.agent.mark_file_modified(relative_path)
+            self._write_file(relative_path, edited_file.get_contents())
+
+    def _write_file(self, relative_path: str, content: str) -> None:
+        """
+        Saves the given content of an edited file and notifies the agent (if provided).
+        """
+        abs_path = os.path.join(self.project_root, relative_path)
+        with open(abs_path, "w", encoding=self.encoding) as f:
+            f.write(content)
+        if self.agent is not None:
+            self.agent.mark_file_modified(relative_path)
+
+    @contextmanager
+    def edit_transaction(self) -> Iterator["CodeEditor.EditTransaction"]:
+        """
+        Context manager for applying several edits (possibly to several files) all-or-nothing: the edits made within the
+        context are kept pending (for the language server, in the buffers of the edited files) and are only written to disk
+        when the context is exited normally; if an exception is raised within the context, the pending edits are discarded,
+        leaving the files unchanged, and the exception is re-raised.
+        Nested transactions are part of the enclosing transaction.
+        The transaction handle allows previewing the symbol-level changes before the transaction is completed.
+        """
+        if self._transaction is not None:
+            yield self._transaction
+            return
+        transaction = self.EditTransaction(self)
+        self._transaction = transaction
+        try:
+            try:
+                yield transaction
+            except BaseException:
+                self._transaction = None
+                transaction._discard()
+                raise
+            self._transaction = None
+            transaction._commit()
+        finally:
+            self._transaction = None
+            transaction._close()
+
+    def _diff_symbols(self, relative_path: str, original_content: str, content: str) -> LanguageServerSymbolRetriever.SymbolDiff:
+        """
+        :param relative_path: the relative path of an edited file
+        :param original_content: the content of the file before the edits
+        :param content: the content of the file including the edits
+        :return: the symbol differences between the original and the edited content of the file
+        """
+        raise NotImplementedError(f"Comparing symbols is not supported by {self.__class__.__name__}")
 
     @abstractmethod
     def _find_unique_symbol(self, name_path: str, relative_file_path: str) -> TSymbol:
@@ -111,11 +296,55 @@ class CodeEditor(Generic[TSymbol], ABC):
     def _count_trailing_newlines(cls, text: Reversible) -> int:
         return cls._count_leading_newlines(reversed(text))
 
-    def insert_after_symbol(self, name_path: str, relative_file_path: str, body: str) -> None:
+    @staticmethod
+    def _get_line_comment_prefix(relative_file_path: str) -> str:
+        """
+        :param relative_file_path: the relative path of the file
+        :return: the prefix of line comments in the file's language, e.g. `//` for Go or `#` for Python
+        """
+        file_name = os.path.basename(relative_file_path)
+        for language, prefix in _LINE_COMMENT_PREFIXES.items():
+            if language.get_source_fn_matcher().is_relevant_filename(file_name):
+                return prefix
+        raise ValueError(f"Doc comments are not supported for {relative_file_path}, since its language is unknown")
+
+    @classmethod
+    def _add_doc_comment(cls, body: str, doc_comment: str | None, relative_file_path: str) -> str:
+        """
+        Places the given doc comment directly above the (first line of the) given body, using the indentation of that line.
+
+        :param body: the body to which to add the doc comment
+        :param doc_comment: the doc comment; lines which are not yet comment lines are turned into line comments
+            of the file's language (e.g. `//` for Go, `#` for Python).
+            If None or empty, the body is returned unchanged.
+        :param relative_file_path: the relative path of the file into which the body is inserted
+        :return: the body including the doc comment
+        """
+        if not doc_comment or not doc_comment.strip():
+            return body
+        comment_prefix = cls._get_line_comment_prefix(relative_file_path)
+        stripped_body = body.lstrip("\r\n")
+        leading_newlines = body[: len(body) - len(stripped_body)]
+        indent = stripped_body[: len(stripped_body) - len(stripped_body.lstrip(" \t"))]
+        comment_lines = []
+        for line in doc_comment.strip("\r\n").splitlines():
+            line = line.strip()
+            if not line.startswith(comment_prefix):
+                line = (f"{comment_prefix} " + line).rstrip()
+            comment_lines.append(indent + line)
+        return leading_newlines + "\n".join(comment_lines) + "\n" + stripped_body
+
+    def insert_after_symbol(self, name_path: str, relative_file_path: str, body: str, doc_comment: str | None = None) -> None:
         """
         Inserts content after the symbol with the given name in the given file.
+
+        :param name_path: the name path of the symbol after which to insert the content
+        :param relative_file_path: the relative path of the file in which the symbol is defined
+        :param body: the content to insert
+        :param doc_comment: an optional doc comment to place directly above the inserted content
         """
         symbol = self._find_unique_symbol(name_path, relative_file_path)
+        body = self._add_doc_comment(body, doc_comment, relative_file_path)
 
         # make sure body always ends with at least one newline
         if not body.endswith("\n"):
@@ -145,11 +374,17 @@ class CodeEditor(Generic[TSymbol], ABC):
         with self._edited_file_context(relative_file_path) as edited_file:
             edited_file.insert_text_at_position(PositionInFile(line, col), body)
 
-    def insert_before_symbol(self, name_path: str, relative_file_path: str, body: str) -> None:
+    def insert_before_symbol(self, name_path: str, relative_file_path: str, body: str, doc_comment: str | None = None) -> None:
         """
         Inserts content before the symbol with the given name in the given file.
+
+        :param name_path: the name path of the symbol before which to insert the content
+        :param relative_file_path: the relative path of the file in which the symbol is defined
+        :param body: the content to insert
+        :param doc_comment: an optional doc comment to place directly above the inserted content
         """
         symbol = self._find_unique_symbol(name_path, relative_file_path)
+        body = self._add_doc_comment(body, doc_comment, relative_file_path)
         symbol_start_pos = symbol.get_body_start_position_or_raise()
 
         # insert position is the start of line where the symbol is defined
@@ -171,8 +406,24 @@ class CodeEditor(Generic[TSymbol], ABC):
 
         # apply edit
         with self._edited_file_context(relative_file_path) as edited_file:
+            if relative_file_path.endswith(".go"):
+                # in Go, the doc comment directly precedes a declaration (and is not part of the symbol's range),
+                # so we insert before the doc comment in order to not separate it from the declaration
+                line = self._get_go_doc_comment_start_line(edited_file.get_contents(), line)
             edited_file.insert_text_at_position(PositionInFile(line=line, col=col), body)
 
+    @staticmethod
+    def _get_go_doc_comment_start_line(contents: str, line: int) -> int:
+        """
+        :param contents: the contents of a Go file
+        :param line: the line in which a declaration starts
+        :return: the line in which the declaration's doc comment starts (the given line if there is no doc comment)
+        """
+        lines = contents.splitlines()
+        while line > 0 and line - 1 < len(lines) and lines[line - 1].strip().startswith("//"):
+            line -= 1
+        return line
+
     def insert_at_line(self, relative_path: str, line: int, content: str) -> None:
         """
         Inserts content at the given line in the given file.
@@ -200,6 +451,21 @@ class CodeEditor(Generic[TSymbol], ABC):
             end_pos = PositionInFile(line=end_line_for_delete, col=end_col)
             edited_file.delete_text_between_positions(start_pos, end_pos)
 
+    def replace_text(self, relative_path: str, start_pos: PositionInFile, end_pos: PositionInFile, text: str) -> None:
+        """
+        Replaces the text between the given positions in the given file.
+
+        :param relative_path: the relative path of the file
+        :param start_pos: the start position of the text to replace
+        :param end_pos: the end position (exclusive) of the text to replace; equal to `start_pos` for pure insertions
+        :param text: the text to insert instead
+        """
+        with self._edited_file_context(relative_path) as edited_file:
+            if start_pos != end_pos:
+                edited_file.delete_text_between_positions(start_pos, end_pos)
+            if text:
+                edited_file.insert_text_at_position(start_pos, text)
+
     def delete_symbol(self, name_path: str, relative_file_path: str) -> None:
         """
         Deletes the symbol with the given name in the given file.
@@ -212,8 +478,8 @@ class CodeEditor(Generic[TSymbol], ABC):
 
 
 class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
-    def __init__(self, symbol_retriever: LanguageServerSymbolRetriever, agent: Optional["SerenaAgent"] = None):
-        super().__init__(project_root=symbol_retriever.get_language_server().repository_root_path, agent=agent)
+    def __init__(self, symbol_retriever: LanguageServerSymbolRetriever, agent: Optional["SerenaAgent"] = None, encoding: str = "utf-8"):
+        super().__init__(project_root=symbol_retriever.get_language_server().repository_root_path, agent=agent, encoding=encoding)
         self._symbol_retriever = symbol_retriever
 
     @property
@@ -240,6 +506,55 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
         with self._lang_server.open_file(relative_path) as file_buffer:
             yield self.EditedFile(self._lang_server, relative_path, file_buffer)
 
+    def _diff_symbols(self, relative_path: str, original_content: str, content: str) -> LanguageServerSymbolRetriever.SymbolDiff:
+        return self._symbol_retriever.diff_symbols(relative_path, original_content, content)
+
+    def format_file(self, relative_path: str, organize_imports: bool = False) -> None:
+        """
+        Formats the given file using the language server (for Go, gopls applies gofmt).
+
+        :param relative_path: the relative path of the file to format
+        :param organize_imports: whether to organize the file's imports before formatting it, i.e. to add missing
+            and remove unused imports (if supported by the language server)
+        """
+        with self._edited_file_context(relative_path):
+            if organize_imports:
+                edits = self._lang_server.request_source_action_edits(relative_path, "source.organizeImports")
+                self._lang_server.apply_text_edits(relative_path, edits)
+            self._lang_server.apply_text_edits(relative_path, self._lang_server.request_formatting(relative_path))
+
+    def get_rename_edits(self, name_path: str, relative_file_path: str, new_name: str) -> dict[str, list[ls_types.TextEdit]]:
+        """
+        Determines the edits renaming the symbol with the given name path in the given file along with all its references,
+        as computed by the language server, without applying them.
+
+        :param name_path: the name path of the symbol to rename
+        :param relative_file_path: the relative path of the file in which the symbol is defined
+        :param new_name: the new name of the symbol
+        :return: the edits by the relative paths of the files to change (with ranges referring to the current contents)
+        """
+        symbol = self._find_unique_symbol(name_path, relative_file_path)
+        location = symbol.location
+        if location.line is None or location.column is None:
+            raise ValueError(f"The symbol {name_path} has no position in {relative_file_path}")
+        file_edits = self._lang_server.request_rename(relative_file_path, location.line, location.column, new_name)
+        if not file_edits:
+            raise ValueError(f"The language server cannot rename {name_path} in {relative_file_path}")
+        return file_edits
+
+    def apply_text_edits(self, file_edits: dict[str, list[ls_types.TextEdit]]) -> None:
+        """
+        Applies text edits as computed by the language server (e.g. by `get_rename_edits`) within a transaction, i.e.
+        either all edits are applied or none.
+
+        :param file_edits: the edits by the relative paths of the files to change (with ranges referring to their current
+            contents)
+        """
+        with self.edit_transaction():
+            for relative_path, edits in sorted(file_edits.items()):
+                with self._edited_file_context(relative_path):
+                    self._lang_server.apply_text_edits(relative_path, edits)
+
     def _get_code_file_content(self, relative_path: str) -> str:
         """Get the content of a file using the language server."""
         return self._lang_server.language_server.retrieve_full_file_content(relative_path)
@@ -259,7 +574,7 @@ class LanguageServerCodeEditor(CodeEditor[LanguageServerSymbol]):
 class JetBrainsCodeEditor(CodeEditor[JetBrainsSymbol]):
     def __init__(self, project: Project, agent: Optional["SerenaAgent"] = None) -> None:
         self._project = project
-        super().__init__(project_root=project.project_root, agent=agent)
+        super().__init__(project_root=project.project_root, agent=agent, encoding=project.project_config.encoding)
 
     class EditedFile(CodeEditor.EditedFile):
         def __init__(self, relative_path: str, project: Project):
@@ -165,6 +165,8 @@ class ProjectConfig(ToolInclusionDefinition, ToStringMixin):
     ignored_paths: list[str] = field(default_factory=list)
     read_only: bool = False
     ignore_all_files_in_gitignore: bool = True
+    include_vendor: bool = False
+    include_generated: bool = True
     initial_prompt: str = ""
     encoding: str = DEFAULT_ENCODING
 
@@ -207,6 +209,9 @@ class ProjectConfig(ToolInclusionDefinition, ToStringMixin):
                     )
                 # find the language with the highest percentage
                 dominant_language = max(language_composition.keys(), key=lambda lang: language_composition[lang])
+                # a Go workspace file marks a Go monorepo, even if its modules are outnumbered by other files (e.g. a frontend)
+                if (project_root / "go.work").is_file() and Language.GO.value in language_composition:
+                    dominant_language = Language.GO.value
             else:
                 dominant_language = project_language.value
             config_with_comments = load_yaml(PROJECT_TEMPLATE_FILE, preserve_comments=True)
@@ -243,6 +248,8 @@ class ProjectConfig(ToolInclusionDefinition, ToStringMixin):
             included_optional_tools=data.get("included_optional_tools", []),
             read_only=data.get("read_only", False),
             ignore_all_files_in_gitignore=data.get("ignore_all_files_in_gitignore", True),
+            include_vendor=data.get("include_vendor", False),
+            include_generated=data.get("include_generated", True),
             initial_prompt=data.get("initial_prompt", ""),
             encoding=data.get("encoding", DEFAULT_ENCODING),
         )
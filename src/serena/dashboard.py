@@ -39,6 +39,13 @@ class ResponseToolStats(BaseModel):
     stats: dict[str, dict[str, int]]
 
 
+class ResponseReindexingProgress(BaseModel):
+    running: bool
+    num_files_processed: int = 0
+    num_files_total: int = 0
+    current_file: str | None = None
+
+
 class SerenaDashboardAPI:
     log = logging.getLogger(__qualname__)
 
@@ -104,6 +111,16 @@ class SerenaDashboardAPI:
             estimator_name = self._tool_usage_stats.token_estimator_name if self._tool_usage_stats else "unknown"
             return {"token_count_estimator_name": estimator_name}
 
+        @self._app.route("/get_reindexing_progress", methods=["GET"])
+        def get_reindexing_progress() -> dict[str, Any]:
+            result = self._get_reindexing_progress()
+            return result.model_dump()
+
+        @self._app.route("/cancel_reindexing", methods=["POST"])
+        def cancel_reindexing() -> dict[str, str]:
+            is_cancelled = self._agent.cancel_reindexing()
+            return {"status": "cancelling" if is_cancelled else "not running"}
+
         @self._app.route("/shutdown", methods=["PUT"])
         def shutdown() -> dict[str, str]:
             self._shutdown()
@@ -129,6 +146,17 @@ class SerenaDashboardAPI:
         if self._tool_usage_stats is not None:
             self._tool_usage_stats.clear()
 
+    def _get_reindexing_progress(self) -> ResponseReindexingProgress:
+        progress = self._agent.get_reindexing_progress()
+        if progress is None:
+            return ResponseReindexingProgress(running=False)
+        return ResponseReindexingProgress(
+            running=True,
+            num_files_processed=progress.num_files_processed,
+            num_files_total=progress.num_files_total,
+            current_file=progress.current_file,
+        )
+
     def _shutdown(self) -> None:
         log.info("Shutting down Serena")
         if self._shutdown_callback:
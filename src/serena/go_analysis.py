@@ -0,0 +1,1724 @@
+"""
+Go-specific code analysis, which complements the symbol information provided by gopls
+with information obtained from a textual analysis of the Go sources
+"""
+
+import json
+import logging
+import os
+import pathlib
+import re
+import subprocess
+import tempfile
+from collections.abc import Iterable
+from dataclasses import dataclass, field
+from enum import Enum
+from typing import Any
+
+from serena.symbol import LanguageServerSymbol, LanguageServerSymbolRetriever
+from serena.util.go_source import (
+    KEYWORDS,
+    GoDeclaration,
+    GoDeclarationKind,
+    GoFile,
+    GoImport,
+    GoInterfaceElement,
+    GoObjectSignature,
+    GoReferenceRole,
+    GoReturnStatement,
+    GoStructField,
+    GoToken,
+    GoTokenKind,
+    GoUnderlyingKind,
+    classify_reference,
+    classify_type_expression,
+    find_assignments,
+    find_matching_bracket,
+    find_return_statements,
+    get_file_name_build_constraint,
+    get_import_insertion,
+    get_import_removal,
+    get_named_type_identifier,
+    get_parameter_and_result_types,
+    get_parameter_names,
+    is_exported,
+    normalize_method_signature,
+    parse_go_file,
+    parse_go_work_use_directives,
+    parse_interface_elements,
+    parse_object_signature,
+    parse_struct_fields,
+    split_expression_list,
+    tokenize,
+)
+from serena.util.name_path import format_name_path
+from solidlsp import ls_types
+from solidlsp.ls_types import SymbolKind
+from solidlsp.util.subprocess_util import subprocess_kwargs
+
+log = logging.getLogger(__name__)
+
+_MODULE_DIRECTIVE_PATTERN = re.compile(r"^module\s+(\S+)", re.MULTILINE)
+_GO_CODE_BLOCK_PATTERN = re.compile(r"```go\n(.*?)\n```", re.DOTALL)
+_OVERLAY_FILE_PATH_PATTERN = re.compile(r"[^\s:]*?(serena-overlay-\d+-[^\s:/\\]+)")
+"""
+matches the paths of the files replacing overlaid files in the go command's output (see `find_compile_errors`)
+"""
+_UNUSED_IMPORT_ERROR_PATTERN = re.compile(r'^(.+?):\d+:\d+: "([^"]+)" imported (?:as \S+ )?and not used$')
+_UNDEFINED_ERROR_PATTERN = re.compile(r"^(.+?):\d+:\d+: undefined: (\w+)$")
+
+_DECLARATION_SYMBOL_KINDS = {
+    GoDeclarationKind.FUNCTION: SymbolKind.Function,
+    GoDeclarationKind.METHOD: SymbolKind.Method,
+    GoDeclarationKind.VARIABLE: SymbolKind.Variable,
+    GoDeclarationKind.CONSTANT: SymbolKind.Constant,
+}
+_TYPE_SYMBOL_KINDS = {
+    GoUnderlyingKind.STRUCT: SymbolKind.Struct,
+    GoUnderlyingKind.INTERFACE: SymbolKind.Interface,
+    GoUnderlyingKind.FUNC: SymbolKind.Function,
+}
+"""
+the symbol kinds gopls reports for type declarations by the syntax of the type; all other types are reported as classes
+"""
+
+
+@dataclass
+class InterfaceSatisfaction:
+    """
+    The result of checking whether a type's method set satisfies an interface
+    """
+
+    missing: list[dict[str, str]] = field(default_factory=list)
+    """
+    the interface methods the type lacks, each with the method `name` and the `expected` signature
+    """
+    mismatched: list[dict[str, str]] = field(default_factory=list)
+    """
+    the methods whose signatures differ, each with the method `name` and the `expected` and `actual` signatures
+    """
+    pointer_receiver_methods: list[str] = field(default_factory=list)
+    """
+    the names of the interface methods that only pointers to the type have (because of pointer receivers)
+    """
+
+    @property
+    def satisfied_by_pointer(self) -> bool:
+        return not self.missing and not self.mismatched
+
+    @property
+    def satisfied_by_value(self) -> bool:
+        return self.satisfied_by_pointer and not self.pointer_receiver_methods
+
+
+def check_interface_satisfaction(
+    method_set: dict[str, tuple[str, bool]], interface_methods: list[tuple[GoInterfaceElement, str | None]]
+) -> InterfaceSatisfaction:
+    """
+    :param method_set: the method set of a type (see `GoAnalyzer.get_method_set`)
+    :param interface_methods: the methods of an interface (see `GoAnalyzer.get_interface_methods`)
+    :return: the result of checking whether the type satisfies the interface
+    """
+    result = InterfaceSatisfaction()
+    checked_names = set()
+    for element, _ in interface_methods:
+        assert element.method_name is not None
+        if element.method_name in checked_names:
+            continue
+        checked_names.add(element.method_name)
+        expected_signature = normalize_method_signature(element.get_signature())
+        if element.method_name not in method_set:
+            result.missing.append({"name": element.method_name, "expected": element.method_name + expected_signature})
+            continue
+        actual_signature, pointer_only = method_set[element.method_name]
+        if actual_signature != expected_signature:
+            result.mismatched.append(
+                {
+                    "name": element.method_name,
+                    "expected": element.method_name + expected_signature,
+                    "actual": element.method_name + actual_signature,
+                }
+            )
+        elif pointer_only:
+            result.pointer_receiver_methods.append(element.method_name)
+    return result
+
+
+INDETERMINATE_TYPE = "indeterminate"
+"""
+the type reported for values whose concrete type cannot be determined by the (local) analysis
+"""
+
+
+@dataclass
+class ReturnFlow:
+    """
+    A return statement of a function along with the concrete types of the returned value
+    """
+
+    statement: GoReturnStatement
+    concrete_types: list[str]
+    """
+    the possible concrete types of the returned value (`INDETERMINATE_TYPE` if not inferable), e.g. ["*ConcreteProcessor"]
+    """
+
+
+def _is_constant_argument(argument: str, case_values: list[str]) -> bool:
+    tokens = tokenize(argument)
+    if len(tokens) != 1:
+        return False
+    return tokens[0].kind in (GoTokenKind.STRING, GoTokenKind.NUMBER, GoTokenKind.RUNE) or argument in case_values
+
+
+def _is_selected_clause(statement: GoReturnStatement, argument: str, selects_default: bool) -> bool:
+    assert statement.case_values is not None
+    return not statement.case_values if selects_default else argument in statement.case_values
+
+
+def select_return_flows(flows: list[ReturnFlow], parameter_names: list[str | None], arguments: list[str]) -> list[ReturnFlow]:
+    """
+    Selects the return flows which are possible for a call with the given arguments: if the function switches on one
+    of its parameters and a constant is passed for it, only the return statements of the selected clause and those
+    outside the switch remain (the latter only if the clause does not return unconditionally).
+
+    :param flows: the return flows of the function
+    :param parameter_names: the names of the function's parameters
+    :param arguments: the arguments of the call
+    :return: the selected flows
+    """
+    selected = list(flows)
+    switches = {id(f.statement.switch): f.statement.switch for f in flows if f.statement.switch is not None}
+    for switch in switches.values():
+        if switch.subject not in parameter_names or parameter_names.index(switch.subject) >= len(arguments):
+            continue
+        argument = arguments[parameter_names.index(switch.subject)]
+        if not _is_constant_argument(argument, switch.case_values):
+            continue
+        selects_default = argument not in switch.case_values
+        returns_unconditionally = any(
+            f.statement.switch is switch
+            and _is_selected_clause(f.statement, argument, selects_default)
+            and not f.statement.is_nested_in_clause
+            for f in flows
+        )
+        selected = [
+            f
+            for f in selected
+            if (f.statement.switch is not switch or _is_selected_clause(f.statement, argument, selects_default))
+            and not (returns_unconditionally and f.statement.start > switch.end)
+        ]
+    return selected
+
+
+def find_cycles(graph: dict[str, list[str]]) -> list[list[str]]:
+    """
+    Finds the elementary cycles in a directed graph that are discovered by a depth-first search
+    (which includes at least one cycle through every strongly connected component containing a cycle).
+
+    :param graph: a mapping from nodes to their successors
+    :return: the cycles, each given as a list of nodes starting and ending with the same node; cycles are rotated
+        such that they start with their smallest node and are sorted
+    """
+    cycles: set[tuple[str, ...]] = set()
+    visited: set[str] = set()
+    stack: list[str] = []
+    on_stack: set[str] = set()
+
+    def visit(node: str) -> None:
+        visited.add(node)
+        stack.append(node)
+        on_stack.add(node)
+        for successor in graph.get(node, []):
+            if successor in on_stack:
+                cycle = stack[stack.index(successor) :]
+                min_index = cycle.index(min(cycle))
+                cycles.add(tuple(cycle[min_index:] + cycle[:min_index]))
+            elif successor not in visited:
+                visit(successor)
+        stack.pop()
+        on_stack.remove(node)
+
+    for node in sorted(graph):
+        if node not in visited:
+            visit(node)
+    return [[*cycle, cycle[0]] for cycle in sorted(cycles)]
+
+
+class ApiChangeKind(Enum):
+    REMOVED = "removed"
+    ADDED = "added"
+    SIGNATURE_CHANGED = "signature_changed"
+    RECEIVER_CHANGED = "receiver_changed"
+    TYPE_CHANGED = "type_changed"
+    FIELD_REMOVED = "field_removed"
+    FIELD_TYPE_CHANGED = "field_type_changed"
+    FIELD_ADDED = "field_added"
+    INTERFACE_METHOD_REMOVED = "interface_method_removed"
+    INTERFACE_METHOD_CHANGED = "interface_method_changed"
+    INTERFACE_METHOD_ADDED = "interface_method_added"
+
+
+@dataclass
+class ApiChange:
+    """
+    A change to the exported API of a Go file
+    """
+
+    kind: ApiChangeKind
+    name_path: str
+    """
+    the name path of the affected symbol, e.g. "BaseStruct/GetName" for a method or a field of a type
+    """
+    is_breaking: bool
+    """
+    whether code using the API (or, for interfaces, implementing it) may no longer compile
+    """
+    old: str | None = None
+    """
+    the previous declaration (e.g. signature or type) of the affected element, if applicable
+    """
+    new: str | None = None
+    """
+    the new declaration of the affected element, if applicable
+    """
+
+
+def _get_api_declarations(go_file: GoFile) -> dict[str, GoDeclaration]:
+    """
+    :return: a mapping from name paths to the exported declarations of the file (methods only if their receiver type is exported)
+    """
+    declarations = {}
+    for declaration in go_file.declarations:
+        if not declaration.is_exported:
+            continue
+        if declaration.kind == GoDeclarationKind.METHOD:
+            if declaration.receiver_type is None or not is_exported(declaration.receiver_type):
+                continue
+            declarations[format_name_path([declaration.receiver_type, declaration.name])] = declaration
+        else:
+            declarations[declaration.name] = declaration
+    return declarations
+
+
+def _get_api_text(declaration: GoDeclaration, go_file: GoFile | None = None) -> str | None:
+    """
+    :return: the signature of a function or method (if the file is given) or the type of any other declaration
+        (with normalized whitespace)
+    """
+    if go_file is not None and declaration.kind in (GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD):
+        return go_file.get_signature_text(declaration)
+    if declaration.type_expr is None:
+        return None
+    return " ".join(declaration.type_expr.split())
+
+
+def _get_function_api_signature(go_file: GoFile, declaration: GoDeclaration) -> str:
+    signature = go_file.get_parameters_and_results_text(declaration)
+    assert signature is not None
+    return (declaration.type_params or "") + normalize_method_signature(signature)
+
+
+def _compare_type_apis(name: str, old_declaration: GoDeclaration, new_declaration: GoDeclaration) -> list[ApiChange]:
+    old_expr, new_expr = old_declaration.type_expr or "", new_declaration.type_expr or ""
+    old_kind, new_kind = classify_type_expression(old_expr), classify_type_expression(new_expr)
+    if (
+        old_declaration.is_alias != new_declaration.is_alias
+        or old_declaration.type_params != new_declaration.type_params
+        or old_kind != new_kind
+        or (old_kind not in (GoUnderlyingKind.STRUCT, GoUnderlyingKind.INTERFACE) and old_expr.split() != new_expr.split())
+    ):
+        return [ApiChange(ApiChangeKind.TYPE_CHANGED, name, True, old=_get_api_text(old_declaration), new=_get_api_text(new_declaration))]
+    changes = []
+    if old_kind == GoUnderlyingKind.STRUCT:
+
+        def exported_fields(type_expr: str) -> dict[str, GoStructField]:
+            return {f.name: f for f in parse_struct_fields(type_expr) if f.is_exported}
+
+        old_fields, new_fields = exported_fields(old_expr), exported_fields(new_expr)
+        for field_name, old_field in old_fields.items():
+            field_path = format_name_path([name, field_name])
+            new_field = new_fields.get(field_name)
+            if new_field is None:
+                changes.append(ApiChange(ApiChangeKind.FIELD_REMOVED, field_path, True, old=old_field.type_expr))
+            elif (new_field.type_expr, new_field.embedded) != (old_field.type_expr, old_field.embedded):
+                changes.append(
+                    ApiChange(ApiChangeKind.FIELD_TYPE_CHANGED, field_path, True, old=old_field.type_expr, new=new_field.type_expr)
+                )
+        for field_name, new_field in new_fields.items():
+            if field_name not in old_fields:
+                changes.append(ApiChange(ApiChangeKind.FIELD_ADDED, format_name_path([name, field_name]), False, new=new_field.type_expr))
+    else:
+        # an interface's elements are methods (compared by name and signature) and embedded types (compared textually)
+        def interface_elements(type_expr: str) -> dict[str, str]:
+            elements = {}
+            for element in parse_interface_elements(type_expr):
+                if element.method_name is not None:
+                    elements[element.method_name] = element.method_name + normalize_method_signature(element.get_signature())
+                else:
+                    elements[element.text] = element.text
+            return elements
+
+        old_elements, new_elements = interface_elements(old_expr), interface_elements(new_expr)
+        for key, old_element in old_elements.items():
+            new_element = new_elements.get(key)
+            if new_element is None:
+                changes.append(ApiChange(ApiChangeKind.INTERFACE_METHOD_REMOVED, format_name_path([name, key]), True, old=old_element))
+            elif new_element != old_element:
+                changes.append(
+                    ApiChange(ApiChangeKind.INTERFACE_METHOD_CHANGED, format_name_path([name, key]), True, old=old_element, new=new_element)
+                )
+        for key, new_element in new_elements.items():
+            if key not in old_elements:
+                # implementers of the interface no longer satisfy it
+                changes.append(ApiChange(ApiChangeKind.INTERFACE_METHOD_ADDED, format_name_path([name, key]), True, new=new_element))
+    return changes
+
+
+def compare_apis(old_file: GoFile, new_file: GoFile) -> list[ApiChange]:
+    """
+    Compares the exported APIs of two versions of a Go file: the exported functions, types (including exported struct fields
+    and interface methods), methods of exported types, variables and constants.
+    The comparison is purely textual, so changes of types declared elsewhere are not taken into account, and the elements
+    of embedded interfaces are compared by name only.
+
+    :param old_file: the current version of the file
+    :param new_file: the new version of the file
+    :return: the changes, in the order of the declarations in the respective version
+    """
+    old_declarations, new_declarations = _get_api_declarations(old_file), _get_api_declarations(new_file)
+    removed_types = {
+        name for name, d in old_declarations.items() if d.kind == GoDeclarationKind.TYPE and name not in new_declarations
+    }
+    changes = []
+    for name_path, old_declaration in old_declarations.items():
+        new_declaration = new_declarations.get(name_path)
+        if new_declaration is None or new_declaration.kind != old_declaration.kind:
+            # the methods of removed types are implied by the removal of the type
+            if old_declaration.receiver_type not in removed_types:
+                changes.append(ApiChange(ApiChangeKind.REMOVED, name_path, True, old=_get_api_text(old_declaration, old_file)))
+            continue
+        if old_declaration.kind in (GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD):
+            old_signature = _get_function_api_signature(old_file, old_declaration)
+            new_signature = _get_function_api_signature(new_file, new_declaration)
+            if old_signature != new_signature:
+                changes.append(ApiChange(ApiChangeKind.SIGNATURE_CHANGED, name_path, True, old=old_signature, new=new_signature))
+            if old_declaration.receiver_is_pointer != new_declaration.receiver_is_pointer:
+                # moving a method to a pointer receiver removes it from the method set of the value type
+                old_receiver = ("*" if old_declaration.receiver_is_pointer else "") + str(old_declaration.receiver_type)
+                new_receiver = ("*" if new_declaration.receiver_is_pointer else "") + str(new_declaration.receiver_type)
+                changes.append(
+                    ApiChange(
+                        ApiChangeKind.RECEIVER_CHANGED, name_path, new_declaration.receiver_is_pointer, old=old_receiver, new=new_receiver
+                    )
+                )
+        elif old_declaration.kind == GoDeclarationKind.TYPE:
+            changes.extend(_compare_type_apis(name_path, old_declaration, new_declaration))
+        elif old_declaration.type_expr is not None:
+            # changes of the (explicitly declared) type of a variable or constant
+            old_type, new_type = _get_api_text(old_declaration), _get_api_text(new_declaration)
+            if new_type != old_type:
+                changes.append(ApiChange(ApiChangeKind.TYPE_CHANGED, name_path, True, old=old_type, new=new_type))
+    for name_path, new_declaration in new_declarations.items():
+        old_declaration = old_declarations.get(name_path)
+        if old_declaration is None or old_declaration.kind != new_declaration.kind:
+            changes.append(ApiChange(ApiChangeKind.ADDED, name_path, False, new=_get_api_text(new_declaration, new_file)))
+    return changes
+
+
+class MemberSelectionKind(Enum):
+    OWN = "own"
+    """
+    the member is declared by the type itself
+    """
+    OVERRIDE = "override"
+    """
+    the member is declared by the type itself and shadows members of the same name promoted from embedded fields
+    """
+    PROMOTED = "promoted"
+    """
+    the member is promoted from an embedded field (at the shallowest depth at which a member of the name exists)
+    """
+
+
+@dataclass
+class GoSelectedMember:
+    """
+    A field or method of a type which a selector `x.f` may denote
+    """
+
+    owner: str
+    """
+    the name of the type declaring the member
+    """
+    relative_path: str
+    """
+    the relative path of the file declaring the member (for fields and interface methods, the file declaring the owner)
+    """
+    is_method: bool
+    embedding_path: list[str]
+    """
+    the names of the embedded fields through which the member is reached from the operand's type (empty for own members)
+    """
+    declaration: GoDeclaration | None = None
+    """
+    for methods declared with a receiver, the method declaration
+    """
+
+    def get_name_path(self, name: str) -> str:
+        return format_name_path([self.owner, name])
+
+
+@dataclass
+class MemberSelection:
+    """
+    The member which a selector `x.f` denotes for a given type of `x`, according to Go's rules for promotion and shadowing
+    """
+
+    name: str
+    member: GoSelectedMember
+    kind: MemberSelectionKind
+    shadowed: list[GoSelectedMember] = field(default_factory=list)
+    """
+    the members of the same name at greater embedding depths, which are shadowed by the selected member
+    """
+
+
+@dataclass
+class SelectorResolution:
+    """
+    The resolution of a selector expression `x.f` in the source code
+    """
+
+    operand: str
+    operand_type: str
+    """
+    the type of the operand `x` as inferred by gopls, e.g. "*ChildStruct"
+    """
+    selection: MemberSelection
+
+
+class GoAnalyzer:
+    """
+    Provides Go-specific information on symbols and packages.
+
+    Within Go, a package corresponds to a directory, so all functions taking a package directory
+    consider the (non-recursive) set of `.go` files in that directory.
+    """
+
+    def __init__(self, symbol_retriever: LanguageServerSymbolRetriever, encoding: str = "utf-8"):
+        self._symbol_retriever = symbol_retriever
+        self._project_root = symbol_retriever.get_language_server().repository_root_path
+        self._encoding = encoding
+        self._parsed_files: dict[str, tuple[tuple[int, int] | str, GoFile]] = {}
+        self._workspace_modules: tuple[tuple[int, int], list[tuple[str, str]]] | None = None
+        self._standard_packages: dict[str, str] | None = None
+
+    def read_file(self, relative_path: str) -> str:
+        """
+        :param relative_path: the relative path of a file
+        :return: the file's current content, i.e. the content of its buffer if the file is open in the language server
+            (which includes the pending edits of an ongoing edit transaction) and the content on disk otherwise
+        """
+        file_buffer = self._symbol_retriever.get_language_server().get_open_file_buffer(relative_path)
+        if file_buffer is not None:
+            return file_buffer.contents
+        with open(os.path.join(self._project_root, relative_path), encoding=self._encoding) as f:
+            return f.read()
+
+    def parse_file(self, relative_path: str) -> GoFile:
+        """
+        :param relative_path: the relative path of a Go file
+        :return: the parsed file's current content (see `read_file`), cached as long as the content does not change
+        """
+        file_version: tuple[int, int] | str
+        file_buffer = self._symbol_retriever.get_language_server().get_open_file_buffer(relative_path)
+        if file_buffer is not None:
+            file_version = file_buffer.content_hash
+        else:
+            stat = os.stat(os.path.join(self._project_root, relative_path))
+            file_version = (stat.st_mtime_ns, stat.st_size)
+        cached = self._parsed_files.get(relative_path)
+        if cached is not None and cached[0] == file_version:
+            return cached[1]
+        go_file = parse_go_file(self.read_file(relative_path))
+        self._parsed_files[relative_path] = (file_version, go_file)
+        return go_file
+
+    def get_build_constraint(self, relative_path: str) -> str | None:
+        """
+        :param relative_path: the relative path of a Go file
+        :return: the build constraint under which the file is compiled, combining the file's `//go:build` line with the
+            constraint implied by its name (e.g. a `_windows` suffix), or None if the file is always compiled
+        """
+        explicit_constraint = self.parse_file(relative_path).get_build_constraint()
+        implied_constraint = get_file_name_build_constraint(os.path.basename(relative_path))
+        if explicit_constraint is None or explicit_constraint == implied_constraint:
+            return implied_constraint
+        if implied_constraint is None:
+            return explicit_constraint
+        return f"({explicit_constraint}) && {implied_constraint}"
+
+    def get_package_files(self, package_dir: str) -> list[str]:
+        """
+        :param package_dir: the relative path of a package directory ("" for the project root)
+        :return: the (sorted) relative paths of the Go files in the package directory
+        """
+        abs_dir = os.path.join(self._project_root, package_dir)
+        return sorted(
+            os.path.normpath(os.path.join(package_dir, fn))
+            for fn in os.listdir(abs_dir)
+            if fn.endswith(".go") and os.path.isfile(os.path.join(abs_dir, fn))
+        )
+
+    def _read_module_path(self, module_dir: str) -> str | None:
+        """
+        :param module_dir: the relative path of a directory
+        :return: the module path declared by the directory's `go.mod` file or None if there is no such file
+        """
+        go_mod_path = os.path.join(self._project_root, module_dir, "go.mod")
+        if not os.path.isfile(go_mod_path):
+            return None
+        with open(go_mod_path, encoding="utf-8") as f:
+            match = _MODULE_DIRECTIVE_PATTERN.search(f.read())
+        return match.group(1) if match is not None else None
+
+    def find_module(self, package_dir: str) -> tuple[str, str] | None:
+        """
+        :param package_dir: the relative path of a package directory
+        :return: a tuple (relative path of the module directory, module path) for the innermost module (`go.mod` file)
+            containing the package within the project, or None if there is no such module
+        """
+        current_dir = os.path.normpath(package_dir) if package_dir else ""
+        while True:
+            module_path = self._read_module_path(current_dir)
+            if module_path is not None:
+                return current_dir, module_path
+            if current_dir in ("", "."):
+                return None
+            current_dir = os.path.dirname(current_dir)
+
+    def get_workspace_modules(self) -> list[tuple[str, str]]:
+        """
+        :return: tuples (relative path of the module directory, module path) for the modules used by the `go.work` file
+            in the project root, in the order of its `use` directives and omitting modules outside the project;
+            empty if the project is not a Go workspace
+        """
+        go_work_path = os.path.join(self._project_root, "go.work")
+        if not os.path.isfile(go_work_path):
+            return []
+        stat = os.stat(go_work_path)
+        file_version = (stat.st_mtime_ns, stat.st_size)
+        if self._workspace_modules is not None and self._workspace_modules[0] == file_version:
+            return self._workspace_modules[1]
+        with open(go_work_path, encoding="utf-8") as f:
+            use_dirs = parse_go_work_use_directives(f.read())
+        modules = []
+        for use_dir in use_dirs:
+            module_dir = os.path.relpath(os.path.normpath(os.path.join(self._project_root, use_dir)), self._project_root)
+            if module_dir == os.pardir or module_dir.startswith(os.pardir + os.sep):
+                continue
+            module_dir = "" if module_dir == "." else module_dir
+            module_path = self._read_module_path(module_dir)
+            if module_path is not None:
+                modules.append((module_dir, module_path))
+        self._workspace_modules = (file_version, modules)
+        return modules
+
+    def get_import_path(self, package_dir: str) -> str | None:
+        """
+        :param package_dir: the relative path of a package directory
+        :return: the import path of the package or None if it is not part of a module
+        """
+        module = self.find_module(package_dir)
+        if module is None:
+            return None
+        module_dir, module_path = module
+        sub_path = os.path.relpath(package_dir or ".", module_dir or ".")
+        return module_path if sub_path == "." else f"{module_path}/{sub_path.replace(os.sep, '/')}"
+
+    def resolve_import_path(self, import_path: str, package_dir: str) -> str | None:
+        """
+        :param import_path: an import path appearing in the given package
+        :param package_dir: the relative path of the importing package's directory
+        :return: the relative path of the imported package's directory if it belongs to the same module or, if the project
+            is a Go workspace, to one of the workspace's modules; None otherwise
+        """
+        module = self.find_module(package_dir)
+        if module is None:
+            return None
+        # like the go command, resolve the import within the module with the longest matching module path
+        candidate_modules = sorted({module, *self.get_workspace_modules()}, key=lambda m: len(m[1]), reverse=True)
+        for module_dir, module_path in candidate_modules:
+            if import_path == module_path:
+                return module_dir
+            if import_path.startswith(module_path + "/"):
+                resolved_dir = os.path.normpath(os.path.join(module_dir, *import_path[len(module_path) + 1 :].split("/")))
+                return resolved_dir if os.path.isdir(os.path.join(self._project_root, resolved_dir)) else None
+        return None
+
+    def get_imported_packages(self, package_dir: str) -> list[str]:
+        """
+        :param package_dir: the relative path of a package directory
+        :return: the (sorted) relative paths of the directories of the packages of the same module (or workspace) which
+            are imported by the package's non-test files
+        """
+        imported_dirs = set()
+        for relative_path in self.get_package_files(package_dir):
+            if relative_path.endswith("_test.go"):
+                continue
+            for go_import in self.parse_file(relative_path).imports:
+                imported_dir = self.resolve_import_path(go_import.path, package_dir)
+                if imported_dir is not None:
+                    imported_dirs.add(imported_dir)
+        return sorted(imported_dirs)
+
+    def find_compile_errors(self, package_dirs: list[str], overlay: dict[str, str] | None = None) -> list[str]:
+        """
+        Compiles the given packages, including their tests, with the go command. The test binaries are only built (into a
+        temporary directory), such that no code of the project (e.g. `init` functions or `TestMain`) is executed.
+
+        :param package_dirs: the relative paths of the package directories
+        :param overlay: maps the relative paths of files to the contents with which to compile them instead of their contents
+            on disk (files which do not exist are added to their packages); the files on disk remain unchanged
+        :return: the compiler's error messages, e.g. "./base.go:14:2: undefined: x" (with paths relative to the
+            respective module directory, also for overlaid files); empty if all packages compile
+        """
+        module_packages: dict[str, list[str]] = {}
+        for package_dir in sorted(set(package_dirs)):
+            module = self.find_module(package_dir)
+            if module is None:
+                raise ValueError(f"The package directory {package_dir or '.'} is not part of a Go module")
+            package = os.path.relpath(os.path.join(self._project_root, package_dir), os.path.join(self._project_root, module[0]))
+            module_packages.setdefault(module[0], []).append("./" + package.replace(os.sep, "/"))
+        errors = []
+        with tempfile.TemporaryDirectory() as temp_dir:
+            # with several packages, -o requires a directory (ending with a separator), which receives the test binaries
+            command = ["go", "test", "-vet=off", "-c", "-o", os.path.join(temp_dir, "bin") + os.sep]
+            # maps the names of the files replacing overlaid files to the absolute paths of the overlaid files
+            overlay_file_paths: dict[str, str] = {}
+            if overlay:
+                replacements = {}
+                for i, (relative_path, content) in enumerate(sorted(overlay.items())):
+                    # the go command reports errors in overlaid files with the paths of the replacing files
+                    replacement_name = f"serena-overlay-{i}-{os.path.basename(relative_path)}"
+                    replacement_path = os.path.join(temp_dir, replacement_name)
+                    with open(replacement_path, "w", encoding="utf-8") as f:
+                        f.write(content)
+                    absolute_path = os.path.join(self._project_root, relative_path)
+                    replacements[absolute_path] = replacement_path
+                    overlay_file_paths[replacement_name] = absolute_path
+                overlay_path = os.path.join(temp_dir, "overlay.json")
+                with open(overlay_path, "w", encoding="utf-8") as f:
+                    json.dump({"Replace": replacements}, f)
+                command.append(f"-overlay={overlay_path}")
+            for module_dir, packages in module_packages.items():
+                absolute_module_dir = os.path.join(self._project_root, module_dir)
+                completed_process = subprocess.run(
+                    [*command, *packages],
+                    cwd=absolute_module_dir,
+                    stdin=subprocess.DEVNULL,
+                    capture_output=True,
+                    text=True,
+                    check=False,
+                    **subprocess_kwargs(),
+                )
+                if completed_process.returncode == 0:
+                    continue
+
+                def to_module_path(match: re.Match) -> str:
+                    path = os.path.relpath(overlay_file_paths[match.group(1)], absolute_module_dir).replace(os.sep, "/")
+                    return path if "/" in path else "./" + path
+
+                for line in (completed_process.stdout + completed_process.stderr).splitlines():
+                    # skip the package headers and summaries, e.g. "# test_repo [test_repo.test]" and "FAIL test_repo [build failed]"
+                    if line.strip() and not line.startswith(("#", "FAIL", "ok ", "? ")):
+                        errors.append(_OVERLAY_FILE_PATH_PATTERN.sub(to_module_path, line.strip()))
+        return errors
+
+    def format_source(self, source: str) -> str:
+        """
+        :param source: the source code of a Go file
+        :return: the source formatted with gofmt
+        """
+        completed_process = subprocess.run(
+            ["gofmt"], input=source, capture_output=True, text=True, encoding="utf-8", check=False, **subprocess_kwargs()
+        )
+        if completed_process.returncode != 0:
+            raise ValueError(f"The source cannot be formatted: {completed_process.stderr.strip()}")
+        return completed_process.stdout
+
+    def get_standard_packages(self) -> dict[str, str]:
+        """
+        :return: a mapping from package names to the import paths of the (non-internal) packages of the standard library,
+            omitting names shared by several packages (e.g. "rand")
+        """
+        if self._standard_packages is None:
+            completed_process = subprocess.run(
+                ["go", "list", "std"],
+                cwd=self._project_root,
+                stdin=subprocess.DEVNULL,
+                capture_output=True,
+                text=True,
+                check=False,
+                **subprocess_kwargs(),
+            )
+            import_paths: dict[str, list[str]] = {}
+            for import_path in completed_process.stdout.split():
+                if not any(segment in ("internal", "vendor") for segment in import_path.split("/")):
+                    name = GoImport(path=import_path, alias=None, start=0, end=0).get_package_name()
+                    import_paths.setdefault(name, []).append(import_path)
+            self._standard_packages = {name: paths[0] for name, paths in import_paths.items() if len(paths) == 1}
+        return self._standard_packages
+
+    def check_contents(
+        self, contents: dict[str, str], package_dirs: list[str], organize_imports: bool = False
+    ) -> tuple[dict[str, str], list[str]]:
+        """
+        Formats new contents of files (with gofmt) and compiles the given packages with them (see `find_compile_errors`),
+        leaving the files on disk unchanged.
+        If imports are to be organized, the imports the compiler reports as unused are removed, and, for the identifiers
+        it reports as undefined, the packages of the same name are imported (as imported by the package's other files or,
+        failing that, from the standard library), after which the packages are compiled again.
+
+        :param contents: maps the relative paths of Go files to their new contents
+        :param package_dirs: the relative paths of the directories of the packages to compile
+        :param organize_imports: whether to organize the imports of the given files
+        :return: a tuple (the formatted contents (with organized imports), the compile errors)
+        """
+        contents = {relative_path: self.format_source(content) for relative_path, content in contents.items()}
+        errors = self.find_compile_errors(package_dirs, overlay=contents)
+        # the imports added to each file, which are not added again once removed (as unused)
+        added_imports: dict[str, set[str]] = {relative_path: set() for relative_path in contents}
+        while organize_imports and errors:
+            organized = False
+            for relative_path, content in contents.items():
+                module = self.find_module(os.path.dirname(relative_path))
+                assert module is not None
+                error_path = os.path.relpath(relative_path, module[0]).replace(os.sep, "/")
+                error_path = error_path if "/" in error_path else "./" + error_path
+                unused_import_paths = set()
+                undefined_names = set()
+                for error in errors:
+                    if (match := _UNUSED_IMPORT_ERROR_PATTERN.match(error)) is not None and match.group(1) == error_path:
+                        unused_import_paths.add(match.group(2))
+                    elif (match := _UNDEFINED_ERROR_PATTERN.match(error)) is not None and match.group(1) == error_path:
+                        undefined_names.add(match.group(2))
+                go_file = parse_go_file(content)
+                for go_import in sorted(go_file.imports, key=lambda i: i.start, reverse=True):
+                    if go_import.path in unused_import_paths:
+                        content = get_import_removal(go_file, go_import).apply(content)
+                package_import_paths = self._get_package_import_paths(os.path.dirname(relative_path), exclude=contents)
+                for name in sorted(undefined_names):
+                    import_path = package_import_paths.get(name, self.get_standard_packages().get(name))
+                    if import_path is not None and import_path not in added_imports[relative_path]:
+                        added_imports[relative_path].add(import_path)
+                        content = get_import_insertion(parse_go_file(content), import_path).apply(content)
+                if content != contents[relative_path]:
+                    contents[relative_path] = self.format_source(content)
+                    organized = True
+            if not organized:
+                break
+            errors = self.find_compile_errors(package_dirs, overlay=contents)
+        return contents, errors
+
+    def _get_package_import_paths(self, package_dir: str, exclude: Iterable[str] = ()) -> dict[str, str]:
+        """
+        :param package_dir: the relative path of a package directory
+        :param exclude: the relative paths of files to ignore
+        :return: a mapping from the names under which the package's files import packages to the import paths
+        """
+        result = {}
+        for relative_path in self.get_package_files(package_dir):
+            if relative_path not in exclude:
+                for go_import in self.parse_file(relative_path).imports:
+                    if go_import.alias not in ("_", "."):
+                        result.setdefault(go_import.get_package_name(), go_import.path)
+        return result
+
+    def find_type_declaration(self, type_name: str, package_dir: str) -> tuple[str, GoDeclaration] | None:
+        """
+        :param type_name: the name of a type
+        :param package_dir: the package directory in which to search
+        :return: a tuple (relative path, declaration) or None if the package does not declare a type with the given name
+        """
+        for relative_path in self.get_package_files(package_dir):
+            declaration = self.parse_file(relative_path).find_declaration(type_name)
+            if declaration is not None and declaration.kind == GoDeclarationKind.TYPE:
+                return relative_path, declaration
+        return None
+
+    def get_methods(self, type_name: str, package_dir: str) -> list[tuple[str, GoDeclaration]]:
+        """
+        :param type_name: the name of a type
+        :param package_dir: the directory of the package declaring the type
+        :return: the methods declared for the type (with value or pointer receivers) as tuples (relative path, declaration)
+        """
+        methods = []
+        for relative_path in self.get_package_files(package_dir):
+            for declaration in self.parse_file(relative_path).iter_declarations(GoDeclarationKind.METHOD):
+                if declaration.receiver_type == type_name:
+                    methods.append((relative_path, declaration))
+        return methods
+
+    def find_unique_declaration(
+        self, name_path: str, relative_path: str, kinds: tuple[GoDeclarationKind, ...] = ()
+    ) -> tuple[LanguageServerSymbol, GoDeclaration]:
+        """
+        Finds the unique top-level declaration matching the given name path in the given file.
+
+        :param name_path: the name path of the symbol (see `find_symbol`)
+        :param relative_path: the relative path of the file containing the declaration
+        :param kinds: the permissible kinds of declarations; if empty, all kinds are permissible
+        :return: a tuple (symbol, declaration)
+        """
+        candidates = []
+        for symbol in self._symbol_retriever.find_by_name(name_path, within_relative_path=relative_path):
+            declaration = self.get_declaration(symbol)
+            if declaration is not None and (not kinds or declaration.kind in kinds):
+                candidates.append((symbol, declaration))
+        kinds_str = "/".join(k.value for k in kinds) if kinds else "declaration"
+        if len(candidates) == 0:
+            raise ValueError(f"No {kinds_str} matching {name_path} found in {relative_path}")
+        if len(candidates) > 1:
+            raise ValueError(
+                f"Found {len(candidates)} declarations matching {name_path} in {relative_path}: "
+                + ", ".join(s.get_name_path() for s, _ in candidates)
+            )
+        return candidates[0]
+
+    def get_declaration(self, symbol: LanguageServerSymbol) -> GoDeclaration | None:
+        """
+        :param symbol: a top-level symbol reported by the language server
+        :return: the corresponding declaration or None if the symbol is not a (supported) top-level declaration
+        """
+        relative_path = symbol.relative_path
+        if relative_path is None or symbol.line is None or not relative_path.endswith(".go"):
+            return None
+        name = symbol.get_name_path_parts()[-1]
+        go_file = self.parse_file(relative_path)
+        declaration = go_file.find_declaration_at_line(symbol.line)
+        if declaration is None or declaration.name != name:
+            return None
+        return declaration
+
+    def find_symbol(self, relative_path: str, declaration: GoDeclaration) -> LanguageServerSymbol | None:
+        """
+        :param relative_path: the relative path of the file containing the declaration
+        :param declaration: a top-level declaration
+        :return: the symbol reported by the language server for the declaration (if any)
+        """
+        name_line, _ = self.parse_file(relative_path).get_line_and_column(declaration.name_start)
+        for symbol in self._symbol_retriever.get_document_symbols(relative_path):
+            if symbol.line == name_line and symbol.get_name_path_parts()[-1] == declaration.name:
+                return symbol
+        return None
+
+    def find_documented_declaration(self, relative_path: str, offset: int) -> GoDeclaration | None:
+        """
+        :param relative_path: the relative path of a Go file
+        :param offset: an offset within the file
+        :return: the declaration whose doc comment contains the given offset (if any)
+        """
+        for declaration in self.parse_file(relative_path).declarations:
+            if declaration.doc_start is not None and declaration.doc_start <= offset < declaration.start:
+                return declaration
+        return None
+
+    def get_underlying_kind(self, declaration: GoDeclaration, package_dir: str) -> GoUnderlyingKind:
+        """
+        Determines the kind of the type underlying the given type declaration.
+        If the type is defined in terms of another named type declared in the same package, this type is resolved
+        (one level of naming); otherwise the kind is reported as `NAMED`.
+
+        :param declaration: a type declaration
+        :param package_dir: the directory of the package containing the declaration
+        :return: the underlying kind
+        """
+        assert declaration.type_expr is not None
+        kind = classify_type_expression(declaration.type_expr)
+        if kind == GoUnderlyingKind.NAMED:
+            named_type = get_named_type_identifier(declaration.type_expr)
+            if named_type is not None and named_type[0] is None:
+                resolved = self.find_type_declaration(named_type[1], package_dir)
+                if resolved is not None and resolved[1] is not declaration and resolved[1].type_expr is not None:
+                    kind = classify_type_expression(resolved[1].type_expr)
+        return kind
+
+    def classify_type(self, type_expr: str, package_dir: str) -> GoUnderlyingKind:
+        """
+        Classifies a type expression (e.g. the type of a struct field), resolving names of types declared in the given package.
+
+        :param type_expr: the type expression
+        :param package_dir: the directory of the package in whose context the expression appears
+        :return: the kind of the type
+        """
+        kind = classify_type_expression(type_expr)
+        if kind == GoUnderlyingKind.NAMED:
+            named_type = get_named_type_identifier(type_expr)
+            if named_type is not None and named_type[0] is None:
+                resolved = self.find_type_declaration(named_type[1], package_dir)
+                if resolved is not None:
+                    kind = self.get_underlying_kind(resolved[1], package_dir)
+        return kind
+
+    def get_interface_methods(
+        self, declaration: GoDeclaration, package_dir: str
+    ) -> tuple[list[tuple[GoInterfaceElement, str | None]], list[str]]:
+        """
+        Determines the method set of an interface type, resolving embedded interfaces declared in the same package
+        (recursively).
+
+        :param declaration: an interface type declaration
+        :param package_dir: the directory of the package containing the declaration
+        :return: a tuple (methods, unresolved), where methods is the list of tuples (method element, name of the embedded
+            interface declaring it or None if declared by the interface itself) and unresolved is the list of embedded
+            elements that could not be resolved (e.g. interfaces from other packages or type constraint terms)
+        """
+        methods: list[tuple[GoInterfaceElement, str | None]] = []
+        unresolved: list[str] = []
+        visited = {declaration.name}
+
+        def collect(type_expr: str, origin: str | None) -> None:
+            for element in parse_interface_elements(type_expr):
+                if element.is_method:
+                    methods.append((element, origin))
+                    continue
+                named_type = get_named_type_identifier(element.text) if "|" not in element.text else None
+                if named_type is not None and named_type[0] is None and named_type[1] in visited:
+                    continue
+                resolved = None
+                if named_type is not None and named_type[0] is None:
+                    resolved = self.find_type_declaration(named_type[1], package_dir)
+                if resolved is None or classify_type_expression(resolved[1].type_expr or "") != GoUnderlyingKind.INTERFACE:
+                    unresolved.append(element.text)
+                    continue
+                visited.add(resolved[1].name)
+                assert resolved[1].type_expr is not None
+                collect(resolved[1].type_expr, resolved[1].name)
+
+        assert declaration.type_expr is not None
+        collect(declaration.type_expr, None)
+        return methods, unresolved
+
+    def get_method_set(
+        self, declaration: GoDeclaration, package_dir: str, extra_file: GoFile | None = None
+    ) -> tuple[dict[str, tuple[str, bool]], list[str]]:
+        """
+        Determines the method set of a type, including the methods promoted from embedded fields whose types are declared
+        in the same package (recursively).
+
+        :param declaration: a type declaration
+        :param package_dir: the directory of the package in whose context the type is declared
+        :param extra_file: a file which is not (yet) part of the package but whose declarations are to be considered
+            in addition to the package's declarations, e.g. a draft of a new type
+        :return: a tuple (method_set, unresolved), where method_set maps method names to tuples (normalized signature,
+            whether the method is available only for pointers to the type) and unresolved is the list of embedded types
+            whose methods could not be determined
+        """
+
+        def find_type(type_name: str) -> GoDeclaration | None:
+            if extra_file is not None:
+                type_declaration = extra_file.find_declaration(type_name)
+                if type_declaration is not None and type_declaration.kind == GoDeclarationKind.TYPE:
+                    return type_declaration
+            resolved = self.find_type_declaration(type_name, package_dir)
+            return resolved[1] if resolved is not None else None
+
+        def find_methods(type_name: str) -> list[tuple[str, GoDeclaration]]:
+            methods = []
+            if extra_file is not None:
+                for method in extra_file.iter_declarations(GoDeclarationKind.METHOD):
+                    if method.receiver_type == type_name:
+                        methods.append((self._get_normalized_signature(extra_file, method), method))
+            for relative_path, method in self.get_methods(type_name, package_dir):
+                methods.append((self._get_normalized_signature(self.parse_file(relative_path), method), method))
+            return methods
+
+        method_set: dict[str, tuple[str, bool]] = {}
+        unresolved: list[str] = []
+        visited = {declaration.name}
+        # process the embedding levels breadth-first, because methods at shallower depths take precedence;
+        # each type is paired with whether it is reached through an embedded pointer
+        level: list[tuple[GoDeclaration, bool]] = [(declaration, False)]
+        while level:
+            level_methods: dict[str, tuple[str, bool]] = {}
+            next_level: list[tuple[GoDeclaration, bool]] = []
+            for type_declaration, via_pointer in level:
+                assert type_declaration.type_expr is not None
+                kind = classify_type_expression(type_declaration.type_expr)
+                if kind == GoUnderlyingKind.INTERFACE:
+                    interface_methods, interface_unresolved = self.get_interface_methods(type_declaration, package_dir)
+                    for element, _ in interface_methods:
+                        assert element.method_name is not None
+                        level_methods.setdefault(element.method_name, (normalize_method_signature(element.get_signature()), False))
+                    unresolved.extend(interface_unresolved)
+                    continue
+                for signature, method in find_methods(type_declaration.name):
+                    level_methods.setdefault(method.name, (signature, method.receiver_is_pointer and not via_pointer))
+                if kind != GoUnderlyingKind.STRUCT:
+                    continue
+                for struct_field in parse_struct_fields(type_declaration.type_expr):
+                    if not struct_field.embedded:
+                        continue
+                    named_type = get_named_type_identifier(struct_field.type_expr.lstrip("*"))
+                    embedded_declaration = find_type(named_type[1]) if named_type is not None and named_type[0] is None else None
+                    if embedded_declaration is None or embedded_declaration.type_expr is None:
+                        unresolved.append(struct_field.type_expr)
+                    elif embedded_declaration.name not in visited:
+                        visited.add(embedded_declaration.name)
+                        next_level.append((embedded_declaration, via_pointer or struct_field.is_pointer))
+            for name, entry in level_methods.items():
+                method_set.setdefault(name, entry)
+            level = next_level
+        return method_set, unresolved
+
+    def find_implementations(self, interface: GoDeclaration, package_dir: str) -> list[tuple[str, GoDeclaration]]:
+        """
+        Finds the types declared in the given package which implement the given interface (with values or pointers).
+
+        :param interface: an interface type declaration
+        :param package_dir: the directory of the package in which to search (which must also contain the interface)
+        :return: the implementing types as tuples (relative path, declaration)
+        """
+        interface_methods, _ = self.get_interface_methods(interface, package_dir)
+        implementations = []
+        for relative_path in self.get_package_files(package_dir):
+            for declaration in self.parse_file(relative_path).iter_declarations(GoDeclarationKind.TYPE):
+                if declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) == GoUnderlyingKind.INTERFACE:
+                    continue
+                method_set, _ = self.get_method_set(declaration, package_dir)
+                if check_interface_satisfaction(method_set, interface_methods).satisfied_by_pointer:
+                    implementations.append((relative_path, declaration))
+        return implementations
+
+    def find_narrower_interfaces(self, interface: GoDeclaration, package_dir: str) -> list[tuple[str, GoDeclaration]]:
+        """
+        Finds the interfaces declared in the given package whose method sets include all methods of the given interface
+        (e.g. because they embed it), such that their values are assignable to variables of the given interface type.
+
+        :param interface: an interface type declaration
+        :param package_dir: the directory of the package in which to search (which must also contain the interface)
+        :return: the narrower interfaces as tuples (relative path, declaration), excluding the given interface itself
+        """
+        interface_methods, _ = self.get_interface_methods(interface, package_dir)
+        narrower_interfaces = []
+        for relative_path in self.get_package_files(package_dir):
+            for declaration in self.parse_file(relative_path).iter_declarations(GoDeclarationKind.TYPE):
+                if declaration.name == interface.name or declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+                    continue
+                candidate_methods, _ = self.get_interface_methods(declaration, package_dir)
+                method_set = {
+                    element.method_name: (normalize_method_signature(element.get_signature()), False)
+                    for element, _ in candidate_methods
+                    if element.method_name is not None
+                }
+                if check_interface_satisfaction(method_set, interface_methods).satisfied_by_value:
+                    narrower_interfaces.append((relative_path, declaration))
+        return narrower_interfaces
+
+    @staticmethod
+    def _get_normalized_signature(go_file: GoFile, method: GoDeclaration) -> str:
+        signature = go_file.get_parameters_and_results_text(method)
+        assert signature is not None
+        return normalize_method_signature(signature)
+
+    def get_identifier_at(self, relative_path: str, line: int, column: int) -> GoToken:
+        """
+        :param relative_path: the relative path of a Go file
+        :param line: a 0-based line
+        :param column: a 0-based column
+        :return: the identifier token at the given position; a ValueError is raised if there is no identifier at the position
+        """
+        go_file = self.parse_file(relative_path)
+        offset = go_file.get_offset(line, column)
+        for token in go_file.get_tokens():
+            if token.start <= offset < token.end:
+                if token.is_identifier() and token.text not in KEYWORDS:
+                    return token
+                break
+        raise ValueError(f"The position {line}:{column} in {relative_path} is not an identifier")
+
+    def get_reference_role(self, relative_path: str, line: int, column: int, refers_to_type: bool) -> GoReferenceRole:
+        """
+        :param relative_path: the relative path of the Go file containing the reference
+        :param line: the 0-based line of the reference
+        :param column: the 0-based column of the reference
+        :param refers_to_type: whether the referenced entity is a type
+        :return: the syntactic role of the reference (`READ` if there is no identifier at the given position)
+        """
+        go_file = self.parse_file(relative_path)
+        offset = go_file.get_offset(line, column)
+        tokens = go_file.get_tokens()
+        for i, token in enumerate(tokens):
+            if token.start <= offset < token.end:
+                if token.is_identifier():
+                    return classify_reference(tokens, i, refers_to_type)
+                break
+        return GoReferenceRole.READ
+
+    def get_object_signature_at(self, relative_path: str, line: int, column: int) -> GoObjectSignature:
+        """
+        Determines the signature (and thus the type) of the object denoted by the identifier at the given position,
+        based on the hover information provided by gopls.
+
+        :param relative_path: the relative path of a Go file
+        :param line: a 0-based line
+        :param column: a 0-based column
+        :return: the signature of the object
+        """
+        identifier = self.get_identifier_at(relative_path, line, column)
+        identifier_line, identifier_column = self.parse_file(relative_path).get_line_and_column(identifier.start)
+        hover_text = self._symbol_retriever.get_hover_text(relative_path, identifier_line, identifier_column) or ""
+        code_block_match = _GO_CODE_BLOCK_PATTERN.search(hover_text)
+        signature = parse_object_signature(code_block_match.group(1) if code_block_match is not None else hover_text)
+        if signature is None:
+            raise ValueError(f"Could not determine the type of '{identifier.text}' at {line}:{column} in {relative_path}")
+        return signature
+
+    def select_member(self, relative_path: str, declaration: GoDeclaration, name: str, package_dir: str) -> MemberSelection:
+        """
+        Determines the field or method a selector with the given name denotes for values of the given type:
+        a member declared by the type itself takes precedence over members promoted from embedded fields, and among
+        promoted members, the one at the shallowest embedding depth is selected. Only embedded types declared in the
+        same package are considered. A ValueError is raised if there is no such member or the selector is ambiguous.
+
+        :param relative_path: the relative path of the file declaring the type
+        :param declaration: a type declaration
+        :param name: the name of the selected field or method
+        :param package_dir: the directory of the package declaring the type
+        :return: the selected member
+        """
+        # search the embedding levels breadth-first, collecting the members of the given name at each level
+        levels: list[list[GoSelectedMember]] = []
+        unresolved: list[str] = []
+        visited = {declaration.name}
+        level: list[tuple[str, GoDeclaration, list[str]]] = [(relative_path, declaration, [])]
+        while level:
+            members: list[GoSelectedMember] = []
+            next_level: list[tuple[str, GoDeclaration, list[str]]] = []
+            for type_path, type_declaration, embedding_path in level:
+                assert type_declaration.type_expr is not None
+                kind = classify_type_expression(type_declaration.type_expr)
+                if kind == GoUnderlyingKind.INTERFACE:
+                    interface_methods, interface_unresolved = self.get_interface_methods(type_declaration, package_dir)
+                    if any(element.method_name == name for element, _ in interface_methods):
+                        members.append(GoSelectedMember(type_declaration.name, type_path, True, embedding_path))
+                    unresolved.extend(interface_unresolved)
+                    continue
+                for method_path, method in self.get_methods(type_declaration.name, package_dir):
+                    if method.name == name:
+                        members.append(GoSelectedMember(type_declaration.name, method_path, True, embedding_path, declaration=method))
+                if kind != GoUnderlyingKind.STRUCT:
+                    continue
+                for struct_field in parse_struct_fields(type_declaration.type_expr):
+                    if struct_field.name == name:
+                        members.append(GoSelectedMember(type_declaration.name, type_path, False, embedding_path))
+                    if not struct_field.embedded:
+                        continue
+                    embedded_type = get_named_type_identifier(struct_field.type_expr.lstrip("*"))
+                    resolved = None
+                    if embedded_type is not None and embedded_type[0] is None:
+                        resolved = self.find_type_declaration(embedded_type[1], package_dir)
+                    if resolved is None or resolved[1].type_expr is None:
+                        unresolved.append(struct_field.type_expr)
+                    elif resolved[1].name not in visited:
+                        visited.add(resolved[1].name)
+                        next_level.append((resolved[0], resolved[1], [*embedding_path, struct_field.name]))
+            if members:
+                levels.append(members)
+            level = next_level
+
+        if not levels:
+            message = f"{declaration.name} has no field or method {name}"
+            if unresolved:
+                message += f" (the embedded types {', '.join(unresolved)} could not be resolved)"
+            raise ValueError(message)
+        if len(levels[0]) > 1:
+            owners = ", ".join(member.get_name_path(name) for member in levels[0])
+            raise ValueError(f"The selector {name} is ambiguous for {declaration.name}: {owners} are promoted at the same depth")
+        member = levels[0][0]
+        shadowed = [shadowed_member for shadowed_level in levels[1:] for shadowed_member in shadowed_level]
+        if member.embedding_path:
+            kind = MemberSelectionKind.PROMOTED
+        elif shadowed:
+            kind = MemberSelectionKind.OVERRIDE
+        else:
+            kind = MemberSelectionKind.OWN
+        return MemberSelection(name=name, member=member, kind=kind, shadowed=shadowed)
+
+    def get_promoted_members(self, relative_path: str, declaration: GoDeclaration, package_dir: str) -> list[MemberSelection]:
+        """
+        Determines the fields and methods which a struct type promotes from its embedded fields (recursively), excluding
+        those shadowed by the type's own members and those which are ambiguous. As for `select_member`, only embedded
+        types declared in the same package are considered.
+
+        :param relative_path: the relative path of the file declaring the type
+        :param declaration: a type declaration
+        :param package_dir: the directory of the package declaring the type
+        :return: the selections of the promoted members, ordered by embedding depth
+        """
+        names: list[str] = []
+        visited = {declaration.name}
+        pending = [declaration]
+        while pending:
+            type_declaration = pending.pop(0)
+            if type_declaration.type_expr is None or classify_type_expression(type_declaration.type_expr) != GoUnderlyingKind.STRUCT:
+                continue
+            for struct_field in parse_struct_fields(type_declaration.type_expr):
+                if not struct_field.embedded:
+                    continue
+                embedded_type = get_named_type_identifier(struct_field.type_expr.lstrip("*"))
+                if embedded_type is None or embedded_type[0] is not None:
+                    continue
+                resolved = self.find_type_declaration(embedded_type[1], package_dir)
+                if resolved is None or resolved[1].name in visited:
+                    continue
+                embedded_declaration = resolved[1]
+                if embedded_declaration.type_expr is None:
+                    continue
+                visited.add(embedded_declaration.name)
+                pending.append(embedded_declaration)
+                member_names = [method.name for _, method in self.get_methods(embedded_declaration.name, package_dir)]
+                kind = classify_type_expression(embedded_declaration.type_expr)
+                if kind == GoUnderlyingKind.STRUCT:
+                    member_names.extend(f.name for f in parse_struct_fields(embedded_declaration.type_expr))
+                elif kind == GoUnderlyingKind.INTERFACE:
+                    interface_methods, _ = self.get_interface_methods(embedded_declaration, package_dir)
+                    member_names.extend(element.method_name for element, _ in interface_methods if element.method_name is not None)
+                names.extend(name for name in member_names if name not in names)
+
+        promoted = []
+        for name in names:
+            try:
+                selection = self.select_member(relative_path, declaration, name, package_dir)
+            except ValueError:
+                # ambiguous selector
+                continue
+            if selection.kind == MemberSelectionKind.PROMOTED:
+                promoted.append(selection)
+        promoted.sort(key=lambda selection: len(selection.member.embedding_path))
+        return promoted
+
+    def resolve_selector(self, relative_path: str, line: int, column: int) -> SelectorResolution:
+        """
+        Determines the field or method denoted by a selector expression `x.f` (see `select_member`), where the type of
+        the operand `x` is inferred by gopls.
+
+        :param relative_path: the relative path of a Go file
+        :param line: the 0-based line of the selected identifier `f`
+        :param column: the 0-based column of (any character of) the selected identifier
+        :return: the resolution of the selector
+        """
+        go_file = self.parse_file(relative_path)
+        offset = go_file.get_offset(line, column)
+        tokens = go_file.get_tokens()
+        index = next((i for i, token in enumerate(tokens) if token.start <= offset < token.end), None)
+        if (
+            index is None
+            or index < 2
+            or not tokens[index].is_identifier()
+            or not tokens[index - 1].is_operator(".")
+            or not tokens[index - 2].is_identifier()
+        ):
+            raise ValueError(f"The position {line}:{column} in {relative_path} is not the selected identifier of a selector x.f")
+        name, operand = tokens[index].text, tokens[index - 2]
+        operand_line, operand_column = go_file.get_line_and_column(operand.start)
+        signature = self.get_object_signature_at(relative_path, operand_line, operand_column)
+        if signature.kind == "package" or signature.type_expr is None:
+            raise ValueError(f"{operand.text}.{name} is a qualified identifier, not a selector on a value or type")
+        package_dir = os.path.dirname(relative_path)
+        named_type = get_named_type_identifier(signature.type_expr.removeprefix("*"))
+        resolved = None
+        if named_type is not None and named_type[0] is None:
+            resolved = self.find_type_declaration(named_type[1], package_dir)
+        if resolved is None or resolved[1].type_expr is None:
+            raise ValueError(f"The type {signature.type_expr} of {operand.text} is not a named type declared in package {package_dir!r}")
+        selection = self.select_member(resolved[0], resolved[1], name, package_dir)
+        return SelectorResolution(operand=operand.text, operand_type=signature.type_expr, selection=selection)
+
+    def get_call_arguments(self, relative_path: str, line: int, column: int) -> list[str] | None:
+        """
+        :param relative_path: the relative path of a Go file
+        :param line: the 0-based line of the identifier of the called function
+        :param column: the 0-based column of the identifier
+        :return: the argument expressions of the call or None if the identifier is not called at the given position
+        """
+        go_file = self.parse_file(relative_path)
+        offset = go_file.get_offset(line, column)
+        tokens = go_file.get_tokens()
+        for i, token in enumerate(tokens):
+            if token.start <= offset < token.end:
+                if not token.is_identifier() or i + 1 >= len(tokens) or not tokens[i + 1].is_operator("("):
+                    return None
+                close = find_matching_bracket(tokens, i + 1)
+                return split_expression_list(go_file.source[tokens[i + 1].end : tokens[close].start])
+        return None
+
+    def get_return_flows(self, relative_path: str, function: GoDeclaration, result_index: int = 0) -> list[ReturnFlow]:
+        """
+        Determines the concrete types of the values returned by a function, based on a local (textual) dataflow analysis
+        of the returned expressions: composite literals, `new` expressions, conversions, calls of functions of the same
+        package (whose concrete types are determined recursively if they return an interface) and local variables
+        (considering all assignments preceding the return statement) are supported.
+
+        :param relative_path: the relative path of the file containing the function
+        :param function: the declaration of a function or method
+        :param result_index: the index of the result whose types to determine (for functions with several results)
+        :return: the flows of all return statements of the function
+        """
+        return self._get_return_flows(relative_path, function, result_index, set())
+
+    def infer_concrete_types(self, expression: str, relative_path: str, offset: int) -> list[str]:
+        """
+        :param expression: an expression appearing within a function
+        :param relative_path: the relative path of the file containing the expression
+        :param offset: the offset of the expression within the file
+        :return: the possible concrete types of the expression (see `get_return_flows` for the supported expressions)
+        """
+        go_file = self.parse_file(relative_path)
+        function = next((d for d in go_file.declarations if d.body_start is not None and d.body_start <= offset < d.end), None)
+        if function is None:
+            return [INDETERMINATE_TYPE]
+        return self._infer_concrete_types(expression, 0, relative_path, function, offset, set())
+
+    def _get_return_flows(
+        self, relative_path: str, function: GoDeclaration, result_index: int, visited: set[tuple[str, int]]
+    ) -> list[ReturnFlow]:
+        visited = visited | {(relative_path, function.start)}
+        go_file = self.parse_file(relative_path)
+        flows = []
+        for statement in find_return_statements(go_file, function):
+            if len(statement.results) == 1 and result_index > 0:
+                # a multi-valued call whose results are returned directly
+                types = self._infer_concrete_types(statement.results[0], result_index, relative_path, function, statement.start, visited)
+            elif result_index < len(statement.results):
+                types = self._infer_concrete_types(statement.results[result_index], 0, relative_path, function, statement.start, visited)
+            else:
+                # bare return of named results
+                types = [INDETERMINATE_TYPE]
+            flows.append(ReturnFlow(statement=statement, concrete_types=types))
+        return flows
+
+    def _infer_concrete_types(
+        self,
+        expression: str,
+        result_index: int,
+        relative_path: str,
+        function: GoDeclaration,
+        offset: int,
+        visited: set[tuple[str, int]],
+        depth: int = 0,
+    ) -> list[str]:
+        """
+        :return: the possible concrete types of the given expression (which appears in the given function before the given
+            offset), in the order in which they were found
+        """
+        tokens = tokenize(expression)
+        if not tokens or depth > 10:
+            return [INDETERMINATE_TYPE]
+        if len(tokens) == 1 and tokens[0].is_identifier("nil"):
+            return ["nil"]
+        if tokens[0].is_operator("&"):
+            referenced_expression = expression[tokens[1].start :]
+            referenced_types = self._infer_concrete_types(referenced_expression, 0, relative_path, function, offset, visited, depth + 1)
+            return [INDETERMINATE_TYPE if t in (INDETERMINATE_TYPE, "nil") else "*" + t for t in referenced_types]
+        if tokens[-1].is_operator("}"):
+            # composite literal, e.g. `ConcreteProcessor{}` or `pkg.Type{X: 1}`
+            open_idx = next(
+                (i for i, t in enumerate(tokens) if t.is_operator("{") and find_matching_bracket(tokens, i) == len(tokens) - 1), None
+            )
+            type_tokens = tokens[:open_idx]
+            if open_idx and all(t.is_identifier() or t.is_operator(".", "[", "]", ",", "*") for t in type_tokens):
+                return [expression[: tokens[open_idx].start].strip()]
+            return [INDETERMINATE_TYPE]
+        is_call = len(tokens) > 2 and tokens[0].is_identifier() and tokens[1].is_operator("(")
+        if is_call and find_matching_bracket(tokens, 1) == len(tokens) - 1:
+            arguments = split_expression_list(expression[tokens[1].end : tokens[-1].start])
+            return self._infer_call_result_types(tokens[0].text, arguments, result_index, relative_path, function, offset, visited, depth)
+        if len(tokens) == 1 and tokens[0].is_identifier():
+            go_file = self.parse_file(relative_path)
+            assignments = find_assignments(go_file, function, tokens[0].text, offset)
+            if not assignments:
+                return [INDETERMINATE_TYPE]
+            types: list[str] = []
+            for assigned_expression, assigned_index in assignments:
+                assigned_types = self._infer_concrete_types(
+                    assigned_expression, assigned_index, relative_path, function, offset, visited, depth + 1
+                )
+                types.extend(t for t in assigned_types if t not in types)
+            return types
+        return [INDETERMINATE_TYPE]
+
+    def _infer_call_result_types(
+        self,
+        callee: str,
+        arguments: list[str],
+        result_index: int,
+        relative_path: str,
+        function: GoDeclaration,
+        offset: int,
+        visited: set[tuple[str, int]],
+        depth: int,
+    ) -> list[str]:
+        package_dir = os.path.dirname(relative_path)
+        if callee == "new" and len(arguments) == 1:
+            return ["*" + arguments[0]]
+        type_declaration = self.find_type_declaration(callee, package_dir)
+        if type_declaration is not None:
+            # conversion
+            if self.get_underlying_kind(type_declaration[1], package_dir) != GoUnderlyingKind.INTERFACE:
+                return [callee]
+            if len(arguments) == 1:
+                return self._infer_concrete_types(arguments[0], 0, relative_path, function, offset, visited, depth + 1)
+            return [INDETERMINATE_TYPE]
+        for file_path in self.get_package_files(package_dir):
+            go_file = self.parse_file(file_path)
+            callee_declaration = go_file.find_declaration(callee)
+            if callee_declaration is None or callee_declaration.kind != GoDeclarationKind.FUNCTION:
+                continue
+            signature = go_file.get_parameters_and_results_text(callee_declaration)
+            if signature is None:
+                return [INDETERMINATE_TYPE]
+            result_types = get_parameter_and_result_types(signature)[1]
+            if result_index >= len(result_types):
+                return [INDETERMINATE_TYPE]
+            result_kind = self.classify_type(result_types[result_index], package_dir)
+            if result_kind not in (GoUnderlyingKind.INTERFACE, GoUnderlyingKind.NAMED):
+                return [result_types[result_index]]
+            if result_kind == GoUnderlyingKind.NAMED or (file_path, callee_declaration.start) in visited:
+                return [INDETERMINATE_TYPE]
+            flows = self._get_return_flows(file_path, callee_declaration, result_index, visited)
+            parameter_names = get_parameter_names(signature)
+            types: list[str] = []
+            for flow in select_return_flows(flows, parameter_names, arguments):
+                types.extend(t for t in flow.concrete_types if t not in types)
+            return types or [INDETERMINATE_TYPE]
+        return [INDETERMINATE_TYPE]
+
+    def get_fast_symbol_overview(self, relative_path: str) -> list[LanguageServerSymbolRetriever.SymbolOverviewElement]:
+        """
+        Determines the top-level symbols of a file by parsing it locally, i.e. without a round trip to gopls.
+        The result mirrors gopls' document symbols: methods are reported below their receiver type and the kinds of
+        type declarations are derived from their syntax (struct, interface, func or class for all other types).
+
+        :param relative_path: the relative path of a Go file
+        :return: the overview elements in the order of declaration
+        """
+        go_file = self.parse_file(relative_path)
+        result = []
+        for declaration in go_file.declarations:
+            name_path_parts = [declaration.name]
+            if declaration.kind == GoDeclarationKind.TYPE:
+                assert declaration.type_expr is not None
+                kind = _TYPE_SYMBOL_KINDS.get(classify_type_expression(declaration.type_expr), SymbolKind.Class)
+            else:
+                kind = _DECLARATION_SYMBOL_KINDS[declaration.kind]
+            receiver_type = go_file.get_receiver_type_text(declaration)
+            if receiver_type:
+                name_path_parts.insert(0, receiver_type)
+            start_line = go_file.get_line_and_column(declaration.start)[0]
+            end_line = go_file.get_line_and_column(declaration.end - 1)[0]
+            result.append(
+                LanguageServerSymbolRetriever.SymbolOverviewElement(
+                    name_path=format_name_path(name_path_parts), kind=int(kind), num_lines=end_line - start_line + 1
+                )
+            )
+        return result
+
+    def get_local_document_symbols(self, relative_path: str) -> list[ls_types.UnifiedSymbolInformation]:
+        """
+        Determines the document symbols of a file by parsing its current content locally, i.e. without waiting for gopls
+        to process the latest changes. The symbols mirror those reported by gopls (see `request_document_symbols`):
+        methods are top-level symbols named after their receiver (e.g. "(*ChildStruct).GetValue") and the fields of
+        struct types as well as the methods of interface types are reported as children. All symbols include their bodies.
+
+        :param relative_path: the relative path of a Go file
+        :return: the root symbols in the order of declaration
+        """
+        go_file = self.parse_file(relative_path)
+        absolute_path = os.path.join(self._project_root, relative_path)
+        lines = go_file.source.split("\n")
+
+        def to_position(offset: int) -> ls_types.Position:
+            line, column = go_file.get_line_and_column(offset)
+            return ls_types.Position(line=line, character=column)
+
+        def create_symbol(
+            name: str, kind: SymbolKind, start: int, end: int, name_start: int, parent: ls_types.UnifiedSymbolInformation | None
+        ) -> ls_types.UnifiedSymbolInformation:
+            symbol_range = ls_types.Range(start=to_position(start), end=to_position(end))
+            start_position, end_position = symbol_range["start"], symbol_range["end"]
+            # the body comprises the full lines spanned by the symbol (as for symbols retrieved from the language server)
+            body = "\n".join(lines[start_position["line"] : end_position["line"] + 1])[start_position["character"] :]
+            return ls_types.UnifiedSymbolInformation(  # type: ignore
+                name=name,
+                kind=kind,
+                location=ls_types.Location(
+                    uri=pathlib.Path(absolute_path).as_uri(),
+                    range=symbol_range,
+                    absolutePath=absolute_path,
+                    relativePath=relative_path,
+                ),
+                range=symbol_range,
+                selectionRange=ls_types.Range(start=to_position(name_start), end=to_position(name_start + len(name))),
+                body=body,
+                children=[],
+                parent=parent,
+            )
+
+        roots = []
+        for declaration in go_file.declarations:
+            name = declaration.name
+            if declaration.kind == GoDeclarationKind.TYPE:
+                assert declaration.type_expr is not None
+                kind = _TYPE_SYMBOL_KINDS.get(classify_type_expression(declaration.type_expr), SymbolKind.Class)
+            else:
+                kind = _DECLARATION_SYMBOL_KINDS[declaration.kind]
+            start = declaration.start
+            if declaration.kind not in (GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD) and not declaration.in_group:
+                # as for grouped declarations, gopls' range starts at the spec, i.e. after the `type`/`var`/`const` keyword
+                keyword_end = start + len(go_file.source[start:].split(None, 1)[0])
+                start = keyword_end + len(go_file.source[keyword_end:]) - len(go_file.source[keyword_end:].lstrip())
+            receiver_type = go_file.get_receiver_type_text(declaration)
+            symbol = create_symbol(name, kind, start, declaration.end, declaration.name_start, None)
+            if receiver_type:
+                symbol["name"] = f"({'*' if declaration.receiver_is_pointer else ''}{receiver_type}).{name}"
+            if declaration.kind == GoDeclarationKind.TYPE and not declaration.is_alias:
+                assert declaration.type_expr is not None and declaration.type_expr_start is not None
+                type_expr_start = declaration.type_expr_start
+                if kind == SymbolKind.Struct:
+                    for struct_field in parse_struct_fields(declaration.type_expr):
+                        name_start = declaration.type_expr.find(struct_field.name, struct_field.start)
+                        symbol["children"].append(
+                            create_symbol(
+                                struct_field.name,
+                                SymbolKind.Field,
+                                type_expr_start + struct_field.start,
+                                type_expr_start + struct_field.end,
+                                type_expr_start + name_start,
+                                symbol,
+                            )
+                        )
+                elif kind == SymbolKind.Interface:
+                    for element in parse_interface_elements(declaration.type_expr):
+                        if element.method_name is not None:
+                            symbol["children"].append(
+                                create_symbol(
+                                    element.method_name,
+                                    SymbolKind.Method,
+                                    type_expr_start + element.start,
+                                    type_expr_start + element.end,
+                                    type_expr_start + element.start,
+                                    symbol,
+                                )
+                            )
+            roots.append(symbol)
+        return roots
+
+    @staticmethod
+    def _get_struct_member_details(type_expr: str) -> dict[str, Any]:
+        """
+        :param type_expr: a struct type expression
+        :return: a dictionary with the struct's named `fields` and its `embedded` types, which are kept apart because
+            the members of the latter are promoted
+        """
+        fields = []
+        embedded = []
+        for struct_field in parse_struct_fields(type_expr):
+            entry: dict[str, Any]
+            if struct_field.embedded:
+                entry = {
+                    "name": struct_field.name,
+                    "type": struct_field.type_expr.lstrip("*"),
+                    "pointer": struct_field.is_pointer,
+                    "exported": struct_field.is_exported,
+                }
+                embedded.append(entry)
+            else:
+                entry = {"name": struct_field.name, "type": struct_field.type_expr, "exported": struct_field.is_exported}
+                fields.append(entry)
+            if struct_field.tag is not None:
+                entry["tag"] = struct_field.tag
+        return {"fields": fields, "embedded": embedded}
+
+    def get_symbol_details(self, symbol: LanguageServerSymbol) -> dict[str, Any]:
+        """
+        :param symbol: a symbol reported by the language server
+        :return: a dictionary with Go-specific information on the symbol, which can be added to the
+            symbol's dictionary representation
+        """
+        details: dict[str, Any] = {}
+        declaration = self.get_declaration(symbol)
+        if declaration is None:
+            interface_method = self._find_interface_method(symbol)
+            if interface_method is not None:
+                details["declaration"] = interface_method.text
+            return details
+        assert symbol.relative_path is not None
+        package_dir = os.path.dirname(symbol.relative_path)
+        signature = self.parse_file(symbol.relative_path).get_signature_text(declaration)
+        if signature is not None:
+            details["declaration"] = signature
+        if declaration.kind == GoDeclarationKind.TYPE:
+            details["underlying_kind"] = self.get_underlying_kind(declaration, package_dir).value
+            if declaration.type_expr is not None and classify_type_expression(declaration.type_expr) == GoUnderlyingKind.STRUCT:
+                details.update(self._get_struct_member_details(declaration.type_expr))
+        deprecation_message = declaration.deprecation_message
+        details["deprecated"] = deprecation_message is not None
+        if deprecation_message is not None:
+            details["deprecation_message"] = deprecation_message
+        # `init` functions and blank identifiers may legitimately be declared several times in a package
+        build_variants = self.get_build_variants(symbol.relative_path, declaration) if declaration.name not in ("init", "_") else []
+        if len(build_variants) > 1:
+            details["build_variants"] = build_variants
+        return details
+
+    def _find_interface_method(self, symbol: LanguageServerSymbol) -> GoInterfaceElement | None:
+        """
+        :param symbol: a symbol reported by the language server
+        :return: the method spec within an interface type declaration which the symbol corresponds to (if any)
+        """
+        relative_path = symbol.relative_path
+        if relative_path is None or symbol.line is None or symbol.column is None or not relative_path.endswith(".go"):
+            return None
+        name = symbol.get_name_path_parts()[-1]
+        go_file = self.parse_file(relative_path)
+        offset = go_file.get_offset(symbol.line, symbol.column)
+        for declaration in go_file.iter_declarations(GoDeclarationKind.TYPE):
+            if declaration.type_expr is None or declaration.type_expr_start is None:
+                continue
+            if not declaration.type_expr_start <= offset < declaration.end:
+                continue
+            for element in parse_interface_elements(declaration.type_expr):
+                element_start = declaration.type_expr_start + element.start
+                if element.method_name == name and element_start <= offset < declaration.type_expr_start + element.end:
+                    return element
+        return None
+
+    def get_build_variants(self, relative_path: str, declaration: GoDeclaration) -> list[dict[str, Any]]:
+        """
+        Finds all declarations of the same name in the declaration's package, which exist when the symbol is declared
+        in several files that are compiled under different build constraints (e.g. `config_linux.go` and `config_windows.go`).
+
+        :param relative_path: the relative path of the file containing the declaration
+        :param declaration: a top-level declaration
+        :return: the package's declarations of the symbol (including the given one) as dictionaries with keys
+            "relative_path", "line" (0-based) and "build_constraint" (None for files without constraints)
+        """
+        package_name = self.parse_file(relative_path).package_name
+        variants = []
+        for variant_path in self.get_package_files(os.path.dirname(relative_path)):
+            go_file = self.parse_file(variant_path)
+            if go_file.package_name != package_name:
+                continue
+            variant = go_file.find_declaration(declaration.name, receiver_type=declaration.receiver_type)
+            if variant is None:
+                continue
+            line, _ = go_file.get_line_and_column(variant.name_start)
+            build_constraint = self.get_build_constraint(variant_path)
+            variants.append({"relative_path": variant_path, "line": line, "build_constraint": build_constraint})
+        return variants
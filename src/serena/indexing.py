@@ -0,0 +1,146 @@
+"""
+Indexing of a project's symbols, i.e. populating the language server's document symbols cache
+"""
+
+import logging
+import threading
+import time
+from collections.abc import Callable, Sequence
+from dataclasses import dataclass, field
+
+from solidlsp import SolidLanguageServer
+
+log = logging.getLogger(__name__)
+
+
+@dataclass
+class IndexingProgress:
+    """
+    The progress of an indexing run, which is reported after each processed file
+    """
+
+    num_files_processed: int
+    num_files_total: int
+    current_file: str | None
+    """
+    the file that was processed last (None before the first file is processed)
+    """
+
+
+@dataclass
+class IndexingStats:
+    """
+    The summary of an indexing run
+    """
+
+    num_files_total: int
+    num_files_indexed: int = 0
+    """
+    the number of files whose symbols were (re-)requested from the language server
+    """
+    num_files_unchanged: int = 0
+    """
+    the number of files whose cached symbols were still up to date
+    """
+    failed_files: dict[str, str] = field(default_factory=dict)
+    """
+    maps the relative paths of the files that could not be indexed to the respective error messages
+    """
+    cancelled: bool = False
+    """
+    whether the run was cancelled before all files were processed
+    """
+    duration_seconds: float = 0.0
+
+    @property
+    def num_files_processed(self) -> int:
+        return self.num_files_indexed + self.num_files_unchanged + len(self.failed_files)
+
+
+class SymbolIndexer:
+    """
+    Indexes the symbols of a set of files by populating the document symbols cache of a language server.
+    Files whose cached symbols are up to date are not parsed again (unless a forced run is requested).
+
+    An indexing run can be cancelled from another thread via `cancel`; the files processed up to that point
+    remain cached.
+    """
+
+    def __init__(self, language_server: SolidLanguageServer, cache_save_interval: int = 10):
+        """
+        :param language_server: the (started) language server whose cache to populate
+        :param cache_save_interval: the number of newly indexed files after which the cache is saved to disk
+        """
+        self._language_server = language_server
+        self._cache_save_interval = cache_save_interval
+        self._cancel_event = threading.Event()
+        self._progress: IndexingProgress | None = None
+
+    @property
+    def progress(self) -> IndexingProgress | None:
+        """
+        :return: the progress of the current (or last) run or None if no run was started yet
+        """
+        return self._progress
+
+    def cancel(self) -> None:
+        """
+        Requests the cancellation of the current run, which stops before processing the next file.
+        """
+        self._cancel_event.set()
+
+    def is_cancelled(self) -> bool:
+        return self._cancel_event.is_set()
+
+    def _is_up_to_date(self, relative_path: str) -> bool:
+        return all(
+            self._language_server.has_up_to_date_document_symbols(relative_path, include_body=include_body)
+            for include_body in (False, True)
+        )
+
+    def index(
+        self,
+        relative_paths: Sequence[str],
+        progress_callback: Callable[[IndexingProgress], None] | None = None,
+        force: bool = False,
+    ) -> IndexingStats:
+        """
+        Indexes the given files, continuing with the next file if a file cannot be indexed.
+
+        :param relative_paths: the relative paths of the files to index
+        :param progress_callback: a function to call with the progress after each processed file
+        :param force: whether to request the symbols of all files from the language server, even if the cached
+            symbols are up to date
+        :return: the summary of the run
+        """
+        start_time = time.time()
+        stats = IndexingStats(num_files_total=len(relative_paths))
+        self._progress = IndexingProgress(num_files_processed=0, num_files_total=len(relative_paths), current_file=None)
+        num_files_since_save = 0
+        for relative_path in relative_paths:
+            if self._cancel_event.is_set():
+                log.info(f"Indexing cancelled after {stats.num_files_processed} of {stats.num_files_total} files")
+                stats.cancelled = True
+                break
+            try:
+                if not force and self._is_up_to_date(relative_path):
+                    stats.num_files_unchanged += 1
+                else:
+                    self._language_server.request_document_symbols(relative_path, include_body=False)
+                    self._language_server.request_document_symbols(relative_path, include_body=True)
+                    stats.num_files_indexed += 1
+                    num_files_since_save += 1
+            except Exception as e:
+                log.error(f"Failed to index {relative_path}, continuing: {e}")
+                stats.failed_files[relative_path] = str(e)
+            if num_files_since_save >= self._cache_save_interval:
+                self._language_server.save_cache()
+                num_files_since_save = 0
+            self._progress = IndexingProgress(
+                num_files_processed=stats.num_files_processed, num_files_total=stats.num_files_total, current_file=relative_path
+            )
+            if progress_callback is not None:
+                progress_callback(self._progress)
+        self._language_server.save_cache()
+        stats.duration_seconds = time.time() - start_time
+        return stats
@@ -2,9 +2,10 @@
 The Serena Model Context Protocol (MCP) Server
 """
 
+import asyncio
 import sys
 from abc import abstractmethod
-from collections.abc import AsyncIterator, Iterator, Sequence
+from collections.abc import AsyncIterator, Callable, Iterator, Sequence
 from contextlib import asynccontextmanager
 from copy import deepcopy
 from dataclasses import dataclass
@@ -12,7 +13,7 @@ from typing import Any, Literal, cast
 
 import docstring_parser
 from mcp.server.fastmcp import server
-from mcp.server.fastmcp.server import FastMCP, Settings
+from mcp.server.fastmcp.server import Context, FastMCP, Settings
 from mcp.server.fastmcp.tools.base import Tool as MCPTool
 from pydantic_settings import SettingsConfigDict
 from sensai.util import logging
@@ -177,7 +178,7 @@ class SerenaMCPFactory:
         func_doc = tool.get_apply_docstring() or ""
         func_arg_metadata = tool.get_apply_fn_metadata()
         is_async = False
-        parameters = func_arg_metadata.arg_model.model_json_schema()
+        parameters = tool.get_parameters_json_schema_from_cls()
         if openai_tool_compatible:
             parameters = SerenaMCPFactory._sanitize_for_openai_tools(parameters)
 
@@ -201,26 +202,37 @@ class SerenaMCPFactory:
             prefix = " " if func_doc else ""
             func_doc = f"{func_doc}{prefix}Returns {docstring_returns_descr.strip().strip('.')}."
 
-        # Parse the parameter descriptions from the docstring and add pass its description
-        # to the parameter schema.
-        docstring_params = {param.arg_name: param for param in docstring.params}
-        parameters_properties: dict[str, dict[str, Any]] = parameters["properties"]
-        for parameter, properties in parameters_properties.items():
-            if (param_doc := docstring_params.get(parameter)) and param_doc.description:
-                param_desc = f"{param_doc.description.strip().strip('.') + '.'}"
-                properties["description"] = param_desc[0].upper() + param_desc[1:]
-
         def execute_fn(**kwargs) -> str:  # type: ignore
             return tool.apply_ex(log_call=True, catch_exceptions=True, **kwargs)
 
+        fn: Callable[..., Any] = execute_fn
+        context_kwarg = None
+        if tool.reports_progress:
+            # the tool is applied in a worker thread, such that the event loop can send the progress notifications
+            # while the tool is running
+            is_async = True
+            context_kwarg = "mcp_context"
+
+            async def execute_fn_reporting_progress(mcp_context: Context, **kwargs) -> str:  # type: ignore
+                loop = asyncio.get_running_loop()
+
+                def report_progress(progress: float, total: float | None, message: str | None) -> None:
+                    asyncio.run_coroutine_threadsafe(mcp_context.report_progress(progress, total=total, message=message), loop)
+
+                return await asyncio.to_thread(
+                    tool.apply_ex, log_call=True, catch_exceptions=True, progress_reporter=report_progress, **kwargs
+                )
+
+            fn = execute_fn_reporting_progress
+
         return MCPTool(
-            fn=execute_fn,
+            fn=fn,
             name=func_name,
             description=func_doc,
             parameters=parameters,
             fn_metadata=func_arg_metadata,
             is_async=is_async,
-            context_kwarg=None,
+            context_kwarg=context_kwarg,
             annotations=None,
             title=None,
         )
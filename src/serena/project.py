@@ -296,6 +296,8 @@ class Project:
         ls_config = LanguageServerConfig(
             code_language=self.language,
             ignored_paths=self._ignored_patterns,
+            include_vendor=self.project_config.include_vendor,
+            include_generated=self.project_config.include_generated,
             trace_lsp_communication=trace_lsp_communication,
         )
         ls_logger = LanguageServerLogger(log_level=log_level)
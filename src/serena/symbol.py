@@ -1,7 +1,10 @@
+import hashlib
 import json
 import logging
 import os
+import re
 from abc import ABC, abstractmethod
+from collections import defaultdict
 from collections.abc import Iterator, Sequence
 from dataclasses import asdict, dataclass
 from typing import TYPE_CHECKING, Any, Self, Union
@@ -10,9 +13,12 @@ from sensai.util.string import ToStringMixin
 
 from solidlsp import SolidLanguageServer
 from solidlsp.ls import ReferenceInSymbol as LSPReferenceInSymbol
-from solidlsp.ls_types import Position, SymbolKind, UnifiedSymbolInformation
+from solidlsp.ls_types import Hover, Position, SymbolKind, UnifiedSymbolInformation
+from solidlsp.ls_utils import PathUtils
+from solidlsp.lsp_protocol_handler.lsp_types import CallHierarchyItem
 
 from .project import Project
+from .util.name_path import format_name_path, match_name_path_part, parse_name_path
 
 if TYPE_CHECKING:
     from .agent import SerenaAgent
@@ -114,42 +120,44 @@ class Symbol(ABC):
 
 class LanguageServerSymbol(Symbol, ToStringMixin):
     _NAME_PATH_SEP = "/"
+    _GO_METHOD_NAME_PATTERN = re.compile(r"^\((\*?)([^)]+)\)\.(.+)$")
+    """
+    gopls reports methods as top-level symbols named after their receiver, e.g. "(*ChildStruct).GetValue"
+    """
 
     @staticmethod
     def match_name_path(
         name_path: str,
         symbol_name_path_parts: list[str],
         substring_matching: bool,
+        separator: str = _NAME_PATH_SEP,
     ) -> bool:
         """
         Checks if a given `name_path` matches a symbol's qualified name parts.
         See docstring of `Symbol.find` for more details.
+
+        :raises ValueError: if `name_path` is malformed (see `parse_name_path`)
         """
         assert name_path, "name_path must not be empty"
         assert symbol_name_path_parts, "symbol_name_path_parts must not be empty"
-        name_path_sep = LanguageServerSymbol._NAME_PATH_SEP
 
-        is_absolute_pattern = name_path.startswith(name_path_sep)
-        pattern_parts = name_path.lstrip(name_path_sep).rstrip(name_path_sep).split(name_path_sep)
+        parsed_name_path = parse_name_path(name_path, separator=separator)
+        pattern_parts = parsed_name_path.parts
 
         # filtering based on ancestors
         if len(pattern_parts) > len(symbol_name_path_parts):
             # can't possibly match if pattern has more parts than symbol
             return False
-        if is_absolute_pattern and len(pattern_parts) != len(symbol_name_path_parts):
+        if parsed_name_path.is_absolute and len(pattern_parts) != len(symbol_name_path_parts):
             # for absolute patterns, the number of parts must match exactly
             return False
-        if symbol_name_path_parts[-len(pattern_parts) : -1] != pattern_parts[:-1]:
+        ancestor_parts = symbol_name_path_parts[-len(pattern_parts) : -1]
+        if not all(match_name_path_part(p, a) for p, a in zip(pattern_parts[:-1], ancestor_parts, strict=True)):
             # ancestors must match
             return False
 
         # matching the last part of the symbol name
-        name_to_match = pattern_parts[-1]
-        symbol_name = symbol_name_path_parts[-1]
-        if substring_matching:
-            return name_to_match in symbol_name
-        else:
-            return name_to_match == symbol_name
+        return match_name_path_part(pattern_parts[-1], symbol_name_path_parts[-1], substring_matching=substring_matching)
 
     def __init__(self, symbol_root_from_ls: UnifiedSymbolInformation) -> None:
         self.symbol_root = symbol_root_from_ls
@@ -253,11 +261,40 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
     def body(self) -> str | None:
         return self.symbol_root.get("body")
 
+    def get_body_hash(self, file_content: str | None = None) -> str | None:
+        """
+        :param file_content: the current content of the symbol's file, from which the body is extracted (via the symbol's
+            range) if the symbol was loaded without its body
+        :return: a hash of the symbol's body, which changes if and only if the body changes;
+            None if the symbol was loaded without its body and no file content is given
+        """
+        body = self.body
+        if body is None:
+            if file_content is None or "location" not in self.symbol_root:
+                return None
+            # extract the body in the same way as the language server does when loading symbols with their bodies
+            symbol_range = self.symbol_root["location"]["range"]
+            lines = file_content.split("\n")
+            body = "\n".join(lines[symbol_range["start"]["line"] : symbol_range["end"]["line"] + 1])
+            body = body[symbol_range["start"]["character"] :]
+        return hashlib.md5(body.encode("utf-8")).hexdigest()
+
     def get_name_path(self) -> str:
         """
         Get the name path of the symbol (e.g. "class/method/inner_function").
+        Separators and unbalanced brackets within symbol names are escaped (see `parse_name_path`).
         """
-        return self._NAME_PATH_SEP.join(self.get_name_path_parts())
+        return format_name_path(self.get_name_path_parts(), separator=self._NAME_PATH_SEP)
+
+    def get_symbol_id(self) -> str | None:
+        """
+        :return: an identifier of the symbol of the form "<relative path>:<name path>" (e.g. "processor.go:ConcreteProcessor"),
+            which remains valid as long as the symbol is neither renamed nor moved; None if the symbol's file is unknown
+        """
+        relative_path = self.relative_path
+        if relative_path is None:
+            return None
+        return f"{relative_path}:{self.get_name_path()}"
 
     def get_name_path_parts(self) -> list[str]:
         """
@@ -265,7 +302,19 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         """
         ancestors_within_file = list(self.iter_ancestors(up_to_symbol_kind=SymbolKind.File))
         ancestors_within_file.reverse()
-        return [a.name for a in ancestors_within_file] + [self.name]
+        return [a.name for a in ancestors_within_file] + self._get_own_name_path_parts()
+
+    def _get_own_name_path_parts(self) -> list[str]:
+        """
+        :return: the name path parts contributed by this symbol itself. This is usually just the symbol's name,
+            but Go methods (which are reported as top-level symbols) are placed below their receiver type,
+            such that "(*ChildStruct).GetValue" is addressed as "ChildStruct/GetValue".
+        """
+        if self.symbol_kind == SymbolKind.Method:
+            match = self._GO_METHOD_NAME_PATTERN.match(self.name)
+            if match is not None:
+                return [match.group(2), match.group(3)]
+        return [self.name]
 
     def iter_children(self) -> Iterator[Self]:
         for c in self.symbol_root["children"]:
@@ -297,6 +346,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         substring_matching: bool = False,
         include_kinds: Sequence[SymbolKind] | None = None,
         exclude_kinds: Sequence[SymbolKind] | None = None,
+        name_path_separator: str = "/",
     ) -> list[Self]:
         """
         Find all symbols within the symbol's subtree that match the given `name_path`.
@@ -319,6 +369,12 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
           that the first segment of it must match the first segment of the symbol's name path.
           For example, passing `/class` will match only against top-level symbols like `class` but not against `nested_class/class`.
           Passing `/class/method` will match against `class/method` but not `nested_class/class/method` or `method`.
+        - Bracket groups are never split, so the Go method `Add` of the generic type `Container[T]` is addressed as
+          `Container[T]/Add` (or `Container[T].Add` with separator `.`). A type parameter list is only compared if both
+          the segment and the symbol name contain one, i.e. `Container/Add` matches, too.
+        - A backslash escapes the next character, allowing separators and brackets within a segment (e.g. `a\\.b`
+          with separator `.`). Empty segments, unbalanced brackets and dangling escapes are rejected with a `ValueError`.
+          See `serena.util.name_path` for the full grammar.
 
         :param name_path: the name path to match against
         :param substring_matching: whether to use substring matching (as opposed to exact matching)
@@ -326,6 +382,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         :param include_kinds: an optional sequence of ints representing the LSP symbol kind.
             If provided, only symbols of the given kinds will be included in the result.
         :param exclude_kinds: If provided, symbols of the given kinds will be excluded from the result.
+        :param name_path_separator: the (single) character separating the segments of `name_path`.
         """
         result = []
 
@@ -338,6 +395,7 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
                 name_path=name_path,
                 symbol_name_path_parts=s.get_name_path_parts(),
                 substring_matching=substring_matching,
+                separator=name_path_separator,
             )
 
         def traverse(s: "LanguageServerSymbol") -> None:
@@ -357,6 +415,8 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
         include_body: bool = False,
         include_children_body: bool = False,
         include_relative_path: bool = True,
+        include_body_hash: bool = False,
+        file_content: str | None = None,
     ) -> dict[str, Any]:
         """
         Converts the symbol to a dictionary.
@@ -371,6 +431,11 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
             and pass the children without passing the parent body to the LM.
         :param include_relative_path: whether to include the relative path of the symbol in the location
             entry. Relative paths of the symbol's children are always excluded.
+        :param include_body_hash: whether to include a hash of the body (for the symbol and its children), which allows
+            to detect changes without comparing the bodies themselves. Requires the symbol to have been loaded with its body
+            or `file_content` to be given.
+        :param file_content: the current content of the symbol's file, from which the body hashes are computed for symbols
+            which were loaded without their bodies
         :return: a dictionary representation of the symbol
         """
         result: dict[str, Any] = {"name": self.name, "name_path": self.get_name_path()}
@@ -388,6 +453,9 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
                 log.warning("Requested body for symbol, but it is not present. The symbol might have been loaded with include_body=False.")
             result["body"] = self.body
 
+        if include_body_hash:
+            result["body_hash"] = self.get_body_hash(file_content)
+
         def add_children(s: Self) -> list[dict[str, Any]]:
             children = []
             for c in s.iter_children():
@@ -400,6 +468,8 @@ class LanguageServerSymbol(Symbol, ToStringMixin):
                         include_children_body=include_children_body,
                         # all children have the same relative path as the parent
                         include_relative_path=False,
+                        include_body_hash=include_body_hash,
+                        file_content=file_content,
                     )
                 )
             return children
@@ -440,6 +510,28 @@ class ReferenceInLanguageServerSymbol(ToStringMixin):
         return self.symbol.location.relative_path
 
 
+@dataclass
+class CallHierarchyEntry:
+    """
+    Represents a symbol reached when following the calls from or to another symbol.
+    """
+
+    symbol: LanguageServerSymbol
+    depth: int
+    """
+    the number of calls between the start symbol and this symbol (1 for direct callers/callees)
+    """
+    via: LanguageServerSymbol
+    """
+    the symbol at the previous depth from which this symbol was reached, i.e. the symbol called by this symbol
+    (for callers) or the symbol calling this symbol (for callees)
+    """
+    call_lines: list[int]
+    """
+    the (0-based) lines of the calls connecting `via` and this symbol, which are located in the calling symbol's file
+    """
+
+
 class LanguageServerSymbolRetriever:
     def __init__(self, lang_server: SolidLanguageServer, agent: Union["SerenaAgent", None] = None) -> None:
         """
@@ -468,6 +560,7 @@ class LanguageServerSymbolRetriever:
         exclude_kinds: Sequence[SymbolKind] | None = None,
         substring_matching: bool = False,
         within_relative_path: str | None = None,
+        name_path_separator: str = "/",
     ) -> list[LanguageServerSymbol]:
         """
         Find all symbols that match the given name. See docstring of `Symbol.find` for more details.
@@ -479,16 +572,197 @@ class LanguageServerSymbolRetriever:
         for root in symbol_roots:
             symbols.extend(
                 LanguageServerSymbol(root).find(
-                    name_path, include_kinds=include_kinds, exclude_kinds=exclude_kinds, substring_matching=substring_matching
+                    name_path,
+                    include_kinds=include_kinds,
+                    exclude_kinds=exclude_kinds,
+                    substring_matching=substring_matching,
+                    name_path_separator=name_path_separator,
                 )
             )
         return symbols
 
-    def get_document_symbols(self, relative_path: str) -> list[LanguageServerSymbol]:
-        symbol_dicts, _roots = self._lang_server.request_document_symbols(relative_path, include_body=False)
+    def find_by_symbol_id(self, symbol_id: str) -> LanguageServerSymbol:
+        """
+        :param symbol_id: the ID of a symbol (see `LanguageServerSymbol.get_symbol_id`), e.g. "processor.go:ConcreteProcessor"
+        :return: the symbol with the given ID; a ValueError is raised if there is no unique such symbol
+        """
+        relative_path, sep, name_path = symbol_id.partition(":")
+        if not sep or not relative_path or not name_path:
+            raise ValueError(f"Invalid symbol ID {symbol_id!r}; expected <relative path>:<name path>")
+        candidates = [
+            s
+            for s in self.find_by_name(name_path, within_relative_path=relative_path)
+            if s.relative_path == relative_path and s.get_name_path() == name_path
+        ]
+        if not candidates:
+            raise ValueError(f"No symbol with ID {symbol_id!r} found")
+        if len(candidates) > 1:
+            raise ValueError(f"The symbol ID {symbol_id!r} is ambiguous: {len(candidates)} symbols in {relative_path} share the name path")
+        return candidates[0]
+
+    def find_by_name_within_parent(
+        self,
+        parent: LanguageServerSymbol,
+        name_path: str,
+        include_body: bool = False,
+        include_kinds: Sequence[SymbolKind] | None = None,
+        exclude_kinds: Sequence[SymbolKind] | None = None,
+        substring_matching: bool = False,
+        within_relative_path: str | None = None,
+        name_path_separator: str = "/",
+    ) -> list[LanguageServerSymbol]:
+        """
+        Finds the symbols below the given parent symbol (i.e. the symbols whose name paths extend the parent's name path)
+        which match the given name path relative to the parent, e.g. "Process" below "ConcreteProcessor".
+        An absolute name path (e.g. "/Process") only matches the parent's direct children.
+        See docstring of `Symbol.find` for the remaining parameters.
+
+        :param parent: the parent symbol
+        :param within_relative_path: the file or directory in which to search; if None, the parent's file is searched.
+            Note that Go methods are top-level symbols, which may be declared in other files than their receiver type.
+        """
+        parent_parts = parent.get_name_path_parts()
+        is_absolute = name_path.startswith(name_path_separator)
+        candidates = self.find_by_name(
+            name_path[1:] if is_absolute else name_path,
+            include_body=include_body,
+            include_kinds=include_kinds,
+            exclude_kinds=exclude_kinds,
+            substring_matching=substring_matching,
+            within_relative_path=within_relative_path if within_relative_path is not None else parent.relative_path,
+            name_path_separator=name_path_separator,
+        )
+        symbols = []
+        for symbol in candidates:
+            parts = symbol.get_name_path_parts()
+            if len(parts) <= len(parent_parts) or parts[: len(parent_parts)] != parent_parts:
+                continue
+            if LanguageServerSymbol.match_name_path(
+                name_path, parts[len(parent_parts) :], substring_matching=substring_matching, separator=name_path_separator
+            ):
+                symbols.append(symbol)
+        return symbols
+
+    def get_document_symbols(
+        self, relative_path: str, include_body: bool = False, content: str | None = None
+    ) -> list[LanguageServerSymbol]:
+        """
+        :param relative_path: the relative path of the file
+        :param include_body: whether to include the symbols' bodies
+        :param content: if given, the symbols are determined for this (alternative) content of the file instead of its
+            actual content; the file itself remains unchanged
+        :return: all symbols in the file (flattened)
+        """
+        if content is not None:
+            with self._lang_server.overlay_file(relative_path, content):
+                symbol_dicts, _roots = self._lang_server.request_document_symbols(relative_path, include_body=include_body)
+        else:
+            symbol_dicts, _roots = self._lang_server.request_document_symbols(relative_path, include_body=include_body)
         symbols = [LanguageServerSymbol(s) for s in symbol_dicts]
         return symbols
 
+    @dataclass
+    class SymbolDiff:
+        """
+        The symbol-level differences between two versions of a file's content
+        """
+
+        added: list[LanguageServerSymbol]
+        """
+        the symbols which exist only in the other version
+        """
+        removed: list[LanguageServerSymbol]
+        """
+        the symbols which exist only in the first version
+        """
+        modified: list[LanguageServerSymbol]
+        """
+        the symbols (of the first version) whose bodies differ between the versions
+        """
+
+        def is_empty(self) -> bool:
+            return not self.added and not self.removed and not self.modified
+
+        def to_dict(self) -> dict[str, list[dict[str, Any]]]:
+            """
+            :return: a dictionary with the lists `added`, `removed` and `modified`, each entry given by name path and kind
+            """
+
+            def to_entries(symbols: list[LanguageServerSymbol]) -> list[dict[str, Any]]:
+                return [{"name_path": symbol.get_name_path(), "kind": symbol.kind} for symbol in symbols]
+
+            return {"added": to_entries(self.added), "removed": to_entries(self.removed), "modified": to_entries(self.modified)}
+
+    def diff_symbols(self, relative_path: str, content: str | None, other_content: str | None) -> SymbolDiff:
+        """
+        Compares the symbols of two versions of a file's content. A symbol is considered modified if its body changed
+        (as indicated by its body hash); symbols which were merely moved are not reported.
+
+        :param relative_path: the relative path of the file
+        :param content: the first version of the content; None for the file's actual content
+        :param other_content: the other version of the content; None for the file's actual content
+        :return: the differences
+        """
+
+        def get_symbols_by_name_path(version: str | None) -> dict[str, list[LanguageServerSymbol]]:
+            symbols_by_name_path: dict[str, list[LanguageServerSymbol]] = defaultdict(list)
+            for symbol in self.get_document_symbols(relative_path, include_body=True, content=version):
+                symbols_by_name_path[symbol.get_name_path()].append(symbol)
+            return symbols_by_name_path
+
+        symbols = get_symbols_by_name_path(content)
+        other_symbols = get_symbols_by_name_path(other_content)
+        diff = self.SymbolDiff(added=[], removed=[], modified=[])
+        for name_path, symbols_with_name_path in symbols.items():
+            if name_path not in other_symbols:
+                diff.removed.extend(symbols_with_name_path)
+            else:
+                # symbols sharing a name path (e.g. overloads) are compared as a multiset of bodies
+                other_hashes = sorted(str(s.get_body_hash()) for s in other_symbols[name_path])
+                if sorted(str(s.get_body_hash()) for s in symbols_with_name_path) != other_hashes:
+                    diff.modified.append(symbols_with_name_path[0])
+        for name_path, other_symbols_with_name_path in other_symbols.items():
+            if name_path not in symbols:
+                diff.added.extend(other_symbols_with_name_path)
+        return diff
+
+    def find_symbol_at_line(self, relative_path: str, line: int) -> LanguageServerSymbol | None:
+        """
+        Finds the innermost symbol containing the given line (see `SolidLanguageServer.request_containing_symbol`).
+
+        :param relative_path: the relative path of the file
+        :param line: the 0-based line
+        :return: the innermost symbol containing the line or None if the line is not within any symbol
+        """
+        symbol_dict = self._lang_server.request_containing_symbol(relative_path, line)
+        if symbol_dict is None:
+            return None
+        return LanguageServerSymbol(symbol_dict)
+
+    def find_symbols_in_range(self, relative_path: str, start_line: int, end_line: int) -> list[LanguageServerSymbol]:
+        """
+        Finds all symbols whose bodies overlap the given range of lines, including symbols enclosing the range
+        (e.g. the type of which the range covers some fields).
+
+        :param relative_path: the relative path of the file
+        :param start_line: the 0-based first line of the range
+        :param end_line: the 0-based last line of the range (inclusive)
+        :return: the overlapping symbols, ordered by start position (enclosing symbols preceding the symbols they contain)
+        """
+        symbol_dicts, _roots = self._lang_server.request_document_symbols(relative_path, include_body=False)
+        symbols_with_keys = []
+        for symbol_dict in symbol_dicts:
+            symbol = LanguageServerSymbol(symbol_dict)
+            symbol_start_line, symbol_end_line = symbol.get_body_line_numbers()
+            if symbol_start_line is None or symbol_end_line is None:
+                continue
+            if symbol_start_line <= end_line and start_line <= symbol_end_line:
+                start_position = symbol.body_start_position
+                assert start_position is not None
+                symbols_with_keys.append(((symbol_start_line, start_position["character"], -symbol_end_line), symbol))
+        symbols_with_keys.sort(key=lambda entry: entry[0])
+        return [symbol for _, symbol in symbols_with_keys]
+
     def find_by_location(self, location: LanguageServerSymbolLocation) -> LanguageServerSymbol | None:
         if location.relative_path is None:
             return None
@@ -499,6 +773,121 @@ class LanguageServerSymbolRetriever:
                 return symbol
         return None
 
+    @staticmethod
+    def _get_position_sort_key(symbol: LanguageServerSymbol) -> tuple[str, int, int]:
+        line = symbol.line if symbol.line is not None else -1
+        column = symbol.column if symbol.column is not None else -1
+        return symbol.relative_path or "", line, column
+
+    def _find_unique_symbol(self, name_path: str, relative_file_path: str) -> LanguageServerSymbol:
+        """
+        :param name_path: the name path of the symbol
+        :param relative_file_path: the relative path of the file containing the symbol
+        :return: the symbol with the given name path in the file; a ValueError is raised if the name path does not match
+            a unique symbol with a position in the file
+        """
+        candidates = self.find_by_name(name_path, substring_matching=False, within_relative_path=relative_file_path)
+        if not candidates:
+            raise ValueError(f"No symbol matching {name_path} found in {relative_file_path}")
+        if len(candidates) > 1:
+            raise ValueError(
+                f"Found {len(candidates)} symbols matching {name_path} in {relative_file_path}: "
+                + ", ".join(s.get_name_path() for s in candidates)
+            )
+        if not candidates[0].location.has_position_in_file():
+            raise ValueError(f"The symbol {name_path} has no position in {relative_file_path}")
+        return candidates[0]
+
+    def find_implementations(self, name_path: str, relative_file_path: str) -> list[LanguageServerSymbol]:
+        """
+        Finds the implementations of the symbol with the given name path as determined by the language server, i.e. for an
+        interface the types implementing it (for Go, this includes types which implement it implicitly through the methods
+        promoted from embedded fields) and for an interface method the implementing methods. Some language servers
+        (e.g. gopls) also report the interfaces a concrete type implements.
+
+        :param name_path: the name path of the symbol
+        :param relative_file_path: the relative path of the file containing the symbol
+        :return: the implementing symbols, ordered by file and position; a ValueError is raised if the name path does not
+            match a unique symbol in the file
+        """
+        location = self._find_unique_symbol(name_path, relative_file_path).location
+        assert location.line is not None and location.column is not None
+        symbols: list[LanguageServerSymbol] = []
+        for implementation in self._lang_server.request_implementation(relative_file_path, location.line, location.column):
+            start = implementation["range"]["start"]
+            implementation_location = LanguageServerSymbolLocation(
+                relative_path=implementation["relativePath"], line=start["line"], column=start["character"]
+            )
+            symbol = self.find_by_location(implementation_location)
+            if symbol is None:
+                log.warning(f"No symbol found at the location of the implementation {implementation_location}")
+                continue
+            if all(s.location != symbol.location for s in symbols):
+                symbols.append(symbol)
+        symbols.sort(key=self._get_position_sort_key)
+        return symbols
+
+    def _find_symbol_for_call_hierarchy_item(self, item: CallHierarchyItem) -> LanguageServerSymbol | None:
+        relative_path = os.path.relpath(PathUtils.uri_to_path(item["uri"]), self._lang_server.repository_root_path)
+        start = item["selectionRange"]["start"]
+        symbol = self.find_by_location(
+            LanguageServerSymbolLocation(relative_path=relative_path, line=start["line"], column=start["character"])
+        )
+        if symbol is None:
+            # the item's identifier may not coincide with the symbol's, e.g. for anonymous functions
+            containing_symbol = self._lang_server.request_containing_symbol(relative_path, start["line"], start["character"])
+            if containing_symbol is not None:
+                symbol = LanguageServerSymbol(containing_symbol)
+        return symbol
+
+    def find_call_hierarchy(self, name_path: str, relative_file_path: str, incoming: bool, max_depth: int = 1) -> list[CallHierarchyEntry]:
+        """
+        Follows the calls to (incoming) or from (outgoing) the symbol with the given name path, as determined by the
+        language server's call hierarchy, up to the given depth. Each symbol is reported once, at the smallest depth at
+        which it is reached (recursive calls thus end the traversal).
+
+        :param name_path: the name path of the function or method
+        :param relative_file_path: the relative path of the file containing the symbol
+        :param incoming: whether to find the callers (True) or the callees (False)
+        :param max_depth: the maximum number of calls between the symbol and the reported symbols
+        :return: the reached symbols ordered by depth and, within the same depth, by file and position; a ValueError is
+            raised if the name path does not match a unique symbol in the file
+        """
+        if max_depth < 1:
+            raise ValueError(f"The maximum depth must be at least 1, got {max_depth}")
+        start_symbol = self._find_unique_symbol(name_path, relative_file_path)
+        location = start_symbol.location
+        assert location.line is not None and location.column is not None
+        items = self._lang_server.request_call_hierarchy_items(relative_file_path, location.line, location.column)
+        if not items:
+            raise ValueError(f"The symbol {name_path} in {relative_file_path} is not a function or method")
+        entries: list[CallHierarchyEntry] = []
+        visited_locations = [start_symbol.location]
+        frontier = [(items[0], start_symbol)]
+        for depth in range(1, max_depth + 1):
+            next_frontier: list[tuple[CallHierarchyItem, LanguageServerSymbol]] = []
+            depth_entries: list[CallHierarchyEntry] = []
+            for item, item_symbol in frontier:
+                if incoming:
+                    calls = [(call["from"], call["fromRanges"]) for call in self._lang_server.request_incoming_calls(item)]
+                else:
+                    calls = [(call["to"], call["fromRanges"]) for call in self._lang_server.request_outgoing_calls(item)]
+                for call_item, call_ranges in calls:
+                    symbol = self._find_symbol_for_call_hierarchy_item(call_item)
+                    if symbol is None:
+                        log.warning(f"No symbol found for the call hierarchy item {call_item['name']} in {call_item['uri']}")
+                        continue
+                    if symbol.location in visited_locations:
+                        continue
+                    visited_locations.append(symbol.location)
+                    call_lines = sorted({r["start"]["line"] for r in call_ranges})
+                    depth_entries.append(CallHierarchyEntry(symbol=symbol, depth=depth, via=item_symbol, call_lines=call_lines))
+                    next_frontier.append((call_item, symbol))
+            depth_entries.sort(key=lambda e: self._get_position_sort_key(e.symbol))
+            entries.extend(depth_entries)
+            frontier = next_frontier
+        return entries
+
     def find_referencing_symbols(
         self,
         name_path: str,
@@ -583,10 +972,26 @@ class LanguageServerSymbolRetriever:
     class SymbolOverviewElement:
         name_path: str
         kind: int
+        num_lines: int | None = None
+        """
+        the number of lines spanned by the symbol's range (including, e.g., a function's signature and closing brace),
+        i.e. its end line minus its start line plus one; None if the range is unknown
+        """
 
         @classmethod
         def from_symbol(cls, symbol: LanguageServerSymbol) -> Self:
-            return cls(name_path=symbol.get_name_path(), kind=int(symbol.symbol_kind))
+            start_line, end_line = symbol.get_body_line_numbers()
+            num_lines = end_line - start_line + 1 if start_line is not None and end_line is not None else None
+            return cls(name_path=symbol.get_name_path(), kind=int(symbol.symbol_kind), num_lines=num_lines)
+
+        def to_dict(self, include_size: bool = False) -> dict[str, Any]:
+            """
+            :param include_size: whether to include the number of lines spanned by the symbol (`num_lines`)
+            """
+            result: dict[str, Any] = {"name_path": self.name_path, "kind": self.kind}
+            if include_size:
+                result["num_lines"] = self.num_lines
+            return result
 
     def get_symbol_overview(self, relative_path: str) -> dict[str, list[SymbolOverviewElement]]:
         path_to_unified_symbols = self._lang_server.request_overview(relative_path)
@@ -597,6 +1002,30 @@ class LanguageServerSymbolRetriever:
             result[file_path] = [self.SymbolOverviewElement.from_symbol(LanguageServerSymbol(s)) for s in unified_symbols]
         return result
 
+    @staticmethod
+    def _get_hover_markdown(hover: Hover) -> str:
+        contents = hover["contents"]
+        if isinstance(contents, str):
+            return contents
+        if isinstance(contents, list):
+            return "\n".join(c if isinstance(c, str) else f"```{c['language']}\n{c['value']}\n```" for c in contents)
+        if "language" in contents:
+            return f"```{contents['language']}\n{contents['value']}\n```"
+        return contents["value"]
+
+    def get_hover_text(self, relative_path: str, line: int, column: int) -> str | None:
+        """
+        :param relative_path: the relative path of the file
+        :param line: the 0-based line
+        :param column: the 0-based column
+        :return: the hover information the language server provides for the given position (as markdown, with
+            marked strings converted to fenced code blocks), or None if there is none
+        """
+        hover = self._lang_server.request_hover(relative_path, line, column)
+        if hover is None:
+            return None
+        return self._get_hover_markdown(hover)
+
 
 class JetBrainsSymbol(Symbol):
     def __init__(self, symbol_dict: dict, project: Project) -> None:
@@ -1,7 +1,8 @@
 import json
 
+from serena import serena_version
 from serena.config.context_mode import SerenaAgentMode
-from serena.tools import Tool, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOptional
+from serena.tools import Tool, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOptional, ToolRegistry
 
 
 class ActivateProjectTool(Tool, ToolMarkerDoesNotRequireActiveProject):
@@ -81,3 +82,50 @@ class GetCurrentConfigTool(Tool):
         Print the current configuration of the agent, including the active and available projects, tools, contexts, and modes.
         """
         return self.agent.get_current_config_overview()
+
+
+class ToolsManifestTool(Tool, ToolMarkerDoesNotRequireActiveProject, ToolMarkerOptional):
+    """
+    Provides a machine-readable manifest describing the parameters and results of the symbolic and editing tools.
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "schema_version": {"type": "integer"},
+            "version": {"type": "string"},
+            "serena_version": {"type": "string"},
+            "tools": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "name": {"type": "string"},
+                        "description": {"type": "string"},
+                        "input_schema": {"type": "object"},
+                        "output_schema": {"type": "object"},
+                        "output_format": {"type": "string", "enum": ["json", "text"]},
+                        "can_edit": {"type": "boolean"},
+                        "optional": {"type": "boolean"},
+                        "active": {"type": "boolean"},
+                    },
+                    "required": ["name", "description", "input_schema", "output_schema", "output_format", "can_edit", "optional", "active"],
+                },
+            },
+        },
+        "required": ["schema_version", "version", "serena_version", "tools"],
+    }
+
+    def apply(self) -> str:
+        """
+        Provides the JSON schemas of the parameters and results of all symbolic and editing tools, which allows clients
+        to validate tool calls before sending them. The manifest's `version` changes whenever any of the described tools
+        changes, so clients can use it to detect changes in the tools' capabilities.
+
+        :return: a JSON object with the `schema_version` of the manifest's structure, the content `version`, the
+            `serena_version` and the list of `tools`, each with its `name`, `description`, `input_schema`, `output_schema`,
+            `output_format` ("json" or "text") and the flags `can_edit`, `optional` and `active`
+        """
+        manifest = ToolRegistry().create_tools_manifest(self.agent.get_active_tool_names())
+        manifest["serena_version"] = serena_version()
+        return json.dumps(manifest)
@@ -14,7 +14,7 @@ from fnmatch import fnmatch
 from pathlib import Path
 
 from serena.text_utils import search_files
-from serena.tools import SUCCESS_RESULT, EditedFileContext, Tool, ToolMarkerCanEdit, ToolMarkerOptional
+from serena.tools import SUCCESS_RESULT, SUCCESS_RESULT_SCHEMA, EditedFileContext, Tool, ToolMarkerCanEdit, ToolMarkerOptional
 from serena.util.file_system import scan_directory
 
 
@@ -163,6 +163,8 @@ class ReplaceRegexTool(Tool, ToolMarkerCanEdit):
     Replaces content in a file by using regular expressions.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         relative_path: str,
@@ -213,6 +215,8 @@ class DeleteLinesTool(Tool, ToolMarkerCanEdit, ToolMarkerOptional):
     Deletes a range of lines within a file.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         relative_path: str,
@@ -241,6 +245,8 @@ class ReplaceLinesTool(Tool, ToolMarkerCanEdit, ToolMarkerOptional):
     Replaces a range of lines within a file with new content.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         relative_path: str,
@@ -272,6 +278,8 @@ class InsertAtLineTool(Tool, ToolMarkerCanEdit, ToolMarkerOptional):
     Inserts content at a given line in a file.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         relative_path: str,
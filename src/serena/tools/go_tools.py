@@ -0,0 +1,3796 @@
+"""
+Go-specific tools, which build upon the symbol information provided by gopls and a textual analysis of the Go sources
+"""
+
+import json
+import os
+import re
+from collections import defaultdict
+from typing import TYPE_CHECKING, Any
+
+from serena.go_analysis import (
+    INDETERMINATE_TYPE,
+    ApiChange,
+    ApiChangeKind,
+    GoAnalyzer,
+    MemberSelectionKind,
+    ReturnFlow,
+    check_interface_satisfaction,
+    compare_apis,
+    find_cycles,
+    select_return_flows,
+)
+from serena.symbol import PositionInFile
+from serena.tools import SUCCESS_RESULT, SUCCESS_RESULT_SCHEMA, Tool, ToolMarkerOptional, ToolMarkerSymbolicEdit, ToolMarkerSymbolicRead
+from serena.tools.symbol_tools import _SYMBOL_SCHEMA, _sanitize_symbol_dict
+from serena.util.go_source import (
+    BASIC_TYPES,
+    GoControlFlowFeatureKind,
+    GoDeclaration,
+    GoDeclarationKind,
+    GoFile,
+    GoInterfaceElement,
+    GoReferenceExpression,
+    GoReferenceRole,
+    GoTextEdit,
+    GoUnderlyingKind,
+    add_struct_tag_key,
+    classify_type_expression,
+    count_body_statements,
+    find_blocks,
+    find_common_body,
+    find_control_flow_features,
+    find_field_assignments,
+    find_local_declarations,
+    find_matching_bracket,
+    find_reference_expression,
+    find_unwrapped_error_returns,
+    find_variable_uses,
+    get_block_replacement,
+    get_body_similarity,
+    get_embedded_type_replacement,
+    get_error_wrapping_edit,
+    get_interface_method_insertion,
+    get_named_type_identifier,
+    get_parameter_and_result_types,
+    get_parameter_names,
+    get_reference_transformation_edit,
+    get_struct_field_insertion,
+    get_struct_field_removal,
+    get_struct_tag_value,
+    get_struct_tags_edit,
+    get_zero_value_literal,
+    instantiate_reference_template,
+    is_exported,
+    normalize_method_signature,
+    parse_go_file,
+    parse_interface_elements,
+    parse_struct_fields,
+    references_receiver,
+    select_block,
+    to_json_field_name,
+    tokenize,
+)
+from serena.util.name_path import format_name_path, parse_name_path
+
+if TYPE_CHECKING:
+    from serena.code_editor import LanguageServerCodeEditor
+
+# kinds of types whose zero value (nil) cannot be used without prior initialisation, with the reason why
+_NIL_ZERO_VALUE_KINDS = {
+    GoUnderlyingKind.MAP: "the zero value is a nil map; writing to it panics",
+    GoUnderlyingKind.CHAN: "the zero value is a nil channel; sending or receiving blocks forever",
+    GoUnderlyingKind.FUNC: "the zero value is a nil function; calling it panics",
+    GoUnderlyingKind.POINTER: "the zero value is a nil pointer; dereferencing it panics",
+    GoUnderlyingKind.INTERFACE: "the zero value is a nil interface; calling a method on it panics",
+}
+
+
+# JSON schemas of result elements shared by several Go tools
+_GO_TYPE_LOCATION_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {"type": {"type": "string"}, "relative_path": {"type": "string"}},
+    "required": ["type", "relative_path"],
+}
+_GO_MEMBER_LOCATION_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {"name_path": {"type": "string"}, "relative_path": {"type": "string"}},
+    "required": ["name_path", "relative_path"],
+}
+_GODOC_ENTRY_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {
+        "name": {"type": "string"},
+        "declaration": {"type": "string"},
+        "doc": {"type": ["string", "null"]},
+        "deprecated": {"type": "boolean"},
+        "deprecation_message": {"type": "string"},
+    },
+    "required": ["name", "declaration", "doc", "deprecated"],
+}
+_API_CHANGE_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {
+        "change": {"type": "string", "enum": [k.value for k in ApiChangeKind]},
+        "name_path": {"type": "string"},
+        "old": {"type": "string"},
+        "new": {"type": "string"},
+    },
+    "required": ["change", "name_path"],
+}
+
+
+def _to_position(go_file: GoFile, offset: int) -> PositionInFile:
+    line, column = go_file.get_line_and_column(offset)
+    return PositionInFile(line=line, col=column)
+
+
+def _apply_edit(
+    code_editor: "LanguageServerCodeEditor", relative_path: str, go_file: GoFile, edit: GoTextEdit, organize_imports: bool
+) -> None:
+    """
+    Applies the given edit to the file and formats it afterwards (as gofmt would).
+    If formatting fails (e.g. because the edit introduced a syntax error), the file is restored.
+
+    :param code_editor: the code editor with which to apply the edit
+    :param relative_path: the relative path of the file
+    :param go_file: the parsed file to which the edit's offsets refer
+    :param edit: the edit to apply
+    :param organize_imports: whether to add missing and remove unused imports after the edit
+    """
+    with code_editor.edit_transaction():
+        code_editor.replace_text(relative_path, _to_position(go_file, edit.start), _to_position(go_file, edit.end), edit.new_text)
+        code_editor.format_file(relative_path, organize_imports=organize_imports)
+
+
+def _write_contents(code_editor: "LanguageServerCodeEditor", go_analyzer: GoAnalyzer, contents: dict[str, str]) -> None:
+    """
+    Replaces the contents of the given files with the code editor (within one transaction).
+
+    :param code_editor: the code editor with which to write the files
+    :param go_analyzer: the analyzer with which to read the current contents
+    :param contents: maps the relative paths of the files to their new contents
+    """
+    with code_editor.edit_transaction():
+        for relative_path, content in sorted(contents.items()):
+            go_file = go_analyzer.parse_file(relative_path)
+            code_editor.replace_text(relative_path, _to_position(go_file, 0), _to_position(go_file, len(go_file.source)), content)
+
+
+def _get_zero_value_expression(go_analyzer: GoAnalyzer, type_expr: str, package_dir: str) -> str:
+    """
+    :param go_analyzer: the analyzer with which to resolve types declared in the package
+    :param type_expr: a type expression, e.g. "error" or "[]byte"
+    :param package_dir: the directory of the package in whose context the type expression appears
+    :return: an expression evaluating to the zero value of the type, e.g. "nil" or `""`
+    """
+    kind = go_analyzer.classify_type(type_expr, package_dir)
+    if kind in _NIL_ZERO_VALUE_KINDS or kind == GoUnderlyingKind.SLICE:
+        return "nil"
+    if kind == GoUnderlyingKind.BASIC and type_expr in BASIC_TYPES:
+        return {"string": '""', "bool": "false"}.get(type_expr, "0")
+    if kind in (GoUnderlyingKind.STRUCT, GoUnderlyingKind.ARRAY):
+        return type_expr + "{}"
+    # e.g. types from other packages, whose kind is unknown
+    return f"*new({type_expr})"
+
+
+class ZeroValueTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Provides a snippet constructing the zero value of a Go type (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "type": {"type": "string"},
+            "relative_path": {"type": "string"},
+            "zero_value": {"type": "string"},
+            "needs_pointer": {"type": "boolean"},
+            "pointer_receiver_methods": {"type": "array", "items": {"type": "string"}},
+            "required_fields": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"name": {"type": "string"}, "type": {"type": "string"}, "reason": {"type": "string"}},
+                    "required": ["name", "type", "reason"],
+                },
+            },
+        },
+        "required": ["type", "relative_path", "zero_value", "needs_pointer", "pointer_receiver_methods", "required_fields"],
+    }
+
+    def apply(self, type_name_path: str, relative_path: str) -> str:
+        """
+        Provides a Go snippet constructing the zero value of the given named type, e.g. `BaseStruct{}`, or a pointer to it,
+        e.g. `&ConcreteProcessor{}`, if the type has methods with pointer receivers (which implies that it is typically used
+        via a pointer). Also reports the struct fields whose zero values are not usable without initialisation
+        (maps, channels, functions, pointers and interfaces), which should be set explicitly.
+
+        :param type_name_path: the name path of the type, e.g. "BaseStruct"
+        :param relative_path: the relative path of the file declaring the type
+        :return: a JSON object with the type name, the relative path, the `zero_value` snippet, whether a pointer is needed
+            (`needs_pointer`), the names of the methods with pointer receivers and the `required_fields`
+            (each with name, type and reason)
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        assert declaration.type_expr is not None
+        package_dir = os.path.dirname(relative_path)
+        underlying_kind = go_analyzer.get_underlying_kind(declaration, package_dir)
+
+        pointer_receiver_methods = [
+            method.name for _, method in go_analyzer.get_methods(declaration.name, package_dir) if method.receiver_is_pointer
+        ]
+        needs_pointer = len(pointer_receiver_methods) > 0
+        zero_value = get_zero_value_literal(declaration.name, underlying_kind, declaration.type_expr)
+        if needs_pointer:
+            # composite literals can be addressed directly; other values require new(T)
+            zero_value = "&" + zero_value if zero_value.endswith("{}") else f"new({declaration.name})"
+
+        required_fields = []
+        if classify_type_expression(declaration.type_expr) == GoUnderlyingKind.STRUCT:
+            for field in parse_struct_fields(declaration.type_expr):
+                field_kind = go_analyzer.classify_type(field.type_expr, package_dir)
+                reason = _NIL_ZERO_VALUE_KINDS.get(field_kind)
+                if reason is not None:
+                    required_fields.append({"name": field.name, "type": field.type_expr, "reason": reason})
+
+        result = {
+            "type": declaration.name,
+            "relative_path": relative_path,
+            "zero_value": zero_value,
+            "needs_pointer": needs_pointer,
+            "pointer_receiver_methods": pointer_receiver_methods,
+            "required_fields": required_fields,
+        }
+        return json.dumps(result)
+
+
+class PackageFilesTool(Tool, ToolMarkerOptional):
+    """
+    Lists the Go files of a package, tagging each as regular, test or generated (Go only).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "relative_path": {"type": "string"},
+                "role": {"type": "string", "enum": ["regular", "test", "generated"]},
+                "package": {"type": ["string", "null"]},
+            },
+            "required": ["relative_path", "role", "package"],
+        },
+    }
+
+    def apply(self, relative_path: str = "") -> str:
+        """
+        Lists the Go files of a package (i.e. of a directory, non-recursively) and tags each of them with its role:
+        "generated" for files carrying the standard `// Code generated ... DO NOT EDIT.` header, "test" for `_test.go` files
+        and "regular" for all other files. Generated files should not be edited manually.
+
+        :param relative_path: the relative path of the package directory or of a file within it; "" for the project root
+        :return: a JSON list of objects with the relative path, the role and the package name of each file
+        """
+        self.project.validate_relative_path(relative_path)
+        package_dir = relative_path
+        if os.path.isfile(os.path.join(self.get_project_root(), relative_path)):
+            package_dir = os.path.dirname(relative_path)
+        go_analyzer = self.create_go_analyzer()
+        result = []
+        for file_path in go_analyzer.get_package_files(package_dir):
+            go_file = go_analyzer.parse_file(file_path)
+            if go_file.is_generated():
+                role = "generated"
+            elif file_path.endswith("_test.go"):
+                role = "test"
+            else:
+                role = "regular"
+            result.append({"relative_path": file_path, "role": role, "package": go_file.package_name})
+        return json.dumps(result)
+
+
+class DetectCyclesTool(Tool, ToolMarkerOptional):
+    """
+    Detects cyclic struct embeddings and import cycles, both of which are compile errors (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "embedding_cycles": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "package_dir": {"type": "string"},
+                        "cycle": {"type": "array", "items": {"type": "string"}},
+                        "locations": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "type": {"type": "string"},
+                                    "relative_path": {"type": "string"},
+                                    "line": {"type": "integer"},
+                                },
+                                "required": ["type", "relative_path", "line"],
+                            },
+                        },
+                    },
+                    "required": ["package_dir", "cycle", "locations"],
+                },
+            },
+            "import_cycles": {"type": "array", "items": {"type": "array", "items": {"type": "string"}}},
+        },
+        "required": ["embedding_cycles", "import_cycles"],
+    }
+
+    def apply(self, relative_path: str = "", max_answer_chars: int = -1) -> str:
+        """
+        Detects cyclic struct embeddings (e.g. A embeds B, which embeds A) among the struct types of the packages within
+        the given scope as well as import cycles involving these packages. Both are forbidden in Go, so this allows
+        detecting such mistakes before compilation.
+
+        :param relative_path: the relative path of the file or directory defining the scope; "" for the entire project
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the `embedding_cycles` (each with the package directory, the `cycle` as a list of type
+            names starting and ending with the same type, and the locations of the types involved) and the `import_cycles`
+            (each given as a list of import paths starting and ending with the same path)
+        """
+        go_analyzer = self.create_go_analyzer()
+        package_dirs = sorted({os.path.dirname(p) for p in self.project.gather_source_files(relative_path) if p.endswith(".go")})
+
+        embedding_cycles = []
+        for package_dir in package_dirs:
+            embedding_graph: dict[str, list[str]] = defaultdict(list)
+            locations: dict[str, tuple[str, int]] = {}
+            for file_path in go_analyzer.get_package_files(package_dir):
+                go_file = go_analyzer.parse_file(file_path)
+                for declaration in go_file.iter_declarations(GoDeclarationKind.TYPE):
+                    if declaration.type_expr is None or classify_type_expression(declaration.type_expr) != GoUnderlyingKind.STRUCT:
+                        continue
+                    locations[declaration.name] = (file_path, go_file.get_line_and_column(declaration.name_start)[0])
+                    for field in parse_struct_fields(declaration.type_expr):
+                        # embedding a pointer does not contain the embedded value and is therefore not cyclic
+                        if not field.embedded or field.is_pointer:
+                            continue
+                        named_type = get_named_type_identifier(field.type_expr)
+                        if named_type is not None and named_type[0] is None:
+                            embedding_graph[declaration.name].append(named_type[1])
+            for cycle in find_cycles(embedding_graph):
+                if all(type_name in locations for type_name in cycle):
+                    embedding_cycles.append(
+                        {
+                            "package_dir": package_dir,
+                            "cycle": cycle,
+                            "locations": [
+                                {"type": t, "relative_path": locations[t][0], "line": locations[t][1]} for t in cycle[:-1]
+                            ],
+                        }
+                    )
+
+        # the import graph is explored beyond the scope, as cycles involving packages in scope may pass through other packages
+        import_graph: dict[str, list[str]] = {}
+        pending_dirs = list(package_dirs)
+        while pending_dirs:
+            package_dir = pending_dirs.pop()
+            if package_dir in import_graph:
+                continue
+            import_graph[package_dir] = go_analyzer.get_imported_packages(package_dir)
+            pending_dirs.extend(import_graph[package_dir])
+        import_cycles = []
+        for cycle in find_cycles(import_graph):
+            if any(package_dir in package_dirs for package_dir in cycle):
+                import_cycles.append([go_analyzer.get_import_path(package_dir) or package_dir for package_dir in cycle])
+
+        result = {"embedding_cycles": embedding_cycles, "import_cycles": import_cycles}
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class VariableTypeTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Determines the type of the variable, field or other identifier at a given position, as inferred by gopls (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "identifier": {"type": "string"},
+            "kind": {"type": "string", "enum": ["var", "field", "const", "func", "type", "package"]},
+            "type": {"type": ["string", "null"]},
+        },
+        "required": ["identifier", "kind", "type"],
+    }
+
+    def apply(self, relative_path: str, line: int, column: int) -> str:
+        """
+        Determines the type of the identifier at the given position as inferred by gopls, e.g. `*ConcreteProcessor`
+        for a receiver variable `cp` or `[]byte` for the field in a selector like `mi.data`. This is particularly useful
+        for local variables declared without an explicit type (e.g. via `:=`).
+
+        :param relative_path: the relative path of the Go file
+        :param line: the 0-based line of the identifier
+        :param column: the 0-based column of (any character of) the identifier
+        :return: a JSON object with the `identifier`, the `kind` of object it denotes (var, field, const, func, type or package)
+            and its `type` (the function type for functions, null for packages)
+        """
+        self.project.validate_relative_path(relative_path)
+        go_analyzer = self.create_go_analyzer()
+        signature = go_analyzer.get_object_signature_at(relative_path, line, column)
+        result = {"identifier": signature.name, "kind": signature.kind, "type": signature.type_expr}
+        return json.dumps(result)
+
+
+class AddStructFieldTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Adds a field to a Go struct type (Go only).
+    """
+
+    output_schema = SUCCESS_RESULT_SCHEMA
+
+    def apply(
+        self,
+        type_name_path: str,
+        relative_path: str,
+        field_name: str,
+        field_type: str,
+        tag: str = "",
+        position: int = -1,
+        organize_imports: bool = True,
+    ) -> str:
+        """
+        Adds a field to the definition of a struct type. The struct is formatted afterwards (i.e. fields are aligned
+        as with gofmt), and, if `organize_imports` is enabled, imports required by the field's type (e.g. `time`
+        for `time.Time`) are added automatically.
+
+        :param type_name_path: the name path of the struct type, e.g. "BaseStruct"
+        :param relative_path: the relative path of the file declaring the type
+        :param field_name: the name of the field; empty to embed the given type
+        :param field_type: the type of the field, e.g. "time.Time"
+        :param tag: the field's tag, e.g. `json:"created"` (backquotes are added if missing); empty for no tag
+        :param position: the index of the field before which to insert the new field; -1 to append it
+        :param organize_imports: whether to organize the file's imports after the edit, adding missing and
+            removing unused imports
+        :return: a success message or an error
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        embedded_type = get_named_type_identifier(field_type.lstrip("*"))
+        new_field_name = field_name or (embedded_type[1] if embedded_type is not None else "")
+        if not new_field_name:
+            raise ValueError(f"Cannot embed {field_type}, which is not a named type; provide a field name")
+        if any(f.name == new_field_name for f in parse_struct_fields(declaration.type_expr or "")):
+            raise ValueError(f"Struct {declaration.name} already has a field {new_field_name}")
+        field_text = f"{field_name} {field_type}" if field_name else field_type
+        if tag:
+            field_text += " " + (tag if tag.startswith(("`", '"')) else f"`{tag}`")
+        go_file = go_analyzer.parse_file(relative_path)
+        edit = get_struct_field_insertion(go_file, declaration, field_text, position)
+        _apply_edit(self.create_language_server_code_editor(), relative_path, go_file, edit, organize_imports)
+        return SUCCESS_RESULT
+
+
+class RemoveStructFieldTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Removes a field from a Go struct type (Go only).
+    """
+
+    output_schema = SUCCESS_RESULT_SCHEMA
+
+    def apply(self, type_name_path: str, relative_path: str, field_name: str, organize_imports: bool = True) -> str:
+        """
+        Removes a field from the definition of a struct type, including the field's comments. The struct is formatted
+        afterwards (i.e. the remaining fields are aligned as with gofmt). Note that usages of the field are not removed.
+
+        :param type_name_path: the name path of the struct type, e.g. "BaseStruct"
+        :param relative_path: the relative path of the file declaring the type
+        :param field_name: the name of the field to remove (for embedded fields, the name of the embedded type)
+        :param organize_imports: whether to organize the file's imports after the edit, removing imports which
+            are no longer used
+        :return: a success message or an error
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        go_file = go_analyzer.parse_file(relative_path)
+        edit = get_struct_field_removal(go_file, declaration, field_name)
+        _apply_edit(self.create_language_server_code_editor(), relative_path, go_file, edit, organize_imports)
+        return SUCCESS_RESULT
+
+
+class OwningTypeTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the type a Go method belongs to, i.e. the declaration of the method's receiver type (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            **_SYMBOL_SCHEMA["properties"],
+            "fields": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"name": {"type": "string"}, "type": {"type": "string"}, "embedded": {"type": "boolean"}},
+                    "required": ["name", "type", "embedded"],
+                },
+                "description": "the fields of the type (structs only)",
+            },
+        },
+        "required": ["name_path", "kind", "underlying_kind"],
+    }
+
+    def apply(self, method_name_path: str, relative_path: str) -> str:
+        """
+        Finds the declaration of the receiver type of the given method, e.g. the struct `ChildStruct` for the method
+        `ChildStruct/GetValue`. The type may be declared in another file of the method's package.
+
+        :param method_name_path: the name path of the method, e.g. "GetValue" or "ChildStruct/GetValue"
+        :param relative_path: the relative path of the file containing the method
+        :return: a JSON object describing the type's symbol (name path, kind, location and children), its `underlying_kind`
+            and, for structs, its `fields` (each with name, type and whether it is embedded)
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, method = go_analyzer.find_unique_declaration(method_name_path, relative_path, kinds=(GoDeclarationKind.METHOD,))
+        assert method.receiver_type is not None
+        package_dir = os.path.dirname(relative_path)
+        type_declaration = go_analyzer.find_type_declaration(method.receiver_type, package_dir)
+        if type_declaration is None:
+            raise ValueError(f"The receiver type {method.receiver_type} of {method_name_path} is not declared in '{package_dir}'")
+        type_path, declaration = type_declaration
+        symbol = go_analyzer.find_symbol(type_path, declaration)
+        if symbol is None:
+            raise ValueError(f"The language server did not report a symbol for type {declaration.name} in {type_path}")
+        result = _sanitize_symbol_dict(symbol.to_dict(kind=True, location=True, depth=1))
+        result["underlying_kind"] = go_analyzer.get_underlying_kind(declaration, package_dir).value
+        if declaration.type_expr is not None and classify_type_expression(declaration.type_expr) == GoUnderlyingKind.STRUCT:
+            result["fields"] = [
+                {"name": f.name, "type": f.type_expr, "embedded": f.embedded} for f in parse_struct_fields(declaration.type_expr)
+            ]
+        return json.dumps(result)
+
+
+class InterfaceMethodsTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Lists the methods of a Go interface, including those obtained from embedded interfaces, with their origins (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interface": {"type": "string"},
+            "methods": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"name": {"type": "string"}, "signature": {"type": "string"}, "origin": {"type": "string"}},
+                    "required": ["name", "signature", "origin"],
+                },
+            },
+            "unresolved_embedded": {"type": "array", "items": {"type": "string"}},
+        },
+        "required": ["interface", "methods", "unresolved_embedded"],
+    }
+
+    def apply(self, interface_name_path: str, relative_path: str) -> str:
+        """
+        Lists the full method set of the given interface. Methods obtained from embedded interfaces that are declared in the
+        same package are resolved recursively; each method's `origin` is either "own" (declared by the interface itself) or
+        "from <Interface>" (declared by an embedded interface). A method that is declared with the same signature by several
+        embedded interfaces is listed once, with all origins separated by commas.
+
+        :param interface_name_path: the name path of the interface, e.g. "Worker"
+        :param relative_path: the relative path of the file containing the interface
+        :return: a JSON object with the `interface` name, its `methods` (each with name, signature and origin) and the list
+            `unresolved_embedded` of embedded elements whose methods could not be determined (e.g. interfaces from other packages)
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if declaration.type_expr is None or classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"{declaration.name} is not an interface type")
+        methods, unresolved = go_analyzer.get_interface_methods(declaration, os.path.dirname(relative_path))
+        origins_by_method: dict[tuple[str, str], list[str]] = defaultdict(list)
+        for element, origin in methods:
+            assert element.method_name is not None
+            origin_str = "own" if origin is None else f"from {origin}"
+            method_origins = origins_by_method[(element.method_name, element.get_signature())]
+            if origin_str not in method_origins:
+                method_origins.append(origin_str)
+        result = {
+            "interface": declaration.name,
+            "methods": [
+                {"name": name, "signature": name + signature, "origin": ", ".join(origins)}
+                for (name, signature), origins in origins_by_method.items()
+            ],
+            "unresolved_embedded": unresolved,
+        }
+        return json.dumps(result)
+
+
+class CheckSnippetSatisfiesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Checks whether the type defined in a draft code snippet would satisfy a Go interface (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "type": {"type": "string"},
+            "interface": {"type": "string"},
+            "satisfied_by_value": {"type": "boolean"},
+            "satisfied_by_pointer": {"type": "boolean"},
+            "type_checked": {"type": "boolean"},
+            "compile_errors": {"type": "array", "items": {"type": "string"}},
+            "missing": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"name": {"type": "string"}, "expected": {"type": "string"}},
+                    "required": ["name", "expected"],
+                },
+            },
+            "mismatched": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"name": {"type": "string"}, "expected": {"type": "string"}, "actual": {"type": "string"}},
+                    "required": ["name", "expected", "actual"],
+                },
+            },
+            "pointer_receiver_methods": {"type": "array", "items": {"type": "string"}},
+            "unresolved_embedded": {"type": "array", "items": {"type": "string"}},
+        },
+        "required": [
+            "type",
+            "interface",
+            "satisfied_by_value",
+            "satisfied_by_pointer",
+            "type_checked",
+            "compile_errors",
+            "missing",
+            "mismatched",
+            "pointer_receiver_methods",
+            "unresolved_embedded",
+        ],
+    }
+
+    _PACKAGE_CLAUSE_PATTERN = re.compile(r"package\s+\w+")
+    _ERROR_LOCATION_PATTERN = re.compile(r"(.+?):(\d+):\d+: ")
+
+    def apply(self, snippet: str, interface_name_path: str, relative_path: str) -> str:
+        """
+        Checks whether the type declared in the given snippet (a draft of Go code that is not yet part of the project)
+        would satisfy the given interface if it were added to the interface's package.
+        The snippet must declare exactly one type and may declare methods for it.
+        The snippet is type-checked by the compiler as part of the interface's package (without changing any files),
+        asserting that values of the type and pointers to it are assignable to the interface (for generic types or
+        interfaces, only the snippet itself is compiled).
+        Missing and mismatched methods are additionally determined by comparing the method signatures textually
+        (by parameter and result types), taking methods promoted from embedded types into account,
+        in order to explain why the interface is not satisfied.
+
+        :param snippet: the Go code declaring the type and its methods (a package clause is optional)
+        :param interface_name_path: the name path of the interface, e.g. "Processable"
+        :param relative_path: the relative path of the file containing the interface
+        :return: a JSON object with the `type` and `interface` names, whether the interface is satisfied by values of the type
+            (`satisfied_by_value`) and by pointers to it (`satisfied_by_pointer`), whether these were determined by the
+            compiler (`type_checked`; otherwise by the textual comparison), the `compile_errors` of the snippet in the
+            interface's package (excluding the failed assertions; if non-empty, the interface is not considered satisfied),
+            the `missing` methods (with their expected signatures), the `mismatched` methods (with expected and actual
+            signatures), the `pointer_receiver_methods` that are only available for pointers and the list
+            `unresolved_embedded` of embedded types whose methods could not be determined textually
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, interface = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if interface.type_expr is None or classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"{interface.name} is not an interface type")
+        package_dir = os.path.dirname(relative_path)
+        snippet_file = parse_go_file(snippet)
+        snippet_types = list(snippet_file.iter_declarations(GoDeclarationKind.TYPE))
+        if len(snippet_types) != 1:
+            raise ValueError(
+                f"The snippet must declare exactly one type but declares {len(snippet_types)}: " + ", ".join(t.name for t in snippet_types)
+            )
+        snippet_type = snippet_types[0]
+
+        method_set, unresolved = go_analyzer.get_method_set(snippet_type, package_dir, extra_file=snippet_file)
+        interface_methods, interface_unresolved = go_analyzer.get_interface_methods(interface, package_dir)
+        satisfaction = check_interface_satisfaction(method_set, interface_methods)
+        type_checked = not snippet_type.type_params and not interface.type_params
+        compile_errors, failed_assertions = self._type_check(
+            go_analyzer, snippet, snippet_file, snippet_type.name, interface.name, relative_path, type_checked
+        )
+        if type_checked:
+            satisfied_by_value = not compile_errors and "value" not in failed_assertions
+            satisfied_by_pointer = not compile_errors and "pointer" not in failed_assertions
+        else:
+            satisfied_by_value = not compile_errors and satisfaction.satisfied_by_value
+            satisfied_by_pointer = not compile_errors and satisfaction.satisfied_by_pointer
+        result = {
+            "type": snippet_type.name,
+            "interface": interface.name,
+            "satisfied_by_value": satisfied_by_value,
+            "satisfied_by_pointer": satisfied_by_pointer,
+            "type_checked": type_checked,
+            "compile_errors": compile_errors,
+            "missing": satisfaction.missing,
+            "mismatched": satisfaction.mismatched,
+            "pointer_receiver_methods": satisfaction.pointer_receiver_methods,
+            "unresolved_embedded": unresolved + interface_unresolved,
+        }
+        return json.dumps(result)
+
+    def _type_check(
+        self,
+        go_analyzer: GoAnalyzer,
+        snippet: str,
+        snippet_file: GoFile,
+        type_name: str,
+        interface_name: str,
+        interface_path: str,
+        add_assertions: bool,
+    ) -> tuple[list[str], set[str]]:
+        """
+        Compiles the snippet as an additional file of the interface's package by means of an overlay.
+
+        :return: a tuple (compile_errors, failed_assertions), where failed_assertions contains "value" and/or "pointer"
+            if the respective assignment to the interface failed to compile; the errors of these assignments are not
+            included in compile_errors
+        """
+        package_dir = os.path.dirname(interface_path)
+        package_name = go_analyzer.parse_file(interface_path).package_name
+        code = snippet
+        if snippet_file.package_clause_start is not None:
+            match = self._PACKAGE_CLAUSE_PATTERN.match(snippet, snippet_file.package_clause_start)
+            assert match is not None
+            code = snippet[: match.start()] + snippet[match.end() :]
+        lines = [f"package {package_name}", "", *code.rstrip().splitlines(), ""]
+        assertion_lines = {}
+        if add_assertions:
+            assertion_lines[len(lines) + 1] = "value"
+            lines.append(f"var _ {interface_name} = *new({type_name})")
+            assertion_lines[len(lines) + 1] = "pointer"
+            lines.append(f"var _ {interface_name} = (*{type_name})(nil)")
+        file_name = "serena_snippet.go"
+        i = 1
+        while os.path.exists(os.path.join(self.get_project_root(), package_dir, file_name)):
+            i += 1
+            file_name = f"serena_snippet{i}.go"
+        overlay_path = os.path.join(package_dir, file_name)
+        errors = go_analyzer.find_compile_errors([package_dir], overlay={overlay_path: "\n".join(lines) + "\n"})
+
+        compile_errors = []
+        failed_assertions = set()
+        for error in errors:
+            match = self._ERROR_LOCATION_PATTERN.match(error)
+            if match is not None and os.path.basename(match.group(1)) == file_name:
+                line = int(match.group(2))
+                if line in assertion_lines:
+                    failed_assertions.add(assertion_lines[line])
+                    continue
+                # refer to the lines of the snippet, which follows the package clause and an empty line
+                error = f"snippet:{line - 2}:" + error[match.end(2) + 1 :]
+            compile_errors.append(error)
+        return compile_errors, failed_assertions
+
+
+class FindByDocTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds Go declarations whose doc comments contain the given text (Go only).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "name_path": {"type": "string"},
+                "kind": {"type": ["integer", "null"], "description": "the LSP symbol kind"},
+                "relative_path": {"type": "string"},
+                "line": {"type": "integer"},
+                "snippet": {"type": "string"},
+            },
+            "required": ["name_path", "kind", "relative_path", "line", "snippet"],
+        },
+    }
+
+    def apply(
+        self,
+        query: str,
+        relative_path: str = "",
+        case_sensitive: bool = False,
+        whole_word: bool = False,
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Finds the top-level declarations (types, functions, methods, variables and constants) whose doc comments contain
+        the given query, e.g. "inheritance" to find the types whose documentation mentions inheritance.
+        The query is matched literally, except that any whitespace within it also matches line breaks in the comment.
+
+        :param query: the text to search for in doc comments
+        :param relative_path: the relative path of the file or directory in which to search; "" for the entire project
+        :param case_sensitive: whether to match the query case-sensitively
+        :param whole_word: whether the query must match whole words only (e.g. "err" does not match "error")
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON list of objects with the name path, kind, relative path and (0-based) line of each matching symbol
+            as well as the `snippet` of the doc comment containing the match
+        """
+        words = query.split()
+        if not words:
+            raise ValueError("The query must not be empty")
+        pattern_str = r"\s+".join(re.escape(w) for w in words)
+        if whole_word:
+            pattern_str = rf"\b{pattern_str}\b"
+        pattern = re.compile(pattern_str, 0 if case_sensitive else re.IGNORECASE)
+        go_analyzer = self.create_go_analyzer()
+        result = []
+        for file_path in sorted(self.project.gather_source_files(relative_path)):
+            if not file_path.endswith(".go"):
+                continue
+            go_file = go_analyzer.parse_file(file_path)
+            for declaration in go_file.declarations:
+                if declaration.doc is None:
+                    continue
+                match = pattern.search(declaration.doc)
+                if match is None:
+                    continue
+                # the snippet consists of the doc lines spanned by the match
+                snippet_start = declaration.doc.rfind("\n", 0, match.start()) + 1
+                snippet_end = declaration.doc.find("\n", match.end())
+                snippet = declaration.doc[snippet_start : snippet_end if snippet_end != -1 else len(declaration.doc)]
+                symbol = go_analyzer.find_symbol(file_path, declaration)
+                result.append(
+                    {
+                        "name_path": symbol.get_name_path() if symbol is not None else declaration.name,
+                        "kind": symbol.kind if symbol is not None else None,
+                        "relative_path": file_path,
+                        "line": go_file.get_line_and_column(declaration.name_start)[0],
+                        "snippet": " ".join(snippet.split()),
+                    }
+                )
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+def _get_godoc_declaration_text(go_file: GoFile, declaration: GoDeclaration) -> str:
+    """
+    :return: the declaration as rendered by `go doc`, i.e. the signature for functions and methods and the full
+        declaration (including the keyword, even for specs within groups) for all others
+    """
+    signature = go_file.get_signature_text(declaration)
+    if signature is not None:
+        return signature
+    text = go_file.get_declaration_text(declaration)
+    if declaration.in_group:
+        text = f"{declaration.kind.value} {text}"
+    return text
+
+
+def _get_deprecation_dict(declaration: GoDeclaration) -> dict[str, Any]:
+    deprecation_message = declaration.deprecation_message
+    if deprecation_message is None:
+        return {"deprecated": False}
+    return {"deprecated": True, "deprecation_message": deprecation_message}
+
+
+class GodocTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Retrieves the documentation of a Go symbol in the shape of `go doc` output, as structured data (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "package": {"type": ["string", "null"]},
+            "import_path": {"type": ["string", "null"]},
+            "kind": {"type": "string", "enum": [k.value for k in GoDeclarationKind]},
+            "declaration": {"type": "string"},
+            "doc": {"type": ["string", "null"]},
+            "deprecated": {"type": "boolean"},
+            "deprecation_message": {"type": "string"},
+            "values": {"type": "array", "items": _GODOC_ENTRY_SCHEMA},
+            "functions": {"type": "array", "items": _GODOC_ENTRY_SCHEMA},
+            "methods": {"type": "array", "items": _GODOC_ENTRY_SCHEMA},
+        },
+        "required": ["package", "import_path", "kind", "declaration", "doc", "deprecated"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, include_unexported: bool = False) -> str:
+        """
+        Retrieves the information `go doc` would show for the given top-level symbol (type, function, method, variable or
+        constant): its declaration and doc comment and, for types, the constants and variables of the type, the functions
+        returning it (e.g. constructors) and its methods, each with declaration and doc comment.
+        Like `go doc`, test files are not considered.
+
+        :param name_path: the name path of the symbol, e.g. "BaseStruct" or "BaseStruct/Execute"
+        :param relative_path: the relative path of the file containing the symbol
+        :param include_unexported: whether to include unexported methods and functions of types (like `go doc -u`)
+        :return: a JSON object with the `package` name, its `import_path`, the `kind`, `declaration` and `doc` of the symbol
+            and, for types, the lists `values`, `functions` and `methods`. The symbol and each list entry are flagged as
+            `deprecated` if their doc comment contains a "Deprecated: " paragraph, whose text is given as `deprecation_message`.
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(name_path, relative_path)
+        package_dir = os.path.dirname(relative_path)
+        go_file = go_analyzer.parse_file(relative_path)
+        result: dict[str, Any] = {
+            "package": go_file.package_name,
+            "import_path": go_analyzer.get_import_path(package_dir),
+            "kind": declaration.kind.value,
+            "declaration": _get_godoc_declaration_text(go_file, declaration),
+            "doc": declaration.doc,
+            **_get_deprecation_dict(declaration),
+        }
+        if declaration.kind != GoDeclarationKind.TYPE:
+            return json.dumps(result)
+
+        def to_dict(file: GoFile, d: GoDeclaration) -> dict[str, Any]:
+            return {"name": d.name, "declaration": _get_godoc_declaration_text(file, d), "doc": d.doc, **_get_deprecation_dict(d)}
+
+        values = []
+        functions = []
+        methods = []
+        for file_path in go_analyzer.get_package_files(package_dir):
+            if file_path.endswith("_test.go"):
+                continue
+            package_file = go_analyzer.parse_file(file_path)
+            for d in package_file.declarations:
+                if not (include_unexported or d.is_exported):
+                    continue
+                if d.kind in (GoDeclarationKind.CONSTANT, GoDeclarationKind.VARIABLE):
+                    named_type = get_named_type_identifier(d.type_expr) if d.type_expr is not None else None
+                    if named_type == (None, declaration.name):
+                        values.append(to_dict(package_file, d))
+                elif d.kind == GoDeclarationKind.FUNCTION:
+                    signature = package_file.get_parameters_and_results_text(d)
+                    assert signature is not None
+                    _, result_types = get_parameter_and_result_types(signature)
+                    if any(get_named_type_identifier(t.lstrip("*")) == (None, declaration.name) for t in result_types):
+                        functions.append(to_dict(package_file, d))
+                elif d.kind == GoDeclarationKind.METHOD and d.receiver_type == declaration.name:
+                    methods.append(to_dict(package_file, d))
+        result["values"] = values
+        result["functions"] = functions
+        result["methods"] = methods
+        return json.dumps(result)
+
+
+def _get_receiver_text(go_file: GoFile, method: GoDeclaration) -> str:
+    """
+    :return: the receiver of the given method as declared, e.g. "(cp *ConcreteProcessor)"
+    """
+    return go_file.get_text(method.start + len("func"), method.name_start).strip()
+
+
+def _get_receiver_convention(methods: list[tuple[str, GoDeclaration]]) -> tuple[str, GoDeclaration] | None:
+    """
+    :param methods: the methods of a type (with the relative paths of the files declaring them)
+    :return: a method whose receiver follows the convention of the majority of the methods (preferring pointer receivers)
+        together with the relative path of its file, or None if there are no methods
+    """
+    if not methods:
+        return None
+    num_pointer_receivers = sum(1 for _, m in methods if m.receiver_is_pointer)
+    use_pointer = 2 * num_pointer_receivers >= len(methods)
+    return next((p, m) for p, m in methods if m.receiver_is_pointer == use_pointer)
+
+
+class AddInterfaceMethodAndStubTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Adds a method to a Go interface and adds stub implementations to all types implementing the interface (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interface": {"type": "string"},
+            "method": {"type": "string"},
+            "stubbed": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"type": {"type": "string"}, "relative_path": {"type": "string"}, "receiver": {"type": "string"}},
+                    "required": ["type", "relative_path", "receiver"],
+                },
+            },
+            "promoted": {"type": "array", "items": {"type": "string"}},
+            "already_implemented": {"type": "array", "items": {"type": "string"}},
+        },
+        "required": ["interface", "method", "stubbed", "promoted", "already_implemented"],
+    }
+
+    def apply(self, interface_name_path: str, relative_path: str, method_signature: str, organize_imports: bool = True) -> str:
+        """
+        Adds the given method to the interface and, such that the code keeps compiling, adds a stub implementation
+        (which panics) to each type in the interface's package that currently implements the interface.
+        Stubs follow the receiver conventions of the existing methods of the respective type (receiver name and whether
+        it is a pointer) and are inserted after its last method. Types which obtain the method by embedding another
+        implementing type are not stubbed.
+        All edits are applied all-or-nothing: if any of them fails, all affected files are restored.
+
+        :param interface_name_path: the name path of the interface, e.g. "Processable"
+        :param relative_path: the relative path of the file containing the interface
+        :param method_signature: the method to add, e.g. "Close() error"
+        :param organize_imports: whether to add missing imports (e.g. for types from other packages used in the signature)
+            and remove unused imports in the edited files
+        :return: a JSON object with the `interface`, the added `method`, the list `stubbed` of stubbed types (each with
+            type name, relative path and receiver), the list `promoted` of implementing types which obtain the method through
+            embedding and the list `already_implemented` of implementing types which already have a matching method
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, interface = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        package_dir = os.path.dirname(relative_path)
+        new_elements = parse_interface_elements("interface {\n" + method_signature.strip() + "\n}")
+        if len(new_elements) != 1 or new_elements[0].method_name is None:
+            raise ValueError(f"Not a method signature: {method_signature}")
+        new_method = new_elements[0]
+        method_name = new_method.method_name
+        assert method_name is not None
+        new_signature = normalize_method_signature(new_method.get_signature())
+        interface_methods, _ = go_analyzer.get_interface_methods(interface, package_dir)
+        if any(element.method_name == method_name for element, _ in interface_methods):
+            raise ValueError(f"Interface {interface.name} already has a method {method_name}")
+
+        # determine the implementing types and which of them require a stub
+        implementations = go_analyzer.find_implementations(interface, package_dir)
+        implementing_type_names = {declaration.name for _, declaration in implementations}
+        types_to_stub: list[tuple[str, GoDeclaration]] = []
+        promoted = []
+        already_implemented = []
+        for type_path, declaration in implementations:
+            method_set, _ = go_analyzer.get_method_set(declaration, package_dir)
+            if method_name in method_set:
+                if method_set[method_name][0] != new_signature:
+                    raise ValueError(
+                        f"{declaration.name} already has a method {method_name}{method_set[method_name][0]}, "
+                        f"which conflicts with {method_name}{new_signature}"
+                    )
+                already_implemented.append(declaration.name)
+                continue
+            assert declaration.type_expr is not None
+            embedded_type_names = set()
+            for struct_field in parse_struct_fields(declaration.type_expr):
+                named_type = get_named_type_identifier(struct_field.type_expr.lstrip("*")) if struct_field.embedded else None
+                if named_type is not None and named_type[0] is None:
+                    embedded_type_names.add(named_type[1])
+            if embedded_type_names & implementing_type_names:
+                promoted.append(declaration.name)
+            else:
+                types_to_stub.append((type_path, declaration))
+
+        code_editor = self.create_language_server_code_editor()
+        stubbed = []
+        with code_editor.edit_transaction():
+            interface_file = go_analyzer.parse_file(relative_path)
+            declaration = interface_file.find_declaration(interface.name)
+            assert declaration is not None
+            edit = get_interface_method_insertion(interface_file, declaration, new_method.text)
+            _apply_edit(code_editor, relative_path, interface_file, edit, organize_imports)
+            for type_path, type_declaration in types_to_stub:
+                methods = go_analyzer.get_methods(type_declaration.name, package_dir)
+                convention = _get_receiver_convention(methods)
+                if convention is not None:
+                    convention_path, convention_method = convention
+                    receiver = _get_receiver_text(go_analyzer.parse_file(convention_path), convention_method)
+                    methods_in_type_file = [(p, m) for p, m in methods if p == type_path]
+                    anchor_path, anchor = (methods_in_type_file or methods)[-1]
+                else:
+                    receiver_name = type_declaration.name[0].lower()
+                    receiver = f"({receiver_name} *{type_declaration.name})"
+                    anchor_path, anchor = type_path, type_declaration
+                stub = (
+                    f"// {method_name} implements {interface.name}.\n"
+                    f"func {receiver} {new_method.text} {{\n"
+                    f'\tpanic("not implemented")\n'
+                    "}"
+                )
+                # re-parse the file, as previous edits may have changed it
+                anchor_file = go_analyzer.parse_file(anchor_path)
+                current_anchor = anchor_file.find_declaration(anchor.name, receiver_type=anchor.receiver_type)
+                assert current_anchor is not None
+                edit = GoTextEdit(current_anchor.end, current_anchor.end, "\n\n" + stub)
+                _apply_edit(code_editor, anchor_path, anchor_file, edit, organize_imports)
+                stubbed.append({"type": type_declaration.name, "relative_path": anchor_path, "receiver": receiver})
+        result = {
+            "interface": interface.name,
+            "method": new_method.text,
+            "stubbed": stubbed,
+            "promoted": promoted,
+            "already_implemented": already_implemented,
+        }
+        return json.dumps(result)
+
+
+class ReturnFlowTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Determines the concrete types a Go function returns, overall and at each of its call sites (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "function": {"type": "string"},
+            "result_type": {"type": "string"},
+            "returned_types": {"type": "array", "items": {"type": "string"}},
+            "call_sites": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "relative_path": {"type": "string"},
+                        "line": {"type": "integer"},
+                        "caller": {"type": "string"},
+                        "arguments": {"type": "array", "items": {"type": "string"}},
+                        "returned_types": {"type": "array", "items": {"type": "string"}},
+                    },
+                    "required": ["relative_path", "line", "caller", "arguments", "returned_types"],
+                },
+            },
+        },
+        "required": ["function", "result_type", "returned_types", "call_sites"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, max_answer_chars: int = -1) -> str:
+        """
+        Determines the concrete types of the values returned by a function (or method), which is particularly useful
+        for functions returning an interface, e.g. factory functions. The analysis is local and textual: composite literals,
+        `new` expressions, conversions, local variables and calls of functions of the same package are followed.
+        At each call site, the types are narrowed down if the function switches on a parameter for which the call
+        passes a constant, e.g. for `NewProcessor("child")`. Where the type of a returned value cannot be inferred
+        (e.g. because it is the result of a method call), "indeterminate" is reported. If the function returns one of its
+        parameters, the type at a call site is the one of the corresponding argument.
+
+        :param name_path: the name path of the function, e.g. "NewProcessor"
+        :param relative_path: the relative path of the file containing the function
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the `function`'s name path, its (first) `result_type`, the `returned_types` in general
+            and the `call_sites`, each with the `relative_path`, (0-based) `line`, name path of the `caller`, the `arguments`
+            and the `returned_types` at the call site
+        """
+        go_analyzer = self.create_go_analyzer()
+        symbol, declaration = go_analyzer.find_unique_declaration(
+            name_path, relative_path, kinds=(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD)
+        )
+        go_file = go_analyzer.parse_file(relative_path)
+        signature = go_file.get_parameters_and_results_text(declaration)
+        assert signature is not None
+        _, result_types = get_parameter_and_result_types(signature)
+        if not result_types:
+            raise ValueError(f"{name_path} does not return any values")
+        flows = go_analyzer.get_return_flows(relative_path, declaration)
+        parameter_names = get_parameter_names(signature)
+
+        def get_types(selected_flows: list[ReturnFlow]) -> list[str]:
+            types: list[str] = []
+            for flow in selected_flows:
+                types.extend(t for t in flow.concrete_types if t not in types)
+            return types
+
+        call_sites = []
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        for ref in symbol_retriever.find_referencing_symbols(name_path, relative_file_path=relative_path):
+            ref_relative_path = ref.symbol.location.relative_path
+            if ref_relative_path is None or not ref_relative_path.endswith(".go"):
+                continue
+            arguments = go_analyzer.get_call_arguments(ref_relative_path, ref.line, ref.character)
+            if arguments is None:
+                continue
+            offset = go_analyzer.parse_file(ref_relative_path).get_offset(ref.line, ref.character)
+            call_site_flows = []
+            for flow in select_return_flows(flows, parameter_names, arguments):
+                returned = flow.statement.results[0] if flow.statement.results else None
+                if returned is not None and returned in parameter_names and parameter_names.index(returned) < len(arguments):
+                    # a parameter is returned: its concrete type is the one of the corresponding argument
+                    argument_types = go_analyzer.infer_concrete_types(arguments[parameter_names.index(returned)], ref_relative_path, offset)
+                    flow = ReturnFlow(statement=flow.statement, concrete_types=argument_types)
+                call_site_flows.append(flow)
+            call_sites.append(
+                {
+                    "relative_path": ref_relative_path,
+                    "line": ref.line,
+                    "caller": ref.symbol.get_name_path(),
+                    "arguments": arguments,
+                    "returned_types": get_types(call_site_flows),
+                }
+            )
+        call_sites.sort(key=lambda c: (c["relative_path"], c["line"]))
+        result = {
+            "function": symbol.get_name_path(),
+            "result_type": result_types[0],
+            "returned_types": get_types(flows),
+            "call_sites": call_sites,
+        }
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class SingleImplementerInterfacesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds Go interfaces which are implemented by exactly one type, which often indicates a premature abstraction (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interfaces": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "interface": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "num_methods": {"type": "integer"},
+                        "implementer": _GO_TYPE_LOCATION_SCHEMA,
+                    },
+                    "required": ["interface", "relative_path", "num_methods", "implementer"],
+                },
+            },
+            "num_interfaces_checked": {"type": "integer"},
+            "skipped": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"interface": {"type": "string"}, "relative_path": {"type": "string"}},
+                    "required": ["interface", "relative_path"],
+                },
+            },
+        },
+        "required": ["interfaces", "num_interfaces_checked", "skipped"],
+    }
+
+    def apply(self, relative_path: str = "", max_answer_chars: int = -1) -> str:
+        """
+        Finds the interfaces declared in the given file or directory which are implemented (with values or pointers) by
+        exactly one of the types declared in the interface's package. Such interfaces are candidates for inlining, i.e.
+        for using the implementing type directly.
+        Interfaces without methods and interfaces whose method sets cannot be fully determined (because they embed
+        interfaces from other packages or type constraint terms) are not considered.
+
+        :param relative_path: the relative path of the file or directory in which to search for interfaces; "" for the
+            entire project
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the list `interfaces` of single-implementer interfaces (each with the `interface` name,
+            its `relative_path`, its number of methods `num_methods` and the `implementer`, given by its type name and
+            relative path), the number of considered interfaces `num_interfaces_checked` and the list `skipped` of
+            interfaces which could not be checked (each with name and relative path)
+        """
+        go_analyzer = self.create_go_analyzer()
+        flagged = []
+        skipped = []
+        num_interfaces_checked = 0
+        for file_path in sorted(self.project.gather_source_files(relative_path)):
+            if not file_path.endswith(".go"):
+                continue
+            package_dir = os.path.dirname(file_path)
+            for declaration in go_analyzer.parse_file(file_path).iter_declarations(GoDeclarationKind.TYPE):
+                if declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+                    continue
+                methods, unresolved = go_analyzer.get_interface_methods(declaration, package_dir)
+                if not methods:
+                    continue
+                if unresolved:
+                    skipped.append({"interface": declaration.name, "relative_path": file_path})
+                    continue
+                num_interfaces_checked += 1
+                implementations = go_analyzer.find_implementations(declaration, package_dir)
+                if len(implementations) != 1:
+                    continue
+                implementer_path, implementer = implementations[0]
+                flagged.append(
+                    {
+                        "interface": declaration.name,
+                        "relative_path": file_path,
+                        "num_methods": len({element.method_name for element, _ in methods}),
+                        "implementer": {"type": implementer.name, "relative_path": implementer_path},
+                    }
+                )
+        result = {"interfaces": flagged, "num_interfaces_checked": num_interfaces_checked, "skipped": skipped}
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class AssignableTypesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the concrete types and narrower interfaces whose values are assignable to a given Go interface type (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interface": {"type": "string"},
+            "concrete_types": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"type": {"type": "string"}, "relative_path": {"type": "string"}, "assignable": {"type": "string"}},
+                    "required": ["type", "relative_path", "assignable"],
+                },
+            },
+            "interfaces": {"type": "array", "items": _GO_TYPE_LOCATION_SCHEMA},
+        },
+        "required": ["interface", "concrete_types", "interfaces"],
+    }
+
+    def apply(self, interface_name_path: str, relative_path: str) -> str:
+        """
+        Finds the types declared in the interface's package whose values can be assigned to a variable of the given
+        interface type: the concrete types implementing the interface and the narrower interfaces, whose method sets
+        include all of the interface's methods (e.g. because they embed it).
+
+        :param interface_name_path: the name path of the interface, e.g. "Processable"
+        :param relative_path: the relative path of the file containing the interface
+        :return: a JSON object with the `interface` name, the list `concrete_types` (each with the type name, relative path
+            and the form `assignable` to the interface, which is the pointer type if some of the methods have pointer
+            receivers) and the list `interfaces` of narrower interfaces (each with the type name and relative path)
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, interface = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if interface.type_expr is None or classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"{interface.name} is not an interface type")
+        package_dir = os.path.dirname(relative_path)
+        interface_methods, _ = go_analyzer.get_interface_methods(interface, package_dir)
+        concrete_types = []
+        for type_path, declaration in go_analyzer.find_implementations(interface, package_dir):
+            method_set, _ = go_analyzer.get_method_set(declaration, package_dir)
+            satisfied_by_value = check_interface_satisfaction(method_set, interface_methods).satisfied_by_value
+            concrete_types.append(
+                {
+                    "type": declaration.name,
+                    "relative_path": type_path,
+                    "assignable": declaration.name if satisfied_by_value else "*" + declaration.name,
+                }
+            )
+        interfaces = [
+            {"type": declaration.name, "relative_path": type_path}
+            for type_path, declaration in go_analyzer.find_narrower_interfaces(interface, package_dir)
+        ]
+        result = {"interface": interface.name, "concrete_types": concrete_types, "interfaces": interfaces}
+        return json.dumps(result)
+
+
+class ReplaceBlockTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Replaces the content of a single block (e.g. a case clause or the body of an if statement) within a Go function (Go only).
+    """
+
+    output_schema = SUCCESS_RESULT_SCHEMA
+
+    def apply(self, name_path: str, relative_path: str, block_selector: str, body: str, organize_imports: bool = True) -> str:
+        """
+        Replaces the statements of a block nested within a function or method, leaving the rest of the function unchanged.
+        This is useful for changing a single clause of a large switch statement without rewriting the entire function.
+        The block is identified by a selector, which is one of the following:
+        `case <values>` for the case clause listing the given value(s), e.g. `case "echo"`;
+        `default` for the default clause;
+        `<keyword>@<line>` for the if/for/switch/select statement or case/default clause whose keyword is in the given
+        0-based line, e.g. "if@14" (required if the other forms are ambiguous).
+        For statements, the content between the braces is replaced; for clauses, the statements following the label.
+        If the selector does not identify exactly one block, the error lists the available blocks.
+
+        :param name_path: the name path of the function or method containing the block, e.g. "RunCommand"
+        :param relative_path: the relative path of the file containing the function
+        :param block_selector: the selector identifying the block
+        :param body: the new statements of the block, without the enclosing braces or the case label;
+            they are re-indented to the block's indentation
+        :param organize_imports: whether to organize the file's imports after the edit, adding missing and
+            removing unused imports
+        :return: a success message or an error
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(
+            name_path, relative_path, kinds=(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD)
+        )
+        go_file = go_analyzer.parse_file(relative_path)
+        block = select_block(find_blocks(go_file, declaration), block_selector)
+        edit = get_block_replacement(go_file, block, body)
+        code_editor = self.create_language_server_code_editor()
+        with code_editor.edit_transaction():
+            _apply_edit(code_editor, relative_path, go_file, edit, organize_imports)
+            # validate that the edit did not change the structure beyond the block
+            edited_file = go_analyzer.parse_file(relative_path)
+            edited_declaration = edited_file.find_declaration(declaration.name, receiver_type=declaration.receiver_type)
+            if len(edited_file.declarations) != len(go_file.declarations) or edited_declaration is None:
+                raise ValueError("Replacing the block changed the declarations of the file; the edit was reverted")
+        return SUCCESS_RESULT
+
+
+class ApiCompatibilityTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Reports the breaking and compatible changes a proposed new content would make to the exported API of a Go file (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "is_compatible": {"type": "boolean"},
+            "breaking": {"type": "array", "items": _API_CHANGE_SCHEMA},
+            "compatible": {"type": "array", "items": _API_CHANGE_SCHEMA},
+        },
+        "required": ["is_compatible", "breaking", "compatible"],
+    }
+
+    def apply(self, relative_path: str, new_content: str) -> str:
+        """
+        Compares the exported API of the given file with the API defined by the given new content (e.g. a proposed edit),
+        without changing the file. Breaking changes are removed exported symbols, changed signatures of exported
+        functions and methods, changed (pointer/value) receivers which remove methods from the method set of value types,
+        changed types, removed or changed exported struct fields, and removed, changed or added interface methods
+        (the latter break implementers of the interface). Additions of symbols and struct fields are reported as
+        compatible changes. Methods are only considered if their receiver type is exported.
+        The comparison is based on the declarations in the file, so type changes elsewhere are not taken into account.
+
+        :param relative_path: the relative path of the Go file
+        :param new_content: the proposed new content of the file
+        :return: a JSON object with the flag `is_compatible` and the lists `breaking` and `compatible` of changes, each
+            with the kind of `change` (e.g. "removed", "signature_changed" or "interface_method_added"), the `name_path`
+            of the affected symbol (e.g. "BaseStruct/GetName") and, where applicable, its `old` and `new` declaration
+        """
+        self.project.validate_relative_path(relative_path)
+        go_analyzer = self.create_go_analyzer()
+        changes = compare_apis(go_analyzer.parse_file(relative_path), parse_go_file(new_content))
+
+        def to_entry(change: ApiChange) -> dict[str, Any]:
+            entry: dict[str, Any] = {"change": change.kind.value, "name_path": change.name_path}
+            if change.old is not None:
+                entry["old"] = change.old
+            if change.new is not None:
+                entry["new"] = change.new
+            return entry
+
+        breaking = [to_entry(c) for c in changes if c.is_breaking]
+        compatible = [to_entry(c) for c in changes if not c.is_breaking]
+        return json.dumps({"is_compatible": not breaking, "breaking": breaking, "compatible": compatible})
+
+
+class ResolveSelectorTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Determines the field or method a selector like `c.Execute` denotes, taking overriding and promotion into account (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "selector": {"type": "string"},
+            "operand_type": {"type": "string"},
+            "member": {"type": "string", "enum": ["field", "method"]},
+            "resolution": {"type": "string", "enum": [k.value for k in MemberSelectionKind]},
+            "target": _GO_MEMBER_LOCATION_SCHEMA,
+            "embedding_path": {"type": "array", "items": {"type": "string"}},
+            "shadowed": {"type": "array", "items": _GO_MEMBER_LOCATION_SCHEMA},
+            "reasoning": {"type": "string"},
+        },
+        "required": ["selector", "operand_type", "member", "resolution", "target", "embedding_path", "shadowed", "reasoning"],
+    }
+
+    def apply(self, relative_path: str, line: int, column: int) -> str:
+        """
+        Determines the field or method which a selector expression `x.f` denotes, i.e. the method the compiler dispatches
+        a call like `c.Execute()` to. A member declared by the type of `x` itself takes precedence over (overrides)
+        members promoted from embedded fields; among promoted members, the one at the shallowest embedding depth is
+        selected. The type of `x` is inferred by gopls and must be declared in the package of the file.
+        For operands of interface types, the selected interface method is dispatched dynamically at runtime.
+
+        :param relative_path: the relative path of the Go file containing the selector
+        :param line: the 0-based line of the selected identifier `f`
+        :param column: the 0-based column of (any character of) the selected identifier
+        :return: a JSON object with the `selector`, the `operand_type`, the `member` kind ("field" or "method"),
+            the `resolution` ("own", "override" or "promoted"), the `target` member (name path and relative path),
+            the `embedding_path` of embedded fields through which a promoted member is reached, the `shadowed` members
+            of the same name and a `reasoning` explaining the resolution
+        """
+        self.project.validate_relative_path(relative_path)
+        go_analyzer = self.create_go_analyzer()
+        resolution = go_analyzer.resolve_selector(relative_path, line, column)
+        selection = resolution.selection
+        member = selection.member
+        member_kind = "method" if member.is_method else "field"
+        target_name_path = member.get_name_path(selection.name)
+        if selection.kind == MemberSelectionKind.PROMOTED:
+            embedded_fields = "field" if len(member.embedding_path) == 1 else "fields"
+            reasoning = f"{selection.name} is promoted from {target_name_path} through the embedded {embedded_fields} "
+            reasoning += ".".join(member.embedding_path)
+        else:
+            reasoning = f"{member.owner} declares the {member_kind} {selection.name} itself"
+            if selection.kind == MemberSelectionKind.OVERRIDE:
+                shadowed = ", ".join(m.get_name_path(selection.name) for m in selection.shadowed)
+                reasoning += f", which shadows {shadowed} promoted from embedded fields"
+        if member.is_method and member.declaration is None:
+            reasoning += f"; as {member.owner} is an interface, the call is dispatched to the method of the dynamic type at runtime"
+
+        result = {
+            "selector": f"{resolution.operand}.{selection.name}",
+            "operand_type": resolution.operand_type,
+            "member": member_kind,
+            "resolution": selection.kind.value,
+            "target": {"name_path": target_name_path, "relative_path": member.relative_path},
+            "embedding_path": member.embedding_path,
+            "shadowed": [{"name_path": m.get_name_path(selection.name), "relative_path": m.relative_path} for m in selection.shadowed],
+            "reasoning": reasoning,
+        }
+        return json.dumps(result)
+
+
+class GenerateMockTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Generates a mock implementation of a Go interface whose methods delegate to configurable function fields (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "mock": {"type": "string"},
+            "relative_path": {"type": "string"},
+            "hooks": {"type": "array", "items": {"type": "string"}},
+        },
+        "required": ["mock", "relative_path", "hooks"],
+    }
+
+    def apply(
+        self,
+        interface_name_path: str,
+        relative_path: str,
+        mock_name: str = "",
+        target_relative_path: str = "",
+        organize_imports: bool = True,
+    ) -> str:
+        """
+        Generates a mock struct implementing the given interface (including the methods of embedded interfaces) for use in
+        tests. For each method `M`, the mock has a function field `MFunc` with the method's signature (e.g.
+        `ProcessFunc func() error`), to which the method delegates; if the field is nil, the method returns zero values.
+        Each call is recorded by appending the method name to the field `Calls`. The mock is appended to the target file
+        together with a compile-time assertion that it implements the interface.
+
+        :param interface_name_path: the name path of the interface, e.g. "Processable"
+        :param relative_path: the relative path of the file containing the interface
+        :param mock_name: the name of the mock type; if empty, "Mock" followed by the interface name is used
+        :param target_relative_path: the relative path of the (existing) file of the interface's package to which to append the mock,
+            e.g. a `_test.go` file; if empty, the file containing the interface is used
+        :param organize_imports: whether to add missing imports (e.g. for types from other packages used in the signatures)
+        :return: a JSON object with the name of the `mock`, the `relative_path` of the file it was added to and the names of
+            its function fields (`hooks`)
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, interface = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        assert interface.type_expr is not None
+        if classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"{interface.name} is not an interface type")
+        if interface.type_params is not None:
+            raise ValueError(f"Generating mocks for generic interfaces such as {interface.name} is not supported")
+        package_dir = os.path.dirname(relative_path)
+        target_relative_path = target_relative_path or relative_path
+        self.project.validate_relative_path(target_relative_path)
+        if os.path.dirname(target_relative_path) != package_dir:
+            raise ValueError(f"The target file {target_relative_path} must belong to the package of {interface.name} ({package_dir!r})")
+        mock_name = mock_name or f"Mock{interface.name}"
+        if go_analyzer.find_type_declaration(mock_name, package_dir) is not None:
+            raise ValueError(f"The package already declares a type {mock_name}")
+        interface_methods, unresolved = go_analyzer.get_interface_methods(interface, package_dir)
+        if unresolved:
+            raise ValueError(f"Cannot implement {interface.name}: the embedded elements {', '.join(unresolved)} could not be resolved")
+        methods: dict[str, str] = {}
+        for element, _ in interface_methods:
+            assert element.method_name is not None
+            methods.setdefault(element.method_name, element.get_signature())
+        if "Calls" in methods or any(name + "Func" in methods for name in methods):
+            raise ValueError(f"The methods of {interface.name} conflict with the fields of the mock")
+
+        hooks = []
+        fields = []
+        method_texts = []
+        for name, signature in methods.items():
+            parameter_types, result_types = get_parameter_and_result_types(signature)
+            # use the parameter names of the interface where they are usable (the receiver is named m)
+            parameter_names = [
+                n if n is not None and n not in ("_", "m") else f"p{i}" for i, n in enumerate(get_parameter_names(signature))
+            ]
+            results = ""
+            if len(result_types) == 1:
+                results = " " + result_types[0]
+            elif result_types:
+                results = " (" + ", ".join(result_types) + ")"
+            parameters = ", ".join(f"{n} {t}" for n, t in zip(parameter_names, parameter_types, strict=True))
+            arguments = ", ".join(n + "..." if t.startswith("...") else n for n, t in zip(parameter_names, parameter_types, strict=True))
+            hook = name + "Func"
+            hooks.append(hook)
+            fields.append(f"\t{hook} func({', '.join(parameter_types)}){results}\n")
+            body = f'\tm.Calls = append(m.Calls, "{name}")\n\tif m.{hook} != nil {{\n'
+            if result_types:
+                zero_values = ", ".join(_get_zero_value_expression(go_analyzer, t, package_dir) for t in result_types)
+                body += f"\t\treturn m.{hook}({arguments})\n\t}}\n\treturn {zero_values}\n"
+            else:
+                body += f"\t\tm.{hook}({arguments})\n\t}}\n"
+            method_texts.append(
+                f"// {name} records the call and delegates to {hook}.\nfunc (m *{mock_name}) {name}({parameters}){results} {{\n{body}}}"
+            )
+        mock_text = (
+            f"// {mock_name} is a mock implementation of {interface.name}. Each method records its call in Calls and delegates\n"
+            f"// to the corresponding function field; if the field is nil, the method returns zero values.\n"
+            f"type {mock_name} struct {{\n{''.join(fields)}\tCalls []string\n}}\n\n"
+            f"var _ {interface.name} = (*{mock_name})(nil)\n\n" + "\n\n".join(method_texts)
+        )
+
+        code_editor = self.create_language_server_code_editor()
+        with code_editor.edit_transaction():
+            target_file = go_analyzer.parse_file(target_relative_path)
+            end = target_file.declarations[-1].end if target_file.declarations else len(target_file.source.rstrip())
+            _apply_edit(code_editor, target_relative_path, target_file, GoTextEdit(end, end, "\n\n" + mock_text), organize_imports)
+        result = {"mock": mock_name, "relative_path": target_relative_path, "hooks": hooks}
+        return json.dumps(result)
+
+
+class ControlFlowFeaturesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Locates the go and defer statements and the panic and recover calls within a Go function (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "function": {"type": "string"},
+            "features": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "kind": {"type": "string", "enum": [k.value for k in GoControlFlowFeatureKind]},
+                        "line": {"type": "integer"},
+                        "column": {"type": "integer"},
+                        "text": {"type": "string"},
+                        "in_loop": {"type": "boolean"},
+                        "in_function_literal": {"type": "boolean"},
+                    },
+                    "required": ["kind", "line", "column", "text", "in_loop", "in_function_literal"],
+                },
+            },
+            "warnings": {"type": "array", "items": {"type": "string"}},
+        },
+        "required": ["function", "features", "warnings"],
+    }
+
+    def apply(self, name_path: str, relative_path: str) -> str:
+        """
+        Finds the `go` statements, `defer` statements and calls of the built-in functions `panic` and `recover` within the body
+        of a function or method, including those within nested function literals (e.g. the function of a goroutine).
+        This is useful for auditing a function for common concurrency mistakes without reading its entire body:
+        for each feature, it is reported whether it is located within a loop of its (innermost) enclosing function,
+        and a warning is given for each deferred call within a loop, as it only runs when the function returns
+        rather than at the end of the iteration.
+
+        :param name_path: the name path of the function or method, e.g. "LockAll"
+        :param relative_path: the relative path of the file containing the function
+        :return: a JSON object with the `function`'s name path, the `features`, each with the `kind` ("go", "defer", "panic"
+            or "recover"), the (0-based) `line` and `column`, the (first line of the) statement's `text`, `in_loop` and
+            `in_function_literal`, and a list of `warnings`
+        """
+        go_analyzer = self.create_go_analyzer()
+        symbol, declaration = go_analyzer.find_unique_declaration(
+            name_path, relative_path, kinds=(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD)
+        )
+        go_file = go_analyzer.parse_file(relative_path)
+        features = []
+        warnings = []
+        for feature in find_control_flow_features(go_file, declaration):
+            line, column = go_file.get_line_and_column(feature.start)
+            features.append(
+                {
+                    "kind": feature.kind.value,
+                    "line": line,
+                    "column": column,
+                    "text": feature.text,
+                    "in_loop": feature.in_loop,
+                    "in_function_literal": feature.in_function_literal,
+                }
+            )
+            if feature.kind == GoControlFlowFeatureKind.DEFER and feature.in_loop:
+                warnings.append(
+                    f"line {line}: `{feature.text}` is deferred within a loop; "
+                    "the deferred calls only run when the function returns, not at the end of each iteration"
+                )
+        result = {"function": symbol.get_name_path(), "features": features, "warnings": warnings}
+        return json.dumps(result)
+
+
+class TypeViewTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Assembles a complete view of a Go type: its declaration, its methods across files, promoted members and satisfied interfaces (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "type": {"type": "string"},
+            "kind": {"type": "string", "enum": [k.value for k in GoUnderlyingKind]},
+            "files": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "relative_path": {"type": "string"},
+                        "declarations": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "properties": {
+                                    "name_path": {"type": "string"},
+                                    "kind": {"type": "string", "enum": ["type", "method"]},
+                                    "line": {"type": "integer"},
+                                    "text": {"type": "string"},
+                                },
+                                "required": ["name_path", "kind", "line", "text"],
+                            },
+                        },
+                    },
+                    "required": ["relative_path", "declarations"],
+                },
+            },
+            "promoted": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "name": {"type": "string"},
+                        "kind": {"type": "string", "enum": ["field", "method"]},
+                        "target": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "embedding_path": {"type": "array", "items": {"type": "string"}},
+                    },
+                    "required": ["name", "kind", "target", "relative_path", "embedding_path"],
+                },
+            },
+            "satisfied_interfaces": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"interface": {"type": "string"}, "relative_path": {"type": "string"}, "by_value": {"type": "boolean"}},
+                    "required": ["interface", "relative_path", "by_value"],
+                },
+            },
+        },
+        "required": ["type", "kind", "files", "promoted", "satisfied_interfaces"],
+    }
+
+    def apply(self, type_name_path: str, relative_path: str, include_body: bool = False, max_answer_chars: int = -1) -> str:
+        """
+        Assembles everything that makes up a type in a single view, grouped by the files of the package: the type
+        declaration and all methods declared for the type (with value or pointer receivers), which in Go may be spread
+        across several files. In addition, the fields and methods promoted from embedded fields (and not shadowed by
+        the type's own members) and the interfaces of the package whose methods the type provides are reported.
+        Embedded types and interfaces declared in other packages are not considered.
+
+        :param type_name_path: the name path of the type, e.g. "BaseStruct"
+        :param relative_path: the relative path of the file declaring the type or of its package directory
+        :param include_body: whether to include the methods' bodies; if False, only their signatures are included
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the `type` name, its `kind` (e.g. "struct"), the `files`, each with the `relative_path`
+            and the `declarations` in it (each with `name_path`, `kind` ("type" or "method"), (0-based) `line` and `text`;
+            the type's file comes first), the `promoted` members (each with `name`, `kind` ("field" or "method"),
+            the `target` name path, its `relative_path` and the `embedding_path` of embedded fields through which it is
+            reached) and the `satisfied_interfaces` (each with `interface` name, `relative_path` and whether it is
+            satisfied `by_value` or only by pointers to the type)
+        """
+        self.project.validate_relative_path(relative_path)
+        go_analyzer = self.create_go_analyzer()
+        if os.path.isfile(os.path.join(self.get_project_root(), relative_path)):
+            package_dir = os.path.dirname(relative_path)
+            type_path = relative_path
+            _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        else:
+            package_dir = relative_path
+            resolved = go_analyzer.find_type_declaration(type_name_path.strip("/"), package_dir)
+            if resolved is None:
+                raise ValueError(f"No type matching {type_name_path} found in package directory {relative_path or '.'}")
+            type_path, declaration = resolved
+        if declaration.type_expr is None:
+            raise ValueError(f"{declaration.name} is not a type")
+
+        def to_entry(file_path: str, member: GoDeclaration) -> dict[str, Any]:
+            go_file = go_analyzer.parse_file(file_path)
+            is_method = member.kind == GoDeclarationKind.METHOD
+            text = _get_godoc_declaration_text(go_file, member)
+            if is_method and include_body:
+                text = go_file.get_declaration_text(member)
+            return {
+                "name_path": format_name_path([declaration.name, member.name]) if is_method else member.name,
+                "kind": "method" if is_method else "type",
+                "line": go_file.get_line_and_column(member.start)[0],
+                "text": text,
+            }
+
+        declarations_by_file: dict[str, list[dict[str, Any]]] = {type_path: [to_entry(type_path, declaration)]}
+        for method_path, method in go_analyzer.get_methods(declaration.name, package_dir):
+            declarations_by_file.setdefault(method_path, []).append(to_entry(method_path, method))
+        files = [{"relative_path": p, "declarations": entries} for p, entries in declarations_by_file.items()]
+
+        promoted = [
+            {
+                "name": selection.name,
+                "kind": "method" if selection.member.is_method else "field",
+                "target": selection.member.get_name_path(selection.name),
+                "relative_path": selection.member.relative_path,
+                "embedding_path": selection.member.embedding_path,
+            }
+            for selection in go_analyzer.get_promoted_members(type_path, declaration, package_dir)
+        ]
+
+        satisfied_interfaces = []
+        method_set, _ = go_analyzer.get_method_set(declaration, package_dir)
+        for file_path in go_analyzer.get_package_files(package_dir):
+            for interface in go_analyzer.parse_file(file_path).iter_declarations(GoDeclarationKind.TYPE):
+                if interface.name == declaration.name or interface.is_alias or interface.type_expr is None:
+                    continue
+                if classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+                    continue
+                interface_methods, unresolved = go_analyzer.get_interface_methods(interface, package_dir)
+                if not interface_methods or unresolved:
+                    continue
+                satisfaction = check_interface_satisfaction(method_set, interface_methods)
+                if satisfaction.satisfied_by_pointer:
+                    satisfied_interfaces.append(
+                        {"interface": interface.name, "relative_path": file_path, "by_value": satisfaction.satisfied_by_value}
+                    )
+
+        result = {
+            "type": declaration.name,
+            "kind": go_analyzer.get_underlying_kind(declaration, package_dir).value,
+            "files": files,
+            "promoted": promoted,
+            "satisfied_interfaces": satisfied_interfaces,
+        }
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class WrapErrorsTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Rewrites the returns of unwrapped errors in a Go function to wrap the errors with context using fmt.Errorf and %w (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "num_wrapped": {"type": "integer"},
+            "lines": {"type": "array", "items": {"type": "integer"}},
+        },
+        "required": ["num_wrapped", "lines"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, context_template: str) -> str:
+        """
+        Rewrites the return statements of a function (or method) which return an error variable as is, e.g. `return nil, err`,
+        such that they wrap the error with context, e.g. `return nil, fmt.Errorf("loading config: %w", err)`, which keeps the
+        original error accessible via `errors.Is` and `errors.As`. Only returns of variables are rewritten: returns of `nil`
+        and of calls, such as those which already wrap errors (`fmt.Errorf(...)`) or create new ones (`errors.New(...)`), are
+        left unchanged, as are the return statements of nested function literals. The last result of the function must be
+        of type `error`. The import of `fmt` is added if necessary.
+
+        :param name_path: the name path of the function or method, e.g. "LoadConfig"
+        :param relative_path: the relative path of the file containing the function
+        :param context_template: the context with which to wrap the errors, e.g. "loading config"; the placeholder
+            `{function}` is replaced with the function's name. The error is appended as ": %w".
+        :return: a JSON object with the number of rewritten return statements `num_wrapped` and their (0-based) `lines`
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(
+            name_path, relative_path, kinds=(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD)
+        )
+        go_file = go_analyzer.parse_file(relative_path)
+        errors = find_unwrapped_error_returns(go_file, declaration)
+        lines = [go_file.get_line_and_column(error.start)[0] for error in errors]
+        if errors:
+            context = context_template.replace("{function}", declaration.name).replace("%", "%%")
+            # a JSON string literal is a valid Go string literal
+            format_literal = json.dumps(context + ": %w", ensure_ascii=False)
+            fmt_import = next((i for i in go_file.imports if i.path == "fmt"), None)
+            fmt_name = fmt_import.get_package_name() if fmt_import is not None else "fmt"
+            edit = get_error_wrapping_edit(go_file, declaration, errors, format_literal, fmt_name)
+            code_editor = self.create_language_server_code_editor()
+            with code_editor.edit_transaction():
+                _apply_edit(code_editor, relative_path, go_file, edit, organize_imports=fmt_import is None)
+        result = {"num_wrapped": len(errors), "lines": lines}
+        return json.dumps(result)
+
+
+class ReceiverlessCandidatesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds Go methods which never reference their receiver and could thus become plain functions (Go only).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "name_path": {"type": "string"},
+                "relative_path": {"type": "string"},
+                "line": {"type": "integer"},
+                "receiver_type": {"type": "string"},
+                "suggested_signature": {"type": "string"},
+                "interfaces": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "properties": {"interface": {"type": "string"}, "relative_path": {"type": "string"}},
+                        "required": ["interface", "relative_path"],
+                    },
+                },
+            },
+            "required": ["name_path", "relative_path", "line", "receiver_type", "suggested_signature", "interfaces"],
+        },
+    }
+
+    def apply(self, relative_path: str = "", max_answer_chars: int = -1) -> str:
+        """
+        Finds the methods declared in the given file or directory whose bodies never reference the receiver variable
+        (including methods with unnamed or blank receivers). Such methods are candidates for becoming plain functions,
+        unless they are needed for the type to satisfy an interface, which is why the interfaces of the package that the
+        type satisfies and that require a method of the same name are reported for each candidate.
+        The check is textual, i.e. a local variable shadowing the receiver counts as a reference.
+
+        :param relative_path: the relative path of the file or directory in which to search for methods; "" for the
+            entire project
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON list of candidates, each with the method's `name_path`, `relative_path`, (0-based) `line`, the declared
+            `receiver_type` (e.g. "*Formatter"), the `suggested_signature` of the function it could become and the
+            `interfaces` requiring the method (each with name and relative path)
+        """
+        go_analyzer = self.create_go_analyzer()
+        candidates = []
+        for file_path in sorted(self.project.gather_source_files(relative_path)):
+            if not file_path.endswith(".go"):
+                continue
+            package_dir = os.path.dirname(file_path)
+            go_file = go_analyzer.parse_file(file_path)
+            for method in go_file.iter_declarations(GoDeclarationKind.METHOD):
+                if method.receiver_type is None or references_receiver(go_file, method):
+                    continue
+                receiver_type = go_file.get_receiver_type_text(method) or method.receiver_type
+                # the function takes over the type parameters of the receiver's type
+                type_declaration = go_analyzer.find_type_declaration(method.receiver_type, package_dir)
+                type_params = type_declaration[1].type_params if type_declaration is not None else None
+                function_name = method.name
+                if any(go_analyzer.parse_file(p).find_declaration(method.name) for p in go_analyzer.get_package_files(package_dir)):
+                    # avoid a conflict with a package-level declaration of the same name
+                    function_name = method.receiver_type + method.name[0].upper() + method.name[1:]
+                interfaces = []
+                if type_declaration is not None:
+                    method_set, _ = go_analyzer.get_method_set(type_declaration[1], package_dir)
+                    for interface_path in go_analyzer.get_package_files(package_dir):
+                        for interface in go_analyzer.parse_file(interface_path).iter_declarations(GoDeclarationKind.TYPE):
+                            if interface.type_expr is None or classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+                                continue
+                            interface_methods, _ = go_analyzer.get_interface_methods(interface, package_dir)
+                            if not any(element.method_name == method.name for element, _ in interface_methods):
+                                continue
+                            if check_interface_satisfaction(method_set, interface_methods).satisfied_by_pointer:
+                                interfaces.append({"interface": interface.name, "relative_path": interface_path})
+                candidates.append(
+                    {
+                        "name_path": format_name_path([method.receiver_type, method.name]),
+                        "relative_path": file_path,
+                        "line": go_file.get_line_and_column(method.start)[0],
+                        "receiver_type": "*" + receiver_type if method.receiver_is_pointer else receiver_type,
+                        "suggested_signature": f"func {function_name}{type_params or ''}{go_file.get_parameters_and_results_text(method)}",
+                        "interfaces": interfaces,
+                    }
+                )
+        return self._limit_length(json.dumps(candidates), max_answer_chars)
+
+
+class DispatchTableTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Resolves, for every implementer of a Go interface, the concrete method a call of a given interface method dispatches to (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interface": {"type": "string"},
+            "method": {"type": "string"},
+            "origin": {"type": "string"},
+            "implementations": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "type": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "resolution": {"type": "string", "enum": [k.value for k in MemberSelectionKind]},
+                        "embedding_path": {"type": "array", "items": {"type": "string"}},
+                        "target": {
+                            "type": "object",
+                            "properties": {
+                                "name_path": {"type": "string"},
+                                "relative_path": {"type": "string"},
+                                "line": {"type": ["integer", "null"]},
+                            },
+                            "required": ["name_path", "relative_path", "line"],
+                        },
+                    },
+                    "required": ["type", "relative_path", "resolution", "embedding_path", "target"],
+                },
+            },
+        },
+        "required": ["interface", "method", "origin", "implementations"],
+    }
+
+    def apply(self, interface_method_path: str, relative_path: str) -> str:
+        """
+        Builds the static counterpart of the runtime dispatch of an interface method: for each type of the interface's
+        package which implements the interface (with values or pointers), the method that is called when a value of that
+        type is used through the interface. This is either the type's own method or a method promoted from an embedded
+        field, selected according to Go's rules for shadowing (see the tool `resolve_selector`).
+
+        :param interface_method_path: the name path of the interface method, e.g. "Processable/Process"; the method may
+            also be obtained from an interface embedded in the given interface
+        :param relative_path: the relative path of the file containing the interface
+        :return: a JSON object with the `interface` name, the `method` name, the `origin` of the method ("own" or the name
+            of the embedded interface declaring it) and the `implementations`, each with the implementing `type`, its
+            `relative_path`, the `resolution` ("own", "override" or "promoted"), the `embedding_path` of embedded fields
+            through which a promoted method is reached and the `target` method (name path, relative path and (0-based) line;
+            the line is null for methods promoted from embedded interfaces, which are in turn dispatched dynamically)
+        """
+        name_path = parse_name_path(interface_method_path)
+        if len(name_path.parts) < 2:
+            raise ValueError(f"{interface_method_path} is not the name path of an interface method, e.g. Processable/Process")
+        interface_name_path = format_name_path(name_path.parts[:-1], is_absolute=name_path.is_absolute)
+        method_name = name_path.parts[-1]
+        go_analyzer = self.create_go_analyzer()
+        _, interface = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if interface.type_expr is None or classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"{interface.name} is not an interface type")
+        package_dir = os.path.dirname(relative_path)
+        interface_methods, _ = go_analyzer.get_interface_methods(interface, package_dir)
+        origins = [origin for element, origin in interface_methods if element.method_name == method_name]
+        if not origins:
+            raise ValueError(f"The interface {interface.name} has no method {method_name}")
+
+        implementations = []
+        for type_path, declaration in go_analyzer.find_implementations(interface, package_dir):
+            selection = go_analyzer.select_member(type_path, declaration, method_name, package_dir)
+            member = selection.member
+            line = None
+            if member.declaration is not None:
+                line = go_analyzer.parse_file(member.relative_path).get_line_and_column(member.declaration.start)[0]
+            implementations.append(
+                {
+                    "type": declaration.name,
+                    "relative_path": type_path,
+                    "resolution": selection.kind.value,
+                    "embedding_path": member.embedding_path,
+                    "target": {"name_path": member.get_name_path(method_name), "relative_path": member.relative_path, "line": line},
+                }
+            )
+        result = {
+            "interface": interface.name,
+            "method": method_name,
+            "origin": origins[0] or "own",
+            "implementations": implementations,
+        }
+        return json.dumps(result)
+
+
+
+class RemovalImpactTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Determines the impact of removing a method from a Go interface on the types satisfying it (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interface": {"type": "string"},
+            "method": {"type": "string"},
+            "affected_interfaces": {"type": "array", "items": {"type": "string"}},
+            "implementations": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "type": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "target": {
+                            "type": "object",
+                            "properties": {
+                                "name_path": {"type": "string"},
+                                "relative_path": {"type": "string"},
+                                "resolution": {"type": "string", "enum": [k.value for k in MemberSelectionKind]},
+                            },
+                            "required": ["name_path", "relative_path", "resolution"],
+                        },
+                        "still_required_by": {"type": "array", "items": {"type": "string"}},
+                        "possibly_unused": {"type": "boolean"},
+                        "becomes_value_assignable": {"type": "boolean"},
+                    },
+                    "required": ["type", "relative_path", "target", "still_required_by", "possibly_unused", "becomes_value_assignable"],
+                },
+            },
+            "newly_satisfying": {"type": "array", "items": _GO_TYPE_LOCATION_SCHEMA},
+        },
+        "required": ["interface", "method", "affected_interfaces", "implementations", "newly_satisfying"],
+    }
+
+    def apply(self, interface_method_path: str, relative_path: str) -> str:
+        """
+        Analyzes which types of the interface's package are affected if a method is removed from an interface.
+        The current implementers continue to satisfy the interface, but their methods of the removed name may no longer
+        be required; a method is reported as possibly unused if the implementer declares it itself and no other interface
+        of the package which the implementer satisfies requires it. Direct calls of the method are not taken into
+        account (use `find_referencing_symbols` to check them).
+        Removing a method can furthermore make values (rather than only pointers) of implementers assignable to the
+        interface and can make further types satisfy it.
+
+        :param interface_method_path: the name path of the interface method, e.g. "Processable/GetType"; the method must
+            be declared by the interface itself (not by an embedded interface)
+        :param relative_path: the relative path of the file containing the interface
+        :return: a JSON object with the `interface` name, the `method` name, the `affected_interfaces` which embed the
+            interface and would thus lose the method as well, the `implementations` (each with the implementing `type`,
+            its `relative_path`, the `target` method implementing the interface method (name path, relative path and
+            `resolution`, see the tool `dispatch_table`), the list `still_required_by` of other interfaces which the type
+            satisfies and which require the method, the flag `possibly_unused` and the flag `becomes_value_assignable`,
+            which indicates that values of the type satisfy the interface only after the removal) and the list
+            `newly_satisfying` of types which satisfy the interface only after the removal (each with type and relative path)
+        """
+        name_path = parse_name_path(interface_method_path)
+        if len(name_path.parts) < 2:
+            raise ValueError(f"{interface_method_path} is not the name path of an interface method, e.g. Processable/GetType")
+        interface_name_path = format_name_path(name_path.parts[:-1], is_absolute=name_path.is_absolute)
+        method_name = name_path.parts[-1]
+        go_analyzer = self.create_go_analyzer()
+        _, interface = go_analyzer.find_unique_declaration(interface_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if interface.type_expr is None or classify_type_expression(interface.type_expr) != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"{interface.name} is not an interface type")
+        package_dir = os.path.dirname(relative_path)
+        interface_methods, _ = go_analyzer.get_interface_methods(interface, package_dir)
+        origins = [origin for element, origin in interface_methods if element.method_name == method_name]
+        if not origins:
+            raise ValueError(f"The interface {interface.name} has no method {method_name}")
+        if None not in origins:
+            raise ValueError(
+                f"The method {method_name} is declared by the embedded interface {origins[0]}; "
+                f"analyze {origins[0]}/{method_name} instead"
+            )
+
+        def without_removed_method(
+            methods: list[tuple[GoInterfaceElement, str | None]], own_name: str
+        ) -> list[tuple[GoInterfaceElement, str | None]]:
+            return [
+                (element, origin)
+                for element, origin in methods
+                if element.method_name != method_name or (origin or own_name) != interface.name
+            ]
+
+        # the other interfaces of the package, which still require the method after the removal if they declare it themselves
+        # or obtain it from an interface other than the given one
+        affected_interfaces = []
+        requiring_interfaces = []
+        package_types = []
+        for file_path in go_analyzer.get_package_files(package_dir):
+            for declaration in go_analyzer.parse_file(file_path).iter_declarations(GoDeclarationKind.TYPE):
+                if declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+                    package_types.append((file_path, declaration))
+                    continue
+                if declaration.name == interface.name:
+                    continue
+                methods, _ = go_analyzer.get_interface_methods(declaration, package_dir)
+                if not any(element.method_name == method_name for element, _ in methods):
+                    continue
+                remaining_methods = without_removed_method(methods, declaration.name)
+                if any(element.method_name == method_name for element, _ in remaining_methods):
+                    requiring_interfaces.append((declaration.name, remaining_methods))
+                else:
+                    affected_interfaces.append(declaration.name)
+
+        remaining_interface_methods = without_removed_method(interface_methods, interface.name)
+        implementations = []
+        newly_satisfying = []
+        for type_path, declaration in package_types:
+            method_set, _ = go_analyzer.get_method_set(declaration, package_dir)
+            satisfaction = check_interface_satisfaction(method_set, interface_methods)
+            if not satisfaction.satisfied_by_pointer:
+                if check_interface_satisfaction(method_set, remaining_interface_methods).satisfied_by_pointer:
+                    newly_satisfying.append({"type": declaration.name, "relative_path": type_path})
+                continue
+            selection = go_analyzer.select_member(type_path, declaration, method_name, package_dir)
+            still_required_by = [
+                name
+                for name, methods in requiring_interfaces
+                if check_interface_satisfaction(method_set, methods).satisfied_by_pointer
+            ]
+            implementations.append(
+                {
+                    "type": declaration.name,
+                    "relative_path": type_path,
+                    "target": {
+                        "name_path": selection.member.get_name_path(method_name),
+                        "relative_path": selection.member.relative_path,
+                        "resolution": selection.kind.value,
+                    },
+                    "still_required_by": still_required_by,
+                    "possibly_unused": selection.kind != MemberSelectionKind.PROMOTED and not still_required_by,
+                    "becomes_value_assignable": not satisfaction.satisfied_by_value
+                    and check_interface_satisfaction(method_set, remaining_interface_methods).satisfied_by_value,
+                }
+            )
+        result = {
+            "interface": interface.name,
+            "method": method_name,
+            "affected_interfaces": affected_interfaces,
+            "implementations": implementations,
+            "newly_satisfying": newly_satisfying,
+        }
+        return json.dumps(result)
+
+_MIN_DEDUPLICATION_STATEMENTS = 2
+"""
+the minimum number of statements of the bodies of methods considered for deduplication (single statements, such as
+delegating calls, are not worth sharing)
+"""
+
+
+def _infer_literal_type(expressions: list[str]) -> str | None:
+    """
+    :param expressions: Go expressions
+    :return: the (default) type of the expressions if all of them are literals of the same kind, e.g. "string", else None
+    """
+    kinds = set()
+    for expression in expressions:
+        if expression in ("true", "false"):
+            kinds.add("bool")
+        elif expression[:1] in ('"', "`"):
+            kinds.add("string")
+        elif expression[:1] == "'":
+            kinds.add("rune")
+        elif re.fullmatch(r"(0[xXbBoO])?[0-9a-fA-F_]+", expression):
+            kinds.add("int")
+        elif re.fullmatch(r"[0-9_]*\.?[0-9_]+([eE][+-]?[0-9_]+)?", expression):
+            kinds.add("float64")
+        else:
+            return None
+    return kinds.pop() if len(kinds) == 1 else None
+
+
+class SuggestDeduplicationTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Finds groups of Go methods with near-identical bodies and proposes a shared helper function they could delegate to (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "groups": {"type": "array", "items": {"type": "object"}},
+            "applied": {"type": ["integer", "null"]},
+        },
+        "required": ["groups", "applied"],
+    }
+
+    def apply(
+        self, relative_path: str = "", min_similarity: float = 0.7, apply_group: int = -1, max_answer_chars: int = -1
+    ) -> str:
+        """
+        Finds groups of methods of the same package with the same signature whose bodies are near-identical, i.e. whose
+        token sequences (ignoring comments, formatting and the names of the receivers) have at least the given similarity.
+        For each group whose bodies differ only in call arguments or returned expressions, a package-level helper function
+        is proposed which takes a parameter for each differing expression, along with the new bodies of the methods, each
+        of which calls the helper. References to the receivers are always passed as arguments.
+        The suggestions are not applied unless the index of a group is passed as `apply_group`, in which case the methods
+        of the group are routed through the helper, which is inserted after the group's first method.
+        The helper's parameters for the differing expressions are typed only if all arguments are literals of the same
+        kind; the others are declared as `any` and should be reviewed.
+
+        :param relative_path: the relative path of the file or directory in which to search for methods; "" for the
+            entire project
+        :param min_similarity: the minimum similarity (between 0 and 1) of the bodies of methods in a group
+        :param apply_group: the index of the group (within the result of a previous call with the same arguments) whose
+            suggested deduplication to apply; -1 to apply nothing
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the list `groups`, each with the `methods` (each with `name_path`, `relative_path`,
+            (0-based) `line` and the `similarity` to the group's first method), the proposed `helper` (with `name`,
+            `relative_path` and `text`) and the `edits` (each with the method's `name_path`, `relative_path` and `new_body`),
+            or, if the methods cannot be routed through a common helper, a null `helper`, empty `edits` and the `reason`,
+            as well as a list of `warnings`; and the index of the `applied` group (null if none was applied)
+        """
+        go_analyzer = self.create_go_analyzer()
+        # collect the candidate methods, grouped by package and signature
+        candidates: dict[tuple[str, str], list[tuple[str, GoFile, GoDeclaration]]] = defaultdict(list)
+        for file_path in sorted(self.project.gather_source_files(relative_path)):
+            if not file_path.endswith(".go"):
+                continue
+            go_file = go_analyzer.parse_file(file_path)
+            for method in go_file.iter_declarations(GoDeclarationKind.METHOD):
+                if method.body_start is None or method.receiver_type is None:
+                    continue
+                receiver_type = go_file.get_receiver_type_text(method) or ""
+                if "[" in receiver_type or count_body_statements(go_file, method) < _MIN_DEDUPLICATION_STATEMENTS:
+                    # methods of generic types are not supported, single statements are not worth sharing
+                    continue
+                signature = go_file.get_parameters_and_results_text(method)
+                assert signature is not None
+                candidates[(os.path.dirname(file_path), normalize_method_signature(signature))].append((file_path, go_file, method))
+
+        groups: list[list[tuple[str, GoFile, GoDeclaration, float]]] = []
+        for methods in candidates.values():
+            package_groups: list[list[tuple[str, GoFile, GoDeclaration, float]]] = []
+            for file_path, go_file, method in methods:
+                for group in package_groups:
+                    similarities = [get_body_similarity(f, m, go_file, method) for _, f, m, _ in group]
+                    if max(similarities) >= min_similarity:
+                        group.append((file_path, go_file, method, similarities[0]))
+                        break
+                else:
+                    package_groups.append([(file_path, go_file, method, 1.0)])
+            groups.extend(group for group in package_groups if len(group) > 1)
+        groups.sort(key=lambda group: (group[0][0], group[0][2].start))
+        if apply_group >= len(groups):
+            raise ValueError(f"There is no group with index {apply_group}; only {len(groups)} groups were found")
+
+        results = []
+        applied = None
+        for group_index, group in enumerate(groups):
+            group_result = self._suggest_helper(go_analyzer, group)
+            if group_index == apply_group:
+                if group_result["helper"] is None:
+                    reason = group_result["reason"]
+                    raise ValueError(f"The methods of group {apply_group} cannot be routed through a common helper: {reason}")
+                self._apply_suggestion(group_result)
+                applied = group_index
+            results.append(group_result)
+        result = {"groups": results, "applied": applied}
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+    @staticmethod
+    def _suggest_helper(go_analyzer: GoAnalyzer, group: list[tuple[str, GoFile, GoDeclaration, float]]) -> dict[str, Any]:
+        first_path, first_file, first_method, _ = group[0]
+        name_paths = [format_name_path([method.receiver_type or "", method.name]) for _, _, method, _ in group]
+        result: dict[str, Any] = {
+            "methods": [
+                {
+                    "name_path": name_path,
+                    "relative_path": file_path,
+                    "line": go_file.get_line_and_column(method.start)[0],
+                    "similarity": round(similarity, 3),
+                }
+                for name_path, (file_path, go_file, method, similarity) in zip(name_paths, group)
+            ],
+            "helper": None,
+            "edits": [],
+            "reason": None,
+            "warnings": [],
+        }
+        common_body = find_common_body([(go_file, method) for _, go_file, method, _ in group])
+        if common_body is None:
+            result["reason"] = "the bodies differ in more than call arguments and returned expressions"
+            return result
+
+        # the helper takes the parameters of the methods it references, followed by a parameter for each hole
+        signature = first_file.get_parameters_and_results_text(first_method)
+        assert signature is not None
+        signature_tokens = tokenize(signature)
+        results_text = signature[signature_tokens[find_matching_bracket(signature_tokens, 0)].end :].strip()
+        parameter_types, _ = get_parameter_and_result_types(signature)
+        method_parameters = [
+            (name, type_expr)
+            for name, type_expr in zip(get_parameter_names(signature), parameter_types, strict=True)
+            if name is not None and name in common_body.identifiers
+        ]
+        taken_names = set(common_body.identifiers)
+        hole_parameters = []
+        for texts in zip(*common_body.hole_texts):
+            hole_name = f"arg{len(hole_parameters) + 1}"
+            while hole_name in taken_names:
+                hole_name += "_"
+            hole_type = _infer_literal_type(list(texts))
+            if hole_type is None:
+                hole_type = "any"
+                expressions = ", ".join(sorted(set(texts)))
+                result["warnings"].append(
+                    f"The type of {hole_name} (for the expressions {expressions}) could not be inferred and is declared as any"
+                )
+            hole_parameters.append((hole_name, hole_type))
+
+        base_name = first_method.name[0].lower() + first_method.name[1:]
+        candidate_names = [base_name, "do" + first_method.name] + [f"{base_name}{i}" for i in range(2, 100)]
+        package_files = [go_analyzer.parse_file(p) for p in go_analyzer.get_package_files(os.path.dirname(first_path))]
+        helper_name = next(
+            name
+            for name in candidate_names
+            if name not in taken_names and not any(f.find_declaration(name) is not None for f in package_files)
+        )
+        helper_parameters = ", ".join(f"{name} {type_expr}" for name, type_expr in method_parameters + hole_parameters)
+        helper_text = (
+            f"// {helper_name} is the common implementation of {', '.join(name_paths)}.\n"
+            f"func {helper_name}({helper_parameters}){' ' + results_text if results_text else ''} "
+            + common_body.instantiate([name for name, _ in hole_parameters])
+        )
+        result["helper"] = {"name": helper_name, "relative_path": first_path, "text": helper_text}
+        parameter_arguments = [name + "..." if type_expr.startswith("...") else name for name, type_expr in method_parameters]
+        for name_path, (file_path, _, _, _), hole_texts in zip(name_paths, group, common_body.hole_texts):
+            call = f"{helper_name}({', '.join(parameter_arguments + hole_texts)})"
+            new_body = f"{{\n\t{'return ' if results_text else ''}{call}\n}}"
+            result["edits"].append({"name_path": name_path, "relative_path": file_path, "new_body": new_body})
+        return result
+
+    def _apply_suggestion(self, suggestion: dict[str, Any]) -> None:
+        go_analyzer = self.create_go_analyzer()
+        code_editor = self.create_language_server_code_editor()
+
+        def find_method(relative_path: str, name_path: str) -> tuple[GoFile, GoDeclaration]:
+            receiver_type, name = parse_name_path(name_path).parts
+            go_file = go_analyzer.parse_file(relative_path)
+            method = go_file.find_declaration(name, receiver_type=receiver_type)
+            assert method is not None and method.body_start is not None
+            return go_file, method
+
+        with code_editor.edit_transaction():
+            for edit in suggestion["edits"]:
+                go_file, method = find_method(edit["relative_path"], edit["name_path"])
+                assert method.body_start is not None
+                body_edit = GoTextEdit(method.body_start, method.end, edit["new_body"])
+                _apply_edit(code_editor, edit["relative_path"], go_file, body_edit, organize_imports=True)
+            helper = suggestion["helper"]
+            go_file, method = find_method(helper["relative_path"], suggestion["methods"][0]["name_path"])
+            helper_edit = GoTextEdit(method.end, method.end, "\n\n" + helper["text"])
+            _apply_edit(code_editor, helper["relative_path"], go_file, helper_edit, organize_imports=True)
+
+
+class MinimalInterfaceTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Determines the narrowest interface a Go function requires of one of its parameters, given the methods it uses (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "function": {"type": "string"},
+            "parameter": {
+                "type": "object",
+                "properties": {"name": {"type": "string"}, "type": {"type": "string"}},
+                "required": ["name", "type"],
+            },
+            "used_methods": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"name": {"type": "string"}, "signature": {"type": "string"}},
+                    "required": ["name", "signature"],
+                },
+            },
+            "field_accesses": {"type": "array", "items": {"type": "string"}},
+            "other_uses": {"type": "array", "items": {"type": "integer"}},
+            "existing_interfaces": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "interface": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "num_methods": {"type": "integer"},
+                        "unused_methods": {"type": "array", "items": {"type": "string"}},
+                    },
+                    "required": ["interface", "relative_path", "num_methods", "unused_methods"],
+                },
+            },
+            "synthesized_interface": {"type": "string"},
+            "suggestion": {"type": ["string", "null"]},
+        },
+        "required": [
+            "function",
+            "parameter",
+            "used_methods",
+            "field_accesses",
+            "other_uses",
+            "existing_interfaces",
+            "synthesized_interface",
+            "suggestion",
+        ],
+    }
+
+    def apply(self, name_path: str, relative_path: str, param_index: int) -> str:
+        """
+        Analyzes which methods of a parameter the body of a function (or method) uses, in order to determine whether the
+        parameter could be declared with an interface type instead, e.g. a `Processable` instead of a `*ConcreteProcessor`.
+        The uses are determined textually (without taking shadowing into account). The parameter's type must be declared
+        in the function's package; existing interfaces are searched for in that package.
+
+        :param name_path: the name path of the function or method, e.g. "RunProcessor"
+        :param relative_path: the relative path of the file containing the function
+        :param param_index: the (0-based) index of the parameter
+        :return: a JSON object with the `function` name, the `parameter` (name and type), the `used_methods` (each with name
+            and signature), the `field_accesses` (names of the fields accessed, which rule out an interface), the (0-based)
+            lines of `other_uses` of the parameter as a whole (e.g. passing it on), which the interface may not suffice for,
+            the `existing_interfaces` of the package that the parameter's type satisfies and that contain all used methods
+            (narrowest first, each with name, relative path, number of methods and its `unused_methods`), the
+            `synthesized_interface` containing exactly the used methods and the `suggestion`, which is the narrowest
+            existing interface or, if there is none, the synthesized one (null if fields are accessed)
+        """
+        go_analyzer = self.create_go_analyzer()
+        go_file = go_analyzer.parse_file(relative_path)
+        _, function = go_analyzer.find_unique_declaration(
+            name_path, relative_path, kinds=(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD)
+        )
+        signature = go_file.get_parameters_and_results_text(function)
+        if signature is None or function.body_start is None:
+            raise ValueError(f"{function.name} has no body")
+        if signature.startswith("["):
+            # skip the type parameters of a generic function
+            tokens = tokenize(signature)
+            signature = signature[tokens[find_matching_bracket(tokens, 0)].end :].strip()
+        parameter_names = get_parameter_names(signature)
+        parameter_types, _ = get_parameter_and_result_types(signature)
+        if not 0 <= param_index < len(parameter_types):
+            raise ValueError(f"{function.name} has no parameter with index {param_index} (it has {len(parameter_types)} parameters)")
+        parameter_name = parameter_names[param_index]
+        parameter_type = parameter_types[param_index]
+        if parameter_name is None or parameter_name == "_":
+            raise ValueError(f"The parameter with index {param_index} of {function.name} is unnamed and thus cannot be used")
+        package_dir = os.path.dirname(relative_path)
+        named_type = get_named_type_identifier(parameter_type.lstrip("*"))
+        resolved = None
+        if named_type is not None and named_type[0] is None:
+            resolved = go_analyzer.find_type_declaration(named_type[1], package_dir)
+        if resolved is None:
+            raise ValueError(f"The type {parameter_type} of parameter {parameter_name} is not declared in the package of {relative_path}")
+        type_path, type_declaration = resolved
+        method_set, _ = go_analyzer.get_method_set(type_declaration, package_dir)
+
+        used_methods: dict[str, str] = {}
+        field_accesses: list[str] = []
+        other_uses: list[int] = []
+        for use in find_variable_uses(go_file, function, parameter_name):
+            if use.selector is None:
+                other_uses.append(use.token.line)
+            elif use.selector in method_set:
+                if use.selector not in used_methods:
+                    member = go_analyzer.select_member(type_path, type_declaration, use.selector, package_dir).member
+                    if member.declaration is not None:
+                        method_signature = go_analyzer.parse_file(member.relative_path).get_parameters_and_results_text(member.declaration)
+                    else:
+                        method_signature = method_set[use.selector][0]
+                    used_methods[use.selector] = method_signature
+            elif use.selector not in field_accesses:
+                field_accesses.append(use.selector)
+
+        is_pointer = parameter_type.startswith("*")
+        existing_interfaces = []
+        for file_path in go_analyzer.get_package_files(package_dir):
+            for declaration in go_analyzer.parse_file(file_path).iter_declarations(GoDeclarationKind.TYPE):
+                if declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+                    continue
+                interface_methods, unresolved = go_analyzer.get_interface_methods(declaration, package_dir)
+                method_names = {element.method_name for element, _ in interface_methods}
+                if unresolved or not method_names.issuperset(used_methods):
+                    continue
+                satisfaction = check_interface_satisfaction(method_set, interface_methods)
+                if not (satisfaction.satisfied_by_pointer if is_pointer else satisfaction.satisfied_by_value):
+                    continue
+                existing_interfaces.append(
+                    {
+                        "interface": declaration.name,
+                        "relative_path": file_path,
+                        "num_methods": len(method_names),
+                        "unused_methods": sorted(name for name in method_names if name not in used_methods),
+                    }
+                )
+        existing_interfaces.sort(key=lambda entry: entry["num_methods"])
+
+        if used_methods:
+            synthesized_interface = "interface {\n" + "".join(f"\t{name}{sig}\n" for name, sig in used_methods.items()) + "}"
+        else:
+            synthesized_interface = "any"
+        suggestion = None
+        if not field_accesses:
+            suggestion = existing_interfaces[0]["interface"] if existing_interfaces else synthesized_interface
+        result = {
+            "function": function.name,
+            "parameter": {"name": parameter_name, "type": parameter_type},
+            "used_methods": [{"name": name, "signature": sig} for name, sig in used_methods.items()],
+            "field_accesses": field_accesses,
+            "other_uses": other_uses,
+            "existing_interfaces": existing_interfaces,
+            "synthesized_interface": synthesized_interface,
+            "suggestion": suggestion,
+        }
+        return json.dumps(result)
+
+
+class UnusedInterfacesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds Go interfaces which are never used as a type, e.g. of a variable, parameter or result (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "interfaces": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "interface": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "embedded_in": {"type": "array", "items": {"type": "string"}},
+                        "implementers": {"type": "array", "items": _GO_TYPE_LOCATION_SCHEMA},
+                        "status": {"type": "string", "enum": ["never_consumed", "unused"]},
+                    },
+                    "required": ["interface", "relative_path", "embedded_in", "implementers", "status"],
+                },
+            },
+            "num_interfaces_checked": {"type": "integer"},
+        },
+        "required": ["interfaces", "num_interfaces_checked"],
+    }
+
+    def apply(self, relative_path_or_dir: str = "", max_answer_chars: int = -1) -> str:
+        """
+        Finds the interfaces declared in the given file or directory that are not consumed anywhere, i.e. not referenced
+        in a type expression (e.g. of a variable, parameter, result or struct field), a conversion or a type assertion.
+        Types satisfying an interface do not reference it and thus do not count as uses. An interface that is only
+        embedded in other interfaces counts as used only if one of these interfaces is used.
+        Flagged interfaces with implementers are declared but never consumed; those without are entirely unused.
+
+        :param relative_path_or_dir: the relative path of the file or directory in which to search for interfaces; ""
+            for the entire project
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the list `interfaces` of unused interfaces (each with the `interface` name, its
+            `relative_path`, the interfaces it is `embedded_in`, the `implementers` of the interface's package (each
+            with type name and relative path) and the `status`, which is "never_consumed" if there are implementers and
+            "unused" otherwise) and the number of considered interfaces `num_interfaces_checked`
+        """
+        go_analyzer = self.create_go_analyzer()
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        # maps (relative path, interface name) to (whether the interface is used directly, the keys of the interfaces it is embedded in)
+        usages: dict[tuple[str, str], tuple[bool, list[tuple[str, str]]]] = {}
+
+        def get_usage(file_path: str, interface_name: str) -> tuple[bool, list[tuple[str, str]]]:
+            key = (file_path, interface_name)
+            if key not in usages:
+                is_used_directly = False
+                embedding_interfaces: list[tuple[str, str]] = []
+                for ref in symbol_retriever.find_referencing_symbols(interface_name, relative_file_path=file_path):
+                    ref_relative_path = ref.symbol.location.relative_path
+                    if ref_relative_path is None or not ref_relative_path.endswith(".go"):
+                        continue
+                    role = go_analyzer.get_reference_role(ref_relative_path, ref.line, ref.character, refers_to_type=True)
+                    if role == GoReferenceRole.EMBED:
+                        ref_file = go_analyzer.parse_file(ref_relative_path)
+                        offset = ref_file.get_offset(ref.line, ref.character)
+                        enclosing = [d for d in ref_file.iter_declarations(GoDeclarationKind.TYPE) if d.start <= offset < d.end]
+                        if enclosing and classify_type_expression(enclosing[0].type_expr or "") == GoUnderlyingKind.INTERFACE:
+                            embedding_interfaces.append((ref_relative_path, enclosing[0].name))
+                            continue
+                    is_used_directly = True
+                usages[key] = (is_used_directly, embedding_interfaces)
+            return usages[key]
+
+        def is_used(key: tuple[str, str], visited: set[tuple[str, str]]) -> bool:
+            visited.add(key)
+            is_used_directly, embedding_interfaces = get_usage(*key)
+            return is_used_directly or any(is_used(k, visited) for k in embedding_interfaces if k not in visited)
+
+        flagged = []
+        num_interfaces_checked = 0
+        for file_path in sorted(self.project.gather_source_files(relative_path_or_dir)):
+            if not file_path.endswith(".go"):
+                continue
+            package_dir = os.path.dirname(file_path)
+            for declaration in go_analyzer.parse_file(file_path).iter_declarations(GoDeclarationKind.TYPE):
+                if declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+                    continue
+                num_interfaces_checked += 1
+                key = (file_path, declaration.name)
+                if is_used(key, set()):
+                    continue
+                implementers = [
+                    {"type": implementer.name, "relative_path": implementer_path}
+                    for implementer_path, implementer in go_analyzer.find_implementations(declaration, package_dir)
+                ]
+                flagged.append(
+                    {
+                        "interface": declaration.name,
+                        "relative_path": file_path,
+                        "embedded_in": [name for _, name in get_usage(*key)[1]],
+                        "implementers": implementers,
+                        "status": "never_consumed" if implementers else "unused",
+                    }
+                )
+        result = {"interfaces": flagged, "num_interfaces_checked": num_interfaces_checked}
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class AddJsonTagsTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Adds `json` tags to the fields of a Go struct type, deriving the keys from the field names (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "tagged": {
+                "type": "array",
+                "items": {"type": "object", "properties": {"field": {"type": "string"}, "tag": {"type": "string"}}},
+            },
+            "skipped": {
+                "type": "array",
+                "items": {"type": "object", "properties": {"field": {"type": "string"}, "reason": {"type": "string"}}},
+            },
+        },
+        "required": ["tagged", "skipped"],
+    }
+
+    def apply(self, type_name_path: str, relative_path: str, style: str = "snake", tag_unexported: bool = False) -> str:
+        """
+        Adds a `json` key to the tag of each field of a struct type, e.g. `json:"name"` for the field `Name`. Existing
+        tags are preserved, the key being added to the other keys of the tag (e.g. `db:"name" json:"name"`); fields
+        whose tag already contains a `json` key are left unchanged. Embedded fields are skipped, since encoding/json
+        promotes their fields, as are fields declared together with other fields (`X, Y int`), which share a tag.
+        The struct is formatted afterwards (as with gofmt).
+
+        :param type_name_path: the name path of the struct type, e.g. "BaseStruct"
+        :param relative_path: the relative path of the file declaring the type
+        :param style: the naming style of the keys: "snake" (e.g. `user_id` for `UserID`) or "camel" (e.g. `userID`)
+        :param tag_unexported: whether to tag unexported fields, which encoding/json ignores anyway, with `json:"-"`
+            (making this explicit) rather than skipping them
+        :return: a JSON object with the `tagged` fields (each with the `field` name and its new `tag`) and the `skipped`
+            fields (each with the `field` name and the `reason`: "already tagged", "embedded", "unexported" or
+            "declared together with other fields")
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if declaration.type_expr is None or classify_type_expression(declaration.type_expr) != GoUnderlyingKind.STRUCT:
+            raise ValueError(f"{declaration.name} is not a struct type")
+        struct_fields = parse_struct_fields(declaration.type_expr)
+        tags = {}
+        tagged = []
+        skipped = []
+        for struct_field in struct_fields:
+            json_name = None
+            if get_struct_tag_value(struct_field.tag, "json") is not None:
+                reason = "already tagged"
+            elif struct_field.embedded:
+                reason = "embedded"
+            elif sum(1 for f in struct_fields if (f.start, f.end) == (struct_field.start, struct_field.end)) > 1:
+                reason = "declared together with other fields"
+            elif not struct_field.is_exported:
+                reason = "unexported"
+                if tag_unexported:
+                    json_name = "-"
+            else:
+                reason = ""
+                json_name = to_json_field_name(struct_field.name, style)
+            if json_name is None:
+                skipped.append({"field": struct_field.name, "reason": reason})
+                continue
+            tags[struct_field.name] = add_struct_tag_key(struct_field.tag, "json", json_name)
+            tagged.append({"field": struct_field.name, "tag": tags[struct_field.name]})
+        if tags:
+            go_file = go_analyzer.parse_file(relative_path)
+            edit = get_struct_tags_edit(go_file, declaration, tags)
+            code_editor = self.create_language_server_code_editor()
+            with code_editor.edit_transaction():
+                _apply_edit(code_editor, relative_path, go_file, edit, organize_imports=False)
+        result = {"tagged": tagged, "skipped": skipped}
+        return json.dumps(result)
+
+
+class FindShadowingTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the local variables and parameters of Go functions which shadow package-level symbols or imports (Go only).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "identifier": {"type": "string"},
+                "line": {"type": "integer"},
+                "column": {"type": "integer"},
+                "function": {"type": "string"},
+                "shadowed": {
+                    "type": "object",
+                    "properties": {
+                        "kind": {"type": "string", "enum": ["import", "func", "type", "var", "const"]},
+                        "relative_path": {"type": "string"},
+                        "line": {"type": "integer"},
+                        "import_path": {"type": "string"},
+                    },
+                    "required": ["kind", "relative_path", "line"],
+                },
+            },
+            "required": ["identifier", "line", "column", "function", "shadowed"],
+        },
+    }
+
+    def apply(self, relative_path: str) -> str:
+        """
+        Finds the local declarations within the functions and methods of a file - receivers, parameters, named results and
+        the variables, constants and types declared in the bodies (including those of function literals) - whose names
+        shadow a package-level symbol of the file's package or a package imported by the file, which hides the shadowed
+        symbol within the local scope and is a common source of bugs (e.g. a local variable `fmt` hiding the fmt package).
+        Methods are not package-level symbols and thus cannot be shadowed.
+
+        :param relative_path: the relative path of the Go file to check
+        :return: a JSON list of the shadowing declarations in the order in which they appear, each with the `identifier`,
+            its (0-based) `line` and `column`, the name path of the enclosing `function` and the `shadowed` symbol with its
+            `kind` ("import", "func", "type", "var" or "const") and location (`relative_path` and `line`; for imports,
+            the `import_path`)
+        """
+        go_analyzer = self.create_go_analyzer()
+        go_file = go_analyzer.parse_file(relative_path)
+        # the package-level symbols, excluding those which are only visible within the package's tests
+        is_test_file = relative_path.endswith("_test.go")
+        package_symbols: dict[str, dict[str, Any]] = {}
+        for file_path in go_analyzer.get_package_files(os.path.dirname(relative_path)):
+            if file_path.endswith("_test.go") and not is_test_file:
+                continue
+            package_file = go_analyzer.parse_file(file_path)
+            if package_file.package_name != go_file.package_name:
+                continue
+            for declaration in package_file.declarations:
+                if declaration.kind == GoDeclarationKind.METHOD or declaration.name == "_":
+                    continue
+                package_symbols.setdefault(
+                    declaration.name,
+                    {
+                        "kind": declaration.kind.value,
+                        "relative_path": file_path,
+                        "line": package_file.get_line_and_column(declaration.name_start)[0],
+                    },
+                )
+        for go_import in go_file.imports:
+            package_name = go_import.get_package_name()
+            if package_name in ("_", "."):
+                continue
+            package_symbols[package_name] = {
+                "kind": "import",
+                "relative_path": relative_path,
+                "line": go_file.get_line_and_column(go_import.start)[0],
+                "import_path": go_import.path,
+            }
+        shadowings = []
+        for declaration in go_file.iter_declarations(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD):
+            if declaration.receiver_type is not None:
+                function_name_path = format_name_path([declaration.receiver_type, declaration.name])
+            else:
+                function_name_path = declaration.name
+            for token in find_local_declarations(go_file, declaration):
+                if token.text not in package_symbols:
+                    continue
+                line, column = go_file.get_line_and_column(token.start)
+                shadowings.append(
+                    {
+                        "identifier": token.text,
+                        "line": line,
+                        "column": column,
+                        "function": function_name_path,
+                        "shadowed": package_symbols[token.text],
+                    }
+                )
+        return json.dumps(shadowings)
+
+
+class TransformReferencesTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Rewrites each reference to a Go symbol according to a template, keeping the result only if it compiles (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "references": {"type": "array", "items": {"type": "object"}},
+            "diff": {"type": "string"},
+            "compile_errors": {"type": "array", "items": {"type": "string"}},
+            "applied": {"type": "boolean"},
+        },
+        "required": ["references", "diff", "compile_errors", "applied"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, template: str, dry_run: bool = False, organize_imports: bool = True) -> str:
+        """
+        Replaces the expression at each reference to the given symbol (as found by `find_referencing_symbols`) by an
+        instantiation of the template, which generalizes renaming, e.g. in order to log the errors of all calls of a function.
+        The expression at a reference comprises the operand the symbol is selected from and, if the symbol is called,
+        the call, e.g. `c.BaseStruct.Execute()` for a reference to `Execute`. In the template, the placeholders `{expr}`
+        (the expression), `{receiver}` (the operand, e.g. `c.BaseStruct`), `{name}` (the referencing identifier) and
+        `{args}` (the call's arguments) are replaced; other braces are left unchanged, and the template may span several
+        lines (the edited files are formatted afterwards, as with gofmt). References within type declarations (such as the
+        methods of interfaces) are not transformed.
+        The transformed files are first checked in memory: the packages containing them are compiled (including their
+        tests) with the new contents in place of the files on disk, and the files are only written if the compilation
+        succeeds (and it is not a dry run); otherwise, the compile errors are reported and the files remain unchanged.
+
+        :param name_path: the name path of the symbol whose references to transform, e.g. "BaseStruct/Execute"
+        :param relative_path: the relative path of the file containing the symbol
+        :param template: the template, e.g. `if err := {expr}; err != nil {\n log.Printf("{name}: %v", err)\n}`
+        :param dry_run: whether to only report the changes (including the compile errors) without keeping them
+        :param organize_imports: whether to organize the imports of the edited files, adding imports of packages
+            introduced by the template (packages imported by other files of the respective package or of the standard
+            library) and removing unused imports
+        :return: a JSON object with the transformed `references` (each with the `relative_path`, the (0-based) `line` and
+            `column`, the original `expression` and its `replacement`), a unified `diff` of the changes, the `compile_errors`
+            and whether the changes were `applied` (false for dry runs and failed compilations)
+        """
+        go_analyzer = self.create_go_analyzer()
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        expressions: dict[str, list[GoReferenceExpression]] = defaultdict(list)
+        for ref in symbol_retriever.find_referencing_symbols(name_path, relative_file_path=relative_path):
+            ref_relative_path = ref.symbol.location.relative_path
+            if ref_relative_path is None or not ref_relative_path.endswith(".go"):
+                continue
+            go_file = go_analyzer.parse_file(ref_relative_path)
+            offset = go_file.get_offset(ref.line, ref.character)
+            if any(d.start <= offset < d.end for d in go_file.iter_declarations(GoDeclarationKind.TYPE)):
+                continue
+            expression = find_reference_expression(go_file, offset)
+            if expression is not None and all(e.start != expression.start for e in expressions[ref_relative_path]):
+                expressions[ref_relative_path].append(expression)
+        if not expressions:
+            raise ValueError(f"No references to {name_path} found that could be transformed")
+
+        references = []
+        for ref_relative_path, file_expressions in sorted(expressions.items()):
+            go_file = go_analyzer.parse_file(ref_relative_path)
+            for expression in sorted(file_expressions, key=lambda e: e.start):
+                line, column = go_file.get_line_and_column(expression.start)
+                references.append(
+                    {
+                        "relative_path": ref_relative_path,
+                        "line": line,
+                        "column": column,
+                        "expression": go_file.get_text(expression.start, expression.end),
+                        "replacement": instantiate_reference_template(go_file, expression, template),
+                    }
+                )
+        from serena.code_editor import get_unified_diff
+
+        original_contents: dict[str, str] = {}
+        new_contents: dict[str, str] = {}
+        for ref_relative_path, file_expressions in expressions.items():
+            go_file = go_analyzer.parse_file(ref_relative_path)
+            original_contents[ref_relative_path] = go_file.source
+            edit = get_reference_transformation_edit(go_file, file_expressions, template)
+            new_contents[ref_relative_path] = edit.apply(go_file.source)
+        new_contents, compile_errors = go_analyzer.check_contents(
+            new_contents, [os.path.dirname(p) for p in expressions], organize_imports=organize_imports
+        )
+        applied = not dry_run and not compile_errors
+        if applied:
+            _write_contents(self.create_language_server_code_editor(), go_analyzer, new_contents)
+        result = {
+            "references": references,
+            "diff": get_unified_diff(original_contents, new_contents),
+            "compile_errors": compile_errors,
+            "applied": applied,
+        }
+        return json.dumps(result)
+
+
+class InternalImportGraphTool(Tool, ToolMarkerOptional):
+    """
+    Determines the import graph among the project's own Go packages, highlighting import cycles (Go only).
+    """
+
+    output_schema = {
+        "anyOf": [
+            {
+                "type": "object",
+                "properties": {
+                    "nodes": {
+                        "type": "array",
+                        "items": {
+                            "type": "object",
+                            "properties": {
+                                "package": {"type": "string"},
+                                "package_dir": {"type": ["string", "null"]},
+                                "kind": {"type": "string", "enum": ["internal", "standard", "third_party"]},
+                            },
+                            "required": ["package", "package_dir", "kind"],
+                        },
+                    },
+                    "edges": {
+                        "type": "array",
+                        "items": {
+                            "type": "object",
+                            "properties": {"from": {"type": "string"}, "to": {"type": "string"}, "in_cycle": {"type": "boolean"}},
+                            "required": ["from", "to", "in_cycle"],
+                        },
+                    },
+                    "cycles": {"type": "array", "items": {"type": "array", "items": {"type": "string"}}},
+                },
+                "required": ["nodes", "edges", "cycles"],
+            },
+            {"type": "string", "description": "the graph in the DOT language (for the DOT format)"},
+        ]
+    }
+
+    def apply(
+        self, relative_path: str = "", output_format: str = "json", include_external: bool = False, max_answer_chars: int = -1
+    ) -> str:
+        """
+        Determines the graph whose nodes are the packages within the given subtree and whose edges are the imports
+        among them (by the packages' non-test files), which gives an overview of a project's architecture at the package
+        level. Imports of packages outside the subtree are ignored, and imports of packages outside the project's
+        modules (the standard library and third-party packages) are only included on request.
+        Edges which are part of an import cycle, which is a compile error, are highlighted.
+
+        :param relative_path: the relative path of the directory whose packages (including those of subdirectories) to
+            include; "" for the entire project
+        :param output_format: "json" or "dot" (for rendering the graph with Graphviz, where cycles are drawn in red and
+            external packages with dashed outlines)
+        :param include_external: whether to include the imported packages of the standard library and third-party packages
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: for the JSON format, an object with the `nodes` (each with the `package`'s import path, the `package_dir`
+            (null for external packages) and the `kind`: "internal", "standard" or "third_party"), the `edges` (each with
+            the importing package (`from`), the imported package (`to`) and whether it is `in_cycle`) and the `cycles`
+            (each given as a list of import paths starting and ending with the same path); for the DOT format, the graph
+            in the DOT language
+        """
+        if output_format not in ("json", "dot"):
+            raise ValueError(f"Unsupported output format {output_format}; use json or dot")
+        go_analyzer = self.create_go_analyzer()
+        package_dirs = sorted({os.path.dirname(p) for p in self.project.gather_source_files(relative_path) if p.endswith(".go")})
+        import_paths = {package_dir: go_analyzer.get_import_path(package_dir) or package_dir for package_dir in package_dirs}
+        nodes = [{"package": import_paths[d], "package_dir": d, "kind": "internal"} for d in package_dirs]
+        graph: dict[str, list[str]] = {}
+        external_packages: set[str] = set()
+        for package_dir in package_dirs:
+            imported_paths = [import_paths[d] for d in go_analyzer.get_imported_packages(package_dir) if d in import_paths]
+            if include_external:
+                for file_path in go_analyzer.get_package_files(package_dir):
+                    if file_path.endswith("_test.go"):
+                        continue
+                    for go_import in go_analyzer.parse_file(file_path).imports:
+                        if go_import.path != "C" and go_analyzer.resolve_import_path(go_import.path, package_dir) is None:
+                            if go_import.path not in imported_paths:
+                                imported_paths.append(go_import.path)
+                            external_packages.add(go_import.path)
+            graph[import_paths[package_dir]] = imported_paths
+        for external_package in sorted(external_packages):
+            # by convention, the paths of third-party packages start with a domain name
+            kind = "third_party" if "." in external_package.split("/")[0] else "standard"
+            nodes.append({"package": external_package, "package_dir": None, "kind": kind})
+        cycles = find_cycles(graph)
+        cycle_edges = {(cycle[i], cycle[i + 1]) for cycle in cycles for i in range(len(cycle) - 1)}
+        edges = [
+            {"from": importer, "to": imported, "in_cycle": (importer, imported) in cycle_edges}
+            for importer, imported_paths in graph.items()
+            for imported in imported_paths
+        ]
+
+        if output_format == "dot":
+            lines = ["digraph imports {"]
+            for node in nodes:
+                lines.append(f'  "{node["package"]}"' + (" [style=dashed];" if node["kind"] != "internal" else ";"))
+            for edge in edges:
+                lines.append(f'  "{edge["from"]}" -> "{edge["to"]}"' + (" [color=red];" if edge["in_cycle"] else ";"))
+            lines.append("}")
+            return self._limit_length("\n".join(lines), max_answer_chars)
+        result = {"nodes": nodes, "edges": edges, "cycles": cycles}
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class FindAllTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds a Go symbol's definitions, implementations (for interfaces) and usages in a single call (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "name_path": {"type": "string"},
+            "kind": {"type": "string", "enum": [k.value for k in GoDeclarationKind]},
+            "definitions": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"relative_path": {"type": "string"}, "line": {"type": "integer"}},
+                    "required": ["relative_path", "line"],
+                },
+            },
+            "implementations": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {"type": {"type": "string"}, "relative_path": {"type": "string"}, "line": {"type": "integer"}},
+                    "required": ["type", "relative_path", "line"],
+                },
+            },
+            "usages": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "relative_path": {"type": "string"},
+                        "line": {"type": "integer"},
+                        "column": {"type": "integer"},
+                        "referencing_symbol": {"type": "string"},
+                        "role": {"type": "string", "enum": [r.value for r in GoReferenceRole]},
+                    },
+                    "required": ["relative_path", "line", "column", "referencing_symbol", "role"],
+                },
+            },
+        },
+        "required": ["name_path", "kind", "definitions", "implementations", "usages"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, max_answer_chars: int = -1) -> str:
+        """
+        Finds everything related to a top-level symbol at once, which saves separate calls of `find_symbol`,
+        `assignable_types` and `find_referencing_symbols`: the symbol's definitions (several if the symbol is declared in
+        files with different build constraints), the concrete types of the symbol's package implementing it (if it is an
+        interface) and the references to it, each classified by its syntactic role.
+
+        :param name_path: the name path of the symbol, e.g. "Processable" or "ChildStruct/Process"
+        :param relative_path: the relative path of the file containing the symbol
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the symbol's `name_path`, its `kind` (func, method, type, var or const) and the
+            buckets `definitions` (each with `relative_path` and (0-based) `line`), `implementations` (each with the
+            implementing `type`, its `relative_path` and `line`; empty unless the symbol is an interface) and `usages`
+            (each with `relative_path`, (0-based) `line` and `column`, the name path of the `referencing_symbol` and the
+            `role`, e.g. "type" for uses of a type in a parameter or field declaration, "embed" or "call")
+        """
+        go_analyzer = self.create_go_analyzer()
+        symbol, declaration = go_analyzer.find_unique_declaration(name_path, relative_path)
+        package_dir = os.path.dirname(relative_path)
+        definitions = [
+            {"relative_path": variant["relative_path"], "line": variant["line"]}
+            for variant in go_analyzer.get_build_variants(relative_path, declaration)
+        ]
+
+        implementations = []
+        is_type = declaration.kind == GoDeclarationKind.TYPE
+        if is_type and declaration.type_expr is not None and classify_type_expression(declaration.type_expr) == GoUnderlyingKind.INTERFACE:
+            for type_path, implementer in go_analyzer.find_implementations(declaration, package_dir):
+                line, _ = go_analyzer.parse_file(type_path).get_line_and_column(implementer.name_start)
+                implementations.append({"type": implementer.name, "relative_path": type_path, "line": line})
+
+        usages = []
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        for ref in symbol_retriever.find_referencing_symbols(name_path, relative_file_path=relative_path):
+            ref_relative_path = ref.symbol.location.relative_path
+            if ref_relative_path is None or not ref_relative_path.endswith(".go"):
+                continue
+            usage = {
+                "relative_path": ref_relative_path,
+                "line": ref.line,
+                "column": ref.character,
+                "referencing_symbol": ref.symbol.get_name_path(),
+                "role": go_analyzer.get_reference_role(ref_relative_path, ref.line, ref.character, refers_to_type=is_type).value,
+            }
+            if usage not in usages:
+                usages.append(usage)
+        usages.sort(key=lambda u: (u["relative_path"], u["line"], u["column"]))
+        result = {
+            "name_path": symbol.get_name_path(),
+            "kind": declaration.kind.value,
+            "definitions": definitions,
+            "implementations": implementations,
+            "usages": usages,
+        }
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class MissingDocsTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds Go declarations which lack a doc comment (Go only).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "name_path": {"type": "string"},
+                "kind": {"type": "string", "enum": [k.value for k in GoDeclarationKind]},
+                "relative_path": {"type": "string"},
+                "line": {"type": "integer"},
+                "insert_line": {"type": "integer"},
+            },
+            "required": ["name_path", "kind", "relative_path", "line", "insert_line"],
+        },
+    }
+
+    def apply(self, relative_path_or_dir: str = "", exported_only: bool = True, max_answer_chars: int = -1) -> str:
+        """
+        Finds the top-level declarations (types, functions, methods, variables and constants) in the given file or
+        directory which are not preceded by a doc comment. Specs within a declaration group (e.g. `const (...)`) count
+        as documented if the group has a doc comment. Test files and generated files are not considered.
+        A doc comment can subsequently be added via `insert_before_symbol` (with `doc_comment`).
+
+        :param relative_path_or_dir: the relative path of the file or directory in which to search; "" for the entire project
+        :param exported_only: whether to report exported declarations only; methods count as exported only if their
+            receiver's type is exported as well
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON list of undocumented declarations, each with the `name_path`, the `kind` (func, method, type, var
+            or const), the `relative_path`, the (0-based) `line` of the declared name and the (0-based) `insert_line`
+            before which the doc comment belongs
+        """
+        go_analyzer = self.create_go_analyzer()
+        result = []
+        for file_path in sorted(self.project.gather_source_files(relative_path_or_dir)):
+            if not file_path.endswith(".go") or file_path.endswith("_test.go"):
+                continue
+            go_file = go_analyzer.parse_file(file_path)
+            if go_file.is_generated():
+                continue
+            for declaration in go_file.declarations:
+                if declaration.doc is not None or declaration.group_doc is not None or declaration.name == "_":
+                    continue
+                if declaration.kind == GoDeclarationKind.FUNCTION and declaration.name in ("init", "main"):
+                    continue
+                receiver_type = declaration.receiver_type if declaration.kind == GoDeclarationKind.METHOD else None
+                if exported_only and not (declaration.is_exported and (receiver_type is None or is_exported(receiver_type))):
+                    continue
+                result.append(
+                    {
+                        "name_path": format_name_path([receiver_type, declaration.name]) if receiver_type is not None else declaration.name,
+                        "kind": declaration.kind.value,
+                        "relative_path": file_path,
+                        "line": go_file.get_line_and_column(declaration.name_start)[0],
+                        "insert_line": go_file.get_line_and_column(declaration.start)[0],
+                    }
+                )
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+_TEST_FUNCTION_PATTERN = re.compile(r"^(Test|Benchmark|Fuzz|Example)(?![a-z])_?(.*)$")
+
+
+def _is_named_after(test_name: str, name: str, receiver_type: str | None) -> bool:
+    """
+    :return: whether the name of the test function follows the conventions for testing the given function or method,
+        e.g. `TestProcess`, `TestChildStructProcess`, `TestChildStruct_Process` or `TestProcess_empty` for the method
+        `ChildStruct.Process` (and `ExampleChildStruct_Process` for examples)
+    """
+    match = _TEST_FUNCTION_PATTERN.match(test_name)
+    if match is None:
+        return False
+    suffix = match.group(2)
+    capitalized_name = name[:1].upper() + name[1:]
+    candidates = [name, capitalized_name]
+    if receiver_type is not None:
+        candidates += [receiver_type + capitalized_name, f"{receiver_type}_{name}"]
+    return any(suffix == c or suffix.startswith(c + "_") for c in candidates)
+
+
+class RelatedTestsTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the Go test functions related to a function or method, ranked by how strongly they relate to it (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "name_path": {"type": "string"},
+            "conventional_test_file": {"type": ["string", "null"]},
+            "tests": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "name": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "line": {"type": "integer"},
+                        "num_references": {"type": "integer"},
+                        "named_after_symbol": {"type": "boolean"},
+                        "score": {"type": "integer"},
+                    },
+                    "required": ["name", "relative_path", "line", "num_references", "named_after_symbol", "score"],
+                },
+            },
+        },
+        "required": ["name_path", "conventional_test_file", "tests"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, max_answer_chars: int = -1) -> str:
+        """
+        Finds the test functions (tests, benchmarks, fuzz tests and examples in `_test.go` files) which reference the given
+        function or method or whose names follow the conventions for testing it (e.g. `TestProcess` or
+        `TestChildStruct_Process` for `ChildStruct/Process`), as well as the conventional test file of the symbol's file
+        (`foo_test.go` for `foo.go`).
+        The tests are ranked by a `score`, which counts each reference to the symbol once and adds 2 if the test is named
+        after the symbol and 1 if it is located in the conventional test file.
+
+        :param name_path: the name path of the function or method, e.g. "ChildStruct/Process"
+        :param relative_path: the relative path of the file containing the symbol
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the symbol's `name_path`, the `conventional_test_file` (null if it does not exist) and
+            the `tests` in descending order of their `score`, each with the `name` of the test function, its `relative_path`,
+            (0-based) `line`, the number of references to the symbol `num_references` and whether it is `named_after_symbol`
+        """
+        if relative_path.endswith("_test.go"):
+            raise ValueError(f"{name_path} is declared in a test file; use find_referencing_symbols instead")
+        go_analyzer = self.create_go_analyzer()
+        symbol, declaration = go_analyzer.find_unique_declaration(
+            name_path, relative_path, kinds=(GoDeclarationKind.FUNCTION, GoDeclarationKind.METHOD)
+        )
+        conventional_test_file: str | None = relative_path.removesuffix(".go") + "_test.go"
+        if not os.path.isfile(os.path.join(self.get_project_root(), conventional_test_file)):
+            conventional_test_file = None
+
+        # maps (relative path, test function name) to the number of references to the symbol
+        num_references: dict[tuple[str, str], int] = defaultdict(int)
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        for ref in symbol_retriever.find_referencing_symbols(name_path, relative_file_path=relative_path):
+            ref_relative_path = ref.symbol.location.relative_path
+            if ref_relative_path is None or not ref_relative_path.endswith("_test.go"):
+                continue
+            go_file = go_analyzer.parse_file(ref_relative_path)
+            offset = go_file.get_offset(ref.line, ref.character)
+            for function in go_file.iter_declarations(GoDeclarationKind.FUNCTION):
+                if function.start <= offset < function.end and _TEST_FUNCTION_PATTERN.match(function.name):
+                    num_references[(ref_relative_path, function.name)] += 1
+        # tests named after the symbol are considered even if they do not reference it directly (e.g. via an interface)
+        for file_path in go_analyzer.get_package_files(os.path.dirname(relative_path)):
+            if file_path.endswith("_test.go"):
+                for function in go_analyzer.parse_file(file_path).iter_declarations(GoDeclarationKind.FUNCTION):
+                    if _is_named_after(function.name, declaration.name, declaration.receiver_type):
+                        num_references.setdefault((file_path, function.name), 0)
+
+        tests = []
+        for (test_path, test_name), count in num_references.items():
+            go_file = go_analyzer.parse_file(test_path)
+            test_function = go_file.find_declaration(test_name)
+            assert test_function is not None
+            named_after_symbol = _is_named_after(test_name, declaration.name, declaration.receiver_type)
+            score = count + (2 if named_after_symbol else 0) + (1 if test_path == conventional_test_file else 0)
+            tests.append(
+                {
+                    "name": test_name,
+                    "relative_path": test_path,
+                    "line": go_file.get_line_and_column(test_function.name_start)[0],
+                    "num_references": count,
+                    "named_after_symbol": named_after_symbol,
+                    "score": score,
+                }
+            )
+        tests.sort(key=lambda t: (-t["score"], t["relative_path"], t["line"]))
+        result = {"name_path": symbol.get_name_path(), "conventional_test_file": conventional_test_file, "tests": tests}
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class ReplaceEmbeddingTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Replaces a type embedded in a Go struct by another type, reporting the promoted members which are lost (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "diff": {"type": "string"},
+            "missing_members": {"type": ["array", "null"], "items": {"type": "object"}},
+            "compile_errors": {"type": "array", "items": {"type": "string"}},
+            "applied": {"type": "boolean"},
+        },
+        "required": ["diff", "missing_members", "compile_errors", "applied"],
+    }
+
+    def apply(self, type_name_path: str, relative_path: str, old_base: str, new_base: str, commit: bool = False) -> str:
+        """
+        Replaces an embedded field of a struct type by another embedded type, e.g. in order to swap the base type
+        the struct builds upon. The members promoted from the old base type which the new one does not provide are
+        reported, and the struct's package as well as the packages of the project importing it are compiled (including
+        their tests) in order to validate the accesses of promoted members (and of the embedded field itself, whose name
+        changes with the type).
+        The change is compiled in memory, i.e. with the new content in place of the file on disk, and, as this
+        refactoring is risky, the file is only written if `commit` is set and the compilation succeeds; otherwise, the
+        diff is reported for review and the file remains unchanged.
+
+        :param type_name_path: the name path of the struct type, e.g. "ConcreteProcessor"
+        :param relative_path: the relative path of the file containing the struct type
+        :param old_base: the name of the embedded type to replace (without pointer and package qualifier), e.g. "BaseStruct"
+        :param new_base: the type to embed instead, e.g. "LabeledBase" or "other.Base"; unless it is given as a pointer
+            type, a pointer embedding is kept. The package of a qualified type is imported if another file of the
+            struct's package imports it (or if it is an unambiguous package of the standard library).
+        :param commit: whether to keep the change (if it compiles); if false, the change is a dry run
+        :return: a JSON object with the unified `diff` of the change, the `missing_members` formerly promoted from the old
+            base type that the struct no longer has (each with the member's `name`, `kind` (field or method) and the
+            `owner` type declaring it; null if the new base type is declared in another package, such that only the
+            compilation can tell), the `compile_errors` and whether the change was `applied`
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        package_dir = os.path.dirname(relative_path)
+        go_file = go_analyzer.parse_file(relative_path)
+        edit = get_embedded_type_replacement(go_file, declaration, old_base, new_base)
+        new_base_identifier = get_named_type_identifier(new_base.lstrip("*"))
+        if new_base_identifier is None:
+            raise ValueError(f"{new_base} is not a named type")
+        is_local_base = new_base_identifier[0] is None
+        if is_local_base and go_analyzer.find_type_declaration(new_base_identifier[1], package_dir) is None:
+            raise ValueError(f"The package does not declare a type {new_base_identifier[1]}")
+        old_members = [
+            selection
+            for selection in go_analyzer.get_promoted_members(relative_path, declaration, package_dir)
+            if selection.member.embedding_path[:1] == [old_base]
+        ]
+        source_dirs = sorted({os.path.dirname(p) for p in self.project.gather_source_files("") if p.endswith(".go")})
+        package_dirs = [package_dir] + [d for d in source_dirs if d != package_dir and package_dir in go_analyzer.get_imported_packages(d)]
+
+        from serena.code_editor import get_unified_diff
+
+        new_contents, compile_errors = go_analyzer.check_contents(
+            {relative_path: edit.apply(go_file.source)}, package_dirs, organize_imports=not is_local_base
+        )
+        result: dict[str, Any] = {"diff": "", "missing_members": None, "compile_errors": compile_errors, "applied": False}
+        if is_local_base:
+            new_declaration = parse_go_file(new_contents[relative_path]).find_declaration(declaration.name)
+            assert new_declaration is not None
+            new_member_names = {
+                selection.name for selection in go_analyzer.get_promoted_members(relative_path, new_declaration, package_dir)
+            }
+            result["missing_members"] = [
+                {"name": s.name, "kind": "method" if s.member.is_method else "field", "owner": s.member.owner}
+                for s in old_members
+                if s.name not in new_member_names
+            ]
+        result["diff"] = get_unified_diff({relative_path: go_file.source}, new_contents)
+        if commit and not compile_errors:
+            _write_contents(self.create_language_server_code_editor(), go_analyzer, new_contents)
+            result["applied"] = True
+        return json.dumps(result)
+
+
+class InterfaceAuditTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Summarizes the exported Go interfaces with their methods, implementers and external use, for reviewing an API's abstractions (Go only).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "interface": {"type": "string"},
+                "relative_path": {"type": "string"},
+                "line": {"type": "integer"},
+                "num_methods": {"type": "integer"},
+                "methods": {"type": "array", "items": {"type": "string"}},
+                "embeds": {"type": "array", "items": {"type": "string"}},
+                "complete": {"type": "boolean"},
+                "num_implementers": {"type": "integer"},
+                "implementers": {"type": "array", "items": _GO_TYPE_LOCATION_SCHEMA},
+                "referenced_outside_package": {"type": "boolean"},
+            },
+            "required": [
+                "interface",
+                "relative_path",
+                "line",
+                "num_methods",
+                "methods",
+                "embeds",
+                "complete",
+                "num_implementers",
+                "implementers",
+                "referenced_outside_package",
+            ],
+        },
+    }
+
+    def apply(self, relative_path_or_dir: str = "", max_answer_chars: int = -1) -> str:
+        """
+        Reports, for each exported interface declared in the given file or directory (excluding test files), the size of
+        its method set, the types of its package implementing it and whether it is referenced outside its package, which
+        combines the information of `interface_methods`, `assignable_types` and `unused_interfaces` into one report for
+        evaluating a package's abstractions (e.g. interfaces with a single implementer which are not used by other
+        packages are candidates for removal).
+
+        :param relative_path_or_dir: the relative path of the file or directory in which to search for interfaces; ""
+            for the entire project
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON list of interfaces, each with the `interface` name, its `relative_path` and (0-based) `line`, the
+            number of methods of its method set `num_methods` (including those of embedded interfaces), the `methods` it
+            declares itself, the elements it `embeds`, whether its method set is `complete` (false if it embeds interfaces
+            from other packages or type constraint terms), the number of implementers `num_implementers` along with the
+            `implementers` of its package (each with type name and relative path) and whether it is
+            `referenced_outside_package`
+        """
+        go_analyzer = self.create_go_analyzer()
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        result = []
+        for file_path in sorted(self.project.gather_source_files(relative_path_or_dir)):
+            if not file_path.endswith(".go") or file_path.endswith("_test.go"):
+                continue
+            package_dir = os.path.dirname(file_path)
+            go_file = go_analyzer.parse_file(file_path)
+            for declaration in go_file.iter_declarations(GoDeclarationKind.TYPE):
+                if not declaration.is_exported or declaration.is_alias or declaration.type_expr is None:
+                    continue
+                if classify_type_expression(declaration.type_expr) != GoUnderlyingKind.INTERFACE:
+                    continue
+                elements = parse_interface_elements(declaration.type_expr)
+                methods, unresolved = go_analyzer.get_interface_methods(declaration, package_dir)
+                implementers = [
+                    {"type": implementer.name, "relative_path": implementer_path}
+                    for implementer_path, implementer in go_analyzer.find_implementations(declaration, package_dir)
+                ]
+                referenced_outside_package = False
+                for ref in symbol_retriever.find_referencing_symbols(declaration.name, relative_file_path=file_path):
+                    ref_relative_path = ref.symbol.location.relative_path
+                    if ref_relative_path is not None and os.path.dirname(ref_relative_path) != package_dir:
+                        referenced_outside_package = True
+                        break
+                result.append(
+                    {
+                        "interface": declaration.name,
+                        "relative_path": file_path,
+                        "line": go_file.get_line_and_column(declaration.name_start)[0],
+                        "num_methods": len({element.method_name for element, _ in methods}),
+                        "methods": [element.method_name for element in elements if element.is_method],
+                        "embeds": [element.text for element in elements if not element.is_method],
+                        "complete": not unresolved,
+                        "num_implementers": len(implementers),
+                        "implementers": implementers,
+                        "referenced_outside_package": referenced_outside_package,
+                    }
+                )
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class FieldMethodTargetsTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Determines the concrete methods a call of a method on an interface-typed Go struct field can dispatch to (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "field": {"type": "string"},
+            "field_type": {"type": "string"},
+            "method": {"type": "string"},
+            "assignments": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "relative_path": {"type": "string"},
+                        "line": {"type": "integer"},
+                        "expression": {"type": "string"},
+                        "types": {"type": "array", "items": {"type": "string"}},
+                    },
+                    "required": ["relative_path", "line", "expression", "types"],
+                },
+            },
+            "targets": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "type": {"type": "string"},
+                        "name_path": {"type": "string"},
+                        "relative_path": {"type": "string"},
+                        "line": {"type": ["integer", "null"]},
+                    },
+                    "required": ["type", "name_path", "relative_path", "line"],
+                },
+            },
+            "indeterminate": {"type": "boolean"},
+        },
+        "required": ["field", "field_type", "method", "assignments", "targets", "indeterminate"],
+    }
+
+    def apply(self, relative_path: str, line: int, column: int) -> str:
+        """
+        For a call of a method on a struct field of an interface type, e.g. `h.processor.Process()`, determines the
+        concrete methods which the call may dispatch to (whereas going to the definition leads to the interface's method
+        spec). To this end, the values assigned to the field within the package declaring the struct are tracked:
+        the values given for the field in composite literals of the struct type and the values assigned via selectors
+        (`x.processor = v`). The concrete types of these values are inferred as by `return_flow`; if a value's type
+        cannot be inferred (e.g. because it is a parameter), the targets are indeterminate.
+
+        :param relative_path: the relative path of the file containing the call
+        :param line: the 0-based line of the called method's name
+        :param column: the 0-based column of (any character of) the called method's name
+        :return: a JSON object with the `field` (as `Type.field`), its `field_type`, the name of the called `method`, the
+            `assignments` found (each with `relative_path`, (0-based) `line`, the assigned `expression` and its concrete
+            `types`), the candidate `targets` (each with the concrete `type` and the `name_path`, `relative_path` and
+            (0-based) `line` of the method it dispatches to) and whether the targets are `indeterminate`, i.e. possibly
+            incomplete
+        """
+        go_analyzer = self.create_go_analyzer()
+        go_file = go_analyzer.parse_file(relative_path)
+        offset = go_file.get_offset(line, column)
+        tokens = go_file.get_tokens()
+        index = next((i for i, token in enumerate(tokens) if token.start <= offset < token.end), None)
+        if (
+            index is None
+            or index < 3
+            or not tokens[index].is_identifier()
+            or not tokens[index - 1].is_operator(".")
+            or not tokens[index - 2].is_identifier()
+            or not tokens[index - 3].is_operator(".")
+        ):
+            raise ValueError(f"The position {line}:{column} in {relative_path} is not the method name of a call x.field.method()")
+        method_name, field_token = tokens[index].text, tokens[index - 2]
+        field_line, field_column = go_file.get_line_and_column(field_token.start)
+        selection = go_analyzer.resolve_selector(relative_path, field_line, field_column).selection
+        if selection.member.is_method:
+            raise ValueError(f"{selection.member.get_name_path(field_token.text)} is a method, not a field")
+        package_dir = os.path.dirname(selection.member.relative_path)
+        owner = go_analyzer.find_type_declaration(selection.member.owner, package_dir)
+        assert owner is not None and owner[1].type_expr is not None
+        fields = parse_struct_fields(owner[1].type_expr)
+        field_index = next(i for i, f in enumerate(fields) if f.name == field_token.text)
+        field_type = fields[field_index].type_expr
+        named_type = get_named_type_identifier(field_type)
+        interface = go_analyzer.find_type_declaration(named_type[1], package_dir) if named_type and named_type[0] is None else None
+        if interface is None or classify_type_expression(interface[1].type_expr or "") != GoUnderlyingKind.INTERFACE:
+            raise ValueError(f"The field {field_token.text} is not of an interface type declared in its package but of type {field_type}")
+
+        assignments = []
+        concrete_types: list[str] = []
+        for file_path in go_analyzer.get_package_files(package_dir):
+            file = go_analyzer.parse_file(file_path)
+            for expression, expression_offset in find_field_assignments(file, owner[1].name, field_token.text, field_index):
+                types = go_analyzer.infer_concrete_types(expression, file_path, expression_offset)
+                assignments.append(
+                    {
+                        "relative_path": file_path,
+                        "line": file.get_line_and_column(expression_offset)[0],
+                        "expression": expression,
+                        "types": types,
+                    }
+                )
+                concrete_types.extend(t for t in types if t not in concrete_types)
+        indeterminate = not assignments or INDETERMINATE_TYPE in concrete_types
+        targets = []
+        for concrete_type in concrete_types:
+            named_concrete_type = get_named_type_identifier(concrete_type.removeprefix("*"))
+            resolved = None
+            if concrete_type != INDETERMINATE_TYPE and named_concrete_type is not None and named_concrete_type[0] is None:
+                resolved = go_analyzer.find_type_declaration(named_concrete_type[1], package_dir)
+            if resolved is None:
+                indeterminate = True
+                continue
+            member = go_analyzer.select_member(resolved[0], resolved[1], method_name, package_dir).member
+            method_line = None
+            if member.declaration is not None:
+                method_line = go_analyzer.parse_file(member.relative_path).get_line_and_column(member.declaration.name_start)[0]
+            targets.append(
+                {
+                    "type": concrete_type,
+                    "name_path": member.get_name_path(method_name),
+                    "relative_path": member.relative_path,
+                    "line": method_line,
+                }
+            )
+        result = {
+            "field": f"{owner[1].name}.{field_token.text}",
+            "field_type": field_type,
+            "method": method_name,
+            "assignments": assignments,
+            "targets": targets,
+            "indeterminate": indeterminate,
+        }
+        return json.dumps(result)
+
+
+class GenerateStringerTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Generates a String method for a Go struct type which formats the type's exported fields (Go only).
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "method": {"type": "string"},
+            "fields": {"type": "array", "items": {"type": "string"}},
+            "status": {"type": "string", "enum": ["added", "updated", "up_to_date", "outdated"]},
+            "relative_path": {"type": "string"},
+            "diff": {"type": "string"},
+        },
+        "required": ["method", "fields", "status", "relative_path", "diff"],
+    }
+
+    def apply(self, type_name_path: str, relative_path: str, include_promoted: bool = False, update: bool = False) -> str:
+        """
+        Generates a `String() string` method for a struct type, which formats the type's exported fields for debugging,
+        e.g. as `BaseStruct{Name: "a", ID: 1}` (quoting string fields). The method follows the receiver conventions of the
+        type's existing methods and is inserted after its last method; the `fmt` import is added if needed.
+        If the type already has a String method which differs from the generated one (e.g. because fields were added since
+        it was generated), the method is only replaced if `update` is set; otherwise, the update is merely offered by
+        reporting its diff. The doc comment of a replaced method is kept.
+
+        :param type_name_path: the name path of the struct type, e.g. "BaseStruct"
+        :param relative_path: the relative path of the file declaring the type
+        :param include_promoted: whether to also format the exported fields promoted from embedded structs declared in the
+            same package (e.g. `Name` and `ID` for a struct embedding `BaseStruct`)
+        :param update: whether to replace an existing String method which differs from the generated one
+        :return: a JSON object with the generated `method`, the formatted `fields`, the `status` ("added", "updated",
+            "up_to_date" or, if an existing method differs and `update` is not set, "outdated"), the `relative_path` of
+            the file containing the method and the unified `diff` of the (offered) change
+        """
+        go_analyzer = self.create_go_analyzer()
+        _, declaration = go_analyzer.find_unique_declaration(type_name_path, relative_path, kinds=(GoDeclarationKind.TYPE,))
+        if declaration.type_expr is None or classify_type_expression(declaration.type_expr) != GoUnderlyingKind.STRUCT:
+            raise ValueError(f"{declaration.name} is not a struct type")
+        if declaration.type_params is not None:
+            raise ValueError(f"Generating String methods for generic types such as {declaration.name} is not supported")
+        package_dir = os.path.dirname(relative_path)
+        struct_fields = parse_struct_fields(declaration.type_expr)
+        if any(f.name == "String" for f in struct_fields):
+            raise ValueError(f"{declaration.name} has a field String, which conflicts with the method")
+        fields = [(f.name, f.type_expr) for f in struct_fields if f.is_exported and not f.embedded]
+        if include_promoted:
+            for selection in go_analyzer.get_promoted_members(relative_path, declaration, package_dir):
+                owner = go_analyzer.find_type_declaration(selection.member.owner, package_dir)
+                if selection.member.is_method or not is_exported(selection.name) or owner is None:
+                    continue
+                assert owner[1].type_expr is not None
+                promoted_field = next(f for f in parse_struct_fields(owner[1].type_expr) if f.name == selection.name)
+                if not promoted_field.embedded:
+                    fields.append((promoted_field.name, promoted_field.type_expr))
+
+        # determine the receiver, reusing the one of an existing String method
+        methods = go_analyzer.get_methods(declaration.name, package_dir)
+        existing = next(((p, m) for p, m in methods if m.name == "String"), None)
+        convention = existing or _get_receiver_convention(methods)
+        receiver_name = declaration.name[0].lower()
+        receiver_is_pointer = True
+        if convention is not None:
+            convention_method = convention[1]
+            if convention_method.receiver_name is not None and convention_method.receiver_name != "_":
+                receiver_name = convention_method.receiver_name
+            receiver_is_pointer = convention_method.receiver_is_pointer
+        if fields:
+            verbs = ", ".join(f'{name}: {"%q" if type_expr == "string" else "%v"}' for name, type_expr in fields)
+            arguments = ", ".join(f"{receiver_name}.{name}" for name, _ in fields)
+            return_statement = f'return fmt.Sprintf("{declaration.name}{{{verbs}}}", {arguments})'
+        else:
+            return_statement = f'return "{declaration.name}{{}}"'
+        pointer = "*" if receiver_is_pointer else ""
+        method_text = f"func ({receiver_name} {pointer}{declaration.name}) String() string {{\n\t{return_statement}\n}}"
+
+        if existing is not None:
+            method_path, existing_method = existing
+            method_file = go_analyzer.parse_file(method_path)
+            if method_file.get_text(existing_method.start, existing_method.end) == method_text:
+                status = "up_to_date"
+            else:
+                status = "updated" if update else "outdated"
+            edit = GoTextEdit(existing_method.start, existing_method.end, method_text)
+        else:
+            methods_in_type_file = [(p, m) for p, m in methods if p == relative_path]
+            method_path, anchor = (methods_in_type_file or methods)[-1] if methods else (relative_path, declaration)
+            method_file = go_analyzer.parse_file(method_path)
+            status = "added"
+            doc = f"// String formats the exported fields of {declaration.name}.\n"
+            edit = GoTextEdit(anchor.end, anchor.end, "\n\n" + doc + method_text)
+
+        result: dict[str, Any] = {
+            "method": method_text,
+            "fields": [name for name, _ in fields],
+            "status": status,
+            "relative_path": method_path,
+            "diff": "",
+        }
+        if status != "up_to_date":
+            from serena.code_editor import get_unified_diff
+
+            # the change is built in memory, such that an offered (outdated) change leaves the file untouched; compile errors
+            # elsewhere in the package do not prevent the generation
+            new_contents, _ = go_analyzer.check_contents(
+                {method_path: edit.apply(method_file.source)}, [os.path.dirname(method_path)], organize_imports=True
+            )
+            result["diff"] = get_unified_diff({method_path: method_file.source}, new_contents)
+            if status != "outdated":
+                _write_contents(self.create_language_server_code_editor(), go_analyzer, new_contents)
+        return json.dumps(result)
@@ -9,6 +9,8 @@ class JetBrainsFindSymbolTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
     Performs a global (or local) search for symbols with/containing a given name/substring (optionally filtered by type).
     """
 
+    output_schema = {"type": "object", "description": "the response of the JetBrains plugin"}
+
     def apply(
         self,
         name_path: str,
@@ -76,6 +78,8 @@ class JetBrainsFindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead, ToolMark
     Finds symbols that reference the given symbol
     """
 
+    output_schema = {"type": "object", "description": "the response of the JetBrains plugin"}
+
     def apply(
         self,
         name_path: str,
@@ -107,6 +111,8 @@ class JetBrainsGetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOp
     Retrieves an overview of the top-level symbols within a specified file
     """
 
+    output_schema = {"type": "object", "description": "the response of the JetBrains plugin"}
+
     def apply(
         self,
         relative_path: str,
@@ -2,21 +2,83 @@
 Language server-related tools
 """
 
-import dataclasses
 import json
 import os
-from collections.abc import Sequence
+import re
+from collections.abc import Iterator, Sequence
 from copy import copy
 from typing import Any
 
+from serena.symbol import CallHierarchyEntry, LanguageServerSymbol, LanguageServerSymbolLocation, LanguageServerSymbolRetriever
 from serena.tools import (
     SUCCESS_RESULT,
+    SUCCESS_RESULT_SCHEMA,
     Tool,
     ToolMarkerSymbolicEdit,
     ToolMarkerSymbolicRead,
 )
 from serena.tools.tools_base import ToolMarkerOptional
+from serena.util.go_source import GoDeclarationKind, GoReferenceRole, GoTokenKind, GoUnderlyingKind, is_exported, tokenize
+from solidlsp.ls_config import Language
 from solidlsp.ls_types import SymbolKind
+from solidlsp.ls_utils import TextUtils
+
+# comment leaders of common languages, used for finding comments in non-Go files
+_COMMENT_LEADER_PATTERN = re.compile(r"//|#|/\*|--|^\s*\*")
+
+# JSON schemas of the results of the core symbolic tools, which are part of the tools manifest
+_SYMBOL_KIND_SCHEMA: dict[str, Any] = {"type": "integer", "description": "the LSP symbol kind"}
+_BODY_LOCATION_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {"start_line": {"type": "integer"}, "end_line": {"type": "integer"}},
+    "description": "the 0-based lines in which the symbol's definition starts and ends",
+}
+_SYMBOL_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {
+        "name_path": {"type": "string"},
+        "symbol_id": {"type": "string", "description": "identifies the symbol in subsequent calls, e.g. as `parent_symbol_id`"},
+        "kind": _SYMBOL_KIND_SCHEMA,
+        "relative_path": {"type": "string"},
+        "body_location": _BODY_LOCATION_SCHEMA,
+        "body": {"type": "string"},
+        "body_hash": {"type": "string"},
+        "children": {"type": "array", "items": {"type": "object"}, "description": "the child symbols (up to the requested depth)"},
+        "underlying_kind": {
+            "type": "string",
+            "enum": [k.value for k in GoUnderlyingKind],
+            "description": "the kind of the underlying type (Go type declarations only)",
+        },
+        "declaration": {
+            "type": "string",
+            "description": "the signature without the body (Go functions, methods and interface methods only)",
+        },
+        "deprecated": {"type": "boolean", "description": "whether the doc comment marks the symbol as deprecated (Go only)"},
+        "deprecation_message": {"type": "string", "description": "the text of the deprecation notice (deprecated Go symbols only)"},
+        "build_variants": {
+            "type": "array",
+            "items": {
+                "type": "object",
+                "properties": {
+                    "relative_path": {"type": "string"},
+                    "line": {"type": "integer"},
+                    "build_constraint": {"type": ["string", "null"]},
+                },
+            },
+            "description": "all declarations of the symbol in its package (Go symbols declared in several build-constrained files only)",
+        },
+    },
+    "required": ["name_path", "kind"],
+}
+_SYMBOL_SUMMARY_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {"name_path": {"type": "string"}, "kind": _SYMBOL_KIND_SCHEMA},
+    "required": ["name_path", "kind"],
+}
+_OVERVIEW_MEMBERS_SCHEMA: dict[str, Any] = {
+    "type": "object",
+    "properties": {"fields": {"type": "array", "items": {"type": "string"}}, "methods": {"type": "array", "items": {"type": "string"}}},
+}
 
 
 def _sanitize_symbol_dict(symbol_dict: dict[str, Any]) -> dict[str, Any]:
@@ -35,6 +97,61 @@ def _sanitize_symbol_dict(symbol_dict: dict[str, Any]) -> dict[str, Any]:
     return symbol_dict
 
 
+_CALL_HIERARCHY_SCHEMA: dict[str, Any] = {
+    "type": "array",
+    "items": {
+        "type": "object",
+        "properties": {
+            "name_path": {"type": "string"},
+            "symbol_id": {"type": "string"},
+            "kind": _SYMBOL_KIND_SCHEMA,
+            "relative_path": {"type": "string"},
+            "body_location": _BODY_LOCATION_SCHEMA,
+            "depth": {"type": "integer"},
+            "via": {"type": "string"},
+            "call_lines": {"type": "array", "items": {"type": "integer"}},
+        },
+        "required": ["name_path", "kind", "depth", "via", "call_lines"],
+    },
+}
+
+
+def _call_hierarchy_to_json(entries: list[CallHierarchyEntry]) -> str:
+    entry_dicts = []
+    for entry in entries:
+        entry_dict = _sanitize_symbol_dict(entry.symbol.to_dict(kind=True, location=True, depth=0))
+        entry_dict["symbol_id"] = entry.symbol.get_symbol_id()
+        entry_dict["depth"] = entry.depth
+        entry_dict["via"] = entry.via.get_symbol_id()
+        entry_dict["call_lines"] = entry.call_lines
+        entry_dicts.append(entry_dict)
+    return json.dumps(entry_dicts)
+
+
+def _iter_comment_lines(relative_path: str, content: str) -> Iterator[tuple[int, int, str]]:
+    """
+    Iterates over the lines of comments in the given file content.
+    For Go files, comments are determined precisely; for other files, a heuristic based on common comment leaders is applied.
+
+    :return: an iterator of tuples (0-based line, offset of the comment text, comment text in the line)
+    """
+    if relative_path.endswith(".go"):
+        for token in tokenize(content, include_comments=True):
+            if token.kind != GoTokenKind.COMMENT:
+                continue
+            offset = token.start
+            for i, comment_line in enumerate(token.text.split("\n")):
+                yield token.line + i, offset, comment_line
+                offset += len(comment_line) + 1
+    else:
+        offset = 0
+        for i, line in enumerate(content.split("\n")):
+            match = _COMMENT_LEADER_PATTERN.search(line)
+            if match is not None:
+                yield i, offset + match.start(), line[match.start() :]
+            offset += len(line) + 1
+
+
 class RestartLanguageServerTool(Tool, ToolMarkerOptional):
     """Restarts the language server, may be necessary when edits not through Serena happen."""
 
@@ -46,18 +163,151 @@ class RestartLanguageServerTool(Tool, ToolMarkerOptional):
         return SUCCESS_RESULT
 
 
+class LanguageServerStatusTool(Tool, ToolMarkerOptional):
+    """
+    Reports the status of the language server, including the resolved version of the server.
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "language": {"type": "string"},
+            "running": {"type": "boolean"},
+            "version": {"type": ["string", "null"]},
+            "minimum_version": {"type": ["string", "null"]},
+        },
+        "required": ["language", "running", "version", "minimum_version"],
+    }
+
+    def apply(self) -> str:
+        """
+        Reports whether the language server of the active project is running and which version of it is used. For
+        language servers with a minimum version requirement (e.g. gopls), the required version is reported as well;
+        a server older than that fails on startup with an explanatory error.
+
+        :return: a JSON object with the `language`, whether the server is `running`, its `version` and its `minimum_version`
+            (each null if not known or not applicable)
+        """
+        language_server = self.agent.language_server
+        if not self.agent.is_using_language_server() or language_server is None:
+            raise Exception("No language server is in use for the active project.")
+        result = {
+            "language": language_server.language.value,
+            "running": language_server.is_running(),
+            "version": language_server.get_server_version(),
+            "minimum_version": language_server.get_minimum_server_version(),
+        }
+        return json.dumps(result)
+
+
+class ReindexTool(Tool, ToolMarkerOptional):
+    """
+    Reindexes the symbols of the files in a directory (or of a single file), reparsing only files that changed.
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "num_files_total": {"type": "integer"},
+            "num_files_indexed": {"type": "integer"},
+            "num_files_unchanged": {"type": "integer"},
+            "failed_files": {"type": "object", "additionalProperties": {"type": "string"}},
+            "cancelled": {"type": "boolean"},
+            "duration_seconds": {"type": "number"},
+        },
+        "required": ["num_files_total", "num_files_indexed", "num_files_unchanged", "failed_files", "cancelled", "duration_seconds"],
+    }
+
+    reports_progress = True
+
+    def apply(self, relative_path_or_dir: str = "", force: bool = False, max_duration_seconds: float = -1) -> str:
+        """
+        Populates the language server's symbol cache for the source files in the given file or directory.
+        Files whose cached symbols are up to date are not parsed again, so repeating a reindexing run is cheap.
+        A run can be limited in duration, in which case it stops after the given time, keeping the files processed so far
+        indexed; calling the tool again continues with the remaining files. This allows indexing large projects in
+        steps that stay within a client's timeout for tool calls.
+        While the run is in progress, its progress (files processed and total, current file) is reported to the client
+        after each file (as MCP progress notifications, if requested by the client) and provided by the dashboard's
+        `get_reindexing_progress` endpoint; the dashboard's `cancel_reindexing` endpoint cancels a run that has no time limit.
+
+        :param relative_path_or_dir: the relative path of the file or directory to reindex; "" for the entire project
+        :param force: whether to reparse all files, even if their cached symbols are up to date
+        :param max_duration_seconds: the number of seconds after which to stop the run (as if it were cancelled);
+            -1 for no limit
+        :return: a JSON object with the number of files considered (`num_files_total`), the numbers of files that were
+            parsed (`num_files_indexed`) and that were up to date (`num_files_unchanged`), the `failed_files` (mapping
+            relative paths to error messages), whether the run was `cancelled` (also if it was stopped because of the
+            time limit) and its `duration_seconds`
+        """
+        if not self.agent.is_using_language_server():
+            raise Exception("No language server is in use for the active project.")
+        relative_paths = self.project.gather_source_files(relative_path_or_dir)
+        stats = self.agent.reindex(
+            relative_paths,
+            force=force,
+            max_duration_seconds=max_duration_seconds if max_duration_seconds >= 0 else None,
+            progress_callback=lambda progress: self.report_progress(
+                progress.num_files_processed, total=progress.num_files_total, message=progress.current_file
+            ),
+        )
+        result = {
+            "num_files_total": stats.num_files_total,
+            "num_files_indexed": stats.num_files_indexed,
+            "num_files_unchanged": stats.num_files_unchanged,
+            "failed_files": stats.failed_files,
+            "cancelled": stats.cancelled,
+            "duration_seconds": round(stats.duration_seconds, 3),
+        }
+        return json.dumps(result)
+
+
 class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
     """
     Gets an overview of the top-level symbols defined in a given file.
     """
 
-    def apply(self, relative_path: str, max_answer_chars: int = -1) -> str:
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "name_path": {"type": "string"},
+                "kind": _SYMBOL_KIND_SCHEMA,
+                # only present if `include_sizes` is enabled
+                "num_lines": {"type": ["integer", "null"]},
+                # only present for types if `group_visibility` is enabled
+                "exported": _OVERVIEW_MEMBERS_SCHEMA,
+                "unexported": _OVERVIEW_MEMBERS_SCHEMA,
+            },
+            "required": ["name_path", "kind"],
+        },
+    }
+
+    def apply(
+        self,
+        relative_path: str,
+        group_visibility: bool = False,
+        fast: bool = False,
+        include_sizes: bool = False,
+        max_answer_chars: int = -1,
+    ) -> str:
         """
         Use this tool to get a high-level understanding of the code symbols in a file.
         This should be the first tool to call when you want to understand a new file, unless you already know
         what you are looking for.
 
         :param relative_path: the relative path to the file to get the overview of
+        :param group_visibility: whether to group the members (fields and methods) of each type declared in the file into
+            `exported` and `unexported` members (according to Go's capitalization rule), which makes a type's public
+            surface obvious. The methods of these types are then not listed separately. Only supported for Go.
+        :param fast: whether to determine the symbols by parsing the file locally instead of querying the language server,
+            which is considerably faster. Only supported for Go. The result is the same for well-formed files (including
+            the kinds of types, which gopls derives from their syntax), but files with syntax errors may yield fewer symbols,
+            and since no type information is available, nothing beyond the file itself is considered. When combined with
+            `group_visibility`, the language server is used regardless.
+        :param include_sizes: whether to annotate each symbol with the number of lines it spans (`num_lines`), including
+            e.g. a function's signature and closing brace, which helps spotting the large functions in a file
         :param max_answer_chars: if the overview is longer than this number of characters,
             no content will be returned. -1 means the default value from the config will be used.
             Don't adjust unless there is really no other way to get the content required for the task.
@@ -72,16 +322,70 @@ class GetSymbolsOverviewTool(Tool, ToolMarkerSymbolicRead):
             raise FileNotFoundError(f"File or directory {relative_path} does not exist in the project.")
         if os.path.isdir(file_path):
             raise ValueError(f"Expected a file path, but got a directory path: {relative_path}. ")
-        result = symbol_retriever.get_symbol_overview(relative_path)[relative_path]
-        result_json_str = json.dumps([dataclasses.asdict(i) for i in result])
+        if group_visibility:
+            if self.project.language != Language.GO:
+                raise ValueError("Grouping members by visibility is only supported for Go")
+            result_json_str = json.dumps(self._get_overview_grouped_by_visibility(relative_path, include_sizes))
+        elif fast:
+            if self.project.language != Language.GO:
+                raise ValueError("The fast symbol overview is only supported for Go")
+            result = self.create_go_analyzer().get_fast_symbol_overview(relative_path)
+            result_json_str = json.dumps([i.to_dict(include_size=include_sizes) for i in result])
+        else:
+            result = symbol_retriever.get_symbol_overview(relative_path)[relative_path]
+            result_json_str = json.dumps([i.to_dict(include_size=include_sizes) for i in result])
         return self._limit_length(result_json_str, max_answer_chars)
 
+    def _get_overview_grouped_by_visibility(self, relative_path: str, include_sizes: bool) -> list[dict[str, Any]]:
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        go_analyzer = self.create_go_analyzer()
+        language_server = symbol_retriever.get_language_server()
+        top_level_symbols = [LanguageServerSymbol(s) for s in language_server.request_document_overview(relative_path)]
+        type_names = set()
+        for symbol in top_level_symbols:
+            declaration = go_analyzer.get_declaration(symbol)
+            if declaration is not None and declaration.kind == GoDeclarationKind.TYPE:
+                type_names.add(declaration.name)
+
+        def is_method_of_type(symbol: LanguageServerSymbol) -> bool:
+            name_path_parts = symbol.get_name_path_parts()
+            return len(name_path_parts) == 2 and name_path_parts[0] in type_names
+
+        result = []
+        for symbol in top_level_symbols:
+            if is_method_of_type(symbol):
+                continue
+            element = LanguageServerSymbolRetriever.SymbolOverviewElement.from_symbol(symbol).to_dict(include_size=include_sizes)
+            if symbol.name in type_names:
+                groups: dict[str, dict[str, list[str]]] = {
+                    "exported": {"fields": [], "methods": []},
+                    "unexported": {"fields": [], "methods": []},
+                }
+                members = [(child.name, child.symbol_kind == SymbolKind.Method) for child in symbol.iter_children()]
+                members.extend(
+                    (method.get_name_path_parts()[-1], True)
+                    for method in top_level_symbols
+                    if is_method_of_type(method) and method.get_name_path_parts()[0] == symbol.name
+                )
+                for member_name, is_method in members:
+                    group = groups["exported" if is_exported(member_name) else "unexported"]
+                    group["methods" if is_method else "fields"].append(member_name)
+                element.update(groups)
+            result.append(element)
+        return result
+
 
 class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
     """
     Performs a global (or local) search for symbols with/containing a given name/substring (optionally filtered by type).
     """
 
+    output_schema = {
+        "type": "object",
+        "properties": {"symbols": {"type": "array", "items": _SYMBOL_SCHEMA}, "total_matches": {"type": "integer"}},
+        "required": ["symbols", "total_matches"],
+    }
+
     def apply(
         self,
         name_path: str,
@@ -91,7 +395,12 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
         include_kinds: list[int] = [],  # noqa: B006
         exclude_kinds: list[int] = [],  # noqa: B006
         substring_matching: bool = False,
+        limit: int = -1,
+        offset: int = 0,
         max_answer_chars: int = -1,
+        name_path_separator: str = "/",
+        parent_symbol_id: str = "",
+        read_after_write: bool = False,
     ) -> str:
         """
         Retrieves information on all symbols/code entities (classes, methods, etc.) based on the given `name_path`,
@@ -122,6 +431,8 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
           that the first segment of it must match the first segment of the symbol's name path.
           For example, passing `/class` will match only against top-level symbols like `class` but not against `nested_class/class`.
           Passing `/class/method` will match against `class/method` but not `nested_class/class/method` or `method`.
+        - Brackets are never split: the method `Add` of the generic Go type `Container[T]` is `Container[T]/Add`
+          (`Container/Add` matches as well). A backslash escapes the next character, e.g. a separator within a name.
 
 
         :param name_path: The name path pattern to search for, see above for details.
@@ -140,31 +451,199 @@ class FindSymbolTool(Tool, ToolMarkerSymbolicRead):
         :param exclude_kinds: Optional. List of LSP symbol kind integers to exclude. Takes precedence over `include_kinds`.
             If not provided, no kinds are excluded.
         :param substring_matching: If True, use substring matching for the last segment of `name`.
+        :param limit: Optional. The maximum number of symbols to return; -1 for no limit. Use this (together with `offset`)
+            for paginating through the matches of common names.
+        :param offset: Optional. The number of matching symbols to skip.
         :param max_answer_chars: Max characters for the JSON result. If exceeded, no content is returned.
             -1 means the default value from the config will be used.
-        :return: a list of symbols (with locations) matching the name.
+        :param name_path_separator: Optional. The single character separating the segments of `name_path`, e.g. "."
+            for writing Go methods as `Type.Method`; the returned `name_path` attributes always use "/".
+        :param parent_symbol_id: Optional. The `symbol_id` of a previously retrieved symbol, e.g. "processor.go:ConcreteProcessor".
+            If given, `name_path` is matched relative to this parent symbol and only symbols below it are returned
+            (e.g. `Process` yields only `ConcreteProcessor/Process`); a leading slash restricts the matches to its direct children.
+            The search covers the parent's file (for Go, the parent's package, as methods may be declared in other files)
+            instead of `relative_path`. An error is returned if there is no matching symbol below the parent.
+        :param read_after_write: Optional. Whether to guarantee that the result reflects the edits made via the editing tools,
+            even if the language server has not caught up with them yet: files edited via the tools whose cached symbols are
+            outdated are then parsed locally instead of waiting for the language server, such that e.g. a method which was
+            just inserted is found immediately. Only supported for Go and not in combination with `parent_symbol_id`.
+        :return: a JSON object with the `symbols` (with locations) matching the name, ordered by file and position
+            (only the requested page if `limit` or `offset` is given), and the number of `total_matches`. Each symbol carries
+            a `symbol_id`, which identifies it in subsequent calls (e.g. as `parent_symbol_id`), and
+            a `body_hash`, which remains stable as long as the symbol's body is unchanged and can thus be used to detect changes.
+            For Go, type declarations additionally carry their `underlying_kind` (struct, interface, map, slice, array,
+            func, chan, pointer or basic; "named" if the type is defined via a named type from another package),
+            struct types list their named `fields` (each with `name`, `type`, `exported` and, if present, `tag`) separately
+            from their `embedded` types (which additionally state whether they are embedded by `pointer`),
+            functions and methods carry their `declaration`, i.e. the signature preceding the body's braces (e.g.
+            `func (mi *MultipleInterfaces) Write(data []byte) error`), as do the method specs of interfaces, and
+            top-level declarations are flagged as `deprecated` if their doc comment contains a "Deprecated: " paragraph,
+            whose text is given as `deprecation_message`. Symbols declared in several files of their package under different
+            build constraints (e.g. in `config_linux.go` and `config_windows.go`) list all their `build_variants`, each with
+            `relative_path`, `line` and the `build_constraint` (the `//go:build` expression, also accounting for GOOS/GOARCH
+            file name suffixes; null for unconstrained files).
         """
+        if offset < 0:
+            raise ValueError(f"offset must not be negative, got {offset}")
         parsed_include_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in include_kinds] if include_kinds else None
         parsed_exclude_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in exclude_kinds] if exclude_kinds else None
+        if read_after_write:
+            if self.project.language != Language.GO:
+                raise ValueError("Read-after-write consistency is only supported for Go")
+            if parent_symbol_id:
+                raise ValueError("read_after_write cannot be combined with parent_symbol_id")
         symbol_retriever = self.create_language_server_symbol_retriever()
-        symbols = symbol_retriever.find_by_name(
-            name_path,
-            include_body=include_body,
-            include_kinds=parsed_include_kinds,
-            exclude_kinds=parsed_exclude_kinds,
-            substring_matching=substring_matching,
-            within_relative_path=relative_path,
-        )
-        symbol_dicts = [_sanitize_symbol_dict(s.to_dict(kind=True, location=True, depth=depth, include_body=include_body)) for s in symbols]
-        result = json.dumps(symbol_dicts)
+        if read_after_write:
+            symbols = self._find_by_name_after_edits(
+                symbol_retriever,
+                name_path,
+                relative_path,
+                include_body=include_body,
+                include_kinds=parsed_include_kinds,
+                exclude_kinds=parsed_exclude_kinds,
+                substring_matching=substring_matching,
+                name_path_separator=name_path_separator,
+            )
+        elif parent_symbol_id:
+            parent = symbol_retriever.find_by_symbol_id(parent_symbol_id)
+            assert parent.relative_path is not None
+            is_go = self.project.language == Language.GO
+            symbols = symbol_retriever.find_by_name_within_parent(
+                parent,
+                name_path,
+                include_body=include_body,
+                include_kinds=parsed_include_kinds,
+                exclude_kinds=parsed_exclude_kinds,
+                substring_matching=substring_matching,
+                within_relative_path=os.path.dirname(parent.relative_path) if is_go else parent.relative_path,
+                name_path_separator=name_path_separator,
+            )
+            if is_go:
+                # the search within the directory also covers subpackages
+                symbols = [s for s in symbols if os.path.dirname(s.relative_path or "") == os.path.dirname(parent.relative_path)]
+            if not symbols:
+                raise ValueError(f"No symbol matching {name_path} found below {parent_symbol_id}")
+        else:
+            symbols = symbol_retriever.find_by_name(
+                name_path,
+                include_body=include_body,
+                include_kinds=parsed_include_kinds,
+                exclude_kinds=parsed_exclude_kinds,
+                substring_matching=substring_matching,
+                within_relative_path=relative_path,
+                name_path_separator=name_path_separator,
+            )
+        symbols.sort(key=lambda s: (s.relative_path or "", s.line if s.line is not None else -1, s.column if s.column is not None else -1))
+        total_matches = len(symbols)
+        symbols = symbols[offset:] if limit < 0 else symbols[offset : offset + limit]
+        # the body hashes of symbols loaded without their bodies are computed from the content of their files
+        file_contents: dict[str, str] = {}
+        language_server = symbol_retriever.get_language_server()
+        symbol_dicts = []
+        for s in symbols:
+            file_content = None
+            if s.body is None and s.relative_path is not None:
+                if s.relative_path not in file_contents:
+                    file_contents[s.relative_path] = language_server.retrieve_current_file_content(s.relative_path)
+                file_content = file_contents[s.relative_path]
+            symbol_dict = s.to_dict(
+                kind=True, location=True, depth=depth, include_body=include_body, include_body_hash=True, file_content=file_content
+            )
+            symbol_dicts.append(_sanitize_symbol_dict(symbol_dict))
+        for symbol, symbol_dict in zip(symbols, symbol_dicts, strict=True):
+            symbol_dict["symbol_id"] = symbol.get_symbol_id()
+        if self.project.language == Language.GO:
+            go_analyzer = self.create_go_analyzer()
+            for symbol, symbol_dict in zip(symbols, symbol_dicts, strict=True):
+                symbol_dict.update(go_analyzer.get_symbol_details(symbol))
+        result = json.dumps({"symbols": symbol_dicts, "total_matches": total_matches})
         return self._limit_length(result, max_answer_chars)
 
+    def _find_by_name_after_edits(
+        self,
+        symbol_retriever: LanguageServerSymbolRetriever,
+        name_path: str,
+        relative_path: str,
+        include_body: bool,
+        include_kinds: Sequence[SymbolKind] | None,
+        exclude_kinds: Sequence[SymbolKind] | None,
+        substring_matching: bool,
+        name_path_separator: str,
+    ) -> list[LanguageServerSymbol]:
+        """
+        Finds the symbols matching the given name path (as `find_by_name`), determining the symbols of the files which were
+        edited via the tools and whose cached symbols are outdated by parsing them locally.
+        """
+        language_server = symbol_retriever.get_language_server()
+        scope = os.path.normpath(relative_path) if relative_path else ""
+
+        def is_outdated_edited_file(edited_path: str) -> bool:
+            if not edited_path.endswith(".go") or not os.path.isfile(os.path.join(self.project.project_root, edited_path)):
+                return False
+            if scope and os.path.normpath(edited_path) != scope and not os.path.normpath(edited_path).startswith(scope + os.sep):
+                return False
+            if language_server.is_ignored_path(edited_path):
+                return False
+            return not language_server.has_up_to_date_document_symbols(edited_path, include_body=include_body)
+
+        edited_paths = sorted(p for p in self.agent.get_edited_files() if is_outdated_edited_file(p))
+        if scope in (os.path.normpath(p) for p in edited_paths):
+            # the search is restricted to a single edited file
+            symbols = []
+        else:
+            symbols = [
+                s
+                for s in symbol_retriever.find_by_name(
+                    name_path,
+                    include_body=include_body,
+                    include_kinds=include_kinds,
+                    exclude_kinds=exclude_kinds,
+                    substring_matching=substring_matching,
+                    within_relative_path=relative_path,
+                    name_path_separator=name_path_separator,
+                )
+                if s.relative_path not in edited_paths
+            ]
+        go_analyzer = self.create_go_analyzer()
+        for edited_path in edited_paths:
+            for root in go_analyzer.get_local_document_symbols(edited_path):
+                symbols.extend(
+                    LanguageServerSymbol(root).find(
+                        name_path,
+                        substring_matching=substring_matching,
+                        include_kinds=include_kinds,
+                        exclude_kinds=exclude_kinds,
+                        name_path_separator=name_path_separator,
+                    )
+                )
+        return symbols
+
 
 class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
     """
     Finds symbols that reference the symbol at the given location (optionally filtered by type).
     """
 
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "name_path": {"type": "string"},
+                "kind": _SYMBOL_KIND_SCHEMA,
+                "relative_path": {"type": "string"},
+                "body_location": _BODY_LOCATION_SCHEMA,
+                "content_around_reference": {"type": "string"},
+                "role": {
+                    "type": "string",
+                    "enum": [r.value for r in GoReferenceRole],
+                    "description": "the syntactic role of the reference (Go only)",
+                },
+            },
+            "required": ["name_path", "kind"],
+        },
+    }
+
     def apply(
         self,
         name_path: str,
@@ -183,7 +662,9 @@ class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
         :param include_kinds: same as in the `find_symbol` tool.
         :param exclude_kinds: same as in the `find_symbol` tool.
         :param max_answer_chars: same as in the `find_symbol` tool.
-        :return: a list of JSON objects with the symbols referencing the requested symbol
+        :return: a list of JSON objects with the symbols referencing the requested symbol.
+            For Go, each object also contains the `role` of the reference, i.e. one of "call", "conversion", "embed",
+            "composite_literal", "composite_literal_field", "assignment", "type" and "read".
         """
         include_body = False  # It is probably never a good idea to include the body of the referencing symbols
         parsed_include_kinds: Sequence[SymbolKind] | None = [SymbolKind(k) for k in include_kinds] if include_kinds else None
@@ -196,12 +677,21 @@ class FindReferencingSymbolsTool(Tool, ToolMarkerSymbolicRead):
             include_kinds=parsed_include_kinds,
             exclude_kinds=parsed_exclude_kinds,
         )
+        go_analyzer = None
+        refers_to_type = False
+        if self.project.language == Language.GO:
+            go_analyzer = self.create_go_analyzer()
+            candidates = symbol_retriever.find_by_name(name_path, substring_matching=False, within_relative_path=relative_path)
+            declaration = go_analyzer.get_declaration(candidates[0]) if candidates else None
+            refers_to_type = declaration is not None and declaration.kind == GoDeclarationKind.TYPE
         reference_dicts = []
         for ref in references_in_symbols:
             ref_dict = ref.symbol.to_dict(kind=True, location=True, depth=0, include_body=include_body)
             ref_dict = _sanitize_symbol_dict(ref_dict)
+            ref_relative_path = ref.symbol.location.relative_path
+            if go_analyzer is not None and ref_relative_path is not None and ref_relative_path.endswith(".go"):
+                ref_dict["role"] = go_analyzer.get_reference_role(ref_relative_path, ref.line, ref.character, refers_to_type).value
             if not include_body:
-                ref_relative_path = ref.symbol.location.relative_path
                 assert ref_relative_path is not None, f"Referencing symbol {ref.symbol.name} has no relative path, this is likely a bug."
                 content_around_ref = self.project.retrieve_content_around_line(
                     relative_file_path=ref_relative_path, line=ref.line, context_lines_before=1, context_lines_after=1
@@ -217,6 +707,8 @@ class ReplaceSymbolBodyTool(Tool, ToolMarkerSymbolicEdit):
     Replaces the full definition of a symbol.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         name_path: str,
@@ -250,11 +742,14 @@ class InsertAfterSymbolTool(Tool, ToolMarkerSymbolicEdit):
     Inserts content after the end of the definition of a given symbol.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         name_path: str,
         relative_path: str,
         body: str,
+        doc_comment: str = "",
     ) -> str:
         """
         Inserts the given body/content after the end of the definition of the given symbol (via the symbol's location).
@@ -264,9 +759,12 @@ class InsertAfterSymbolTool(Tool, ToolMarkerSymbolicEdit):
         :param relative_path: the relative path to the file containing the symbol
         :param body: the body/content to be inserted. The inserted code shall begin with the next line after
             the symbol.
+        :param doc_comment: optional doc comment for the inserted declaration, which is placed directly above it
+            (without an empty line in between). Lines which are not yet comments are turned into line comments of the
+            file's language (e.g. `//` for Go, `#` for Python).
         """
         code_editor = self.create_code_editor()
-        code_editor.insert_after_symbol(name_path, relative_file_path=relative_path, body=body)
+        code_editor.insert_after_symbol(name_path, relative_file_path=relative_path, body=body, doc_comment=doc_comment)
         return SUCCESS_RESULT
 
 
@@ -275,11 +773,14 @@ class InsertBeforeSymbolTool(Tool, ToolMarkerSymbolicEdit):
     Inserts content before the beginning of the definition of a given symbol.
     """
 
+    output_schema = SUCCESS_RESULT_SCHEMA
+
     def apply(
         self,
         name_path: str,
         relative_path: str,
         body: str,
+        doc_comment: str = "",
     ) -> str:
         """
         Inserts the given content before the beginning of the definition of the given symbol (via the symbol's location).
@@ -289,7 +790,343 @@ class InsertBeforeSymbolTool(Tool, ToolMarkerSymbolicEdit):
         :param name_path: name path of the symbol before which to insert content (definitions in the `find_symbol` tool apply)
         :param relative_path: the relative path to the file containing the symbol
         :param body: the body/content to be inserted before the line in which the referenced symbol is defined
+        :param doc_comment: optional doc comment for the inserted declaration, which is placed directly above it
+            (without an empty line in between). Lines which are not yet comments are turned into line comments of the
+            file's language (e.g. `//` for Go, `#` for Python).
         """
         code_editor = self.create_code_editor()
-        code_editor.insert_before_symbol(name_path, relative_file_path=relative_path, body=body)
+        code_editor.insert_before_symbol(name_path, relative_file_path=relative_path, body=body, doc_comment=doc_comment)
         return SUCCESS_RESULT
+
+
+class FindMarkersTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds marker comments (e.g. TODO, FIXME) and the symbols they belong to.
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "marker": {"type": "string"},
+                "text": {"type": "string"},
+                "relative_path": {"type": "string"},
+                "line": {"type": "integer"},
+                "in_doc_comment": {"type": "boolean"},
+                "symbol": {
+                    "type": ["object", "null"],
+                    "properties": {"name_path": {"type": "string"}, "kind": _SYMBOL_KIND_SCHEMA},
+                    "required": ["name_path", "kind"],
+                },
+            },
+            "required": ["marker", "text", "relative_path", "line", "in_doc_comment", "symbol"],
+        },
+    }
+
+    def apply(
+        self,
+        relative_path: str = "",
+        markers: list[str] = ["TODO", "FIXME"],  # noqa: B006
+        max_answer_chars: int = -1,
+    ) -> str:
+        """
+        Finds comments containing the given markers (such as TODO or FIXME) and maps each of them to the symbol it belongs to,
+        i.e. the innermost symbol containing the comment or, for doc comments, the documented symbol.
+
+        :param relative_path: the relative path of the file or directory in which to search; "" for the entire project
+        :param markers: the marker words to search for (matched case-sensitively as whole words)
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a list of JSON objects with the marker, the text following it, the relative path, the (0-based) line,
+            whether the marker is part of a doc comment and the name path and kind of the symbol the marker belongs to
+            (null if it does not belong to any symbol)
+        """
+        if not markers:
+            raise ValueError("At least one marker must be given")
+        marker_pattern = re.compile(r"\b(" + "|".join(re.escape(m) for m in markers) + r")\b[:\s]*(.*)")
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        go_analyzer = self.create_go_analyzer() if self.project.language == Language.GO else None
+        result = []
+        for file_path in sorted(self.project.gather_source_files(relative_path)):
+            content = self.project.read_file(file_path)
+            for line, offset, comment_text in _iter_comment_lines(file_path, content):
+                match = marker_pattern.search(comment_text)
+                if match is None:
+                    continue
+                documented_declaration = None
+                if go_analyzer is not None and file_path.endswith(".go"):
+                    documented_declaration = go_analyzer.find_documented_declaration(file_path, offset)
+                if documented_declaration is not None:
+                    assert go_analyzer is not None
+                    name_line, name_column = go_analyzer.parse_file(file_path).get_line_and_column(documented_declaration.name_start)
+                    symbol = symbol_retriever.find_by_location(
+                        LanguageServerSymbolLocation(relative_path=file_path, line=name_line, column=name_column)
+                    )
+                else:
+                    symbol = symbol_retriever.find_symbol_at_line(file_path, line)
+                result.append(
+                    {
+                        "marker": match.group(1),
+                        "text": match.group(2).rstrip(" */").strip(),
+                        "relative_path": file_path,
+                        "line": line,
+                        "in_doc_comment": documented_declaration is not None,
+                        "symbol": None if symbol is None else {"name_path": symbol.get_name_path(), "kind": symbol.kind},
+                    }
+                )
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class DiffSymbolsTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Compares the symbols of a file with those of an alternative version of its content.
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "added": {"type": "array", "items": _SYMBOL_SUMMARY_SCHEMA},
+            "removed": {"type": "array", "items": _SYMBOL_SUMMARY_SCHEMA},
+            "modified": {"type": "array", "items": _SYMBOL_SUMMARY_SCHEMA},
+        },
+        "required": ["added", "removed", "modified"],
+    }
+
+    def apply(self, relative_path: str, other_content: str, max_answer_chars: int = -1) -> str:
+        """
+        Compares the symbols in the given file with the symbols in the given alternative content of the file
+        (e.g. a proposed edit), which is analyzed by the language server without changing the file.
+        A symbol is considered modified if its body changed (as indicated by its `body_hash`); symbols which were
+        merely moved are not reported.
+
+        :param relative_path: the relative path of the file
+        :param other_content: the alternative content of the file
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a JSON object with the lists of `added`, `removed` and `modified` symbols (each with name path and kind),
+            where added symbols exist only in the alternative content and removed symbols only in the current content
+        """
+        self.project.validate_relative_path(relative_path)
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        result = symbol_retriever.diff_symbols(relative_path, None, other_content).to_dict()
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class SymbolsInRangeTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds all symbols overlapping a range of lines in a file, e.g. an editor selection or a diff hunk.
+    """
+
+    output_schema = {"type": "array", "items": _SYMBOL_SCHEMA}
+
+    def apply(self, relative_path: str, start_line: int, end_line: int, max_answer_chars: int = -1) -> str:
+        """
+        Finds all symbols whose bodies intersect the given range of lines, e.g. both methods if the range spans the end of
+        one method and the beginning of the next. Symbols enclosing the range (e.g. a type of which only some fields are
+        in the range) are included as well.
+
+        :param relative_path: the relative path of the file
+        :param start_line: the 0-based first line of the range
+        :param end_line: the 0-based last line of the range (inclusive)
+        :param max_answer_chars: if the output is longer than this number of characters,
+            no content will be returned. -1 means the default value from the config will be used.
+        :return: a list of JSON objects with the name path, kind, relative path and body location of each overlapping
+            symbol, ordered by start position (enclosing symbols preceding the symbols they contain)
+        """
+        if start_line > end_line:
+            raise ValueError(f"The start line {start_line} is after the end line {end_line}")
+        self.project.validate_relative_path(relative_path)
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        symbols = symbol_retriever.find_symbols_in_range(relative_path, start_line, end_line)
+        result = [_sanitize_symbol_dict(s.to_dict(kind=True, location=True)) for s in symbols]
+        return self._limit_length(json.dumps(result), max_answer_chars)
+
+
+class HoverTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Retrieves the hover information the language server provides for a position, e.g. a symbol's signature and documentation.
+    """
+
+    def apply(self, relative_path: str, line: int, column: int) -> str:
+        """
+        Retrieves the hover information (as rendered by the language server, e.g. gopls) for the given position,
+        which typically comprises the signature or type of the symbol at the position and its documentation.
+        This complements the structured information provided by the other symbolic tools with the language server's
+        canonical rendering.
+
+        :param relative_path: the relative path of the file
+        :param line: the 0-based line of the position
+        :param column: the 0-based column of the position
+        :return: the hover information in markdown format
+        """
+        self.project.validate_relative_path(relative_path)
+        hover_text = self.create_language_server_symbol_retriever().get_hover_text(relative_path, line, column)
+        if not hover_text:
+            raise ValueError(f"No hover information available at {line}:{column} in {relative_path}")
+        return hover_text
+
+
+class FindImplementationsTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the implementations of an interface or interface method (and the interfaces a type implements).
+    """
+
+    output_schema = {
+        "type": "array",
+        "items": {
+            "type": "object",
+            "properties": {
+                "name_path": {"type": "string"},
+                "symbol_id": {"type": "string"},
+                "kind": _SYMBOL_KIND_SCHEMA,
+                "relative_path": {"type": "string"},
+                "body_location": _BODY_LOCATION_SCHEMA,
+            },
+            "required": ["name_path", "kind"],
+        },
+    }
+
+    def apply(self, name_path: str, relative_path: str, max_answer_chars: int = -1) -> str:
+        """
+        Finds the implementations of the given symbol as determined by the language server. For an interface, these are
+        the types implementing it and for an interface method the implementing methods. In Go, where interfaces are
+        satisfied implicitly, this includes types obtaining (some of) the required methods from embedded fields, which
+        cannot be found by searching for references. For a concrete type, the language server may conversely report the
+        interfaces the type implements (as gopls does).
+
+        :param name_path: the name path of the symbol, same logic as in the `find_symbol` tool, e.g. "Processable" or
+            "Processable/Process"; it must match a unique symbol in the file
+        :param relative_path: the relative path of the file containing the symbol
+        :param max_answer_chars: same as in the `find_symbol` tool.
+        :return: a list of the implementing symbols (each with `name_path`, `symbol_id`, `kind`, `relative_path` and
+            `body_location`), ordered by file and position; interfaces declared outside the project (e.g. in the standard
+            library) are not included
+        """
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        symbol_dicts = []
+        for symbol in symbol_retriever.find_implementations(name_path, relative_path):
+            symbol_dict = _sanitize_symbol_dict(symbol.to_dict(kind=True, location=True, depth=0))
+            symbol_dict["symbol_id"] = symbol.get_symbol_id()
+            symbol_dicts.append(symbol_dict)
+        return self._limit_length(json.dumps(symbol_dicts), max_answer_chars)
+
+
+class FindCallersTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the functions and methods calling the given function or method (transitively up to a given depth).
+    """
+
+    output_schema = _CALL_HIERARCHY_SCHEMA
+
+    def apply(self, name_path: str, relative_path: str, max_depth: int = 1, max_answer_chars: int = -1) -> str:
+        """
+        Finds the callers of the given function or method via the language server's call hierarchy. Unlike
+        `find_referencing_symbols`, only calls are considered (not e.g. uses of a function as a value), and callers can be
+        followed transitively: with a depth of 2, the callers of the callers are included, and so on.
+
+        :param name_path: the name path of the function or method, same logic as in the `find_symbol` tool,
+            e.g. "ChildStruct/Process"; it must match a unique symbol in the file
+        :param relative_path: the relative path of the file containing the symbol
+        :param max_depth: the number of levels of callers to follow (1 for the direct callers only)
+        :param max_answer_chars: same as in the `find_symbol` tool.
+        :return: a list of the calling symbols (each with `name_path`, `symbol_id`, `kind`, `relative_path` and
+            `body_location`), ordered by depth; each caller is reported once, at the smallest depth at which it occurs,
+            with its `depth`, the `symbol_id` of the symbol it calls (`via`) and the (0-based) `call_lines` within its
+            body. Callers outside the project are not included
+        """
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        entries = symbol_retriever.find_call_hierarchy(name_path, relative_path, incoming=True, max_depth=max_depth)
+        return self._limit_length(_call_hierarchy_to_json(entries), max_answer_chars)
+
+
+class FindCalleesTool(Tool, ToolMarkerSymbolicRead, ToolMarkerOptional):
+    """
+    Finds the functions and methods called by the given function or method (transitively up to a given depth).
+    """
+
+    output_schema = _CALL_HIERARCHY_SCHEMA
+
+    def apply(self, name_path: str, relative_path: str, max_depth: int = 1, max_answer_chars: int = -1) -> str:
+        """
+        Finds the callees of the given function or method via the language server's call hierarchy, i.e. the functions
+        and methods it calls. Callees can be followed transitively: with a depth of 2, the functions called by the callees
+        are included, and so on.
+
+        :param name_path: the name path of the function or method, same logic as in the `find_symbol` tool,
+            e.g. "ChildStruct/Execute"; it must match a unique symbol in the file
+        :param relative_path: the relative path of the file containing the symbol
+        :param max_depth: the number of levels of callees to follow (1 for the direct callees only)
+        :param max_answer_chars: same as in the `find_symbol` tool.
+        :return: a list of the called symbols (each with `name_path`, `symbol_id`, `kind`, `relative_path` and
+            `body_location`), ordered by depth; each callee is reported once, at the smallest depth at which it occurs,
+            with its `depth`, the `symbol_id` of the symbol calling it (`via`) and the (0-based) `call_lines` within the
+            calling symbol's body. Callees outside the project (e.g. functions of the standard library) are not included
+        """
+        symbol_retriever = self.create_language_server_symbol_retriever()
+        entries = symbol_retriever.find_call_hierarchy(name_path, relative_path, incoming=False, max_depth=max_depth)
+        return self._limit_length(_call_hierarchy_to_json(entries), max_answer_chars)
+
+
+class RenameSymbolTool(Tool, ToolMarkerSymbolicEdit, ToolMarkerOptional):
+    """
+    Renames a symbol and all its references throughout the project using the language server.
+    """
+
+    output_schema = {
+        "type": "object",
+        "properties": {
+            "changes": {
+                "type": "array",
+                "items": {
+                    "type": "object",
+                    "properties": {
+                        "relative_path": {"type": "string"},
+                        "line": {"type": "integer"},
+                        "column": {"type": "integer"},
+                    },
+                    "required": ["relative_path", "line", "column"],
+                },
+            },
+            "diff": {"type": "string"},
+            "applied": {"type": "boolean"},
+        },
+        "required": ["changes", "diff", "applied"],
+    }
+
+    def apply(self, name_path: str, relative_path: str, new_name: str, dry_run: bool = False) -> str:
+        """
+        Renames the given symbol (e.g. a type, function, method or field) along with all of its references, as computed
+        by the language server, which takes the language's semantics into account (unlike textual replacements); e.g. for
+        Go, renaming an interface method also renames the methods implementing it. All affected files are changed
+        together: if any change cannot be applied, none is. The language server refuses renames which would cause
+        conflicts (e.g. with an existing symbol of the same name), in which case an error is returned.
+
+        :param name_path: the name path of the symbol to rename, same logic as in the `find_symbol` tool,
+            e.g. "BaseStruct" or "Processable/GetType"; it must match a unique symbol in the file
+        :param relative_path: the relative path of the file containing the symbol's definition
+        :param new_name: the new name (identifier) of the symbol
+        :param dry_run: whether to only determine the changes (and diff) without applying them
+        :return: an object with the changed locations (`changes`, each with the `relative_path` and the 0-based `line` and
+            `column` in the original file), the unified `diff` of the changes and whether they were `applied`
+        """
+        from serena.code_editor import get_unified_diff
+
+        code_editor = self.create_language_server_code_editor()
+        language_server = self.create_language_server_symbol_retriever().get_language_server()
+        file_edits = code_editor.get_rename_edits(name_path, relative_path, new_name)
+        changes = []
+        original_contents = {}
+        new_contents = {}
+        for edited_path, edits in file_edits.items():
+            for edit in edits:
+                start = edit["range"]["start"]
+                changes.append({"relative_path": edited_path, "line": start["line"], "column": start["character"]})
+            # the edits are applied in memory, such that the diff of a dry run leaves the files untouched
+            original_contents[edited_path] = language_server.retrieve_current_file_content(edited_path)
+            new_contents[edited_path] = TextUtils.apply_text_edits(original_contents[edited_path], edits)
+        changes.sort(key=lambda c: (c["relative_path"], c["line"], c["column"]))
+        if not dry_run:
+            code_editor.apply_text_edits(file_edits)
+        result = {"changes": changes, "diff": get_unified_diff(original_contents, new_contents), "applied": not dry_run}
+        return json.dumps(result)
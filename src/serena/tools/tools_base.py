@@ -1,4 +1,6 @@
+import hashlib
 import inspect
+import json
 import os
 from abc import ABC
 from collections.abc import Iterable
@@ -6,6 +8,7 @@ from dataclasses import dataclass
 from types import TracebackType
 from typing import TYPE_CHECKING, Any, Protocol, Self, TypeVar
 
+import docstring_parser
 from mcp.server.fastmcp.utilities.func_metadata import FuncMetadata, func_metadata
 from sensai.util import logging
 from sensai.util.string import dict_string
@@ -19,11 +22,18 @@ from solidlsp.ls_exceptions import SolidLSPException
 
 if TYPE_CHECKING:
     from serena.agent import LinesRead, MemoriesManager, SerenaAgent
-    from serena.code_editor import CodeEditor
+    from serena.code_editor import CodeEditor, LanguageServerCodeEditor
+    from serena.go_analysis import GoAnalyzer
 
 log = logging.getLogger(__name__)
 T = TypeVar("T")
 SUCCESS_RESULT = "OK"
+SUCCESS_RESULT_SCHEMA: dict[str, Any] = {"type": "string", "const": SUCCESS_RESULT}
+TOOLS_MANIFEST_SCHEMA_VERSION = 1
+"""
+the version of the structure of the tools manifest (see `ToolRegistry.create_tools_manifest`), which is to be
+incremented whenever the structure changes in an incompatible way
+"""
 
 
 class Component(ABC):
@@ -60,10 +70,27 @@ class Component(ABC):
         from ..code_editor import JetBrainsCodeEditor, LanguageServerCodeEditor
 
         if self.agent.is_using_language_server():
-            return LanguageServerCodeEditor(self.create_language_server_symbol_retriever(), agent=self.agent)
+            return LanguageServerCodeEditor(
+                self.create_language_server_symbol_retriever(), agent=self.agent, encoding=self.project.project_config.encoding
+            )
         else:
             return JetBrainsCodeEditor(project=self.project, agent=self.agent)
 
+    def create_language_server_code_editor(self) -> "LanguageServerCodeEditor":
+        """
+        :return: a code editor which is backed by the language server, for operations which are not supported in JetBrains mode
+        """
+        from ..code_editor import LanguageServerCodeEditor
+
+        return LanguageServerCodeEditor(
+            self.create_language_server_symbol_retriever(), agent=self.agent, encoding=self.project.project_config.encoding
+        )
+
+    def create_go_analyzer(self) -> "GoAnalyzer":
+        from ..go_analysis import GoAnalyzer
+
+        return GoAnalyzer(self.create_language_server_symbol_retriever(), encoding=self.project.project_config.encoding)
+
     @property
     def lines_read(self) -> "LinesRead":
         assert self.agent.lines_read is not None
@@ -111,6 +138,13 @@ class ApplyMethodProtocol(Protocol):
         pass
 
 
+class ProgressReporter(Protocol):
+    """Callable protocol for receiving the progress reports of a tool (see `Tool.report_progress`)."""
+
+    def __call__(self, progress: float, total: float | None, message: str | None) -> None:
+        pass
+
+
 class Tool(Component):
     # NOTE: each tool should implement the apply method, which is then used in
     # the central method of the Tool class `apply_ex`.
@@ -122,6 +156,21 @@ class Tool(Component):
     # (which is use by the LLM, so a good description is important)
     # and to validate the tool call arguments.
 
+    output_schema: dict[str, Any] | None = None
+    """
+    the JSON schema of the tool's result, which is included in the tools manifest. For tools returning JSON, it describes
+    the decoded result. If None, the result is described as a string, using the `:return:` section of the apply method's docstring.
+    The schema describes successful applications only; errors are always reported as strings (see `apply_ex`).
+    """
+
+    reports_progress: bool = False
+    """
+    whether the tool reports its progress (via `report_progress`) while it is applied; the MCP server sends the reports
+    of such tools to the client as progress notifications (if the client requested them)
+    """
+
+    _progress_reporter: ProgressReporter | None = None
+
     @classmethod
     def get_name_from_cls(cls) -> str:
         name = cls.__name__
@@ -199,9 +248,37 @@ class Tool(Component):
 
         return func_metadata(apply_fn, skip_names=["self", "cls"])
 
+    @classmethod
+    def get_parameters_json_schema_from_cls(cls) -> dict[str, Any]:
+        """
+        :return: the JSON schema of the apply method's parameters, with the parameter descriptions taken from its docstring
+        """
+        parameters = cls.get_apply_fn_metadata_from_cls().arg_model.model_json_schema()
+        docstring = docstring_parser.parse(cls.get_apply_docstring_from_cls())
+        docstring_params = {param.arg_name: param for param in docstring.params}
+        parameters_properties: dict[str, dict[str, Any]] = parameters["properties"]
+        for parameter, properties in parameters_properties.items():
+            if (param_doc := docstring_params.get(parameter)) and param_doc.description:
+                param_desc = f"{param_doc.description.strip().strip('.') + '.'}"
+                properties["description"] = param_desc[0].upper() + param_desc[1:]
+        return parameters
+
+    @classmethod
+    def get_output_json_schema_from_cls(cls) -> dict[str, Any]:
+        """
+        :return: the JSON schema of the tool's result (see `output_schema`)
+        """
+        if cls.output_schema is not None:
+            return cls.output_schema
+        schema: dict[str, Any] = {"type": "string"}
+        docstring = docstring_parser.parse(cls.get_apply_docstring_from_cls())
+        if docstring.returns and docstring.returns.description:
+            schema["description"] = docstring.returns.description.strip()
+        return schema
+
     def _log_tool_application(self, frame: Any) -> None:
         params = {}
-        ignored_params = {"self", "log_call", "catch_exceptions", "args", "apply_fn"}
+        ignored_params = {"self", "log_call", "catch_exceptions", "progress_reporter", "args", "apply_fn"}
         for param, value in frame.f_locals.items():
             if param in ignored_params:
                 continue
@@ -226,9 +303,24 @@ class Tool(Component):
     def is_active(self) -> bool:
         return self.agent.tool_is_active(self.__class__)
 
-    def apply_ex(self, log_call: bool = True, catch_exceptions: bool = True, **kwargs) -> str:  # type: ignore
+    def report_progress(self, progress: float, total: float | None = None, message: str | None = None) -> None:
         """
-        Applies the tool with logging and exception handling, using the given keyword arguments
+        Reports the progress of the tool's current application to the progress reporter passed to `apply_ex` (if any).
+
+        :param progress: the progress so far, e.g. the number of items processed
+        :param total: the total amount of progress expected (if known), e.g. the number of items to process
+        :param message: a message describing the current progress, e.g. the item processed last
+        """
+        if self._progress_reporter is not None:
+            self._progress_reporter(progress, total, message)
+
+    def apply_ex(  # type: ignore
+        self, log_call: bool = True, catch_exceptions: bool = True, progress_reporter: ProgressReporter | None = None, **kwargs
+    ) -> str:
+        """
+        Applies the tool with logging and exception handling, using the given keyword arguments.
+        The given progress reporter (if any) receives the progress reports of the application (see `report_progress`);
+        it is called from the agent's task executor thread.
         """
 
         def task() -> str:
@@ -255,6 +347,7 @@ class Tool(Component):
                         self.agent.reset_language_server()
 
                 # apply the actual tool
+                self._progress_reporter = progress_reporter
                 try:
                     result = apply_fn(**kwargs)
                 except SolidLSPException as e:
@@ -264,6 +357,8 @@ class Tool(Component):
                         result = apply_fn(**kwargs)
                     else:
                         raise
+                finally:
+                    self._progress_reporter = None
 
                 # record tool usage
                 self.agent.record_tool_usage_if_enabled(kwargs, result, self)
@@ -416,3 +511,39 @@ class ToolRegistry:
 
     def is_valid_tool_name(self, tool_name: str) -> bool:
         return tool_name in self._tool_dict
+
+    def create_tools_manifest(self, active_tool_names: Iterable[str]) -> dict[str, Any]:
+        """
+        Creates a machine-readable description of all symbolic and editing tools, which allows clients to validate
+        tool calls before sending them and to detect changes in the capabilities of the tools.
+
+        :param active_tool_names: the names of the tools which are currently active
+        :return: a dictionary with the `schema_version` of the manifest's structure, the `version` of its content (a hash
+            which changes whenever any of the described tools changes), and the list of `tools`, each with its `name`,
+            `description`, the `input_schema` of its parameters, the `output_schema` of its result, the `output_format`
+            ("json" or "text") and the flags `can_edit`, `optional` and `active`
+        """
+        active_tool_names = set(active_tool_names)
+        tools = []
+        for tool_name in sorted(self._tool_dict.keys()):
+            registered_tool = self._tool_dict[tool_name]
+            tool_class = registered_tool.tool_class
+            if not issubclass(tool_class, ToolMarkerSymbolicRead | ToolMarkerCanEdit):
+                continue
+            output_schema = tool_class.get_output_json_schema_from_cls()
+            tools.append(
+                {
+                    "name": tool_name,
+                    "description": tool_class.get_tool_description(),
+                    "input_schema": tool_class.get_parameters_json_schema_from_cls(),
+                    "output_schema": output_schema,
+                    "output_format": "text" if output_schema.get("type") == "string" else "json",
+                    "can_edit": tool_class.can_edit(),
+                    "optional": registered_tool.is_optional,
+                    "active": tool_name in active_tool_names,
+                }
+            )
+        # the content version must not depend on which tools happen to be active
+        versioned_content = [{k: v for k, v in tool.items() if k != "active"} for tool in tools]
+        version = hashlib.sha256(json.dumps(versioned_content, sort_keys=True).encode("utf-8")).hexdigest()[:16]
+        return {"schema_version": TOOLS_MANIFEST_SCHEMA_VERSION, "version": version, "tools": tools}
@@ -0,0 +1,2922 @@
+"""
+Lightweight, purely textual analysis of Go source code.
+
+The language server (gopls) remains the authoritative source of symbol information; the functions in this module
+complement it where gopls does not provide the required information (e.g. the underlying kind of a named type)
+or where a full round trip to the language server is not warranted.
+The analysis is based on a tokenizer which correctly handles comments, string and rune literals as well as Go's
+automatic semicolon insertion, but it does not perform any type checking.
+"""
+
+import bisect
+import difflib
+import re
+import textwrap
+from collections.abc import Iterator
+from dataclasses import dataclass, field
+from enum import Enum
+
+KEYWORDS = {
+    "break",
+    "case",
+    "chan",
+    "const",
+    "continue",
+    "default",
+    "defer",
+    "else",
+    "fallthrough",
+    "for",
+    "func",
+    "go",
+    "goto",
+    "if",
+    "import",
+    "interface",
+    "map",
+    "package",
+    "range",
+    "return",
+    "select",
+    "struct",
+    "switch",
+    "type",
+    "var",
+}
+# tokens after which a newline terminates a statement (see "Semicolons" in the Go language specification)
+_KEYWORDS_TERMINATING_STATEMENTS = {"break", "continue", "fallthrough", "return"}
+_OPERATORS_TERMINATING_STATEMENTS = {"++", "--", ")", "]", "}"}
+_OPERATORS = sorted(
+    [
+        "<<=",
+        ">>=",
+        "&^=",
+        "...",
+        "&&",
+        "||",
+        "<-",
+        "++",
+        "--",
+        "==",
+        "!=",
+        "<=",
+        ">=",
+        ":=",
+        "+=",
+        "-=",
+        "*=",
+        "/=",
+        "%=",
+        "&=",
+        "|=",
+        "^=",
+        "<<",
+        ">>",
+        "&^",
+        "~",
+    ],
+    key=len,
+    reverse=True,
+)
+_IDENTIFIER_START_PATTERN = re.compile(r"[^\W\d]", re.UNICODE)
+_IDENTIFIER_PATTERN = re.compile(r"[^\W\d]\w*", re.UNICODE)
+_NUMBER_PATTERN = re.compile(r"\.?\d(?:[eEpP][+-]|[\w.])*")
+_DIRECTIVE_PATTERN = re.compile(r"^//(go|[a-z0-9]+):\S")
+# the header marking generated files (see https://pkg.go.dev/cmd/go#hdr-Generate_Go_files_by_processing_source)
+GENERATED_CODE_PATTERN = re.compile(r"^// Code generated .* DO NOT EDIT\.$", re.MULTILINE)
+_BUILD_CONSTRAINT_PATTERN = re.compile(r"^//go:build[ \t]+(.+?)[ \t]*$", re.MULTILINE)
+_GO_WORK_COMMENT_PATTERN = re.compile(r"//.*$")
+_GO_WORK_USE_PATTERN = re.compile(r"^use(?:\s+|(?=\())(.*)$")
+
+# the operating systems and architectures which file name suffixes such as `_linux` or `_windows_amd64` refer to
+# (see https://pkg.go.dev/cmd/go#hdr-Build_constraints)
+KNOWN_OPERATING_SYSTEMS = {
+    "aix",
+    "android",
+    "darwin",
+    "dragonfly",
+    "freebsd",
+    "hurd",
+    "illumos",
+    "ios",
+    "js",
+    "linux",
+    "nacl",
+    "netbsd",
+    "openbsd",
+    "plan9",
+    "solaris",
+    "wasip1",
+    "windows",
+    "zos",
+}
+KNOWN_ARCHITECTURES = {
+    "386",
+    "amd64",
+    "amd64p32",
+    "arm",
+    "arm64",
+    "arm64be",
+    "armbe",
+    "loong64",
+    "mips",
+    "mips64",
+    "mips64le",
+    "mips64p32",
+    "mips64p32le",
+    "mipsle",
+    "ppc",
+    "ppc64",
+    "ppc64le",
+    "riscv",
+    "riscv64",
+    "s390",
+    "s390x",
+    "sparc",
+    "sparc64",
+    "wasm",
+}
+
+BASIC_TYPES = {
+    "bool",
+    "byte",
+    "complex64",
+    "complex128",
+    "float32",
+    "float64",
+    "int",
+    "int8",
+    "int16",
+    "int32",
+    "int64",
+    "rune",
+    "string",
+    "uint",
+    "uint8",
+    "uint16",
+    "uint32",
+    "uint64",
+    "uintptr",
+}
+
+
+class GoTokenKind(Enum):
+    IDENTIFIER = "identifier"
+    NUMBER = "number"
+    STRING = "string"
+    RUNE = "rune"
+    OPERATOR = "operator"
+    COMMENT = "comment"
+
+
+@dataclass
+class GoToken:
+    kind: GoTokenKind
+    text: str
+    start: int
+    """
+    the offset of the token's first character in the source
+    """
+    end: int
+    """
+    the offset after the token's last character in the source
+    """
+    line: int
+    """
+    the 0-based line in which the token starts
+    """
+    end_line: int
+    """
+    the 0-based line in which the token ends (differs from `line` only for multi-line comments and raw strings)
+    """
+
+    def is_operator(self, *texts: str) -> bool:
+        return self.kind == GoTokenKind.OPERATOR and self.text in texts
+
+    def is_identifier(self, *texts: str) -> bool:
+        return self.kind == GoTokenKind.IDENTIFIER and (not texts or self.text in texts)
+
+    def terminates_statement_at_line_end(self) -> bool:
+        """
+        :return: whether a newline following this token terminates the statement (automatic semicolon insertion)
+        """
+        if self.kind in (GoTokenKind.NUMBER, GoTokenKind.STRING, GoTokenKind.RUNE):
+            return True
+        if self.kind == GoTokenKind.IDENTIFIER:
+            return self.text not in KEYWORDS or self.text in _KEYWORDS_TERMINATING_STATEMENTS
+        return self.kind == GoTokenKind.OPERATOR and self.text in _OPERATORS_TERMINATING_STATEMENTS
+
+
+def tokenize(source: str, include_comments: bool = False) -> list[GoToken]:
+    """
+    Splits the given Go source code into tokens.
+    Keywords are reported as identifiers; unterminated literals and comments extend to the end of the source.
+
+    :param source: the source code
+    :param include_comments: whether to include comment tokens in the result
+    :return: the list of tokens
+    """
+    tokens: list[GoToken] = []
+    line = 0
+    i = 0
+    n = len(source)
+
+    def add(kind: GoTokenKind, start: int, end: int) -> None:
+        nonlocal line
+        text = source[start:end]
+        num_newlines = text.count("\n")
+        if kind != GoTokenKind.COMMENT or include_comments:
+            tokens.append(GoToken(kind=kind, text=text, start=start, end=end, line=line, end_line=line + num_newlines))
+        line += num_newlines
+
+    while i < n:
+        c = source[i]
+        if c == "\n":
+            line += 1
+            i += 1
+        elif c.isspace():
+            i += 1
+        elif source.startswith("//", i):
+            end = source.find("\n", i)
+            end = n if end == -1 else end
+            add(GoTokenKind.COMMENT, i, end)
+            i = end
+        elif source.startswith("/*", i):
+            end = source.find("*/", i + 2)
+            end = n if end == -1 else end + 2
+            add(GoTokenKind.COMMENT, i, end)
+            i = end
+        elif c == "`":
+            end = source.find("`", i + 1)
+            end = n if end == -1 else end + 1
+            add(GoTokenKind.STRING, i, end)
+            i = end
+        elif c in "\"'":
+            j = i + 1
+            while j < n and source[j] != c and source[j] != "\n":
+                j += 2 if source[j] == "\\" else 1
+            end = min(j + 1, n)
+            add(GoTokenKind.STRING if c == '"' else GoTokenKind.RUNE, i, end)
+            i = end
+        elif c.isdigit() or (c == "." and i + 1 < n and source[i + 1].isdigit()):
+            match = _NUMBER_PATTERN.match(source, i)
+            assert match is not None
+            add(GoTokenKind.NUMBER, i, match.end())
+            i = match.end()
+        elif _IDENTIFIER_START_PATTERN.match(c):
+            match = _IDENTIFIER_PATTERN.match(source, i)
+            assert match is not None
+            add(GoTokenKind.IDENTIFIER, i, match.end())
+            i = match.end()
+        else:
+            for op in _OPERATORS:
+                if source.startswith(op, i):
+                    add(GoTokenKind.OPERATOR, i, i + len(op))
+                    i += len(op)
+                    break
+            else:
+                add(GoTokenKind.OPERATOR, i, i + 1)
+                i += 1
+    return tokens
+
+
+def find_statement_end(tokens: list[GoToken], start: int) -> int:
+    """
+    Finds the last token of the statement/declaration starting at the given token, taking into account
+    bracket nesting and Go's automatic semicolon insertion.
+
+    :param tokens: the tokens (without comments)
+    :param start: the index of the first token of the statement
+    :return: the index of the statement's last token (never less than `start`)
+    """
+    depth = 0
+    i = start
+    while i < len(tokens):
+        token = tokens[i]
+        if token.is_operator("(", "[", "{"):
+            depth += 1
+        elif token.is_operator(")", "]", "}"):
+            depth -= 1
+            if depth < 0:
+                # closing bracket of an enclosing construct
+                return max(start, i - 1)
+        elif token.is_operator(";") and depth == 0:
+            return max(start, i - 1)
+        if depth == 0 and i + 1 < len(tokens) and tokens[i + 1].line > token.end_line and token.terminates_statement_at_line_end():
+            return i
+        i += 1
+    return len(tokens) - 1
+
+
+def find_matching_bracket(tokens: list[GoToken], start: int) -> int:
+    """
+    :param tokens: the tokens (without comments)
+    :param start: the index of an opening bracket token
+    :return: the index of the matching closing bracket (or the index of the last token if there is none)
+    """
+    depth = 0
+    for i in range(start, len(tokens)):
+        if tokens[i].is_operator("(", "[", "{"):
+            depth += 1
+        elif tokens[i].is_operator(")", "]", "}"):
+            depth -= 1
+            if depth == 0:
+                return i
+    return len(tokens) - 1
+
+
+class GoDeclarationKind(Enum):
+    FUNCTION = "func"
+    METHOD = "method"
+    TYPE = "type"
+    VARIABLE = "var"
+    CONSTANT = "const"
+
+
+@dataclass
+class GoDeclaration:
+    """
+    A top-level declaration (or, for grouped declarations, a single spec within the group) in a Go file
+    """
+
+    kind: GoDeclarationKind
+    name: str
+    start: int
+    """
+    the offset at which the declaration starts (the `func`/`type`/... keyword or, within groups, the spec)
+    """
+    end: int
+    """
+    the offset after the end of the declaration
+    """
+    name_start: int
+    """
+    the offset of the declared identifier
+    """
+    doc: str | None = None
+    """
+    the text of the doc comment (without comment markers) or None if there is no doc comment
+    """
+    doc_start: int | None = None
+    """
+    the offset at which the doc comment starts (if any)
+    """
+    receiver_name: str | None = None
+    """
+    for methods, the name of the receiver variable (None if the receiver is unnamed)
+    """
+    receiver_type: str | None = None
+    """
+    for methods, the name of the receiver's base type (without pointer and type parameters)
+    """
+    receiver_is_pointer: bool = False
+    signature_end: int | None = None
+    """
+    for functions and methods, the offset after the end of the signature (i.e. before the body's opening brace)
+    """
+    body_start: int | None = None
+    """
+    for functions and methods with a body, the offset of the body's opening brace
+    """
+    type_params: str | None = None
+    """
+    for generic types and functions, the type parameter list including brackets
+    """
+    is_alias: bool = False
+    """
+    for types, whether the declaration is an alias declaration (`type A = B`)
+    """
+    type_expr: str | None = None
+    """
+    for types, the type expression defining the type; for variables and constants, the declared type (if any)
+    """
+    type_expr_start: int | None = None
+    """
+    for types, the offset at which the type expression starts
+    """
+    in_group: bool = False
+    """
+    whether the declaration is a spec within a parenthesised declaration group
+    """
+    group_doc: str | None = None
+    """
+    for specs within groups, the text of the group's doc comment (if any), which documents all of the group's specs
+    """
+
+    @property
+    def is_exported(self) -> bool:
+        return is_exported(self.name)
+
+    @property
+    def deprecation_message(self) -> str | None:
+        """
+        the message of the declaration's deprecation notice (see `get_deprecation_message`) or None if it is not deprecated
+        """
+        return get_deprecation_message(self.doc)
+
+
+@dataclass
+class GoImport:
+    path: str
+    alias: str | None
+    start: int
+    end: int
+
+    def get_package_name(self) -> str:
+        """
+        :return: the name under which the package is referenced in the importing file (heuristically derived
+            from the import path if no alias is given)
+        """
+        if self.alias is not None:
+            return self.alias
+        last_segment = self.path.rstrip("/").split("/")[-1]
+        if re.fullmatch(r"v\d+", last_segment) and "/" in self.path:
+            last_segment = self.path.rstrip("/").split("/")[-2]
+        return last_segment.removeprefix("go-").replace("-", "_").replace(".", "_")
+
+
+@dataclass
+class GoFile:
+    source: str
+    package_name: str | None = None
+    package_clause_start: int | None = None
+    imports: list[GoImport] = field(default_factory=list)
+    declarations: list[GoDeclaration] = field(default_factory=list)
+    _line_starts: list[int] = field(default_factory=list, repr=False)
+    _tokens: list[GoToken] | None = field(default=None, repr=False, compare=False)
+    """
+    the tokens of the source without comments (computed lazily, see `get_tokens`)
+    """
+    _tokens_with_comments: list[GoToken] | None = field(default=None, repr=False, compare=False)
+
+    def __post_init__(self) -> None:
+        self._line_starts = [0] + [m.end() for m in re.finditer("\n", self.source)]
+
+    def get_tokens(self, start: int = 0, end: int | None = None, include_comments: bool = False) -> list[GoToken]:
+        """
+        Gets the tokens of the source which start in the given range.
+        The source is tokenized only once; subsequent calls slice the cached tokens.
+
+        :param start: the offset from which to include tokens
+        :param end: the offset before which tokens must start; None for the end of the source
+        :param include_comments: whether to include comment tokens
+        :return: the tokens starting at offsets in the range [start, end)
+        """
+        if self._tokens_with_comments is None:
+            self._tokens_with_comments = tokenize(self.source, include_comments=True)
+        if include_comments:
+            tokens = self._tokens_with_comments
+        else:
+            if self._tokens is None:
+                self._tokens = [t for t in self._tokens_with_comments if t.kind != GoTokenKind.COMMENT]
+            tokens = self._tokens
+        if start <= 0 and end is None:
+            return list(tokens)
+        start_idx = bisect.bisect_left(tokens, start, key=lambda t: t.start)
+        end_idx = len(tokens) if end is None else bisect.bisect_left(tokens, end, lo=start_idx, key=lambda t: t.start)
+        return tokens[start_idx:end_idx]
+
+    def get_line_and_column(self, offset: int) -> tuple[int, int]:
+        """
+        :param offset: an offset in the source
+        :return: the 0-based line and column of the offset
+        """
+        line = bisect.bisect_right(self._line_starts, offset) - 1
+        return line, offset - self._line_starts[line]
+
+    def get_offset(self, line: int, column: int) -> int:
+        """
+        :param line: a 0-based line
+        :param column: a 0-based column
+        :return: the offset in the source
+        """
+        if line >= len(self._line_starts):
+            return len(self.source)
+        return min(self._line_starts[line] + column, len(self.source))
+
+    def get_text(self, start: int, end: int) -> str:
+        return self.source[start:end]
+
+    def is_generated(self) -> bool:
+        """
+        :return: whether the file carries the standard header of generated files before its package clause
+        """
+        header_end = self.package_clause_start if self.package_clause_start is not None else len(self.source)
+        return GENERATED_CODE_PATTERN.search(self.source, 0, header_end) is not None
+
+    def get_build_constraint(self) -> str | None:
+        """
+        :return: the expression of the file's `//go:build` constraint (e.g. "linux && amd64") or None if it has none
+        """
+        header_end = self.package_clause_start if self.package_clause_start is not None else len(self.source)
+        match = _BUILD_CONSTRAINT_PATTERN.search(self.source, 0, header_end)
+        return match.group(1) if match is not None else None
+
+    def get_declaration_text(self, declaration: GoDeclaration) -> str:
+        return self.source[declaration.start : declaration.end]
+
+    def get_signature_text(self, declaration: GoDeclaration) -> str | None:
+        """
+        :return: for functions and methods, the text of the signature (from the `func` keyword up to the body)
+        """
+        if declaration.signature_end is None:
+            return None
+        return self.source[declaration.start : declaration.signature_end].strip()
+
+    def get_parameters_and_results_text(self, declaration: GoDeclaration) -> str | None:
+        """
+        :return: for functions and methods, the part of the signature following the name, e.g. "(item string) error"
+        """
+        if declaration.signature_end is None:
+            return None
+        return self.source[declaration.name_start + len(declaration.name) : declaration.signature_end].strip()
+
+    def get_receiver_type_text(self, declaration: GoDeclaration) -> str | None:
+        """
+        :return: for methods, the type of the receiver as declared but without the pointer, e.g. "Container[T]"
+            for the receiver `(c *Container[T])`
+        """
+        if declaration.kind != GoDeclarationKind.METHOD:
+            return None
+        receiver_source = self.source[declaration.start + len("func") : declaration.name_start]
+        tokens = tokenize(receiver_source)
+        if not tokens or not tokens[0].is_operator("("):
+            return None
+        type_tokens = tokens[1 : find_matching_bracket(tokens, 0)]
+        if declaration.receiver_name is not None:
+            type_tokens = type_tokens[1:]
+        if type_tokens and type_tokens[0].is_operator("*"):
+            type_tokens = type_tokens[1:]
+        return _normalize_whitespace(type_tokens, receiver_source)
+
+    def iter_declarations(self, *kinds: GoDeclarationKind) -> Iterator[GoDeclaration]:
+        for declaration in self.declarations:
+            if not kinds or declaration.kind in kinds:
+                yield declaration
+
+    def find_declaration(self, name: str, receiver_type: str | None = None) -> GoDeclaration | None:
+        """
+        :param name: the name of the declared entity
+        :param receiver_type: for methods, the receiver's base type; None to search among non-methods
+        :return: the first matching declaration or None
+        """
+        for declaration in self.declarations:
+            if declaration.name == name and declaration.receiver_type == receiver_type:
+                return declaration
+        return None
+
+    def find_declaration_at_line(self, line: int) -> GoDeclaration | None:
+        """
+        :param line: a 0-based line
+        :return: the declaration whose identifier is located in the given line (if any)
+        """
+        for declaration in self.declarations:
+            if self.get_line_and_column(declaration.name_start)[0] == line:
+                return declaration
+        return None
+
+
+def is_exported(name: str) -> bool:
+    """
+    :param name: an identifier
+    :return: whether the identifier is exported (i.e. starts with an upper-case letter)
+    """
+    return bool(name) and name[0].isupper()
+
+
+def get_deprecation_message(doc: str | None) -> str | None:
+    """
+    Extracts the deprecation notice from a doc comment, i.e. a paragraph starting with "Deprecated: ".
+
+    :param doc: the text of a doc comment (without comment markers)
+    :return: the message of the deprecation notice with the lines joined by spaces (e.g. "use Name directly."),
+        an empty string if the notice has no message, or None if the doc comment contains no deprecation notice
+    """
+    if doc is None:
+        return None
+    for paragraph in re.split(r"\n\s*\n", doc):
+        if paragraph.startswith("Deprecated:"):
+            return " ".join(line.strip() for line in paragraph[len("Deprecated:") :].split("\n")).strip()
+    return None
+
+
+def _get_doc_comment(
+    source: str, comments: list[GoToken], comment_starts: list[int], declaration_start: int, declaration_line: int
+) -> tuple[str | None, int | None]:
+    """
+    Determines the doc comment of a declaration, i.e. the group of comments directly preceding it
+    (without intervening empty lines).
+    """
+    idx = bisect.bisect_left(comment_starts, declaration_start) - 1
+    group: list[GoToken] = []
+    expected_end_line = declaration_line - 1
+    while idx >= 0:
+        comment = comments[idx]
+        if comment.end_line != expected_end_line:
+            break
+        # the comment must occupy its line(s) on its own
+        line_start = source.rfind("\n", 0, comment.start) + 1
+        if source[line_start : comment.start].strip():
+            break
+        group.insert(0, comment)
+        expected_end_line = comment.line - 1
+        idx -= 1
+    if not group:
+        return None, None
+    lines: list[str] = []
+    for comment in group:
+        if comment.text.startswith("//"):
+            # directives such as //go:generate are not part of the documentation
+            if _DIRECTIVE_PATTERN.match(comment.text):
+                continue
+            text = comment.text[2:]
+            lines.append(text[1:] if text.startswith(" ") else text)
+        else:
+            for text in comment.text[2:-2].strip("\n").split("\n"):
+                lines.append(text.strip())
+    if not lines:
+        return None, None
+    return "\n".join(lines), group[0].start
+
+
+def _parse_receiver(tokens: list[GoToken]) -> tuple[str | None, str | None, bool]:
+    """
+    :param tokens: the tokens within the receiver's parentheses
+    :return: a tuple (receiver name, receiver base type, is_pointer)
+    """
+    if not tokens:
+        return None, None, False
+    type_tokens = tokens
+    receiver_name = None
+    if len(tokens) > 1 and tokens[0].is_identifier() and not tokens[1].is_operator("[", "."):
+        receiver_name = tokens[0].text
+        type_tokens = tokens[1:]
+    is_pointer = bool(type_tokens) and type_tokens[0].is_operator("*")
+    base_type = next((t.text for t in type_tokens if t.is_identifier()), None)
+    return receiver_name, base_type, is_pointer
+
+
+def _is_type_parameter_list(tokens: list[GoToken], open_idx: int, close_idx: int) -> bool:
+    """
+    Distinguishes type parameter lists (`type A[T any] ...`) from array lengths (`type A [N]int`).
+    """
+    inner = tokens[open_idx + 1 : close_idx]
+    return len(inner) >= 2 and inner[0].is_identifier() and (inner[1].is_identifier() or inner[1].is_operator(",", "*", "~", "["))
+
+
+class _GoParser:
+    def __init__(self, source: str):
+        self.source = source
+        all_tokens = tokenize(source, include_comments=True)
+        self.comments = [t for t in all_tokens if t.kind == GoTokenKind.COMMENT]
+        self.comment_starts = [t.start for t in self.comments]
+        self.tokens = [t for t in all_tokens if t.kind != GoTokenKind.COMMENT]
+        self.file = GoFile(source=source, _tokens=self.tokens, _tokens_with_comments=all_tokens)
+
+    def parse(self) -> GoFile:
+        tokens = self.tokens
+        i = 0
+        while i < len(tokens):
+            token = tokens[i]
+            end = find_statement_end(tokens, i)
+            if token.is_identifier("package") and i + 1 < len(tokens):
+                self.file.package_name = tokens[i + 1].text
+                self.file.package_clause_start = token.start
+            elif token.is_identifier("import"):
+                self._parse_imports(i + 1, end)
+            elif token.is_identifier("func"):
+                self._parse_function(i, end)
+            elif token.is_identifier("type", "var", "const"):
+                self._parse_declaration_group(i, end)
+            i = end + 1
+        return self.file
+
+    def _set_doc(self, declaration: GoDeclaration, start_token: GoToken) -> None:
+        declaration.doc, declaration.doc_start = _get_doc_comment(
+            self.source, self.comments, self.comment_starts, start_token.start, start_token.line
+        )
+
+    def _parse_imports(self, start: int, end: int) -> None:
+        tokens = self.tokens
+        if start > end:
+            return
+        if tokens[start].is_operator("("):
+            close = find_matching_bracket(tokens, start)
+            i = start + 1
+            while i < close:
+                spec_end = min(find_statement_end(tokens, i), close - 1)
+                self._parse_import_spec(i, spec_end)
+                i = spec_end + 1
+                while i < close and tokens[i].is_operator(";"):
+                    i += 1
+        else:
+            self._parse_import_spec(start, end)
+
+    def _parse_import_spec(self, start: int, end: int) -> None:
+        spec = self.tokens[start : end + 1]
+        path_token = next((t for t in spec if t.kind == GoTokenKind.STRING), None)
+        if path_token is None:
+            return
+        alias = spec[0].text if spec[0] is not path_token else None
+        self.file.imports.append(GoImport(path=path_token.text.strip('"`'), alias=alias, start=spec[0].start, end=path_token.end))
+
+    def _parse_function(self, start: int, end: int) -> None:
+        tokens = self.tokens
+        i = start + 1
+        receiver_name, receiver_type, receiver_is_pointer = None, None, False
+        kind = GoDeclarationKind.FUNCTION
+        if i <= end and tokens[i].is_operator("("):
+            close = find_matching_bracket(tokens, i)
+            receiver_name, receiver_type, receiver_is_pointer = _parse_receiver(tokens[i + 1 : close])
+            kind = GoDeclarationKind.METHOD
+            i = close + 1
+        if i > end or not tokens[i].is_identifier():
+            return
+        name_token = tokens[i]
+        type_params = None
+        if i + 1 <= end and tokens[i + 1].is_operator("["):
+            close = find_matching_bracket(tokens, i + 1)
+            type_params = self.source[tokens[i + 1].start : tokens[close].end]
+        # the body is the block closed by the declaration's last token
+        signature_end = tokens[end].end
+        body_start = None
+        if tokens[end].is_operator("}"):
+            depth = 0
+            for j in range(end, i, -1):
+                if tokens[j].is_operator(")", "]", "}"):
+                    depth += 1
+                elif tokens[j].is_operator("(", "[", "{"):
+                    depth -= 1
+                    if depth == 0:
+                        body_start = tokens[j].start
+                        signature_end = tokens[j - 1].end
+                        break
+        declaration = GoDeclaration(
+            kind=kind,
+            name=name_token.text,
+            start=tokens[start].start,
+            end=tokens[end].end,
+            name_start=name_token.start,
+            receiver_name=receiver_name,
+            receiver_type=receiver_type,
+            receiver_is_pointer=receiver_is_pointer,
+            signature_end=signature_end,
+            body_start=body_start,
+            type_params=type_params,
+        )
+        self._set_doc(declaration, tokens[start])
+        self.file.declarations.append(declaration)
+
+    def _parse_declaration_group(self, start: int, end: int) -> None:
+        tokens = self.tokens
+        keyword = tokens[start].text
+        kind = {"type": GoDeclarationKind.TYPE, "var": GoDeclarationKind.VARIABLE, "const": GoDeclarationKind.CONSTANT}[keyword]
+        if start + 1 > end:
+            return
+        if tokens[start + 1].is_operator("("):
+            close = find_matching_bracket(tokens, start + 1)
+            group_doc, _ = _get_doc_comment(self.source, self.comments, self.comment_starts, tokens[start].start, tokens[start].line)
+            num_declarations = len(self.file.declarations)
+            i = start + 2
+            while i < close:
+                spec_end = min(find_statement_end(tokens, i), close - 1)
+                self._parse_spec(kind, i, spec_end, tokens[i], in_group=True)
+                i = spec_end + 1
+                while i < close and tokens[i].is_operator(";"):
+                    i += 1
+            for declaration in self.file.declarations[num_declarations:]:
+                declaration.group_doc = group_doc
+        else:
+            self._parse_spec(kind, start + 1, end, tokens[start], in_group=False)
+
+    def _parse_spec(self, kind: GoDeclarationKind, start: int, end: int, first_token: GoToken, in_group: bool) -> None:
+        tokens = self.tokens
+        if not tokens[start].is_identifier():
+            return
+        if kind == GoDeclarationKind.TYPE:
+            name_token = tokens[start]
+            i = start + 1
+            type_params = None
+            if i <= end and tokens[i].is_operator("["):
+                close = find_matching_bracket(tokens, i)
+                if _is_type_parameter_list(tokens, i, close):
+                    type_params = self.source[tokens[i].start : tokens[close].end]
+                    i = close + 1
+            is_alias = i <= end and tokens[i].is_operator("=")
+            if is_alias:
+                i += 1
+            type_expr = self.source[tokens[i].start : tokens[end].end] if i <= end else ""
+            declaration = GoDeclaration(
+                kind=kind,
+                name=name_token.text,
+                start=first_token.start,
+                end=tokens[end].end,
+                name_start=name_token.start,
+                type_params=type_params,
+                is_alias=is_alias,
+                type_expr=type_expr,
+                type_expr_start=tokens[i].start if i <= end else None,
+                in_group=in_group,
+            )
+            self._set_doc(declaration, first_token)
+            self.file.declarations.append(declaration)
+        else:
+            # variable/constant spec: one or more names, optionally followed by a type and/or values
+            names: list[GoToken] = []
+            i = start
+            while i <= end and tokens[i].is_identifier():
+                names.append(tokens[i])
+                if i + 1 <= end and tokens[i + 1].is_operator(","):
+                    i += 2
+                else:
+                    i += 1
+                    break
+            type_end = i
+            while type_end <= end and not tokens[type_end].is_operator("="):
+                type_end += 1
+            type_expr = self.source[tokens[i].start : tokens[type_end - 1].end] if type_end > i else None
+            for name_token in names:
+                declaration = GoDeclaration(
+                    kind=kind,
+                    name=name_token.text,
+                    start=first_token.start,
+                    end=tokens[end].end,
+                    name_start=name_token.start,
+                    type_expr=type_expr,
+                    in_group=in_group,
+                )
+                self._set_doc(declaration, first_token)
+                self.file.declarations.append(declaration)
+
+
+def parse_go_file(source: str) -> GoFile:
+    """
+    Parses the top-level structure of a Go source file.
+
+    :param source: the source code
+    :return: the parsed file
+    """
+    return _GoParser(source).parse()
+
+
+def _unquote_go_work_path(path: str) -> str:
+    if len(path) >= 2 and path[0] == path[-1] and path[0] in "\"`":
+        return path[1:-1]
+    return path
+
+
+def parse_go_work_use_directives(source: str) -> list[str]:
+    """
+    Parses the `use` directives of a `go.work` file, which name the directories of the workspace's modules,
+    e.g. `use ./api` or a block `use ( ./api ./store )` with one directory per line.
+
+    :param source: the content of the `go.work` file
+    :return: the module directories in the order of the directives, as written in the file (i.e. relative to the
+        directory containing the `go.work` file unless absolute)
+    """
+    module_dirs = []
+    in_block = False
+    for line in source.splitlines():
+        line = _GO_WORK_COMMENT_PATTERN.sub("", line).strip()
+        if in_block:
+            if line == ")":
+                in_block = False
+            elif line:
+                module_dirs.append(_unquote_go_work_path(line))
+            continue
+        match = _GO_WORK_USE_PATTERN.match(line)
+        if match is None:
+            continue
+        argument = match.group(1)
+        if argument == "(":
+            in_block = True
+        elif argument.startswith("(") and argument.endswith(")"):
+            # a block on a single line, e.g. `use (./api)`
+            if argument[1:-1].strip():
+                module_dirs.append(_unquote_go_work_path(argument[1:-1].strip()))
+        elif argument:
+            module_dirs.append(_unquote_go_work_path(argument))
+    return module_dirs
+
+
+class GoUnderlyingKind(Enum):
+    STRUCT = "struct"
+    INTERFACE = "interface"
+    MAP = "map"
+    SLICE = "slice"
+    ARRAY = "array"
+    FUNC = "func"
+    CHAN = "chan"
+    POINTER = "pointer"
+    BASIC = "basic"
+    NAMED = "named"
+    """
+    the type is defined in terms of another named type, whose underlying kind could not be determined
+    """
+
+
+def classify_type_expression(type_expr: str) -> GoUnderlyingKind:
+    """
+    Classifies a Go type expression by the kind of type it denotes.
+    Named types (other than predeclared ones) are classified as `NAMED`; they need to be resolved by the caller.
+
+    :param type_expr: the type expression, e.g. "[]byte" or "struct { X int }"
+    :return: the kind of the type
+    """
+    tokens = tokenize(type_expr)
+    while tokens and tokens[0].is_operator("("):
+        # parenthesised type
+        tokens = tokens[1 : find_matching_bracket(tokens, 0)]
+    if not tokens:
+        return GoUnderlyingKind.NAMED
+    first = tokens[0]
+    if first.is_identifier("struct"):
+        return GoUnderlyingKind.STRUCT
+    if first.is_identifier("interface", "any", "error"):
+        return GoUnderlyingKind.INTERFACE
+    if first.is_identifier("map"):
+        return GoUnderlyingKind.MAP
+    if first.is_identifier("func"):
+        return GoUnderlyingKind.FUNC
+    if first.is_identifier("chan") or first.is_operator("<-"):
+        return GoUnderlyingKind.CHAN
+    if first.is_operator("*"):
+        return GoUnderlyingKind.POINTER
+    if first.is_operator("["):
+        if len(tokens) > 1 and tokens[1].is_operator("]"):
+            return GoUnderlyingKind.SLICE
+        return GoUnderlyingKind.ARRAY
+    if first.is_identifier() and len(tokens) == 1 and first.text in BASIC_TYPES:
+        return GoUnderlyingKind.BASIC
+    return GoUnderlyingKind.NAMED
+
+
+def get_named_type_identifier(type_expr: str) -> tuple[str | None, str] | None:
+    """
+    :param type_expr: a type expression referring to a named type, e.g. "Base", "pkg.Base" or "Container[int]"
+    :return: a tuple (package qualifier or None, type name) or None if the expression does not refer to a named type
+    """
+    tokens = tokenize(type_expr)
+    if not tokens or not tokens[0].is_identifier():
+        return None
+    if len(tokens) >= 3 and tokens[1].is_operator(".") and tokens[2].is_identifier():
+        return tokens[0].text, tokens[2].text
+    return None, tokens[0].text
+
+
+@dataclass
+class GoStructField:
+    """
+    A field (or embedded type) within a struct type
+    """
+
+    name: str
+    """
+    the field name; for embedded fields, the name of the embedded type (which is also the implicit field name)
+    """
+    type_expr: str
+    tag: str | None
+    """
+    the raw tag literal (including quotes) or None if the field has no tag
+    """
+    embedded: bool
+    start: int
+    """
+    the offset at which the field declaration starts (relative to the parsed type expression)
+    """
+    end: int
+    """
+    the offset after the end of the field declaration, including the tag (relative to the parsed type expression)
+    """
+
+    @property
+    def is_pointer(self) -> bool:
+        return self.type_expr.startswith("*")
+
+    @property
+    def is_exported(self) -> bool:
+        return is_exported(self.name)
+
+
+def parse_struct_fields(type_expr: str) -> list[GoStructField]:
+    """
+    Parses the fields of a struct type expression. Declarations introducing several fields (`X, Y int`)
+    are reported as one field per name.
+
+    :param type_expr: a struct type expression, e.g. "struct {\n\tBaseStruct\n\tValue int\n}"
+    :return: the list of fields in declaration order; empty if the expression is not a struct type
+    """
+    tokens = tokenize(type_expr)
+    if len(tokens) < 2 or not tokens[0].is_identifier("struct") or not tokens[1].is_operator("{"):
+        return []
+    close = find_matching_bracket(tokens, 1)
+    fields: list[GoStructField] = []
+    i = 2
+    while i < close:
+        if tokens[i].is_operator(";"):
+            i += 1
+            continue
+        end = min(find_statement_end(tokens, i), close - 1)
+        field_tokens = tokens[i : end + 1]
+        i = end + 1
+        tag = None
+        if len(field_tokens) > 1 and field_tokens[-1].kind == GoTokenKind.STRING:
+            tag = field_tokens[-1].text
+            field_tokens = field_tokens[:-1]
+        start_offset, end_offset = field_tokens[0].start, tokens[end].end
+        if _is_embedded_field(field_tokens):
+            type_text = type_expr[field_tokens[0].start : field_tokens[-1].end]
+            type_name = [t.text for t in field_tokens if t.is_identifier()]
+            # for qualified types (pkg.Type), the implicit field name is the unqualified type name
+            name = type_name[1] if len(field_tokens) > 1 and any(t.is_operator(".") for t in field_tokens) else type_name[0]
+            fields.append(GoStructField(name=name, type_expr=type_text, tag=tag, embedded=True, start=start_offset, end=end_offset))
+            continue
+        names: list[str] = []
+        j = 0
+        while j < len(field_tokens) and field_tokens[j].is_identifier():
+            names.append(field_tokens[j].text)
+            if j + 1 < len(field_tokens) and field_tokens[j + 1].is_operator(","):
+                j += 2
+            else:
+                j += 1
+                break
+        if j >= len(field_tokens):
+            continue
+        type_text = type_expr[field_tokens[j].start : field_tokens[-1].end]
+        for name in names:
+            fields.append(GoStructField(name=name, type_expr=type_text, tag=tag, embedded=False, start=start_offset, end=end_offset))
+    return fields
+
+
+def _is_embedded_field(field_tokens: list[GoToken]) -> bool:
+    """
+    Determines whether the tokens of a field declaration (without tag) declare an embedded field,
+    i.e. consist of a (possibly qualified, possibly pointer, possibly instantiated) type name only.
+    """
+    tokens = field_tokens[1:] if field_tokens and field_tokens[0].is_operator("*") else field_tokens
+    if not tokens or not tokens[0].is_identifier():
+        return False
+    if len(tokens) == 1:
+        return True
+    i = 1
+    if tokens[i].is_operator(".") and len(tokens) > 2 and tokens[2].is_identifier():
+        i = 3
+        if i == len(tokens):
+            return True
+    if tokens[i].is_operator("["):
+        # a generic instantiation ends the declaration; otherwise, the brackets belong to an array/slice field type
+        return find_matching_bracket(tokens, i) == len(tokens) - 1
+    return False
+
+
+@dataclass
+class GoInterfaceElement:
+    """
+    An element of an interface type: a method or an embedded type (e.g. an embedded interface or a type constraint term)
+    """
+
+    text: str
+    """
+    the text of the element with normalized whitespace, e.g. "Process() error" or "Processable"
+    """
+    method_name: str | None
+    """
+    the name of the method or None for embedded types
+    """
+    start: int
+    """
+    the offset at which the element starts (relative to the parsed type expression)
+    """
+    end: int
+    """
+    the offset after the end of the element (relative to the parsed type expression)
+    """
+
+    @property
+    def is_method(self) -> bool:
+        return self.method_name is not None
+
+    def get_signature(self) -> str:
+        """
+        :return: for methods, the signature without the method name, e.g. "() error"
+        """
+        assert self.method_name is not None
+        return self.text[len(self.method_name) :]
+
+
+def _normalize_whitespace(tokens: list[GoToken], source: str) -> str:
+    """
+    Concatenates the given tokens, separating them by single spaces only where the original source contains whitespace
+    """
+    parts = []
+    for i, token in enumerate(tokens):
+        if i > 0 and token.start > tokens[i - 1].end:
+            parts.append(" ")
+        parts.append(source[token.start : token.end])
+    return "".join(parts)
+
+
+def parse_interface_elements(type_expr: str) -> list[GoInterfaceElement]:
+    """
+    :param type_expr: an interface type expression, e.g. "interface {\n\tProcessable\n\tExecute()\n}"
+    :return: the elements of the interface in declaration order; empty if the expression is not an interface type
+    """
+    tokens = tokenize(type_expr)
+    if len(tokens) < 2 or not tokens[0].is_identifier("interface") or not tokens[1].is_operator("{"):
+        return []
+    close = find_matching_bracket(tokens, 1)
+    elements: list[GoInterfaceElement] = []
+    i = 2
+    while i < close:
+        if tokens[i].is_operator(";"):
+            i += 1
+            continue
+        end = min(find_statement_end(tokens, i), close - 1)
+        element_tokens = tokens[i : end + 1]
+        i = end + 1
+        is_method = len(element_tokens) > 1 and element_tokens[0].is_identifier() and element_tokens[1].is_operator("(")
+        elements.append(
+            GoInterfaceElement(
+                text=_normalize_whitespace(element_tokens, type_expr),
+                method_name=element_tokens[0].text if is_method else None,
+                start=element_tokens[0].start,
+                end=element_tokens[-1].end,
+            )
+        )
+    return elements
+
+
+
+def _split_at_commas(tokens: list[GoToken]) -> list[list[GoToken]]:
+    """
+    :return: the groups of tokens separated by the commas which are not nested within brackets
+    """
+    groups: list[list[GoToken]] = []
+    current: list[GoToken] = []
+    depth = 0
+    for token in tokens:
+        if token.is_operator("(", "[", "{"):
+            depth += 1
+        elif token.is_operator(")", "]", "}"):
+            depth -= 1
+        elif token.is_operator(",") and depth == 0:
+            groups.append(current)
+            current = []
+            continue
+        current.append(token)
+    if current:
+        groups.append(current)
+    return groups
+
+
+def split_expression_list(expression_list: str) -> list[str]:
+    """
+    :param expression_list: a comma-separated list of expressions, e.g. the arguments of a call
+    :return: the expressions (with normalized whitespace)
+    """
+    return [_normalize_whitespace(group, expression_list) for group in _split_at_commas(tokenize(expression_list))]
+
+
+def _is_named_parameter(parameter_tokens: list[GoToken]) -> bool:
+    if len(parameter_tokens) < 2 or not parameter_tokens[0].is_identifier() or parameter_tokens[0].text in KEYWORDS:
+        return False
+    second = parameter_tokens[1]
+    if second.is_operator("."):
+        return False
+    # a generic type instantiation such as `List[int]` (as opposed to a named parameter such as `items []int`)
+    return not (second.is_operator("[") and len(parameter_tokens) > 2 and parameter_tokens[2].is_identifier())
+
+
+def _get_parameter_types(tokens: list[GoToken], open_idx: int, source: str) -> tuple[list[str], int]:
+    """
+    :param tokens: the tokens of a signature
+    :param open_idx: the index of the parameter list's opening parenthesis
+    :param source: the source of the tokens
+    :return: a tuple (list of parameter types, index of the closing parenthesis)
+    """
+    close_idx = find_matching_bracket(tokens, open_idx)
+    parameters = _split_at_commas(tokens[open_idx + 1 : close_idx])
+    if not any(_is_named_parameter(p) for p in parameters):
+        return [_normalize_whitespace(p, source) for p in parameters], close_idx
+    # parameters are named; in a group such as `a, b int`, the names without a type share the type that follows
+    types: list[str] = []
+    pending_names = 0
+    for parameter in parameters:
+        if len(parameter) == 1:
+            pending_names += 1
+            continue
+        parameter_type = _normalize_whitespace(parameter[1:], source)
+        types.extend([parameter_type] * (pending_names + 1))
+        pending_names = 0
+    return types, close_idx
+
+
+def get_parameter_and_result_types(signature: str) -> tuple[list[str], list[str]]:
+    """
+    :param signature: the signature of a method or function without the `func` keyword and name,
+        e.g. "(data []byte) (n int, err error)"
+    :return: a tuple (parameter types, result types), e.g. (["[]byte"], ["int", "error"])
+    """
+    tokens = tokenize(signature)
+    if not tokens or not tokens[0].is_operator("("):
+        raise ValueError(f"Not a signature: {signature}")
+    parameter_types, close_idx = _get_parameter_types(tokens, 0, signature)
+    result_tokens = tokens[close_idx + 1 :]
+    if result_tokens and result_tokens[0].is_operator("("):
+        result_types, _ = _get_parameter_types(tokens, close_idx + 1, signature)
+    elif result_tokens:
+        result_types = [_normalize_whitespace(result_tokens, signature)]
+    else:
+        result_types = []
+    return parameter_types, result_types
+
+
+def get_parameter_names(signature: str) -> list[str | None]:
+    """
+    :param signature: the signature of a method or function without the `func` keyword and name, e.g. "(a, b int, c string)"
+    :return: the names of the parameters, e.g. ["a", "b", "c"]; None for each parameter if the parameters are unnamed
+    """
+    tokens = tokenize(signature)
+    if not tokens or not tokens[0].is_operator("("):
+        raise ValueError(f"Not a signature: {signature}")
+    parameters = _split_at_commas(tokens[1 : find_matching_bracket(tokens, 0)])
+    if not any(_is_named_parameter(p) for p in parameters):
+        return [None] * len(parameters)
+    return [p[0].text for p in parameters]
+
+
+def normalize_method_signature(signature: str) -> str:
+    """
+    Normalizes the signature of a method or function type by removing the names of parameters and results,
+    such that signatures can be compared for identity.
+
+    :param signature: the signature without the `func` keyword and name, e.g. "(data []byte) (n int, err error)"
+    :return: the normalized signature, e.g. "([]byte) (int, error)"
+    """
+    parameter_types, result_types = get_parameter_and_result_types(signature)
+    normalized = "(" + ", ".join(parameter_types) + ")"
+    if len(result_types) == 1:
+        normalized += " " + result_types[0]
+    elif result_types:
+        normalized += " (" + ", ".join(result_types) + ")"
+    return normalized
+
+
+def get_zero_value_literal(type_name: str, underlying_kind: GoUnderlyingKind, type_expr: str | None = None) -> str:
+    """
+    :param type_name: the name of a (named) type
+    :param underlying_kind: the kind of the type's underlying type
+    :param type_expr: the underlying type expression (used to distinguish basic types)
+    :return: an expression constructing the zero value of the named type
+    """
+    if underlying_kind in (GoUnderlyingKind.STRUCT, GoUnderlyingKind.SLICE, GoUnderlyingKind.MAP, GoUnderlyingKind.ARRAY):
+        return f"{type_name}{{}}"
+    if underlying_kind == GoUnderlyingKind.BASIC and type_expr is not None:
+        basic_type = type_expr.strip()
+        if basic_type == "string":
+            return f'{type_name}("")'
+        if basic_type == "bool":
+            return f"{type_name}(false)"
+        return f"{type_name}(0)"
+    return f"{type_name}(nil)"
+
+
+@dataclass
+class GoTextEdit:
+    """
+    A replacement of the text between two offsets of a source
+    """
+
+    start: int
+    end: int
+    new_text: str
+
+    def apply(self, source: str) -> str:
+        """
+        :param source: the source to which the edit's offsets refer
+        :return: the source with the edit applied
+        """
+        return source[: self.start] + self.new_text + source[self.end :]
+
+
+def _get_line_start(source: str, offset: int) -> int:
+    return source.rfind("\n", 0, offset) + 1
+
+
+def _get_indentation(source: str, offset: int) -> str:
+    line_start = _get_line_start(source, offset)
+    line = source[line_start:offset]
+    return line[: len(line) - len(line.lstrip())]
+
+
+def _get_struct_body(declaration: GoDeclaration) -> tuple[int, int, list[GoStructField]]:
+    """
+    :return: a tuple (offset of the opening brace, offset of the closing brace, fields with absolute offsets)
+    """
+    type_expr = declaration.type_expr
+    if type_expr is None or declaration.type_expr_start is None or classify_type_expression(type_expr) != GoUnderlyingKind.STRUCT:
+        raise ValueError(f"{declaration.name} is not a struct type")
+    base = declaration.type_expr_start
+    tokens = tokenize(type_expr)
+    close = find_matching_bracket(tokens, 1)
+    fields = [
+        GoStructField(name=f.name, type_expr=f.type_expr, tag=f.tag, embedded=f.embedded, start=base + f.start, end=base + f.end)
+        for f in parse_struct_fields(type_expr)
+    ]
+    return base + tokens[1].start, base + tokens[close].start, fields
+
+
+def _get_field_line_start(source: str, open_offset: int, field: GoStructField) -> int:
+    """
+    :return: the offset of the start of the line containing the given field or, if the field is preceded by comment lines,
+        the start of the first of these lines
+    """
+    start = _get_line_start(source, field.start)
+    while start > open_offset + 1:
+        previous_line_start = _get_line_start(source, start - 1)
+        if not source[previous_line_start : start - 1].strip().startswith("//"):
+            break
+        start = previous_line_start
+    return start
+
+
+def _get_field_declaration_texts(go_file: GoFile, fields: list[GoStructField]) -> list[str]:
+    spans = sorted({(f.start, f.end) for f in fields})
+    return [go_file.source[start:end] for start, end in spans]
+
+
+def get_struct_field_insertion(go_file: GoFile, declaration: GoDeclaration, field_text: str, position: int = -1) -> GoTextEdit:
+    """
+    Determines the edit which adds a field declaration to a struct type.
+    The result is not necessarily aligned as required by gofmt, i.e. it should be formatted afterwards.
+
+    :param go_file: the file containing the struct type
+    :param declaration: the declaration of the struct type
+    :param field_text: the field declaration to add, e.g. "Created time.Time"
+    :param position: the index of the field before which to insert the new field; -1 to append the field
+    :return: the edit
+    """
+    open_offset, close_offset, fields = _get_struct_body(declaration)
+    source = go_file.source
+    declaration_indent = _get_indentation(source, declaration.name_start)
+    if fields and "\n" in source[open_offset : fields[0].start]:
+        field_indent = _get_indentation(source, fields[0].start)
+    else:
+        field_indent = declaration_indent + "\t"
+    is_multi_line = "\n" in source[open_offset:close_offset]
+    if not is_multi_line:
+        # rewrite single-line structs (e.g. `struct{}`), placing one field declaration per line
+        field_texts = _get_field_declaration_texts(go_file, fields)
+        insertion_index = len(field_texts) if position < 0 else min(position, len(field_texts))
+        field_texts.insert(insertion_index, field_text)
+        body = "".join(f"{field_indent}{t}\n" for t in field_texts)
+        return GoTextEdit(open_offset, close_offset + 1, "{\n" + body + declaration_indent + "}")
+    if 0 <= position < len(fields):
+        insertion_offset = _get_field_line_start(source, open_offset, fields[position])
+        return GoTextEdit(insertion_offset, insertion_offset, f"{field_indent}{field_text}\n")
+    return _get_append_to_body_edit(source, close_offset, field_indent, declaration_indent, field_text)
+
+
+def _get_append_to_body_edit(source: str, close_offset: int, member_indent: str, declaration_indent: str, member_text: str) -> GoTextEdit:
+    """
+    :return: the edit which adds a member (line) at the end of a multi-line struct or interface body
+    """
+    if source[_get_line_start(source, close_offset) : close_offset].strip() == "":
+        insertion_offset = _get_line_start(source, close_offset)
+        return GoTextEdit(insertion_offset, insertion_offset, f"{member_indent}{member_text}\n")
+    # the closing brace follows the last member on the same line
+    return GoTextEdit(close_offset, close_offset, f"\n{member_indent}{member_text}\n{declaration_indent}")
+
+
+def get_interface_method_insertion(go_file: GoFile, declaration: GoDeclaration, method_text: str) -> GoTextEdit:
+    """
+    Determines the edit which adds a method (or another element) at the end of an interface type.
+
+    :param go_file: the file containing the interface type
+    :param declaration: the declaration of the interface type
+    :param method_text: the method to add, e.g. "Close() error"
+    :return: the edit
+    """
+    type_expr = declaration.type_expr
+    if type_expr is None or declaration.type_expr_start is None or classify_type_expression(type_expr) != GoUnderlyingKind.INTERFACE:
+        raise ValueError(f"{declaration.name} is not an interface type")
+    tokens = tokenize(type_expr)
+    if len(tokens) < 2 or not tokens[1].is_operator("{"):
+        raise ValueError(f"{declaration.name} is not declared with an interface type literal")
+    base = declaration.type_expr_start
+    open_offset = base + tokens[1].start
+    close_offset = base + tokens[find_matching_bracket(tokens, 1)].start
+    elements = parse_interface_elements(type_expr)
+    source = go_file.source
+    declaration_indent = _get_indentation(source, declaration.name_start)
+    if elements and "\n" in source[open_offset : base + elements[0].start]:
+        member_indent = _get_indentation(source, base + elements[0].start)
+    else:
+        member_indent = declaration_indent + "\t"
+    if "\n" not in source[open_offset:close_offset]:
+        # rewrite single-line interfaces (e.g. `interface{}`), placing one element per line
+        body = "".join(f"{member_indent}{e.text}\n" for e in elements) + f"{member_indent}{method_text}\n"
+        return GoTextEdit(open_offset, close_offset + 1, "{\n" + body + declaration_indent + "}")
+    return _get_append_to_body_edit(source, close_offset, member_indent, declaration_indent, method_text)
+
+
+def get_struct_field_removal(go_file: GoFile, declaration: GoDeclaration, field_name: str) -> GoTextEdit:
+    """
+    Determines the edit which removes a field from a struct type. For a field which is declared together with other fields
+    (`X, Y int`), only its name is removed; otherwise the field's declaration is removed including its comments.
+
+    :param go_file: the file containing the struct type
+    :param declaration: the declaration of the struct type
+    :param field_name: the name of the field to remove (for embedded fields, the name of the embedded type)
+    :return: the edit
+    """
+    open_offset, close_offset, fields = _get_struct_body(declaration)
+    source = go_file.source
+    field = next((f for f in fields if f.name == field_name), None)
+    if field is None:
+        fields_info = f"; its fields are: {', '.join(f.name for f in fields)}" if fields else ""
+        raise ValueError(f"Struct {declaration.name} has no field {field_name}{fields_info}")
+    if len(fields) == 1:
+        return GoTextEdit(open_offset, close_offset + 1, "{}")
+    siblings = [f for f in fields if (f.start, f.end) == (field.start, field.end)]
+    if len(siblings) > 1:
+        names = ", ".join(f.name for f in siblings if f is not field)
+        tag = f" {field.tag}" if field.tag is not None else ""
+        return GoTextEdit(field.start, field.end, f"{names} {field.type_expr}{tag}")
+    if "\n" not in source[open_offset:close_offset]:
+        remaining_texts = [t for t in _get_field_declaration_texts(go_file, fields) if t != source[field.start : field.end]]
+        return GoTextEdit(open_offset, close_offset + 1, "{" + "; ".join(remaining_texts) + "}")
+    start = _get_field_line_start(source, open_offset, field)
+    end = source.find("\n", field.end)
+    end = len(source) if end == -1 else end + 1
+    if end > close_offset:
+        # the closing brace follows the field on the same line
+        return GoTextEdit(field.start, close_offset, "")
+    return GoTextEdit(start, end, "")
+
+
+@dataclass
+class GoObjectSignature:
+    """
+    The signature of a Go object (variable, field, constant, function, type or package) as shown in gopls' hover information,
+    e.g. `var cp *ConcreteProcessor` or `field data []byte`
+    """
+
+    kind: str
+    """
+    the kind of the object, i.e. the leading keyword of the signature ("var", "field", "const", "func", "type" or "package")
+    """
+    name: str
+    type_expr: str | None
+    """
+    the type of the object; for functions and methods, the function type (e.g. "func() error");
+    for types, the type itself; None for packages
+    """
+
+
+def parse_object_signature(signature: str) -> GoObjectSignature | None:
+    """
+    :param signature: the signature of an object as shown in gopls' hover information
+    :return: the parsed signature or None if the signature could not be parsed
+    """
+    first_line = signature.strip().split("\n")[0]
+    tokens = tokenize(first_line)
+    if len(tokens) < 2 or not tokens[0].is_identifier("var", "field", "const", "func", "type", "package"):
+        return None
+    kind = tokens[0].text
+    if kind == "package":
+        return GoObjectSignature(kind=kind, name=tokens[1].text, type_expr=None)
+    if kind == "func":
+        name_idx = 1
+        if tokens[1].is_operator("("):
+            name_idx = find_matching_bracket(tokens, 1) + 1
+        if name_idx >= len(tokens) or not tokens[name_idx].is_identifier():
+            return None
+        type_expr = "func" + first_line[tokens[name_idx].end :].rstrip()
+        return GoObjectSignature(kind=kind, name=tokens[name_idx].text, type_expr=type_expr)
+    name = tokens[1].text
+    if kind == "type":
+        return GoObjectSignature(kind=kind, name=name, type_expr=name)
+    if len(tokens) < 3:
+        return None
+    # the type extends up to the value of constants (if any) or the end of the line (excluding comments)
+    type_end = tokens[-1].end
+    depth = 0
+    for token in tokens[2:]:
+        if token.is_operator("(", "[", "{"):
+            depth += 1
+        elif token.is_operator(")", "]", "}"):
+            depth -= 1
+        elif token.is_operator("=") and depth == 0:
+            type_end = token.start
+            break
+        type_end = token.end
+    return GoObjectSignature(kind=kind, name=name, type_expr=first_line[tokens[2].start : type_end].strip())
+
+
+class GoReferenceRole(Enum):
+    """
+    The syntactic role of a reference to a named entity
+    """
+
+    CALL = "call"
+    """
+    the referenced function or method is called
+    """
+    CONVERSION = "conversion"
+    """
+    the referenced type is used in a type conversion, e.g. `ByteSlice(data)`
+    """
+    EMBED = "embed"
+    """
+    the referenced type is embedded in a struct or interface type
+    """
+    COMPOSITE_LITERAL = "composite_literal"
+    """
+    the referenced type is the type of a composite literal, e.g. `BaseStruct{Name: "x"}`
+    """
+    COMPOSITE_LITERAL_FIELD = "composite_literal_field"
+    """
+    the referenced field is a key in a composite literal, e.g. `Name` in `BaseStruct{Name: "x"}`
+    """
+    ASSIGNMENT = "assignment"
+    """
+    the referenced variable or field is the target of an assignment (or an increment/decrement statement)
+    """
+    TYPE = "type"
+    """
+    the referenced type is used in a type expression, e.g. in a parameter or field declaration
+    """
+    READ = "read"
+    """
+    any other use of the referenced entity, e.g. the value of a variable being read
+    """
+
+
+_ASSIGNMENT_OPERATORS = ("=", ":=", "+=", "-=", "*=", "/=", "%=", "&=", "|=", "^=", "<<=", ">>=", "&^=", "++", "--")
+
+
+def _find_enclosing_bracket(tokens: list[GoToken], index: int) -> int | None:
+    """
+    :return: the index of the innermost unclosed opening bracket preceding the given token or None if there is none
+    """
+    depth = 0
+    for i in range(index - 1, -1, -1):
+        if tokens[i].is_operator(")", "]", "}"):
+            depth += 1
+        elif tokens[i].is_operator("(", "[", "{"):
+            if depth == 0:
+                return i
+            depth -= 1
+    return None
+
+
+def _is_embedded_element(tokens: list[GoToken], index: int) -> bool:
+    """
+    :return: whether the given identifier is the type name of an element of a struct or interface body that consists of
+        a (possibly qualified) type name only, i.e. of an embedded field or an embedded interface
+    """
+    line_tokens = [t for t in tokens if t.line == tokens[index].line]
+    if line_tokens and line_tokens[-1].kind == GoTokenKind.STRING:
+        line_tokens = line_tokens[:-1]  # struct tag
+    if line_tokens and line_tokens[-1].is_operator(";"):
+        line_tokens = line_tokens[:-1]
+    if line_tokens and line_tokens[0].is_operator("*", "~"):
+        line_tokens = line_tokens[1:]
+    if not line_tokens or line_tokens[-1] is not tokens[index]:
+        return False
+    return len(line_tokens) == 1 or (len(line_tokens) == 3 and line_tokens[1].is_operator("."))
+
+
+def _is_assignment_target(tokens: list[GoToken], index: int) -> bool:
+    i = index + 1
+    while i < len(tokens):
+        token = tokens[i]
+        if token.is_operator(*_ASSIGNMENT_OPERATORS):
+            return True
+        if token.is_operator("["):
+            # element of the referenced map, slice or array
+            i = find_matching_bracket(tokens, i) + 1
+        elif token.is_operator(",") and i + 1 < len(tokens) and tokens[i + 1].is_identifier():
+            # further targets of a multi-value assignment, e.g. `a, b.c = ...`
+            i += 2
+            while i + 1 < len(tokens) and tokens[i].is_operator(".") and tokens[i + 1].is_identifier():
+                i += 2
+        else:
+            return False
+    return False
+
+
+def classify_reference(tokens: list[GoToken], index: int, refers_to_type: bool) -> GoReferenceRole:
+    """
+    Classifies a reference based on its syntactic context.
+
+    :param tokens: the tokens of the file containing the reference (without comments)
+    :param index: the index of the referencing identifier
+    :param refers_to_type: whether the referenced entity is a type
+    :return: the role of the reference
+    """
+    next_token = tokens[index + 1] if index + 1 < len(tokens) else None
+    enclosing = _find_enclosing_bracket(tokens, index)
+    if enclosing is not None and enclosing > 0 and tokens[enclosing].is_operator("{"):
+        if tokens[enclosing - 1].is_identifier("struct", "interface"):
+            if _is_embedded_element(tokens, index):
+                return GoReferenceRole.EMBED
+            if refers_to_type:
+                return GoReferenceRole.TYPE
+    if refers_to_type:
+        if next_token is not None and next_token.is_operator("{"):
+            type_start = index - 2 if index >= 2 and tokens[index - 1].is_operator(".") else index
+            # a result type preceding a function body (e.g. `func f() *T {`) is not a composite literal
+            if type_start == 0 or not tokens[type_start - 1].is_operator(")", "*"):
+                return GoReferenceRole.COMPOSITE_LITERAL
+            return GoReferenceRole.TYPE
+        if next_token is not None and next_token.is_operator("("):
+            return GoReferenceRole.CONVERSION
+        if next_token is not None and next_token.is_operator("."):
+            # method expression, e.g. `BaseStruct.Execute`, or selection of an embedded field named after the type
+            return GoReferenceRole.READ
+        return GoReferenceRole.TYPE
+    if next_token is not None and next_token.is_operator("("):
+        return GoReferenceRole.CALL
+    if next_token is not None and next_token.is_operator(":"):
+        line_start = next(t for t in tokens if t.line == tokens[index].line)
+        if not line_start.is_identifier("case"):
+            return GoReferenceRole.COMPOSITE_LITERAL_FIELD
+    if _is_assignment_target(tokens, index):
+        return GoReferenceRole.ASSIGNMENT
+    return GoReferenceRole.READ
+
+
+@dataclass
+class GoSwitchStatement:
+    """
+    An expression switch statement, e.g. `switch kind { case "a": ... }`
+    """
+
+    subject: str
+    """
+    the switched expression (without an init statement)
+    """
+    start: int
+    end: int
+    case_values: list[str] = field(default_factory=list)
+    """
+    the values of all case clauses
+    """
+
+
+@dataclass
+class GoReturnStatement:
+    start: int
+    """
+    the offset of the `return` keyword
+    """
+    results: list[str]
+    """
+    the returned expressions (empty for a bare return)
+    """
+    switch: GoSwitchStatement | None = None
+    """
+    the innermost expression switch statement in one of whose clauses the statement is located
+    """
+    case_values: list[str] | None = None
+    """
+    the values of the enclosing clause of `switch` (empty for the default clause)
+    """
+    is_nested_in_clause: bool = False
+    """
+    whether the statement is located within a block nested in the enclosing clause (e.g. an if statement),
+    i.e. whether it is executed only conditionally when the clause is selected
+    """
+
+
+def _find_header_end(tokens: list[GoToken], start: int) -> int:
+    """
+    :return: the index of the opening brace of the block following the header (of a switch statement or function literal)
+        starting at the given token
+    """
+    i = start
+    while i < len(tokens) and not tokens[i].is_operator("{"):
+        if tokens[i].is_operator("(", "["):
+            i = find_matching_bracket(tokens, i)
+        elif tokens[i].is_identifier("struct", "interface") and i + 1 < len(tokens) and tokens[i + 1].is_operator("{"):
+            i = find_matching_bracket(tokens, i + 1)
+        i += 1
+    return i
+
+
+def _find_clause_colon(tokens: list[GoToken], start: int) -> int:
+    depth = 0
+    i = start
+    while i < len(tokens):
+        if tokens[i].is_operator("(", "[", "{"):
+            depth += 1
+        elif tokens[i].is_operator(")", "]", "}"):
+            depth -= 1
+        elif tokens[i].is_operator(":") and depth == 0:
+            return i
+        i += 1
+    return i
+
+
+def find_return_statements(go_file: GoFile, declaration: GoDeclaration) -> list[GoReturnStatement]:
+    """
+    Finds the return statements of a function or method (excluding those of nested function literals).
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method
+    :return: the return statements in the order of their occurrence
+    """
+    if declaration.body_start is None:
+        return []
+    tokens = go_file.get_tokens(declaration.body_start, declaration.end)
+    result: list[GoReturnStatement] = []
+    # the enclosing switch statements along with the depth of their bodies and the values of the current clause
+    switches: list[tuple[GoSwitchStatement | None, int, list[str] | None]] = []
+    depth = 0
+    i = 0
+    while i < len(tokens):
+        token = tokens[i]
+        if token.is_identifier("func") and i + 1 < len(tokens) and tokens[i + 1].is_operator("("):
+            # function literal: its return statements do not belong to the function
+            body_open = _find_header_end(tokens, i + 1)
+            i = find_matching_bracket(tokens, body_open) + 1 if body_open < len(tokens) else body_open
+            continue
+        if token.is_identifier("switch", "select"):
+            body_open = _find_header_end(tokens, i + 1)
+            header = tokens[i + 1 : body_open]
+            semicolons = [j for j, t in enumerate(header) if t.is_operator(";")]
+            if semicolons:
+                header = header[semicolons[-1] + 1 :]
+            switch = None
+            is_type_switch = any(t.is_identifier("type") for t in header)
+            if token.text == "switch" and header and not is_type_switch and body_open < len(tokens):
+                switch_end = tokens[find_matching_bracket(tokens, body_open)].end
+                switch = GoSwitchStatement(subject=_normalize_whitespace(header, go_file.source), start=token.start, end=switch_end)
+            depth += 1
+            switches.append((switch, depth, None))
+            i = body_open + 1
+            continue
+        if token.is_identifier("case", "default") and switches and switches[-1][1] == depth:
+            colon = _find_clause_colon(tokens, i + 1)
+            switch = switches[-1][0]
+            values = [_normalize_whitespace(group, go_file.source) for group in _split_at_commas(tokens[i + 1 : colon])]
+            if switch is not None:
+                switch.case_values.extend(values)
+            switches[-1] = (switch, depth, values)
+            i = colon + 1
+            continue
+        if token.is_operator("{"):
+            depth += 1
+        elif token.is_operator("}"):
+            depth -= 1
+            while switches and switches[-1][1] > depth:
+                switches.pop()
+        elif token.is_identifier("return"):
+            end = find_statement_end(tokens, i)
+            results = [_normalize_whitespace(group, go_file.source) for group in _split_at_commas(tokens[i + 1 : end + 1])]
+            statement = GoReturnStatement(start=token.start, results=results)
+            enclosing = next((s for s in reversed(switches) if s[0] is not None and s[2] is not None), None)
+            if enclosing is not None:
+                statement.switch, statement.case_values = enclosing[0], enclosing[2]
+                statement.is_nested_in_clause = depth > enclosing[1]
+            result.append(statement)
+            i = end + 1
+            continue
+        i += 1
+    return result
+
+
+def find_assignments(go_file: GoFile, declaration: GoDeclaration, name: str, before: int) -> list[tuple[str, int]]:
+    """
+    Finds the assignments of values to a local variable within the body of a function (textually, i.e. without
+    taking into account scopes and control flow).
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of the function or method
+    :param name: the name of the variable
+    :param before: the offset before which to search for assignments
+    :return: a list of tuples (assigned expression, result index), where the result index is the index of the value
+        within the results of the expression, which is non-zero only if a multi-valued call is assigned to several variables
+    """
+    if declaration.body_start is None:
+        return []
+    tokens = go_file.get_tokens(declaration.body_start, before)
+    result = []
+    for start, token in enumerate(tokens):
+        previous = tokens[start - 1] if start > 0 else None
+        is_statement_start = (
+            previous is None
+            or previous.end_line < token.line
+            or previous.is_operator("{", "}", ";")
+            or previous.is_identifier("var", "if", "switch", "for")
+        )
+        if not token.is_identifier() or not is_statement_start:
+            continue
+        # the targets of the assignment, e.g. `a, b :=`, or the names of a variable declaration, e.g. `var a, b T =`
+        targets = [token.text]
+        i = start + 1
+        while i + 1 < len(tokens) and tokens[i].is_operator(",") and tokens[i + 1].is_identifier():
+            targets.append(tokens[i + 1].text)
+            i += 2
+        if name not in targets:
+            continue
+        if previous is not None and previous.is_identifier("var"):
+            while i < len(tokens) and not tokens[i].is_operator("=") and tokens[i].line == token.line:
+                i += 1
+        if i >= len(tokens) or not tokens[i].is_operator("=", ":="):
+            continue
+        end = find_statement_end(tokens, i + 1) if i + 1 < len(tokens) else i
+        values = [_normalize_whitespace(group, go_file.source) for group in _split_at_commas(tokens[i + 1 : end + 1])]
+        index = targets.index(name)
+        if len(values) == len(targets):
+            result.append((values[index], 0))
+        elif len(values) == 1:
+            result.append((values[0], index))
+    return result
+
+
+_BLOCK_STATEMENT_KEYWORDS = ("if", "for", "switch", "select")
+_CLAUSE_KEYWORDS = ("case", "default")
+_LINE_BLOCK_SELECTOR_PATTERN = re.compile(r"^(if|for|switch|select|case|default)\s*@\s*(\d+)$")
+
+
+@dataclass
+class GoBlock:
+    """
+    A block nested within the body of a function: the block of an if, for, switch or select statement or
+    a clause of a switch or select statement
+    """
+
+    kind: str
+    """
+    the keyword introducing the block, i.e. "if", "for", "switch", "select", "case" or "default"
+    """
+    start: int
+    """
+    the offset of the keyword
+    """
+    line: int
+    """
+    the 0-based line of the keyword
+    """
+    body_start: int
+    """
+    the offset at which the content of the block starts, i.e. after the opening brace or the clause's colon
+    (and after a comment in the same line)
+    """
+    body_end: int
+    """
+    the offset at which the content of the block ends, i.e. the offset of the closing brace or, for clauses,
+    the end of the clause's last statement (including a comment in the same line)
+    """
+    case_values: list[str] = field(default_factory=list)
+    """
+    the values of a case clause (with normalized whitespace)
+    """
+
+    @property
+    def is_clause(self) -> bool:
+        return self.kind in _CLAUSE_KEYWORDS
+
+    def get_selector(self) -> str:
+        """
+        :return: the selector identifying the block by its keyword and line, e.g. "if@12"
+        """
+        return f"{self.kind}@{self.line}"
+
+    def get_label(self) -> str:
+        """
+        :return: a human-readable label of the block, e.g. `case "a", "b"` or "if@12"
+        """
+        if self.kind == "case":
+            return "case " + ", ".join(self.case_values)
+        if self.kind == "default":
+            return "default"
+        return self.get_selector()
+
+
+def _extend_by_line_comment(comments: list[GoToken], offset: int, line: int, limit: int) -> int:
+    """
+    :return: the end of the comment starting after the given offset in the given line (before `limit`), if any,
+        otherwise the given offset
+    """
+    for comment in comments:
+        if offset <= comment.start < limit and comment.line == line:
+            return comment.end
+    return offset
+
+
+def _find_clauses(
+    tokens: list[GoToken], comments: list[GoToken], body_open: int, body_close: int, source: str
+) -> list[GoBlock]:
+    clause_starts = []
+    depth = 0
+    for i in range(body_open + 1, body_close):
+        if tokens[i].is_operator("(", "[", "{"):
+            depth += 1
+        elif tokens[i].is_operator(")", "]", "}"):
+            depth -= 1
+        elif depth == 0 and tokens[i].is_identifier(*_CLAUSE_KEYWORDS):
+            clause_starts.append(i)
+    clauses = []
+    for k, start in enumerate(clause_starts):
+        next_start = clause_starts[k + 1] if k + 1 < len(clause_starts) else body_close
+        colon = _find_clause_colon(tokens, start + 1)
+        limit = tokens[next_start].start
+        body_start = _extend_by_line_comment(comments, tokens[colon].end, tokens[colon].line, limit)
+        if next_start - 1 > colon:
+            last = tokens[next_start - 1]
+            body_end = _extend_by_line_comment(comments, last.end, last.end_line, limit)
+        else:
+            body_end = body_start
+        values = []
+        if tokens[start].text == "case":
+            values = [_normalize_whitespace(group, source) for group in _split_at_commas(tokens[start + 1 : colon])]
+        clauses.append(
+            GoBlock(
+                kind=tokens[start].text,
+                start=tokens[start].start,
+                line=tokens[start].line,
+                body_start=body_start,
+                body_end=body_end,
+                case_values=values,
+            )
+        )
+    return clauses
+
+
+def find_blocks(go_file: GoFile, declaration: GoDeclaration) -> list[GoBlock]:
+    """
+    Finds the blocks nested within the body of a function or method (including those within function literals).
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method
+    :return: the blocks, ordered by their start
+    """
+    if declaration.body_start is None:
+        return []
+    body_tokens = go_file.get_tokens(declaration.body_start, declaration.end, include_comments=True)
+    tokens = [t for t in body_tokens if t.kind != GoTokenKind.COMMENT]
+    comments = [t for t in body_tokens if t.kind == GoTokenKind.COMMENT]
+    blocks = []
+    for i, token in enumerate(tokens):
+        if not token.is_identifier(*_BLOCK_STATEMENT_KEYWORDS):
+            continue
+        body_open = _find_header_end(tokens, i + 1)
+        if body_open >= len(tokens):
+            continue
+        body_close = find_matching_bracket(tokens, body_open)
+        body_start = _extend_by_line_comment(comments, tokens[body_open].end, tokens[body_open].line, tokens[body_close].start)
+        body_end = tokens[body_close].start
+        blocks.append(GoBlock(kind=token.text, start=token.start, line=token.line, body_start=body_start, body_end=body_end))
+        if token.text in ("switch", "select"):
+            blocks.extend(_find_clauses(tokens, comments, body_open, body_close, go_file.source))
+    blocks.sort(key=lambda b: b.start)
+    return blocks
+
+
+def select_block(blocks: list[GoBlock], selector: str) -> GoBlock:
+    """
+    Selects a single block by a selector, which is one of the following:
+
+    * `<keyword>@<line>`, where the keyword is one of "if", "for", "switch", "select", "case" and "default" and the line
+      is the 0-based line of the keyword, e.g. "if@12";
+    * `case <values>`, which selects the case clause listing the given values (or a superset of them), e.g. `case "a"`;
+    * `default`, which selects the default clause.
+
+    :param blocks: the blocks from which to select (see `find_blocks`)
+    :param selector: the selector
+    :return: the selected block
+    :raises ValueError: if the selector is invalid or does not identify exactly one block
+    """
+    selector = selector.strip()
+    line_selector_match = _LINE_BLOCK_SELECTOR_PATTERN.match(selector)
+    if line_selector_match is not None:
+        kind, line = line_selector_match.group(1), int(line_selector_match.group(2))
+        candidates = [b for b in blocks if b.kind == kind and b.line == line]
+    elif selector == "default":
+        candidates = [b for b in blocks if b.kind == "default"]
+    elif selector.startswith("case ") or selector.startswith("case\t"):
+        values_source = selector[len("case") :]
+        values = {_normalize_whitespace(group, values_source) for group in _split_at_commas(tokenize(values_source))}
+        candidates = [b for b in blocks if b.kind == "case" and values <= set(b.case_values)]
+    else:
+        raise ValueError(f"Invalid block selector '{selector}'; expected e.g. 'if@12', 'case \"value\"' or 'default'")
+    if len(candidates) == 1:
+        return candidates[0]
+    available = ", ".join(f"{b.get_selector()} ({b.get_label()})" if b.is_clause else b.get_selector() for b in blocks)
+    if not candidates:
+        raise ValueError(f"No block matching '{selector}' found; available blocks: {available or 'none'}")
+    raise ValueError(
+        f"The selector '{selector}' matches {len(candidates)} blocks: "
+        + ", ".join(b.get_selector() for b in candidates)
+        + "; use a selector of the form <keyword>@<line> instead"
+    )
+
+
+def get_block_replacement(go_file: GoFile, block: GoBlock, new_content: str) -> GoTextEdit:
+    """
+    Creates the edit replacing the content of a block. The new content is re-indented to the block's indentation.
+
+    :param go_file: the file containing the block
+    :param block: the block
+    :param new_content: the new statements of the block (without braces or, for clauses, the case label)
+    :return: the edit
+    :raises ValueError: if the new content is not a well-formed sequence of statements for the block, i.e. if its
+        brackets are unbalanced or, for clauses, if it contains further clauses
+    """
+    depth = 0
+    for token in tokenize(new_content):
+        if token.is_operator("(", "[", "{"):
+            depth += 1
+        elif token.is_operator(")", "]", "}"):
+            depth -= 1
+            if depth < 0:
+                raise ValueError(f"Unbalanced '{token.text}' in the new content of the block")
+        elif depth == 0 and block.is_clause and token.is_identifier(*_CLAUSE_KEYWORDS):
+            raise ValueError("The new content of a clause must not contain further case or default clauses")
+    if depth != 0:
+        raise ValueError("Unbalanced brackets in the new content of the block")
+    block_indent = _get_indentation(go_file.source, block.start)
+    content_lines = textwrap.dedent(new_content.strip("\n")).rstrip().split("\n") if new_content.strip() else []
+    new_text = "".join("\n" + (block_indent + "\t" + line if line.strip() else "") for line in content_lines)
+    if not block.is_clause:
+        new_text += "\n" + block_indent
+    return GoTextEdit(block.body_start, block.body_end, new_text)
+
+
+class GoControlFlowFeatureKind(Enum):
+    GO = "go"
+    DEFER = "defer"
+    PANIC = "panic"
+    RECOVER = "recover"
+
+
+@dataclass
+class GoControlFlowFeature:
+    """
+    A `go` or `defer` statement or a call of the built-in function `panic` or `recover` within a function body
+    """
+
+    kind: GoControlFlowFeatureKind
+    start: int
+    """
+    the offset of the `go`/`defer` keyword or of the called identifier
+    """
+    text: str
+    """
+    the (first line of the) statement or the call, e.g. "defer mu.Unlock()" or `panic("no function given")`
+    """
+    in_loop: bool
+    """
+    whether the feature is located within the body of a `for` loop of the (innermost) enclosing function
+    """
+    in_function_literal: bool
+    """
+    whether the feature is located within a function literal nested in the function, e.g. a goroutine's function
+    """
+
+
+def find_control_flow_features(go_file: GoFile, declaration: GoDeclaration) -> list[GoControlFlowFeature]:
+    """
+    Finds the `go` and `defer` statements and the calls of `panic` and `recover` within the body of a function or method,
+    including those within nested function literals.
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method
+    :return: the features in the order of their occurrence
+    """
+    if declaration.body_start is None:
+        return []
+    tokens = go_file.get_tokens(declaration.body_start, declaration.end)
+    result: list[GoControlFlowFeature] = []
+    # the kinds ("loop", "func" or "block") of the enclosing blocks and of the blocks whose opening brace is yet to come
+    block_kinds: list[str] = []
+    pending_block_kinds: dict[int, str] = {}
+    for i, token in enumerate(tokens):
+        if token.is_operator("{"):
+            block_kinds.append(pending_block_kinds.pop(i, "block"))
+            continue
+        if token.is_operator("}"):
+            if block_kinds:
+                block_kinds.pop()
+            continue
+        if not token.is_identifier():
+            continue
+        if token.text == "for" or (token.text == "func" and i + 1 < len(tokens) and tokens[i + 1].is_operator("(")):
+            body_open = _find_header_end(tokens, i + 1)
+            # a function type (e.g. in a variable declaration) is not followed by a body
+            if body_open <= find_statement_end(tokens, i):
+                pending_block_kinds[body_open] = "loop" if token.text == "for" else "func"
+        kind = None
+        if token.text in ("go", "defer"):
+            kind = GoControlFlowFeatureKind(token.text)
+            end_offset = tokens[find_statement_end(tokens, i)].end
+        elif token.text in ("panic", "recover") and i + 1 < len(tokens) and tokens[i + 1].is_operator("("):
+            if i > 0 and tokens[i - 1].is_operator("."):
+                # a method or a function of another package
+                continue
+            kind = GoControlFlowFeatureKind(token.text)
+            end_offset = tokens[find_matching_bracket(tokens, i + 1)].end
+        if kind is None:
+            continue
+        in_loop = False
+        for block_kind in reversed(block_kinds):
+            if block_kind == "func":
+                break
+            if block_kind == "loop":
+                in_loop = True
+        result.append(
+            GoControlFlowFeature(
+                kind=kind,
+                start=token.start,
+                text=go_file.get_text(token.start, end_offset).split("\n")[0].strip(),
+                in_loop=in_loop,
+                in_function_literal="func" in block_kinds,
+            )
+        )
+    return result
+
+
+def find_unwrapped_error_returns(go_file: GoFile, declaration: GoDeclaration) -> list[GoToken]:
+    """
+    Finds the return statements of a function whose last result is of type `error` which return an error variable as is,
+    e.g. `return nil, err` (excluding the return statements of nested function literals). Returns of `nil`, of calls
+    (such as `fmt.Errorf(...)`, which may already wrap the error) and of other expressions are not considered.
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method
+    :return: the identifier tokens of the returned error variables
+    """
+    signature = go_file.get_parameters_and_results_text(declaration)
+    assert signature is not None
+    _, result_types = get_parameter_and_result_types(signature)
+    if not result_types or result_types[-1] != "error":
+        raise ValueError(f"The last result of {declaration.name} is not of type error")
+    tokens = go_file.get_tokens()
+    result = []
+    for statement in find_return_statements(go_file, declaration):
+        i = next(j for j, t in enumerate(tokens) if t.start == statement.start)
+        groups = _split_at_commas(tokens[i + 1 : find_statement_end(tokens, i) + 1])
+        if len(groups) != len(result_types):
+            # bare return or return of a multi-valued call
+            continue
+        returned_error = groups[-1]
+        if len(returned_error) == 1 and returned_error[0].is_identifier() and returned_error[0].text != "nil":
+            result.append(returned_error[0])
+    return result
+
+
+def get_error_wrapping_edit(
+    go_file: GoFile, declaration: GoDeclaration, errors: list[GoToken], format_literal: str, fmt_name: str
+) -> GoTextEdit:
+    """
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of the function
+    :param errors: the identifier tokens of returned errors within the function (see `find_unwrapped_error_returns`)
+    :param format_literal: the Go string literal to use as the format, e.g. `"loading config: %w"`
+    :param fmt_name: the name under which the package `fmt` is referenced in the file
+    :return: the edit replacing the function's declaration with one in which the errors are wrapped using `fmt.Errorf`
+    """
+    text = go_file.get_declaration_text(declaration)
+    for error in sorted(errors, key=lambda t: t.start, reverse=True):
+        start, end = error.start - declaration.start, error.end - declaration.start
+        text = f"{text[:start]}{fmt_name}.Errorf({format_literal}, {error.text}){text[end:]}"
+    return GoTextEdit(declaration.start, declaration.end, text)
+
+
+def references_receiver(go_file: GoFile, declaration: GoDeclaration) -> bool:
+    """
+    Checks (textually, i.e. without taking into account shadowing) whether the body of a method references the receiver.
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a method
+    :return: whether the receiver variable is referenced; False if the receiver is unnamed or blank
+    """
+    receiver_name = declaration.receiver_name
+    if receiver_name is None or receiver_name == "_" or declaration.body_start is None:
+        return False
+    return len(find_variable_uses(go_file, declaration, receiver_name)) > 0
+
+
+_RECEIVER_PLACEHOLDER = "\0receiver"
+
+
+def _get_body_tokens(go_file: GoFile, declaration: GoDeclaration) -> list[GoToken]:
+    """
+    :return: the tokens of the body of a function or method, excluding the enclosing braces
+    """
+    assert declaration.body_start is not None
+    return go_file.get_tokens(declaration.body_start, declaration.end)[1:-1]
+
+
+def _get_normalized_body_texts(tokens: list[GoToken], receiver_name: str | None) -> list[str]:
+    """
+    :return: the texts of the given body tokens, where references to the receiver are replaced by a common placeholder
+    """
+    texts = []
+    for i, token in enumerate(tokens):
+        is_receiver = receiver_name is not None and receiver_name != "_" and token.is_identifier(receiver_name)
+        if is_receiver and not (i > 0 and tokens[i - 1].is_operator(".")):
+            texts.append(_RECEIVER_PLACEHOLDER)
+        else:
+            texts.append(token.text)
+    return texts
+
+
+def count_body_statements(go_file: GoFile, declaration: GoDeclaration) -> int:
+    """
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method with a body
+    :return: the number of top-level statements in the body (a compound statement such as `if` counting as one)
+    """
+    tokens = _get_body_tokens(go_file, declaration)
+    num_statements = 0
+    i = 0
+    while i < len(tokens):
+        if not tokens[i].is_operator(";"):
+            num_statements += 1
+            i = find_statement_end(tokens, i)
+        i += 1
+    return num_statements
+
+
+def get_body_similarity(go_file: GoFile, declaration: GoDeclaration, other_file: GoFile, other_declaration: GoDeclaration) -> float:
+    """
+    Compares the bodies of two functions or methods token by token (ignoring comments and formatting), where references
+    to the receivers are considered equal regardless of the receivers' names.
+
+    :param go_file: the file containing the first declaration
+    :param declaration: the declaration of the first function or method
+    :param other_file: the file containing the second declaration
+    :param other_declaration: the declaration of the second function or method
+    :return: the similarity between 0 and 1 (1 for bodies consisting of the same tokens)
+    """
+    texts = _get_normalized_body_texts(_get_body_tokens(go_file, declaration), declaration.receiver_name)
+    other_texts = _get_normalized_body_texts(_get_body_tokens(other_file, other_declaration), other_declaration.receiver_name)
+    return difflib.SequenceMatcher(None, texts, other_texts, autojunk=False).ratio()
+
+
+@dataclass
+class GoCommonBody:
+    """
+    The common structure of the bodies of several functions or methods which differ only in some of their expressions
+    (the holes), such that all bodies can be obtained from a single function taking a parameter per hole
+    """
+
+    text: str
+    """
+    the body of the first function (including the braces)
+    """
+    hole_spans: list[tuple[int, int]]
+    """
+    the start and end offsets (relative to `text`) of the holes in the body of the first function
+    """
+    hole_texts: list[list[str]]
+    """
+    for each of the functions, the texts of the expressions filling the holes
+    """
+    identifiers: set[str]
+    """
+    the identifiers referenced outside of the holes (excluding selected fields and methods), e.g. parameter names
+    """
+
+    def instantiate(self, replacements: list[str]) -> str:
+        """
+        :param replacements: the texts with which to replace the holes
+        :return: the body with the holes replaced
+        """
+        text = self.text
+        for (start, end), replacement in sorted(zip(self.hole_spans, replacements, strict=True), reverse=True):
+            text = text[:start] + replacement + text[end:]
+        return text
+
+
+def _get_expression_spans(tokens: list[GoToken], start: int, end: int) -> list[tuple[int, int]]:
+    """
+    :return: the index ranges of the comma-separated expressions among the tokens with indices in [start, end)
+    """
+    spans = []
+    depth = 0
+    expression_start = start
+    for i in range(start, end):
+        if tokens[i].is_operator("(", "[", "{"):
+            depth += 1
+        elif tokens[i].is_operator(")", "]", "}"):
+            depth -= 1
+        elif tokens[i].is_operator(",") and depth == 0:
+            spans.append((expression_start, i))
+            expression_start = i + 1
+    spans.append((expression_start, end))
+    return spans
+
+
+def _find_enclosing_expression(tokens: list[GoToken], start: int, end: int) -> tuple[int, int] | None:
+    """
+    :return: the index range of the smallest call argument or returned expression containing the tokens with indices in
+        [start, end) or None if there is no such expression
+    """
+    open_idx = _find_enclosing_bracket(tokens, start)
+    while open_idx is not None:
+        close_idx = find_matching_bracket(tokens, open_idx)
+        previous = tokens[open_idx - 1] if open_idx > 0 else None
+        is_call = previous is not None and (
+            (previous.is_identifier() and previous.text not in KEYWORDS) or previous.is_operator(")", "]")
+        )
+        if close_idx >= end and tokens[open_idx].is_operator("(") and is_call:
+            for span_start, span_end in _get_expression_spans(tokens, open_idx + 1, close_idx):
+                if span_start <= start and end <= span_end:
+                    return span_start, span_end
+        open_idx = _find_enclosing_bracket(tokens, open_idx)
+    for i in range(start - 1, -1, -1):
+        if tokens[i].is_identifier("return"):
+            statement_end = find_statement_end(tokens, i)
+            if statement_end < end - 1:
+                continue
+            for span_start, span_end in _get_expression_spans(tokens, i + 1, statement_end + 1):
+                if span_start <= start and end <= span_end:
+                    return span_start, span_end
+            return None
+    return None
+
+
+def _is_balanced(tokens: list[GoToken]) -> bool:
+    depth = 0
+    for token in tokens:
+        if token.is_operator("(", "[", "{"):
+            depth += 1
+        elif token.is_operator(")", "]", "}"):
+            depth -= 1
+            if depth < 0:
+                return False
+    return depth == 0
+
+
+def find_common_body(bodies: list[tuple[GoFile, GoDeclaration]]) -> GoCommonBody | None:
+    """
+    Determines the common body of the given functions or methods, in which all the parts in which the bodies differ as
+    well as all references to receivers are holes. Each hole is a complete call argument or returned expression.
+
+    :param bodies: the declarations of the functions or methods (with a body) along with the files containing them
+    :return: the common body or None if the bodies differ in other ways (e.g. in their statements)
+    """
+    token_lists = [_get_body_tokens(go_file, declaration) for go_file, declaration in bodies]
+    texts = [_get_normalized_body_texts(tokens, declaration.receiver_name) for tokens, (_, declaration) in zip(token_lists, bodies)]
+    first_file, first_declaration = bodies[0]
+    first = token_lists[0]
+    opcodes_list = [difflib.SequenceMatcher(None, texts[0], other, autojunk=False).get_opcodes() for other in texts[1:]]
+
+    # determine the regions of the first body which must be holes (receivers are not available to a shared function)
+    # (each given by alternative token ranges, one of which must be contained in a hole)
+    regions = [[(i, i + 1)] for i, text in enumerate(texts[0]) if text == _RECEIVER_PLACEHOLDER]
+    for opcodes in opcodes_list:
+        for tag, i1, i2, _, _ in opcodes:
+            if tag != "equal":
+                # an insertion into the other body extends the expression following or preceding it
+                regions.append([(i1, i2)] if i1 < i2 else [(i1, i1 + 1), (i1 - 1, i1)])
+    holes: list[tuple[int, int]] = []
+    for alternatives in regions:
+        for region_start, region_end in alternatives:
+            hole = None
+            if 0 <= region_start and region_end <= len(first):
+                hole = _find_enclosing_expression(first, region_start, region_end)
+            if hole is not None:
+                holes.append(hole)
+                break
+        else:
+            return None
+    merged_holes: list[tuple[int, int]] = []
+    for hole_start, hole_end in sorted(holes, key=lambda h: (h[0], -h[1])):
+        if merged_holes and hole_start < merged_holes[-1][1]:
+            merged_holes[-1] = (merged_holes[-1][0], max(hole_end, merged_holes[-1][1]))
+        else:
+            merged_holes.append((hole_start, hole_end))
+
+    hole_texts = [[first_file.get_text(first[s].start, first[e - 1].end) for s, e in merged_holes]]
+    for (other_file, _), other_tokens, opcodes in zip(bodies[1:], token_lists[1:], opcodes_list):
+        for tag, i1, i2, _, _ in opcodes:
+            if tag != "equal" and not any(s <= i1 and i2 <= e for s, e in merged_holes):
+                return None
+        # the tokens delimiting a hole correspond to equal tokens in the other body
+        mapping = {i: j for tag, i1, i2, j1, _ in opcodes if tag == "equal" for i, j in zip(range(i1, i2), range(j1, j1 + i2 - i1))}
+        other_hole_texts = []
+        for hole_start, hole_end in merged_holes:
+            left = mapping.get(hole_start - 1)
+            right = mapping.get(hole_end) if hole_end < len(first) else len(other_tokens)
+            if left is None or right is None or right - left < 2:
+                return None
+            hole_tokens = other_tokens[left + 1 : right]
+            if not _is_balanced(hole_tokens) or len(_get_expression_spans(hole_tokens, 0, len(hole_tokens))) != 1:
+                return None
+            other_hole_texts.append(other_file.get_text(hole_tokens[0].start, hole_tokens[-1].end))
+        hole_texts.append(other_hole_texts)
+
+    identifiers = set()
+    for i, token in enumerate(first):
+        if any(s <= i < e for s, e in merged_holes) or not token.is_identifier() or token.text in KEYWORDS:
+            continue
+        if not (i > 0 and first[i - 1].is_operator(".")):
+            identifiers.add(token.text)
+    assert first_declaration.body_start is not None
+    offset = first_declaration.body_start
+    return GoCommonBody(
+        text=first_file.get_text(first_declaration.body_start, first_declaration.end),
+        hole_spans=[(first[s].start - offset, first[e - 1].end - offset) for s, e in merged_holes],
+        hole_texts=hole_texts,
+        identifiers=identifiers,
+    )
+
+
+@dataclass
+class GoVariableUse:
+    """
+    A use of a variable within the body of a function or method
+    """
+
+    token: GoToken
+    """
+    the token of the variable's identifier
+    """
+    selector: str | None
+    """
+    the name of the field or method selected from the variable (`v.selector`) or None if the variable is used as a whole,
+    e.g. passed on as an argument or assigned
+    """
+    is_call: bool
+    """
+    whether the selected member is called (`v.selector(...)`)
+    """
+
+
+def find_variable_uses(go_file: GoFile, declaration: GoDeclaration, name: str) -> list[GoVariableUse]:
+    """
+    Finds (textually, i.e. without taking into account shadowing) the uses of a variable, such as a parameter or the
+    receiver, in the body of a function or method.
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method with a body
+    :param name: the name of the variable
+    :return: the uses of the variable in the order in which they appear
+    """
+    assert declaration.body_start is not None
+    tokens = go_file.get_tokens(declaration.body_start, declaration.end)
+    uses = []
+    for i, token in enumerate(tokens):
+        # identifiers following a dot are selected fields/methods rather than variables
+        if not token.is_identifier(name) or (i > 0 and tokens[i - 1].is_operator(".")):
+            continue
+        selector = None
+        is_call = False
+        if i + 2 < len(tokens) and tokens[i + 1].is_operator(".") and tokens[i + 2].is_identifier():
+            selector = tokens[i + 2].text
+            is_call = i + 3 < len(tokens) and tokens[i + 3].is_operator("(")
+        uses.append(GoVariableUse(token=token, selector=selector, is_call=is_call))
+    return uses
+
+
+_NAME_WORD_PATTERN = re.compile(r"[A-Z]+(?![a-z])[0-9]*|[A-Z]?[a-z]+[0-9]*|[0-9]+")
+"""
+matches the words of a Go identifier, treating initialisms such as "ID" or "HTTP" as single words and attaching digits
+to the preceding word
+"""
+
+
+def to_json_field_name(field_name: str, style: str) -> str:
+    """
+    :param field_name: the name of a struct field, e.g. "UserID"
+    :param style: "snake" for snake case (e.g. "user_id") or "camel" for camel case (e.g. "userID", initialisms other
+        than the first word being kept)
+    :return: the name of the field's key in JSON
+    """
+    words = _NAME_WORD_PATTERN.findall(field_name) or [field_name]
+    if style == "snake":
+        return "_".join(word.lower() for word in words)
+    if style == "camel":
+        return words[0].lower() + "".join(words[1:])
+    raise ValueError(f"Unknown naming style '{style}'; use 'snake' or 'camel'")
+
+
+_STRUCT_TAG_PAIR_PATTERN = re.compile(r'([^\s:"]+):"((?:[^"\\]|\\.)*)"')
+
+
+def _get_struct_tag_content(tag: str) -> str:
+    """
+    :param tag: a tag literal (raw or interpreted)
+    :return: the value of the literal
+    """
+    content = tag[1:-1]
+    if tag.startswith('"'):
+        content = re.sub(r"\\(.)", r"\1", content)
+    return content
+
+
+def get_struct_tag_value(tag: str | None, key: str) -> str | None:
+    """
+    :param tag: a tag literal (raw or interpreted), e.g. '`json:"name" db:"name"`', or None
+    :param key: the key to look up, e.g. "json"
+    :return: the value associated with the key (as with Go's `reflect.StructTag.Lookup`) or None if the key is absent
+    """
+    if tag is None:
+        return None
+    for match in _STRUCT_TAG_PAIR_PATTERN.finditer(_get_struct_tag_content(tag)):
+        if match.group(1) == key:
+            return match.group(2)
+    return None
+
+
+def add_struct_tag_key(tag: str | None, key: str, value: str) -> str:
+    """
+    Adds a key to a tag, leaving the existing content of the tag unchanged.
+
+    :param tag: the tag literal (raw or interpreted) or None for a field without tag
+    :param key: the key to add, e.g. "json"
+    :param value: the value of the key, e.g. "name"
+    :return: the new tag literal, e.g. '`db:"name" json:"name"`'
+    """
+    pair = f'{key}:"{value}"'
+    if tag is None:
+        return f"`{pair}`"
+    separator = " " if tag[1:-1].strip() else ""
+    if tag.startswith('"'):
+        pair = pair.replace("\\", "\\\\").replace('"', '\\"')
+    return tag[:-1].rstrip() + separator + pair + tag[-1]
+
+
+def get_struct_tags_edit(go_file: GoFile, declaration: GoDeclaration, tags: dict[str, str]) -> GoTextEdit:
+    """
+    Determines the edit which sets the tags of fields of a struct type.
+    The result is not necessarily aligned as required by gofmt, i.e. it should be formatted afterwards.
+
+    :param go_file: the file containing the struct type
+    :param declaration: the declaration of the struct type
+    :param tags: maps names of fields to their new tag literals; fields declared together with other fields (`X, Y int`),
+        which share their tag, cannot be given different tags
+    :return: the edit, which replaces the body of the struct type
+    """
+    open_offset, close_offset, fields = _get_struct_body(declaration)
+    source = go_file.source
+    replacements: dict[tuple[int, int], str] = {}
+    for field in fields:
+        if field.name not in tags:
+            continue
+        siblings = [f.name for f in fields if (f.start, f.end) == (field.start, field.end)]
+        if any(tags.get(name) != tags[field.name] for name in siblings):
+            raise ValueError(f"The fields {', '.join(siblings)} are declared together and thus cannot have different tags")
+        if field.tag is not None:
+            replacements[(field.end - len(field.tag), field.end)] = tags[field.name]
+        else:
+            replacements[(field.end, field.end)] = " " + tags[field.name]
+    new_text = source[open_offset : close_offset + 1]
+    for (start, end), text in sorted(replacements.items(), reverse=True):
+        new_text = new_text[: start - open_offset] + text + new_text[end - open_offset :]
+    return GoTextEdit(open_offset, close_offset + 1, new_text)
+
+
+def _get_parameter_name_tokens(tokens: list[GoToken], open_idx: int) -> tuple[list[GoToken], int]:
+    """
+    :param tokens: the tokens of a signature
+    :param open_idx: the index of the parameter (or result) list's opening parenthesis
+    :return: a tuple (tokens of the parameter names, index of the closing parenthesis)
+    """
+    close_idx = find_matching_bracket(tokens, open_idx)
+    parameters = _split_at_commas(tokens[open_idx + 1 : close_idx])
+    if not any(_is_named_parameter(p) for p in parameters):
+        return [], close_idx
+    return [p[0] for p in parameters], close_idx
+
+
+def _get_signature_name_tokens(tokens: list[GoToken], open_idx: int) -> list[GoToken]:
+    """
+    :param tokens: the tokens of a function, method or function literal
+    :param open_idx: the index of the parameter list's opening parenthesis
+    :return: the tokens of the names of the parameters and (named) results
+    """
+    names, close_idx = _get_parameter_name_tokens(tokens, open_idx)
+    if close_idx + 1 < len(tokens) and tokens[close_idx + 1].is_operator("("):
+        names += _get_parameter_name_tokens(tokens, close_idx + 1)[0]
+    return names
+
+
+def _get_identifier_list(tokens: list[GoToken], start: int) -> list[GoToken]:
+    """
+    :return: the identifiers of the list `a, b, c` starting at the given index
+    """
+    result = []
+    i = start
+    while i < len(tokens) and tokens[i].is_identifier():
+        result.append(tokens[i])
+        if i + 1 >= len(tokens) or not tokens[i + 1].is_operator(","):
+            break
+        i += 2
+    return result
+
+
+def find_local_declarations(go_file: GoFile, declaration: GoDeclaration) -> list[GoToken]:
+    """
+    Finds the local declarations of a function or method, i.e. its receiver, parameters and named results as well as the
+    variables, constants and types declared in its body (including those of function literals).
+
+    :param go_file: the file containing the declaration
+    :param declaration: the declaration of a function or method
+    :return: the tokens of the declared identifiers (excluding the blank identifier) in the order in which they appear;
+        variables which a short variable declaration (`:=`) merely reassigns are included
+    """
+    tokens = go_file.get_tokens(declaration.start, declaration.end)
+    signature_end = declaration.body_start if declaration.body_start is not None else declaration.end
+    result: list[GoToken] = []
+    # the signature: the receiver, the type parameters (skipped) and the parameters and results
+    i = 1
+    if declaration.kind == GoDeclarationKind.METHOD and i < len(tokens) and tokens[i].is_operator("("):
+        receiver_names, i = _get_parameter_name_tokens(tokens, i)
+        result += receiver_names
+        i += 1
+    i += 1  # the function name
+    if i < len(tokens) and tokens[i].is_operator("["):
+        i = find_matching_bracket(tokens, i) + 1
+    if i < len(tokens) and tokens[i].is_operator("(") and tokens[i].start < signature_end:
+        result += _get_signature_name_tokens(tokens, i)
+    # the body
+    body_tokens = [t for t in tokens if t.start >= signature_end]
+    for i, token in enumerate(body_tokens):
+        if token.is_operator(":="):
+            # the targets `a, b :=`, collected backwards
+            j = i - 1
+            while j >= 0 and body_tokens[j].is_identifier():
+                result.append(body_tokens[j])
+                if j < 1 or not body_tokens[j - 1].is_operator(","):
+                    break
+                j -= 2
+        elif token.is_identifier("var", "const"):
+            if i + 1 < len(body_tokens) and body_tokens[i + 1].is_operator("("):
+                group_end = find_matching_bracket(body_tokens, i + 1)
+                j = i + 2
+                while j < group_end:
+                    result += _get_identifier_list(body_tokens, j)
+                    j = find_statement_end(body_tokens, j) + 1
+                    while j < group_end and body_tokens[j].is_operator(";"):
+                        j += 1
+            else:
+                result += _get_identifier_list(body_tokens, i + 1)
+        elif token.is_identifier("type") and i + 1 < len(body_tokens) and body_tokens[i + 1].is_identifier():
+            result.append(body_tokens[i + 1])
+        elif token.is_identifier("func") and i + 1 < len(body_tokens) and body_tokens[i + 1].is_operator("("):
+            result += _get_signature_name_tokens(body_tokens, i + 1)
+    return sorted((t for t in result if t.text != "_" and t.text not in KEYWORDS), key=lambda t: t.start)
+
+
+@dataclass
+class GoReferenceExpression:
+    """
+    The expression formed by a reference to a function, method, field or variable together with the operand it is
+    selected from (if any) and its call (if it is called), e.g. `c.BaseStruct.Execute()` for the reference to `Execute`
+    """
+
+    start: int
+    end: int
+    name: str
+    """
+    the referencing identifier
+    """
+    receiver: str | None
+    """
+    the operand from which the referenced entity is selected, e.g. "c.BaseStruct"; None if it is referenced directly
+    """
+    arguments: str | None
+    """
+    the text of the call's arguments (without parentheses); None if the reference is not called
+    """
+
+
+REFERENCE_TEMPLATE_PLACEHOLDERS = ("{expr}", "{receiver}", "{name}", "{args}")
+
+
+def _find_operand_start(tokens: list[GoToken], index: int) -> int | None:
+    """
+    :param tokens: the tokens (without comments)
+    :param index: the index of the last token of a primary expression, e.g. of `]` in `items(1)[0]`
+    :return: the index of the expression's first token or None if the token does not end a primary expression
+    """
+    i = index
+    while tokens[i].is_operator(")", "]"):
+        opening = _find_enclosing_bracket(tokens, i)
+        if opening is None:
+            return None
+        previous = tokens[opening - 1] if opening > 0 else None
+        if previous is None or not (previous.is_identifier() or previous.is_operator(")", "]")) or previous.text in KEYWORDS:
+            # a parenthesised expression, e.g. `(x)`
+            return opening
+        i = opening - 1
+    if tokens[i].is_identifier() and tokens[i].text not in KEYWORDS:
+        return i
+    return None
+
+
+def find_reference_expression(go_file: GoFile, offset: int) -> GoReferenceExpression | None:
+    """
+    :param go_file: the file containing the reference
+    :param offset: the offset of the referencing identifier
+    :return: the expression formed by the reference or None if there is no identifier at the given offset
+    """
+    tokens = go_file.get_tokens()
+    index = next((i for i, t in enumerate(tokens) if t.start <= offset < t.end), None)
+    if index is None or not tokens[index].is_identifier():
+        return None
+    # extend to the left across the selector chain, e.g. `a.b().c.` in `a.b().c.Name`
+    first = index
+    while first >= 2 and tokens[first - 1].is_operator("."):
+        operand_start = _find_operand_start(tokens, first - 2)
+        if operand_start is None:
+            break
+        first = operand_start
+    end = tokens[index].end
+    arguments = None
+    if index + 1 < len(tokens) and tokens[index + 1].is_operator("("):
+        closing = find_matching_bracket(tokens, index + 1)
+        arguments = go_file.source[tokens[index + 1].end : tokens[closing].start]
+        end = tokens[closing].end
+    receiver = go_file.source[tokens[first].start : tokens[index - 1].start] if first < index else None
+    return GoReferenceExpression(start=tokens[first].start, end=end, name=tokens[index].text, receiver=receiver, arguments=arguments)
+
+
+def instantiate_reference_template(go_file: GoFile, expression: GoReferenceExpression, template: str) -> str:
+    """
+    :param go_file: the file containing the expression
+    :param expression: the expression formed by a reference
+    :param template: the template, in which the placeholders `{expr}`, `{receiver}`, `{name}` and `{args}` are replaced
+        by the expression's text, receiver, identifier and call arguments respectively (other braces are left unchanged)
+    :return: the instantiated template
+    """
+    line, column = go_file.get_line_and_column(expression.start)
+    values = {
+        "{expr}": go_file.source[expression.start : expression.end],
+        "{receiver}": expression.receiver,
+        "{name}": expression.name,
+        "{args}": expression.arguments,
+    }
+    for placeholder, value in values.items():
+        if placeholder in template and value is None:
+            raise ValueError(f"The template uses {placeholder}, which the reference in line {line}, column {column} does not provide")
+    return re.sub("|".join(re.escape(p) for p in REFERENCE_TEMPLATE_PLACEHOLDERS), lambda m: values[m.group()] or "", template)
+
+
+def get_reference_transformation_edit(go_file: GoFile, expressions: list[GoReferenceExpression], template: str) -> GoTextEdit:
+    """
+    Determines the edit which replaces each of the given expressions by the respective instantiation of a template
+    (see `instantiate_reference_template`).
+    The result is not necessarily formatted as required by gofmt, i.e. it should be formatted afterwards.
+
+    :param go_file: the file containing the expressions
+    :param expressions: the (non-empty list of) expressions, which must not overlap
+    :param template: the template
+    :return: the edit, which replaces the range from the start of the first to the end of the last expression
+    """
+    expressions = sorted(expressions, key=lambda e: e.start)
+    for expression, next_expression in zip(expressions, expressions[1:]):
+        if next_expression.start < expression.end:
+            line = go_file.get_line_and_column(next_expression.start)[0]
+            raise ValueError(f"The references in line {line} are nested and cannot be transformed at once")
+    start, end = expressions[0].start, expressions[-1].end
+    new_text = go_file.source[start:end]
+    for expression in reversed(expressions):
+        replacement = instantiate_reference_template(go_file, expression, template)
+        new_text = new_text[: expression.start - start] + replacement + new_text[expression.end - start :]
+    return GoTextEdit(start, end, new_text)
+
+
+def get_import_insertion(go_file: GoFile, import_path: str) -> GoTextEdit:
+    """
+    Determines the edit which adds an import of a package to a file: to the last import declaration (which is turned
+    into a group if necessary) or, if the file has no imports, after the package clause.
+    The result is not necessarily formatted as required by gofmt, i.e. it should be formatted afterwards.
+
+    :param go_file: the file
+    :param import_path: the import path of the package, e.g. "fmt"
+    :return: the edit
+    """
+    spec = f'"{import_path}"'
+    if go_file.imports:
+        last_import = max(go_file.imports, key=lambda i: i.end)
+        # insert after a comment following the last import in its line
+        offset = last_import.end
+        line_end = go_file.source.find("\n", offset)
+        if line_end != -1 and go_file.source[offset:line_end].strip().startswith("//"):
+            offset = line_end
+        next_tokens = [t for t in go_file.get_tokens(last_import.end) if not t.is_operator(";")][:1]
+        if next_tokens and next_tokens[0].is_operator(")"):
+            return GoTextEdit(offset, offset, "\n\t" + spec)
+        # turn the (ungrouped) last import declaration into a group
+        keyword = go_file.get_tokens(0, last_import.start)[-1]
+        last_spec = go_file.source[last_import.start : last_import.end]
+        return GoTextEdit(keyword.start, last_import.end, f"import (\n\t{last_spec}\n\t{spec}\n)")
+    if go_file.package_clause_start is None:
+        raise ValueError("The file has no package clause")
+    package_name_token = go_file.get_tokens(go_file.package_clause_start)[1]
+    return GoTextEdit(package_name_token.end, package_name_token.end, "\n\nimport " + spec)
+
+
+def get_import_removal(go_file: GoFile, go_import: GoImport) -> GoTextEdit:
+    """
+    Determines the edit which removes an import (along with the keyword `import` if it is not grouped).
+    The result is not necessarily formatted as required by gofmt, i.e. it should be formatted afterwards.
+
+    :param go_file: the file containing the import
+    :param go_import: the import to remove
+    :return: the edit, which removes the import's entire line if the line contains nothing else (except for a comment)
+    """
+    source = go_file.source
+    start = go_import.start
+    previous_tokens = go_file.get_tokens(_get_line_start(source, start), start)
+    if previous_tokens and previous_tokens[-1].is_identifier("import"):
+        start = previous_tokens[-1].start
+    line_start = _get_line_start(source, start)
+    line_end = source.find("\n", go_import.end)
+    line_end = len(source) if line_end == -1 else line_end
+    rest_of_line = source[go_import.end : line_end].strip()
+    if not source[line_start:start].strip() and (not rest_of_line or rest_of_line.startswith("//")):
+        return GoTextEdit(line_start, min(line_end + 1, len(source)), "")
+    end = go_import.end
+    next_tokens = go_file.get_tokens(end, line_end)[:1]
+    if next_tokens and next_tokens[0].is_operator(";"):
+        end = next_tokens[0].end
+    return GoTextEdit(start, end, "")
+
+
+def get_file_name_build_constraint(file_name: str) -> str | None:
+    """
+    :param file_name: the name of a Go file, e.g. "config_windows_amd64.go"
+    :return: the build constraint implied by the file name's suffix (`_GOOS`, `_GOARCH` or `_GOOS_GOARCH`), e.g.
+        "windows && amd64", or None if the file name implies no constraint
+    """
+    parts = file_name.removesuffix(".go").removesuffix("_test").split("_")[1:]
+    if len(parts) >= 2 and parts[-2] in KNOWN_OPERATING_SYSTEMS and parts[-1] in KNOWN_ARCHITECTURES:
+        return f"{parts[-2]} && {parts[-1]}"
+    if parts and (parts[-1] in KNOWN_OPERATING_SYSTEMS or parts[-1] in KNOWN_ARCHITECTURES):
+        return parts[-1]
+    return None
+
+
+def get_embedded_type_replacement(go_file: GoFile, declaration: GoDeclaration, old_type: str, new_type: str) -> GoTextEdit:
+    """
+    Determines the edit which replaces an embedded field of a struct type by another embedded type, keeping the field's
+    tag and comments.
+
+    :param go_file: the file containing the struct type
+    :param declaration: the declaration of the struct type
+    :param old_type: the name of the embedded type to replace (without pointer and package qualifier), e.g. "BaseStruct"
+    :param new_type: the type to embed instead, e.g. "LabeledBase", "*LabeledBase" or "other.Base"; if it is not a pointer
+        type, the pointer of the replaced field (if any) is kept
+    :return: the edit
+    """
+    _, _, fields = _get_struct_body(declaration)
+    field = next((f for f in fields if f.embedded and f.name == old_type), None)
+    if field is None:
+        embedded_types = [f.type_expr for f in fields if f.embedded]
+        embedded_info = f"; its embedded types are: {', '.join(embedded_types)}" if embedded_types else ""
+        raise ValueError(f"Struct {declaration.name} does not embed {old_type}{embedded_info}")
+    if field.is_pointer and not new_type.startswith("*"):
+        new_type = "*" + new_type
+    assert go_file.source.startswith(field.type_expr, field.start)
+    return GoTextEdit(field.start, field.start + len(field.type_expr), new_type)
+
+
+def find_field_assignments(go_file: GoFile, type_name: str, field_name: str, field_index: int) -> list[tuple[str, int]]:
+    """
+    Finds (textually) the values assigned to a field of a struct type in a file: the values given for the field in
+    composite literals of the type (`T{field: v}` or, for literals without keys, the value at the field's index) and the
+    values assigned via selectors (`x.field = v`, assuming every selector of the field's name denotes the field).
+
+    :param go_file: the file in which to search
+    :param type_name: the name of the struct type
+    :param field_name: the name of the field
+    :param field_index: the index of the field among the fields of the struct type
+    :return: a list of tuples (assigned expression, offset of the expression)
+    """
+    source = go_file.source
+    tokens = go_file.get_tokens()
+    result = []
+    for i, token in enumerate(tokens):
+        previous = tokens[i - 1] if i > 0 else None
+        if token.is_identifier(field_name) and previous is not None and previous.is_operator("."):
+            if i + 2 < len(tokens) and tokens[i + 1].is_operator("="):
+                end = find_statement_end(tokens, i + 2)
+                values = _split_at_commas(tokens[i + 2 : end + 1])
+                if len(values) == 1:
+                    result.append((_normalize_whitespace(values[0], source), values[0][0].start))
+        elif token.is_identifier(type_name) and i + 1 < len(tokens) and tokens[i + 1].is_operator("{"):
+            # composite literals are preceded by an operator or `return` (unlike, e.g., result types followed by a body)
+            if previous is None or not (previous.is_operator("=", ":=", "(", ",", "&", ":", "{") or previous.is_identifier("return")):
+                continue
+            close = find_matching_bracket(tokens, i + 1)
+            elements = [group for group in _split_at_commas(tokens[i + 2 : close]) if group]
+            is_keyed = any(len(group) > 1 and group[1].is_operator(":") for group in elements)
+            for element_index, group in enumerate(elements):
+                if is_keyed and len(group) > 2 and group[0].is_identifier(field_name) and group[1].is_operator(":"):
+                    value = group[2:]
+                elif not is_keyed and element_index == field_index:
+                    value = group
+                else:
+                    continue
+                result.append((_normalize_whitespace(value, source), value[0].start))
+    return result
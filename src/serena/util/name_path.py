@@ -0,0 +1,173 @@
+"""
+Parsing and formatting of symbol name paths.
+
+A name path addresses a symbol within the symbol tree of a file, e.g. "MyClass/my_method" or, for a Go method of a
+generic type, "Container[T]/Add". The grammar is as follows (`SEP` being the configured separator, "/" by default)::
+
+    name_path := [SEP] segment (SEP segment)* [SEP]
+    segment   := (char | escape | group)+
+    escape    := "\\" any_char
+    group     := "[" (char | escape | group | SEP)* "]"
+
+* A leading separator makes the name path absolute, a trailing separator is ignored.
+* Within a (balanced) bracket group, the separator has no special meaning, so type parameter lists like
+  "Map[K, pkg.V]" are never split, even if "." is used as the separator.
+* A backslash escapes the character following it, which allows the separator, brackets and backslashes
+  to appear literally within a segment (e.g. "weird\\.name" with separator ".").
+
+Empty segments (e.g. "a//b"), unbalanced brackets and dangling escapes are rejected.
+"""
+
+import re
+from dataclasses import dataclass
+
+DEFAULT_SEPARATOR = "/"
+_ESCAPE = "\\"
+_RESERVED_CHARS = {_ESCAPE, "[", "]"}
+_TYPE_PARAMETERS_PATTERN = re.compile(r"\[.+\]$")
+
+
+@dataclass(frozen=True)
+class NamePath:
+    parts: list[str]
+    """
+    the (unescaped) segments of the name path
+    """
+    is_absolute: bool
+
+
+def validate_separator(separator: str) -> None:
+    """
+    :param separator: the separator to validate
+    :raises ValueError: if the separator is not a single character which can be used to separate name path segments
+    """
+    if len(separator) != 1 or separator.isalnum() or separator.isspace() or separator in _RESERVED_CHARS or separator == "_":
+        raise ValueError(
+            f"Invalid name path separator {separator!r}: must be a single punctuation character other than '\\', '[', ']' and '_'"
+        )
+
+
+def parse_name_path(name_path: str, separator: str = DEFAULT_SEPARATOR) -> NamePath:
+    """
+    Parses a name path according to the grammar given in the module docstring.
+
+    :param name_path: the name path to parse
+    :param separator: the character separating the segments of the name path
+    :return: the parsed name path
+    :raises ValueError: if the name path is malformed
+    """
+    validate_separator(separator)
+    is_absolute = name_path.startswith(separator)
+    text = name_path[1:] if is_absolute else name_path
+    parts: list[str] = []
+    current: list[str] = []
+    depth = 0
+    i = 0
+    while i < len(text):
+        ch = text[i]
+        if ch == _ESCAPE:
+            if i + 1 == len(text):
+                raise ValueError(f"Dangling escape character at the end of name path {name_path!r}")
+            current.append(text[i + 1])
+            i += 2
+            continue
+        if ch == "[":
+            depth += 1
+        elif ch == "]":
+            if depth == 0:
+                raise ValueError(f"Unbalanced ']' in name path {name_path!r}")
+            depth -= 1
+        elif ch == separator and depth == 0:
+            if not current:
+                raise ValueError(f"Empty segment in name path {name_path!r}")
+            parts.append("".join(current))
+            current = []
+            i += 1
+            continue
+        current.append(ch)
+        i += 1
+    if depth != 0:
+        raise ValueError(f"Unbalanced '[' in name path {name_path!r}")
+    if current:
+        parts.append("".join(current))
+    if not parts:
+        raise ValueError(f"Name path {name_path!r} does not contain any segments")
+    return NamePath(parts=parts, is_absolute=is_absolute)
+
+
+def _has_balanced_brackets(name: str) -> bool:
+    depth = 0
+    for ch in name:
+        if ch == "[":
+            depth += 1
+        elif ch == "]":
+            depth -= 1
+            if depth < 0:
+                return False
+    return depth == 0
+
+
+def escape_name_path_part(part: str, separator: str = DEFAULT_SEPARATOR) -> str:
+    """
+    Escapes a single name path segment such that `parse_name_path` recovers it unchanged.
+    Brackets are only escaped if they are unbalanced; separators within bracket groups are left as they are.
+
+    :param part: the segment to escape
+    :param separator: the separator of the name path the segment is to be part of
+    :return: the escaped segment
+    """
+    escape_brackets = not _has_balanced_brackets(part)
+    result = []
+    depth = 0
+    for ch in part:
+        if ch == _ESCAPE or (escape_brackets and ch in "[]") or (ch == separator and depth == 0):
+            result.append(_ESCAPE)
+        elif ch == "[":
+            depth += 1
+        elif ch == "]":
+            depth -= 1
+        result.append(ch)
+    return "".join(result)
+
+
+def format_name_path(parts: list[str], separator: str = DEFAULT_SEPARATOR, is_absolute: bool = False) -> str:
+    """
+    :param parts: the (unescaped) segments of the name path
+    :param separator: the separator to use
+    :param is_absolute: whether to prefix the name path with the separator
+    :return: the name path, escaped such that `parse_name_path` recovers the given parts
+    """
+    name_path = separator.join(escape_name_path_part(p, separator) for p in parts)
+    return separator + name_path if is_absolute else name_path
+
+
+def strip_type_parameters(name: str) -> str:
+    """
+    :param name: a symbol name, e.g. "Container[T]"
+    :return: the name without a trailing type parameter list, e.g. "Container"
+    """
+    return _TYPE_PARAMETERS_PATTERN.sub("", name).strip()
+
+
+def _normalize_type_parameters(name: str) -> str:
+    return re.sub(r"\s+", "", name)
+
+
+def match_name_path_part(pattern: str, name: str, substring_matching: bool = False) -> bool:
+    """
+    Checks whether a name path segment matches a symbol name. Type parameter lists are only compared if both
+    the pattern and the name contain one (ignoring whitespace), such that a generic type can be referred to as
+    "Container" as well as "Container[T]", regardless of how the symbol's name is reported.
+
+    :param pattern: the (unescaped) segment of the name path pattern
+    :param name: the symbol name to match
+    :param substring_matching: whether the pattern may match a substring of the name
+    :return: whether the pattern matches the name
+    """
+    if _TYPE_PARAMETERS_PATTERN.search(pattern) and _TYPE_PARAMETERS_PATTERN.search(name):
+        pattern, name = _normalize_type_parameters(pattern), _normalize_type_parameters(name)
+    else:
+        pattern, name = strip_type_parameters(pattern), strip_type_parameters(name)
+    if substring_matching:
+        return pattern in name
+    return pattern == name
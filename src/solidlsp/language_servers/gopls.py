@@ -1,6 +1,7 @@
 import logging
 import os
 import pathlib
+import re
 import subprocess
 import threading
 
@@ -17,15 +18,63 @@ from solidlsp.settings import SolidLSPSettings
 class Gopls(SolidLanguageServer):
     """
     Provides Go specific instantiation of the LanguageServer class using gopls.
+
+    You can pass the following entries in ls_specific_settings["go"]:
+        - minimum_gopls_version: the minimum gopls version to require instead of MINIMUM_GOPLS_VERSION (e.g. "v0.17.0")
+    """
+
+    MINIMUM_GOPLS_VERSION = "v0.16.0"
+    """
+    the oldest gopls version supported; older versions lack LSP features the Go tools rely on (and behave differently in
+    others), which would otherwise lead to confusing results instead of a clear error
     """
 
+    _GENERATED_CODE_PATTERN = re.compile(r"^// Code generated .* DO NOT EDIT\.$")
+    _GOPLS_VERSION_PATTERN = re.compile(r"\bv(\d+)\.(\d+)\.(\d+)")
+
     @override
     def is_ignored_dirname(self, dirname: str) -> bool:
         # For Go projects, we should ignore:
-        # - vendor: third-party dependencies vendored into the project
         # - node_modules: if the project has JavaScript components
         # - dist/build: common output directories
-        return super().is_ignored_dirname(dirname) or dirname in ["vendor", "node_modules", "dist", "build"]
+        # (vendor directories are handled separately, see is_vendor_dirname)
+        return super().is_ignored_dirname(dirname) or dirname in ["node_modules", "dist", "build"]
+
+    @override
+    def is_vendor_dirname(self, dirname: str) -> bool:
+        # third-party dependencies vendored into the project (`go mod vendor`)
+        return dirname == "vendor"
+
+    @override
+    def is_generated_file(self, relative_path: str) -> bool:
+        # this is called for every file considered (via is_ignored_path), so the result is cached as long as the file's
+        # modification time and size are unchanged
+        absolute_path = os.path.join(self.repository_root_path, relative_path)
+        try:
+            stat = os.stat(absolute_path)
+        except OSError:
+            return False
+        file_version = (stat.st_mtime_ns, stat.st_size)
+        cached = self._generated_files.get(relative_path)
+        if cached is not None and cached[0] == file_version:
+            return cached[1]
+        is_generated = self._has_generated_code_header(absolute_path)
+        self._generated_files[relative_path] = (file_version, is_generated)
+        return is_generated
+
+    @classmethod
+    def _has_generated_code_header(cls, absolute_path: str) -> bool:
+        # see https://go.dev/s/generatedcode: the header must appear before the package clause
+        try:
+            with open(absolute_path, encoding="utf-8", errors="replace") as f:
+                for line in f:
+                    if cls._GENERATED_CODE_PATTERN.match(line.rstrip("\r\n")):
+                        return True
+                    if line.startswith("package "):
+                        return False
+        except OSError:
+            return False
+        return False
 
     @staticmethod
     def _get_go_version():
@@ -49,11 +98,53 @@ class Gopls(SolidLanguageServer):
             return None
         return None
 
+    @classmethod
+    def _parse_gopls_version(cls, version_output: str) -> tuple[int, int, int] | None:
+        """
+        :param version_output: the output of `gopls version` (e.g. "golang.org/x/tools/gopls v0.16.2") or a version string
+        :return: the version as a tuple (major, minor, patch) or None if it cannot be determined (e.g. for development builds)
+        """
+        match = cls._GOPLS_VERSION_PATTERN.search(version_output)
+        if match is None:
+            return None
+        return int(match.group(1)), int(match.group(2)), int(match.group(3))
+
+    @classmethod
+    def _check_gopls_version(cls, version_output: str, minimum_version: str) -> str:
+        """
+        Checks that the installed gopls version is at least the given minimum version.
+        Raises RuntimeError with a helpful message if the installed version is older.
+
+        :param version_output: the output of `gopls version`
+        :param minimum_version: the minimum version, e.g. "v0.16.0"
+        :return: the resolved version of the installed gopls, e.g. "v0.16.2" (or the first line of the output if the version
+            cannot be determined, in which case the check is skipped)
+        """
+        required = cls._parse_gopls_version(minimum_version)
+        if required is None:
+            raise ValueError(f"Invalid minimum gopls version {minimum_version!r}, expected a version like v0.16.0")
+        installed = cls._parse_gopls_version(version_output)
+        if installed is None:
+            return version_output.strip().splitlines()[0] if version_output.strip() else "unknown"
+        resolved_version = "v" + ".".join(str(n) for n in installed)
+        if installed < required:
+            raise RuntimeError(
+                f"Found gopls {resolved_version}, but at least gopls {minimum_version} is required.\n"
+                "Please update gopls, e.g. with `go install golang.org/x/tools/gopls@latest`, and make sure the updated version "
+                "is the one found on your PATH.\n"
+                "If you cannot update, you may lower the requirement via the entry `minimum_gopls_version` in "
+                'ls_specific_settings["go"] (at the risk of incomplete or incorrect results).'
+            )
+        return resolved_version
+
     @staticmethod
-    def _setup_runtime_dependency():
+    def _setup_runtime_dependency(minimum_gopls_version: str) -> str:
         """
         Check if required Go runtime dependencies are available.
-        Raises RuntimeError with helpful message if dependencies are missing.
+        Raises RuntimeError with helpful message if dependencies are missing or gopls is too old.
+
+        :param minimum_gopls_version: the minimum gopls version
+        :return: the resolved version of the installed gopls
         """
         go_version = Gopls._get_go_version()
         if not go_version:
@@ -69,24 +160,51 @@ class Gopls(SolidLanguageServer):
                 "After installation, make sure it is added to your PATH (it might be installed in a different location than Go)."
             )
 
-        return True
+        return Gopls._check_gopls_version(gopls_version, minimum_gopls_version)
 
     def __init__(
         self, config: LanguageServerConfig, logger: LanguageServerLogger, repository_root_path: str, solidlsp_settings: SolidLSPSettings
     ):
-        self._setup_runtime_dependency()
+        go_settings = solidlsp_settings.ls_specific_settings.get(self.get_language_enum_instance(), {})
+        self._minimum_gopls_version: str = go_settings.get("minimum_gopls_version", self.MINIMUM_GOPLS_VERSION)
+        self._gopls_version = self._setup_runtime_dependency(self._minimum_gopls_version)
+        logger.log(f"Using gopls {self._gopls_version} (minimum version: {self._minimum_gopls_version})", logging.INFO)
+        self._generated_files: dict[str, tuple[tuple[int, int], bool]] = {}
+        """
+        maps relative file paths to the file version (modification time in ns, size) and whether the file was generated
+        """
+
+        # For a Go workspace (go.work file in the repository root), gopls loads all the workspace's modules into a single
+        # view, such that symbols and references resolve across module boundaries. We pass the go.work file explicitly,
+        # because an inherited GOWORK variable (e.g. GOWORK=off) would otherwise restrict gopls to a single module.
+        # The modules are resolved through GOWORK only, i.e. they are not passed as workspace folders: modules outside the
+        # repository (e.g. `use ../lib`) are thus loaded for resolving definitions and types, but their files are not
+        # part of the repository's symbols.
+        proc_env = {}
+        go_work_path = os.path.join(repository_root_path, "go.work")
+        if os.path.isfile(go_work_path):
+            logger.log(f"Found Go workspace file {go_work_path}", logging.INFO)
+            proc_env["GOWORK"] = go_work_path
 
         super().__init__(
             config,
             logger,
             repository_root_path,
-            ProcessLaunchInfo(cmd="gopls", cwd=repository_root_path),
+            ProcessLaunchInfo(cmd="gopls", env=proc_env, cwd=repository_root_path),
             "go",
             solidlsp_settings,
         )
         self.server_ready = threading.Event()
         self.request_id = 0
 
+    @override
+    def get_server_version(self) -> str | None:
+        return self._gopls_version
+
+    @override
+    def get_minimum_server_version(self) -> str | None:
+        return self._minimum_gopls_version
+
     @staticmethod
     def _get_initialize_params(repository_absolute_path: str) -> InitializeParams:
         """
@@ -94,6 +94,36 @@ class SolidLanguageServer(ABC):
         """
         return dirname.startswith(".")
 
+    # To be overridden by subclasses
+    def is_vendor_dirname(self, dirname: str) -> bool:
+        """
+        A language-specific condition for directories containing vendored third-party code (e.g. vendor in Go).
+        Such directories are ignored unless `include_vendor` is enabled in the configuration.
+        """
+        return False
+
+    # To be overridden by subclasses
+    def is_generated_file(self, relative_path: str) -> bool:
+        """
+        A language-specific condition for files that were generated by a tool (e.g. Go files with the standard
+        "Code generated ... DO NOT EDIT." header). Such files are ignored if `include_generated` is disabled in the configuration.
+        """
+        return False
+
+    # To be overridden by subclasses
+    def get_server_version(self) -> str | None:
+        """
+        :return: the version of the language server (as determined on creation) or None if it is not known
+        """
+        return None
+
+    # To be overridden by subclasses
+    def get_minimum_server_version(self) -> str | None:
+        """
+        :return: the minimum version of the language server that is required or None if there is no such requirement
+        """
+        return None
+
     @classmethod
     def get_language_enum_instance(cls) -> Language:
         return Language.from_ls_class(cls)
@@ -189,9 +219,17 @@ class SolidLanguageServer(ABC):
 
         # load cache first to prevent any racing conditions due to asyncio stuff
         self._document_symbols_cache: dict[
-            str, tuple[str, tuple[list[ls_types.UnifiedSymbolInformation], list[ls_types.UnifiedSymbolInformation]]]
+            str,
+            tuple[
+                str,
+                tuple[list[ls_types.UnifiedSymbolInformation], list[ls_types.UnifiedSymbolInformation]],
+                tuple[int, int] | None,
+            ],
         ] = {}
-        """Maps file paths to a tuple of (file_content_hash, result_of_request_document_symbols)"""
+        """
+        Maps file paths to a tuple of (file_content_hash, result_of_request_document_symbols, file_version), where the
+        file version is the file's (modification time in ns, size) on disk if the symbols were determined for it
+        """
         self._cache_lock = threading.Lock()
         self._cache_has_changed: bool = False
         self.load_cache()
@@ -228,6 +266,8 @@ class SolidLanguageServer(ABC):
 
         # Create a pathspec matcher from the processed patterns
         self._ignore_spec = pathspec.PathSpec.from_lines(pathspec.patterns.GitWildMatchPattern, processed_patterns)
+        self._include_vendor = config.include_vendor
+        self._include_generated = config.include_generated
 
         self._server_context = None
         self._request_timeout: float | None = None
@@ -293,6 +333,11 @@ class SolidLanguageServer(ABC):
                 continue
             if self.is_ignored_dirname(part):
                 return True
+            if not self._include_vendor and self.is_vendor_dirname(part):
+                return True
+
+        if is_file and not self._include_generated and self.is_generated_file(relative_path):
+            return True
 
         return match_path(relative_path, self.get_ignore_spec(), root_path=self.repository_root_path)
 
@@ -419,6 +464,37 @@ class SolidLanguageServer(ABC):
             )
             del self.open_file_buffers[uri]
 
+    @contextmanager
+    def overlay_file(self, relative_file_path: str, content: str) -> Iterator[LSPFileBuffer]:
+        """
+        Temporarily replaces the content of the given file as seen by the Language Server (without changing the file on disk),
+        such that requests made within the context refer to the given content. The original content is restored afterwards.
+
+        :param relative_file_path: The relative path of the file
+        :param content: The content to use within the context
+        """
+        with self.open_file(relative_file_path) as file_buffer:
+            original_content = file_buffer.contents
+            self._replace_file_buffer_contents(file_buffer, content)
+            try:
+                yield file_buffer
+            finally:
+                self._replace_file_buffer_contents(file_buffer, original_content)
+
+    def _replace_file_buffer_contents(self, file_buffer: LSPFileBuffer, content: str) -> None:
+        file_buffer.version += 1
+        file_buffer.contents = content
+        file_buffer.content_hash = hashlib.md5(content.encode("utf-8")).hexdigest()
+        self.server.notify.did_change_text_document(
+            {
+                LSPConstants.TEXT_DOCUMENT: {
+                    LSPConstants.VERSION: file_buffer.version,
+                    LSPConstants.URI: file_buffer.uri,
+                },
+                LSPConstants.CONTENT_CHANGES: [{"text": content}],
+            }
+        )
+
     def insert_text_at_position(self, relative_file_path: str, line: int, column: int, text_to_be_inserted: str) -> ls_types.Position:
         """
         Insert text at the given line and column in the given file and return
@@ -447,6 +523,7 @@ class SolidLanguageServer(ABC):
 
         new_contents, new_l, new_c = TextUtils.insert_text_at_position(file_buffer.contents, line, column, text_to_be_inserted)
         file_buffer.contents = new_contents
+        file_buffer.content_hash = hashlib.md5(new_contents.encode("utf-8")).hexdigest()
         self.server.notify.did_change_text_document(
             {
                 LSPConstants.TEXT_DOCUMENT: {
@@ -494,6 +571,7 @@ class SolidLanguageServer(ABC):
             file_buffer.contents, start_line=start["line"], start_col=start["character"], end_line=end["line"], end_col=end["character"]
         )
         file_buffer.contents = new_contents
+        file_buffer.content_hash = hashlib.md5(new_contents.encode("utf-8")).hexdigest()
         self.server.notify.did_change_text_document(
             {
                 LSPConstants.TEXT_DOCUMENT: {
@@ -668,6 +746,200 @@ class SolidLanguageServer(ABC):
 
         return ret
 
+    def request_implementation(self, relative_file_path: str, line: int, column: int) -> list[ls_types.Location]:
+        """
+        Raise a [textDocument/implementation](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_implementation) request to the Language Server
+        for the symbol at the given line and column in the given file. Wait for the response and return the result.
+        For an interface (method), the locations of the implementing types (methods) are returned; some Language Servers
+        (e.g. gopls) also return the interfaces which a concrete type implements.
+        Filters out locations outside the repository (e.g. interfaces of the standard library) and in ignored directories.
+
+        :param relative_file_path: The relative path of the file that has the symbol for which implementations should be looked up
+        :param line: The line number of the symbol
+        :param column: The column number of the symbol
+
+        :return: A list of locations of the implementations (excluding ignored directories)
+        """
+        if not self.server_started:
+            self.logger.log(
+                "request_implementation called before Language Server started",
+                logging.ERROR,
+            )
+            raise SolidLSPException("Language Server not started")
+
+        if not self._has_waited_for_cross_file_references:
+            # Some LS require waiting for a while before they can return cross-file results.
+            sleep(self._get_wait_time_for_cross_file_referencing())
+            self._has_waited_for_cross_file_references = True
+
+        with self.open_file(relative_file_path):
+            response = self.server.send.implementation(
+                {
+                    "textDocument": {"uri": PathUtils.path_to_uri(os.path.join(self.repository_root_path, relative_file_path))},
+                    "position": {"line": line, "character": column},
+                }
+            )
+        if response is None:
+            return []
+
+        ret: list[ls_types.Location] = []
+        # the response is of type Location, Location[] or LocationLink[]
+        for item in response if isinstance(response, list) else [response]:
+            assert isinstance(item, dict), f"Unexpected response from Language Server (expected dict, got {type(item)}): {item}"
+            if LSPConstants.TARGET_URI in item:
+                uri, item_range = item[LSPConstants.TARGET_URI], item[LSPConstants.TARGET_SELECTION_RANGE]
+            else:
+                assert LSPConstants.URI in item
+                assert LSPConstants.RANGE in item
+                uri, item_range = item[LSPConstants.URI], item[LSPConstants.RANGE]
+
+            rel_path = self._get_relative_path_in_repository(uri, "implementation")
+            if rel_path is None:
+                continue
+            abs_path = os.path.join(self.repository_root_path, rel_path)
+            ret.append(ls_types.Location(uri=uri, range=item_range, absolutePath=abs_path, relativePath=rel_path))
+
+        return ret
+
+    def _get_relative_path_in_repository(self, uri: str, item_description: str) -> str | None:
+        """
+        :param uri: the URI of a file reported by the Language Server
+        :param item_description: the description of the reported item for logging, e.g. "implementation"
+        :return: the path of the file relative to the repository root or None if the file is outside the repository or
+            ignored
+        """
+        abs_path = PathUtils.uri_to_path(uri)
+        if not Path(abs_path).is_relative_to(self.repository_root_path):
+            self.logger.log(f"Skipping {item_description} outside the repository: {abs_path}", logging.DEBUG)
+            return None
+        rel_path = str(Path(abs_path).relative_to(self.repository_root_path))
+        if self.is_ignored_path(rel_path):
+            self.logger.log(f"Ignoring {item_description} in {rel_path} since it should be ignored", logging.DEBUG)
+            return None
+        return rel_path
+
+    def request_call_hierarchy_items(self, relative_file_path: str, line: int, column: int) -> list[lsp_types.CallHierarchyItem]:
+        """
+        Raise a [textDocument/prepareCallHierarchy](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_prepareCallHierarchy) request to the Language Server
+        for the symbol at the given line and column in the given file. Wait for the response and return the result.
+        The returned items are the starting points for `request_incoming_calls` and `request_outgoing_calls`.
+
+        :param relative_file_path: The relative path of the file that has the symbol
+        :param line: The line number of the symbol
+        :param column: The column number of the symbol
+
+        :return: The call hierarchy items for the symbol (usually a single item; empty if the symbol is not callable)
+        """
+        if not self.server_started:
+            self.logger.log(
+                "request_call_hierarchy_items called before Language Server started",
+                logging.ERROR,
+            )
+            raise SolidLSPException("Language Server not started")
+
+        with self.open_file(relative_file_path):
+            response = self.server.send.prepare_call_hierarchy(
+                {
+                    "textDocument": {"uri": PathUtils.path_to_uri(os.path.join(self.repository_root_path, relative_file_path))},
+                    "position": {"line": line, "character": column},
+                }
+            )
+        return response or []
+
+    def request_incoming_calls(self, item: lsp_types.CallHierarchyItem) -> list[lsp_types.CallHierarchyIncomingCall]:
+        """
+        Raise a [callHierarchy/incomingCalls](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#callHierarchy_incomingCalls) request to the Language Server
+        for the given call hierarchy item. Wait for the response and return the result.
+
+        :param item: a call hierarchy item as returned by `request_call_hierarchy_items` or by a previous call request
+        :return: The calls of the item, each with the calling item (`from`) and the ranges of the calls within the calling
+            item (`fromRanges`); callers outside the repository or in ignored directories are filtered out
+        """
+        if not self.server_started:
+            self.logger.log(
+                "request_incoming_calls called before Language Server started",
+                logging.ERROR,
+            )
+            raise SolidLSPException("Language Server not started")
+
+        if not self._has_waited_for_cross_file_references:
+            # Some LS require waiting for a while before they can return cross-file results.
+            sleep(self._get_wait_time_for_cross_file_referencing())
+            self._has_waited_for_cross_file_references = True
+
+        response = self.server.send.incoming_calls({"item": item})
+        return [call for call in response or [] if self._get_relative_path_in_repository(call["from"]["uri"], "caller") is not None]
+
+    def request_outgoing_calls(self, item: lsp_types.CallHierarchyItem) -> list[lsp_types.CallHierarchyOutgoingCall]:
+        """
+        Raise a [callHierarchy/outgoingCalls](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#callHierarchy_outgoingCalls) request to the Language Server
+        for the given call hierarchy item. Wait for the response and return the result.
+
+        :param item: a call hierarchy item as returned by `request_call_hierarchy_items` or by a previous call request
+        :return: The calls made by the item, each with the called item (`to`) and the ranges of the calls within the given
+            item (`fromRanges`); callees outside the repository (e.g. functions of the standard library) or in ignored
+            directories are filtered out
+        """
+        if not self.server_started:
+            self.logger.log(
+                "request_outgoing_calls called before Language Server started",
+                logging.ERROR,
+            )
+            raise SolidLSPException("Language Server not started")
+
+        response = self.server.send.outgoing_calls({"item": item})
+        return [call for call in response or [] if self._get_relative_path_in_repository(call["to"]["uri"], "callee") is not None]
+
+    def request_rename(self, relative_file_path: str, line: int, column: int, new_name: str) -> dict[str, list[ls_types.TextEdit]]:
+        """
+        Raise a [textDocument/rename](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_rename) request to the Language Server
+        for the symbol at the given line and column in the given file. Wait for the response and return the result.
+        The Language Server computes the edits renaming the symbol and all its references; nothing is applied.
+
+        :param relative_file_path: The relative path of the file that has the symbol to rename
+        :param line: The line number of the symbol
+        :param column: The column number of the symbol
+        :param new_name: The new name of the symbol
+
+        :return: A mapping from the relative paths of the files to change to the respective text edits, whose ranges
+            refer to the current contents of the files. A SolidLSPException is raised if the rename would change files
+            outside the repository or requires file operations (e.g. renaming a file), which are not supported.
+        """
+        if not self.server_started:
+            self.logger.log(
+                "request_rename called before Language Server started",
+                logging.ERROR,
+            )
+            raise SolidLSPException("Language Server not started")
+
+        with self.open_file(relative_file_path):
+            response = self.server.send.rename(
+                {
+                    "textDocument": {"uri": PathUtils.path_to_uri(os.path.join(self.repository_root_path, relative_file_path))},
+                    "position": {"line": line, "character": column},
+                    "newName": new_name,
+                }
+            )
+        if response is None:
+            return {}
+
+        # the workspace edit specifies the changes either per URI (changes) or as document changes, which may include
+        # file operations
+        uri_edits: list[tuple[str, list]] = list(response.get("changes", {}).items())
+        for document_change in response.get("documentChanges", []):
+            if "kind" in document_change:
+                raise SolidLSPException(f"Renaming requires a file operation ({document_change['kind']}), which is not supported")
+            uri_edits.append((document_change[LSPConstants.TEXT_DOCUMENT][LSPConstants.URI], document_change["edits"]))
+
+        ret: dict[str, list[ls_types.TextEdit]] = {}
+        for uri, edits in uri_edits:
+            abs_path = PathUtils.uri_to_path(uri)
+            if not Path(abs_path).is_relative_to(self.repository_root_path):
+                raise SolidLSPException(f"Renaming would change a file outside the repository: {abs_path}")
+            rel_path = str(Path(abs_path).relative_to(self.repository_root_path))
+            ret.setdefault(rel_path, []).extend(ls_types.TextEdit(range=edit["range"], newText=edit["newText"]) for edit in edits)
+        return ret
+
     def request_text_document_diagnostics(self, relative_file_path: str) -> list[ls_types.Diagnostic]:
         """
         Raise a [textDocument/diagnostic](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_diagnostic) request to the Language Server
@@ -828,6 +1100,72 @@ class SolidLanguageServer(ABC):
 
             return [json.loads(json_repr) for json_repr in set(json.dumps(item, sort_keys=True) for item in completions_list)]
 
+    def has_up_to_date_document_symbols(self, relative_file_path: str, include_body: bool = False) -> bool:
+        """
+        Checks whether the document symbols of the given file are cached for the file's current content,
+        i.e. whether `request_document_symbols` can be answered without querying the Language Server.
+
+        :param relative_file_path: The relative path of the file
+        :param include_body: whether to check the cache entry for symbols including their bodies
+        :return: whether the cache entry exists and was computed for the current content of the file
+        """
+        with self._cache_lock:
+            cache_entry = self._document_symbols_cache.get(f"{relative_file_path}-{include_body}")
+        if cache_entry is None:
+            return False
+        file_hash, _result, file_version = cache_entry
+        return self._is_current_content(relative_file_path, file_hash, file_version)
+
+    def retrieve_current_file_content(self, relative_file_path: str) -> str:
+        """
+        Unlike `retrieve_full_file_content`, this does not open the file in the Language Server.
+
+        :param relative_file_path: The relative path of the file
+        :return: the file's current content, i.e. the buffer's content if the file is open (where edits may not have been
+            saved yet) and the content on disk otherwise
+        """
+        file_buffer = self.get_open_file_buffer(relative_file_path)
+        if file_buffer is not None:
+            return file_buffer.contents
+        return FileUtils.read_file(self.logger, str(PurePath(self.repository_root_path, relative_file_path)))
+
+    def get_open_file_buffer(self, relative_file_path: str) -> LSPFileBuffer | None:
+        """
+        :param relative_file_path: The relative path of the file
+        :return: the file's buffer if the file is open in the Language Server, None otherwise
+        """
+        absolute_file_path = str(PurePath(self.repository_root_path, relative_file_path))
+        return self.open_file_buffers.get(pathlib.Path(absolute_file_path).as_uri())
+
+    def _get_file_version(self, relative_file_path: str) -> tuple[int, int] | None:
+        """
+        :param relative_file_path: The relative path of the file
+        :return: the file's (modification time in ns, size) on disk, or None if the file does not exist
+        """
+        try:
+            stat = os.stat(os.path.join(self.repository_root_path, relative_file_path))
+        except OSError:
+            return None
+        return stat.st_mtime_ns, stat.st_size
+
+    def _is_current_content(self, relative_file_path: str, content_hash: str, file_version: tuple[int, int] | None) -> bool:
+        """
+        Checks whether the given content hash is the hash of the file's current content (see `retrieve_current_file_content`).
+        For files which are not open, the content is only read and hashed if the file's version on disk differs from the
+        given one.
+
+        :param relative_file_path: The relative path of the file
+        :param content_hash: the hash of the content to check
+        :param file_version: the file's (modification time in ns, size) on disk for which the hash was computed, if known
+        :return: whether the file's current content has the given hash
+        """
+        file_buffer = self.get_open_file_buffer(relative_file_path)
+        if file_buffer is not None:
+            return file_buffer.content_hash == content_hash
+        if file_version is not None and file_version == self._get_file_version(relative_file_path):
+            return True
+        return hashlib.md5(self.retrieve_current_file_content(relative_file_path).encode("utf-8")).hexdigest() == content_hash
+
     def request_document_symbols(
         self, relative_file_path: str, include_body: bool = False
     ) -> tuple[list[ls_types.UnifiedSymbolInformation], list[ls_types.UnifiedSymbolInformation]]:
@@ -847,19 +1185,25 @@ class SolidLanguageServer(ABC):
         # TODO: it's kinda dumb to not use the cache if include_body is False after include_body was True once
         #   Should be fixed in the future, it's a small performance optimization
         cache_key = f"{relative_file_path}-{include_body}"
-        with self.open_file(relative_file_path) as file_data:
-            with self._cache_lock:
-                file_hash_and_result = self._document_symbols_cache.get(cache_key)
-                if file_hash_and_result is not None:
-                    file_hash, result = file_hash_and_result
-                    if file_hash == file_data.content_hash:
-                        self.logger.log(f"Returning cached document symbols for {relative_file_path}", logging.DEBUG)
-                        return result
-                    else:
-                        self.logger.log(f"Content for {relative_file_path} has changed. Will overwrite in-memory cache", logging.DEBUG)
-                else:
-                    self.logger.log(f"No cache hit for symbols with {include_body=} in {relative_file_path}", logging.DEBUG)
+        # cache hits are answered without opening the file in the Language Server, such that the symbols of unchanged
+        # files (e.g. from the persisted cache of a previous session) are available without any LS interaction
+        with self._cache_lock:
+            cache_entry = self._document_symbols_cache.get(cache_key)
+        if cache_entry is not None:
+            file_hash, result, file_version = cache_entry
+            if self._is_current_content(relative_file_path, file_hash, file_version):
+                self.logger.log(f"Returning cached document symbols for {relative_file_path}", logging.DEBUG)
+                return result
+            self.logger.log(f"Content for {relative_file_path} has changed. Will overwrite in-memory cache", logging.DEBUG)
+        else:
+            self.logger.log(f"No cache hit for symbols with {include_body=} in {relative_file_path}", logging.DEBUG)
 
+        # the version is only known to match the content if the file is opened (i.e. read from disk) below; it is
+        # determined beforehand, such that changes made in the meantime lead to a mismatch (and thus a content check)
+        file_version = None
+        if pathlib.Path(self.repository_root_path, relative_file_path).as_uri() not in self.open_file_buffers:
+            file_version = self._get_file_version(relative_file_path)
+        with self.open_file(relative_file_path) as file_data:
             self.logger.log(f"Requesting document symbols for {relative_file_path} from the Language Server", logging.DEBUG)
             response = self.server.send.document_symbol(
                 {"textDocument": {"uri": pathlib.Path(os.path.join(self.repository_root_path, relative_file_path)).as_uri()}}
@@ -951,7 +1295,7 @@ class SolidLanguageServer(ABC):
         result = flat_all_symbol_list, root_nodes
         self.logger.log(f"Caching document symbols for {relative_file_path}", logging.DEBUG)
         with self._cache_lock:
-            self._document_symbols_cache[cache_key] = (file_data.content_hash, result)
+            self._document_symbols_cache[cache_key] = (file_data.content_hash, result, file_version)
             self._cache_has_changed = True
         return result
 
@@ -1051,8 +1395,7 @@ class SolidLanguageServer(ABC):
 
                     # Create file symbol, link with children
                     file_rel_path = str(Path(contained_dir_or_file_abs_path).resolve().relative_to(self.repository_root_path))
-                    with self.open_file(file_rel_path) as file_data:
-                        fileRange = self._get_range_from_file_content(file_data.contents)
+                    fileRange = self._get_range_from_file_content(self.retrieve_current_file_content(file_rel_path))
                     file_symbol = ls_types.UnifiedSymbolInformation(  # type: ignore
                         name=os.path.splitext(contained_dir_or_file_name)[0],
                         kind=ls_types.SymbolKind.File,
@@ -1205,6 +1548,87 @@ class SolidLanguageServer(ABC):
 
         return ls_types.Hover(**response)
 
+    def request_formatting(self, relative_file_path: str, tab_size: int = 4, insert_spaces: bool = True) -> list[ls_types.TextEdit]:
+        """
+        Raise a [textDocument/formatting](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_formatting) request to the Language Server
+        to format the given file. Wait for the response and return the result.
+
+        :param relative_file_path: The relative path of the file to format
+        :param tab_size: The size of a tab in spaces (ignored by language servers with a fixed style, e.g. gopls)
+        :param insert_spaces: Whether to prefer spaces over tabs (ignored by language servers with a fixed style)
+
+        :return: The edits which format the file (empty if the file is already formatted)
+        """
+        with self.open_file(relative_file_path):
+            response = self.server.send.formatting(
+                {
+                    "textDocument": {"uri": pathlib.Path(os.path.join(self.repository_root_path, relative_file_path)).as_uri()},
+                    "options": {"tabSize": tab_size, "insertSpaces": insert_spaces},
+                }
+            )
+        if response is None:
+            return []
+        return [ls_types.TextEdit(range=edit["range"], newText=edit["newText"]) for edit in response]
+
+    def request_source_action_edits(self, relative_file_path: str, action_kind: str) -> list[ls_types.TextEdit]:
+        """
+        Raise a [textDocument/codeAction](https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/#textDocument_codeAction) request
+        for the source action of the given kind (e.g. "source.organizeImports") for the entire file
+        and return the edits it applies to the file.
+
+        :param relative_file_path: The relative path of the file
+        :param action_kind: The kind of the code action
+
+        :return: The edits of the first matching code action (empty if there is no such action)
+        """
+        uri = pathlib.Path(os.path.join(self.repository_root_path, relative_file_path)).as_uri()
+        with self.open_file(relative_file_path) as file_buffer:
+            lines = file_buffer.contents.split("\n")
+            file_range = ls_types.Range(
+                start=ls_types.Position(line=0, character=0), end=ls_types.Position(line=len(lines) - 1, character=len(lines[-1]))
+            )
+            response = self.server.send.code_action(
+                {
+                    "textDocument": {"uri": uri},
+                    "range": file_range,
+                    "context": {"diagnostics": [], "only": [action_kind]},
+                }
+            )
+        for action in response or []:
+            if action.get("kind") != action_kind or "edit" not in action:
+                continue
+            return self.get_text_edits_for_file(action["edit"], uri)
+        return []
+
+    @staticmethod
+    def get_text_edits_for_file(workspace_edit: lsp_types.WorkspaceEdit, uri: str) -> list[ls_types.TextEdit]:
+        """
+        :param workspace_edit: a workspace edit
+        :param uri: the URI of a file
+        :return: the text edits which the workspace edit applies to the given file
+        """
+        edits: list[ls_types.TextEdit] = []
+        for document_change in workspace_edit.get("documentChanges", []):
+            if "textDocument" in document_change and document_change["textDocument"]["uri"] == uri:
+                edits.extend(ls_types.TextEdit(range=e["range"], newText=e["newText"]) for e in document_change["edits"])
+        for edit in workspace_edit.get("changes", {}).get(uri, []):
+            edits.append(ls_types.TextEdit(range=edit["range"], newText=edit["newText"]))
+        return edits
+
+    def apply_text_edits(self, relative_file_path: str, edits: list[ls_types.TextEdit]) -> None:
+        """
+        Applies the given (non-overlapping) text edits to the given file, which must be open.
+        The ranges of all edits refer to the contents of the file before any edit is applied; edits inserting at the same
+        position are applied such that their texts appear in the order of the edits (as required by the LSP specification).
+
+        :param relative_file_path: The relative path of the file
+        :param edits: The edits to apply
+        """
+        absolute_file_path = str(PurePath(self.repository_root_path, relative_file_path))
+        file_buffer = self.open_file_buffers[pathlib.Path(absolute_file_path).as_uri()]
+        if edits:
+            self._replace_file_buffer_contents(file_buffer, TextUtils.apply_text_edits(file_buffer.contents, edits))
+
     def retrieve_symbol_body(self, symbol: ls_types.UnifiedSymbolInformation | LSPTypes.DocumentSymbol | LSPTypes.SymbolInformation) -> str:
         """
         Load the body of the given symbol. If the body is already contained in the symbol, just return it.
@@ -1559,7 +1983,7 @@ class SolidLanguageServer(ABC):
             / self._solidlsp_settings.project_data_relative_path
             / self.CACHE_FOLDER_NAME
             / self.language_id
-            / "document_symbols_cache_v23-06-25.pkl"
+            / "document_symbols_cache_v26-10-14.pkl"
         )
 
     def save_cache(self):
@@ -1591,6 +2015,18 @@ class SolidLanguageServer(ABC):
                 with open(self.cache_path, "rb") as f:
                     self._document_symbols_cache = pickle.load(f)
                 self.logger.log(f"Loaded {len(self._document_symbols_cache)} document symbols from cache.", logging.INFO)
+                # drop the entries of files which were deleted (or moved) since the cache was saved; the entries of
+                # changed files are replaced when their symbols are requested next
+                deleted_keys = [
+                    key
+                    for key in self._document_symbols_cache
+                    if not os.path.isfile(os.path.join(self.repository_root_path, key.rsplit("-", 1)[0]))
+                ]
+                for key in deleted_keys:
+                    del self._document_symbols_cache[key]
+                if deleted_keys:
+                    self.logger.log(f"Removed {len(deleted_keys)} cached document symbols of deleted files", logging.INFO)
+                    self._cache_has_changed = True
             except Exception as e:
                 # cache often becomes corrupt, so just skip loading it
                 self.logger.log(
@@ -1606,7 +2042,7 @@ class SolidLanguageServer(ABC):
 
         :param query: The query string to filter symbols by
 
-        :return: A list of matching symbols
+        :return: A list of matching symbols (excluding symbols in ignored files)
         """
         response = self.server.send.workspace_symbol({"query": query})
         if response is None:
@@ -1622,6 +2058,15 @@ class SolidLanguageServer(ABC):
             assert LSPConstants.KIND in item
             assert LSPConstants.LOCATION in item
 
+            uri = item[LSPConstants.LOCATION].get(LSPConstants.URI)
+            if uri is not None:
+                abs_path = Path(PathUtils.uri_to_path(uri))
+                if abs_path.is_relative_to(self.repository_root_path) and abs_path.exists():
+                    rel_path = abs_path.relative_to(self.repository_root_path)
+                    if self.is_ignored_path(str(rel_path)):
+                        self.logger.log(f"Ignoring workspace symbol in {rel_path} since it should be ignored", logging.DEBUG)
+                        continue
+
             ret.append(ls_types.UnifiedSymbolInformation(**item))
 
         return ret
@@ -281,6 +281,10 @@ class LanguageServerConfig:
     start_independent_lsp_process: bool = True
     ignored_paths: list[str] = field(default_factory=list)
     """Paths, dirs or glob-like patterns. The matching will follow the same logic as for .gitignore entries"""
+    include_vendor: bool = False
+    """Whether files in vendor directories (as determined by the language server, see `is_vendor_dirname`) are considered"""
+    include_generated: bool = True
+    """Whether generated files (as determined by the language server, see `is_generated_file`) are considered"""
 
     @classmethod
     def from_dict(cls, env: dict):
@@ -319,6 +319,17 @@ class Hover(TypedDict):
     visualize the hover, e.g. by changing the background color. """
 
 
+class TextEdit(TypedDict):
+    """A textual edit applicable to a text document."""
+
+    range: Range
+    """ The range of the text document to be manipulated. To insert
+    text into a document create a range where start === end. """
+    newText: str
+    """ The string to be inserted. For delete operations use an
+    empty string. """
+
+
 class DiagnosticsSeverity(IntEnum):
     ERROR = 1
     WARNING = 2
@@ -17,7 +17,7 @@ import requests
 
 from solidlsp.ls_exceptions import SolidLSPException
 from solidlsp.ls_logger import LanguageServerLogger
-from solidlsp.ls_types import UnifiedSymbolInformation
+from solidlsp.ls_types import TextEdit, UnifiedSymbolInformation
 
 
 class InvalidTextLocationError(Exception):
@@ -109,6 +109,26 @@ class TextUtils:
         new_l, new_c = TextUtils._get_updated_position_from_line_and_column_and_edit(line, col, text_to_be_inserted)
         return new_text, new_l, new_c
 
+    @staticmethod
+    def apply_text_edits(text: str, edits: list[TextEdit]) -> str:
+        """
+        Applies the given (non-overlapping) text edits to the given text, whose ranges all refer to the original text.
+        Edits inserting at the same position are applied such that their texts appear in the order of the edits (as
+        required by the LSP specification).
+        Returns the modified text.
+        """
+        # apply edits in reverse order, such that the positions of edits yet to be applied remain valid
+        indexed_edits = sorted(
+            enumerate(edits), key=lambda ie: (ie[1]["range"]["start"]["line"], ie[1]["range"]["start"]["character"], ie[0]), reverse=True
+        )
+        for _, edit in indexed_edits:
+            start, end = edit["range"]["start"], edit["range"]["end"]
+            if (start["line"], start["character"]) != (end["line"], end["character"]):
+                text, _ = TextUtils.delete_text_between_positions(text, start["line"], start["character"], end["line"], end["character"])
+            if edit["newText"]:
+                text, _, _ = TextUtils.insert_text_at_position(text, start["line"], start["character"], edit["newText"])
+        return text
+
 
 class PathUtils:
     """
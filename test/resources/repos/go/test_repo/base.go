@@ -0,0 +1,37 @@
+package main
+
+import "fmt"
+
+// BaseStruct holds the state shared by the types in this package.
+// Other structs reuse it through embedding, Go's alternative to inheritance.
+type BaseStruct struct {
+	Name string
+	ID   int
+}
+
+// Execute prints the name and ID of the struct.
+func (b *BaseStruct) Execute() {
+	fmt.Printf("executing %s (%d)\n", b.Name, b.ID)
+}
+
+// GetName returns the name of the struct.
+//
+// Deprecated: use Name directly.
+func (b *BaseStruct) GetName() string {
+	return b.Name
+}
+
+// Processable is implemented by types that can process their data.
+type Processable interface {
+	Process() error
+	GetType() string
+}
+
+// Worker is a Processable that can also be executed.
+type Worker interface {
+	Processable
+	Execute()
+}
+
+// ByteSlice is a named slice type.
+type ByteSlice []byte
@@ -0,0 +1,31 @@
+package main
+
+import "fmt"
+
+// ChildStruct embeds BaseStruct, using embedding in place of inheritance.
+type ChildStruct struct {
+	BaseStruct
+	Value int
+}
+
+// Execute overrides the Execute method promoted from BaseStruct.
+func (c *ChildStruct) Execute() {
+	fmt.Printf("executing child %s\n", c.Name)
+	c.BaseStruct.Execute()
+}
+
+// Process processes the value of the child.
+func (c *ChildStruct) Process() error {
+	fmt.Printf("child: processing %v\n", c.Value)
+	return nil
+}
+
+// GetType returns the type name of the child.
+func (c *ChildStruct) GetType() string {
+	return "child:" + c.Name
+}
+
+// GetValue returns the value of the child.
+func (c *ChildStruct) GetValue() int {
+	return c.Value
+}
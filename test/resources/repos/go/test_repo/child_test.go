@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+// TestChildStructGetValue checks that GetValue returns the stored value.
+func TestChildStructGetValue(t *testing.T) {
+	c := &ChildStruct{Value: 42}
+	if got := c.GetValue(); got != 42 {
+		t.Errorf("GetValue() = %d, want 42", got)
+	}
+}
+
+// TestProcess checks that a child can be processed without errors.
+func TestProcess(t *testing.T) {
+	c := &ChildStruct{Value: 1}
+	if err := c.Process(); err != nil {
+		t.Fatalf("Process() failed: %v", err)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RunCommand executes the command with the given name and returns its output.
+func RunCommand(name string, args []string) (string, error) {
+	switch name {
+	case "echo":
+		return strings.Join(args, " "), nil
+	case "upper", "shout":
+		if len(args) == 0 {
+			return "", errors.New("nothing to convert")
+		}
+		return strings.ToUpper(strings.Join(args, " ")), nil
+	case "count":
+		total := 0
+		for _, arg := range args {
+			total += len(arg)
+		}
+		return fmt.Sprint(total), nil
+	default:
+		return "", fmt.Errorf("unknown command %q", name)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// LoadConfig reads the configuration file at the given path and returns its non-empty lines.
+func LoadConfig(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("empty configuration")
+	}
+	return lines, nil
+}
+
+// SaveConfig writes the given lines to the configuration file at the given path.
+func SaveConfig(path string, lines []string) error {
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		return err
+	}
+	return nil
+}
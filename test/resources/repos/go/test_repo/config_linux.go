@@ -0,0 +1,9 @@
+//go:build linux
+
+package main
+
+// Config describes where the configuration is stored on Linux.
+type Config struct {
+	Path string
+	Mode uint32
+}
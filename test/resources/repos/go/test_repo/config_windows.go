@@ -0,0 +1,9 @@
+//go:build windows
+
+package main
+
+// Config describes where the configuration is stored on Windows.
+type Config struct {
+	Path     string
+	Registry string
+}
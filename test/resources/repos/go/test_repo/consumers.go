@@ -0,0 +1,20 @@
+package main
+
+import "fmt"
+
+// RunProcessor processes p and describes the outcome. Of p, it only uses Process.
+func RunProcessor(p *ConcreteProcessor) string {
+	if err := p.Process(); err != nil {
+		return "failed: " + err.Error()
+	}
+	return "processed"
+}
+
+// Rewrite reads the data of rw and writes it back, which requires rw to be both readable and writable.
+func Rewrite(label string, rw *MultipleInterfaces) error {
+	data, err := rw.Read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", label, err)
+	}
+	return rw.Write(data)
+}
@@ -0,0 +1,27 @@
+package main
+
+// The bounds of a Counter's value.
+const (
+	MinCount = 0
+	MaxCount = 100
+)
+
+// Counter counts processed items up to MaxCount.
+type Counter struct {
+	count int
+}
+
+func (c *Counter) Increment() {
+	if c.count < MaxCount {
+		c.count++
+	}
+}
+
+// Value returns the current count.
+func (c *Counter) Value() int {
+	return c.count
+}
+
+func (c *Counter) reset() {
+	c.count = MinCount
+}
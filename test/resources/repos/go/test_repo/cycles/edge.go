@@ -0,0 +1,7 @@
+package cycles
+
+// Edge is a graph edge.
+type Edge struct {
+	Node
+	Weight int
+}
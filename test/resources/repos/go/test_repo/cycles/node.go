@@ -0,0 +1,9 @@
+// Package cycles contains a deliberate embedding cycle (Node embeds Edge, which embeds Node),
+// which is invalid Go and is used for testing cycle detection.
+package cycles
+
+// Node is a graph node.
+type Node struct {
+	Edge
+	Label string
+}
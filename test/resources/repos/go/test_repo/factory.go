@@ -0,0 +1,36 @@
+package main
+
+// NewProcessor creates a processor of the given kind.
+func NewProcessor(kind string) Processable {
+	switch kind {
+	case "child":
+		return &ChildStruct{}
+	case "multiple":
+		processor := &MultipleInterfaces{}
+		return processor
+	}
+	return &ConcreteProcessor{}
+}
+
+// NewDefaultProcessor creates the processor used if no kind is specified.
+func NewDefaultProcessor() Processable {
+	return NewProcessor("")
+}
+
+// WrapProcessor returns the given processor unchanged.
+func WrapProcessor(p Processable) Processable {
+	return p
+}
+
+// RunProcessors creates processors of the given kinds and runs them.
+func RunProcessors(kinds []string) error {
+	if err := NewProcessor("child").Process(); err != nil {
+		return err
+	}
+	for _, kind := range kinds {
+		if err := NewProcessor(kind).Process(); err != nil {
+			return err
+		}
+	}
+	return WrapProcessor(NewDefaultProcessor()).Process()
+}
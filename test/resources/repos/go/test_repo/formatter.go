@@ -0,0 +1,29 @@
+package main
+
+import "strings"
+
+// Formatter formats names for display.
+type Formatter struct {
+	Prefix string
+}
+
+// Format prefixes the given name.
+func (f *Formatter) Format(name string) string {
+	return f.Prefix + Capitalize(name)
+}
+
+// Title capitalizes each of the given words; it does not depend on the formatter's state.
+func (f *Formatter) Title(words ...string) string {
+	for i, word := range words {
+		words[i] = Capitalize(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// Capitalize converts the first letter of the given word to upper case.
+func Capitalize(word string) string {
+	if word == "" {
+		return word
+	}
+	return strings.ToUpper(word[:1]) + word[1:]
+}
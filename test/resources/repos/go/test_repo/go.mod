@@ -0,0 +1,3 @@
+module test_repo
+
+go 1.21
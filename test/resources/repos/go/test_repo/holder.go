@@ -0,0 +1,26 @@
+package main
+
+// Holder delegates its work to a processor, falling back to another one.
+type Holder struct {
+	name      string
+	processor Processable
+	fallback  Processable
+}
+
+// NewHolder creates a holder which processes its work with a child.
+func NewHolder(name string) *Holder {
+	return &Holder{name: name, processor: &ChildStruct{}}
+}
+
+// SetFallback sets the processor to use after the holder's processor.
+func (h *Holder) SetFallback(p Processable) {
+	h.fallback = p
+}
+
+// Run processes the work of the holder with both of its processors.
+func (h *Holder) Run() error {
+	if err := h.processor.Process(); err != nil {
+		return err
+	}
+	return h.fallback.Process()
+}
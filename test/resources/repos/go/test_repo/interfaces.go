@@ -0,0 +1,22 @@
+package main
+
+// Named is implemented by types that report a type name.
+type Named interface {
+	GetType() string
+}
+
+// NamedProcessor combines Processable and Named, which both require GetType.
+type NamedProcessor interface {
+	Processable
+	Named
+}
+
+// ProcessAll processes the given items, stopping at the first error.
+func ProcessAll(items []Processable) error {
+	for _, item := range items {
+		if err := item.Process(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package main
+
+import "fmt"
+
+// LabeledBase provides a name like BaseStruct, but no ID, and can thus replace it as an embedded base type.
+type LabeledBase struct {
+	Name string
+}
+
+// Execute prints the label.
+func (l *LabeledBase) Execute() {
+	fmt.Printf("executing %s\n", l.Name)
+}
@@ -0,0 +1,13 @@
+// Package api is the top layer of the layers packages, which form an import graph for testing package-level analyses.
+package api
+
+import (
+	"fmt"
+
+	"test_repo/layers/service"
+)
+
+// Handle answers a request for the given name.
+func Handle(name string) string {
+	return fmt.Sprintf("<%s>", service.Greet(name))
+}
@@ -0,0 +1,10 @@
+// Package events contains a deliberate import cycle (events imports hooks, which imports events),
+// which is invalid Go and is used for testing cycle detection.
+package events
+
+import "test_repo/layers/hooks"
+
+// Publish publishes an event for the given name.
+func Publish(name string) {
+	hooks.Run(name)
+}
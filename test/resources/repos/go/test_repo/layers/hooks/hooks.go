@@ -0,0 +1,14 @@
+// Package hooks runs the hooks of the events package (see events for the import cycle).
+package hooks
+
+import (
+	"test_repo/layers/events"
+	"test_repo/layers/store"
+)
+
+// Run runs the hooks for the given name, publishing a follow-up event for unknown names.
+func Run(name string) {
+	if store.Lookup(name) == "" {
+		events.Publish(name)
+	}
+}
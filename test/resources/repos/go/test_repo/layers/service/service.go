@@ -0,0 +1,9 @@
+// Package service implements the greetings served by the api package.
+package service
+
+import "test_repo/layers/store"
+
+// Greet greets the person with the given name.
+func Greet(name string) string {
+	return "hello " + store.Lookup(name)
+}
@@ -0,0 +1,9 @@
+// Package store provides the data of the service package.
+package store
+
+import "strings"
+
+// Lookup returns the full name of the person with the given name.
+func Lookup(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
@@ -0,0 +1,11 @@
+package main
+
+import "strings"
+
+// normalizeName trims and lower-cases a name.
+//
+// TODO: also collapse inner whitespace.
+func normalizeName(name string) string {
+	// FIXME: strings.ToLower is not aware of special casing rules
+	return strings.ToLower(strings.TrimSpace(name))
+}
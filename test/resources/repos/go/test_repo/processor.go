@@ -0,0 +1,62 @@
+package main
+
+import "fmt"
+
+// ConcreteProcessor collects data items and processes them.
+type ConcreteProcessor struct {
+	BaseStruct
+	data []string
+}
+
+// Process processes the collected data items.
+func (cp *ConcreteProcessor) Process() error {
+	fmt.Printf("concrete: processing %v\n", len(cp.data))
+	return nil
+}
+
+// GetType returns the type name of the processor.
+func (cp *ConcreteProcessor) GetType() string {
+	return "concrete:" + cp.Name
+}
+
+// AddData adds a data item to be processed.
+func (cp *ConcreteProcessor) AddData(item string) {
+	cp.data = append(cp.data, item)
+}
+
+// Readable is implemented by types whose data can be read.
+type Readable interface {
+	Read() ([]byte, error)
+}
+
+// Writable is implemented by types to which data can be written.
+type Writable interface {
+	Write(data []byte) error
+}
+
+// MultipleInterfaces implements Readable, Writable and Processable at once.
+type MultipleInterfaces struct {
+	data []byte
+}
+
+// Read returns the data written so far.
+func (mi *MultipleInterfaces) Read() ([]byte, error) {
+	return mi.data, nil
+}
+
+// Write appends the given data.
+func (mi *MultipleInterfaces) Write(data []byte) error {
+	mi.data = append(mi.data, data...)
+	return nil
+}
+
+// Process processes the written data.
+func (mi *MultipleInterfaces) Process() error {
+	fmt.Printf("multiple: processing %v\n", len(mi.data))
+	return nil
+}
+
+// GetType returns the type name including the data length.
+func (mi *MultipleInterfaces) GetType() string {
+	return fmt.Sprintf("multiple:%d", len(mi.data))
+}
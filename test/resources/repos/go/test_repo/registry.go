@@ -0,0 +1,13 @@
+package main
+
+// Registry maps names to processors.
+type Registry struct {
+	processors map[string]Processable
+	fallback   Processable
+	name       string
+}
+
+// Register adds a processor to the registry under the given name.
+func (r *Registry) Register(name string, p Processable) {
+	r.processors[name] = p
+}
@@ -0,0 +1,9 @@
+package main
+
+// ExecuteAll executes a child and a processor. The child declares its own Execute method, which takes
+// precedence over the one promoted from BaseStruct, whereas the processor uses the promoted method.
+func ExecuteAll(c *ChildStruct, cp *ConcreteProcessor) string {
+	c.Execute()
+	cp.Execute()
+	return cp.Name
+}
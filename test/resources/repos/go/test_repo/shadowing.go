@@ -0,0 +1,17 @@
+package main
+
+import "fmt"
+
+// Summarize describes the type of p. Its locals shadow package-level names, which hides them within the function.
+func Summarize(p Processable, normalizeName bool) string {
+	fmt := "processor of type "
+	if normalizeName {
+		return fmt + "[" + p.GetType() + "]"
+	}
+	return fmt + p.GetType()
+}
+
+// Report prints the summary of p.
+func Report(p Processable) {
+	fmt.Println(Summarize(p, false))
+}
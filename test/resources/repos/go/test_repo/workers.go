@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProcessConcurrently processes each of the given items in its own goroutine and collects the errors,
+// converting a panic of an item into an error.
+func ProcessConcurrently(items []Processable) []error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(items))
+	for i, item := range items {
+		wg.Add(1)
+		go func(i int, item Processable) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					errs[i] = fmt.Errorf("item %d panicked: %v", i, r)
+				}
+			}()
+			errs[i] = item.Process()
+		}(i, item)
+	}
+	wg.Wait()
+	return errs
+}
+
+// LockAll calls f while holding all the given mutexes. The mutexes are only released when LockAll returns.
+func LockAll(mutexes []*sync.Mutex, f func()) {
+	if f == nil {
+		panic("no function given")
+	}
+	for _, mu := range mutexes {
+		mu.Lock()
+		defer mu.Unlock()
+	}
+	f()
+}
@@ -64,6 +64,18 @@ class TestProjectConfigAutogenerate:
 
         assert config.language == Language.PYTHON
 
+    def test_autogenerate_go_workspace(self):
+        """Test that a go.work file makes Go the project language, even if other files dominate."""
+        (self.project_path / "go.work").write_text("go 1.21\n\nuse ./api\n")
+        (self.project_path / "api").mkdir()
+        (self.project_path / "api" / "main.go").write_text("package main\n")
+        for name in ("app.ts", "util.ts"):
+            (self.project_path / name).write_text("export {};\n")
+
+        config = ProjectConfig.autogenerate(self.project_path, save_to_disk=False)
+
+        assert config.language == Language.GO
+
     def test_autogenerate_saves_to_disk(self):
         """Test that autogenerate can save the configuration to disk."""
         # Create a Go file
@@ -0,0 +1,1411 @@
+"""
+Tests for the Go-specific behaviour of Serena's symbolic tools, using the Go test repository
+"""
+
+import json
+import logging
+import os
+import pickle
+import re
+import shutil
+import subprocess
+import types
+from collections.abc import Iterator
+from pathlib import Path
+
+import pytest
+
+import solidlsp.ls
+from serena.agent import SerenaAgent
+from serena.config.serena_config import ProjectConfig, RegisteredProject, SerenaConfig
+from serena.project import Project
+from serena.symbol import PositionInFile
+from serena.tools import (
+    AddInterfaceMethodAndStubTool,
+    AddJsonTagsTool,
+    AddStructFieldTool,
+    ApiCompatibilityTool,
+    AssignableTypesTool,
+    CheckSnippetSatisfiesTool,
+    ControlFlowFeaturesTool,
+    DetectCyclesTool,
+    DiffSymbolsTool,
+    DispatchTableTool,
+    FieldMethodTargetsTool,
+    FindAllTool,
+    FindByDocTool,
+    FindCalleesTool,
+    FindCallersTool,
+    FindImplementationsTool,
+    FindMarkersTool,
+    FindShadowingTool,
+    FindReferencingSymbolsTool,
+    FindSymbolTool,
+    GenerateMockTool,
+    GenerateStringerTool,
+    GetSymbolsOverviewTool,
+    GodocTool,
+    HoverTool,
+    InsertAfterSymbolTool,
+    InsertBeforeSymbolTool,
+    InterfaceAuditTool,
+    InterfaceMethodsTool,
+    InternalImportGraphTool,
+    LanguageServerStatusTool,
+    MinimalInterfaceTool,
+    MissingDocsTool,
+    OwningTypeTool,
+    PackageFilesTool,
+    ReceiverlessCandidatesTool,
+    ReindexTool,
+    RelatedTestsTool,
+    RemovalImpactTool,
+    RemoveStructFieldTool,
+    RenameSymbolTool,
+    ReplaceBlockTool,
+    ReplaceEmbeddingTool,
+    ReplaceSymbolBodyTool,
+    ResolveSelectorTool,
+    ReturnFlowTool,
+    SingleImplementerInterfacesTool,
+    SuggestDeduplicationTool,
+    SymbolsInRangeTool,
+    ToolRegistry,
+    TransformReferencesTool,
+    TypeViewTool,
+    UnusedInterfacesTool,
+    VariableTypeTool,
+    WrapErrorsTool,
+    ZeroValueTool,
+)
+from solidlsp.language_servers import gopls
+from solidlsp.ls_config import Language
+from test.conftest import get_repo_path
+
+
+def _create_go_agent(project_root: Path, include_vendor: bool = False, include_generated: bool = True) -> SerenaAgent:
+    project = Project(
+        project_root=str(project_root),
+        project_config=ProjectConfig(
+            project_name="test_repo_go",
+            language=Language.GO,
+            ignored_paths=[],
+            excluded_tools=set(),
+            read_only=False,
+            ignore_all_files_in_gitignore=True,
+            include_vendor=include_vendor,
+            include_generated=include_generated,
+            initial_prompt="",
+            encoding="utf-8",
+        ),
+    )
+    config = SerenaConfig(
+        gui_log_window_enabled=False,
+        web_dashboard=False,
+        log_level=logging.ERROR,
+        included_optional_tools=ToolRegistry().get_tool_names_optional(),
+    )
+    config.projects = [RegisteredProject.from_project_instance(project)]
+    return SerenaAgent(project="test_repo_go", serena_config=config)
+
+
+@pytest.fixture
+def go_agent(tmp_path: Path) -> Iterator[SerenaAgent]:
+    """
+    An agent operating on a temporary copy of the Go test repository, such that tests may edit files
+    """
+    repo_copy = tmp_path / "test_repo"
+    shutil.copytree(get_repo_path(Language.GO), repo_copy)
+    agent = _create_go_agent(repo_copy)
+    yield agent
+    if agent.language_server is not None:
+        agent.language_server.stop()
+
+
+def _read_file(agent: SerenaAgent, relative_path: str) -> str:
+    with open(os.path.join(agent.get_project_root(), relative_path), encoding="utf-8") as f:
+        return f.read()
+
+
+def _assert_gofmt_clean(agent: SerenaAgent, relative_path: str) -> None:
+    if shutil.which("gofmt") is None:
+        pytest.skip("gofmt is not available")
+    result = subprocess.run(["gofmt", "-l", relative_path], cwd=agent.get_project_root(), capture_output=True, text=True, check=True)
+    assert result.stdout.strip() == "", f"{relative_path} is not gofmt-formatted:\n{_read_file(agent, relative_path)}"
+
+
+def _find_symbols(agent: SerenaAgent, name_path: str, **kwargs) -> list[dict]:  # type: ignore
+    return json.loads(agent.get_tool(FindSymbolTool).apply_ex(name_path=name_path, **kwargs))["symbols"]
+
+
+@pytest.mark.go
+class TestGoSymbolTools:
+    def test_find_method_by_receiver_name_path(self, go_agent: SerenaAgent) -> None:
+        symbols = _find_symbols(go_agent, "ChildStruct/GetValue")
+        assert [s["relative_path"] for s in symbols] == ["child.go"]
+        assert symbols[0]["kind"] == "Method"
+
+    def test_vendor_files_are_excluded_by_default(self, go_agent: SerenaAgent) -> None:
+        vendored_dir = os.path.join(go_agent.get_project_root(), "vendor", "example.com", "lib")
+        os.makedirs(vendored_dir)
+        with open(os.path.join(vendored_dir, "lib.go"), "w", encoding="utf-8") as f:
+            f.write("package lib\n\nfunc VendoredHelper() {}\n")
+        assert _find_symbols(go_agent, "VendoredHelper") == []
+        assert [s["relative_path"] for s in _find_symbols(go_agent, "/BaseStruct")] == ["base.go"]
+
+    def test_exclude_generated_files(self, tmp_path: Path, monkeypatch: pytest.MonkeyPatch) -> None:
+        repo_copy = tmp_path / "test_repo"
+        shutil.copytree(get_repo_path(Language.GO), repo_copy)
+        agent = _create_go_agent(repo_copy, include_generated=False)
+        try:
+            assert _find_symbols(agent, "GeneratedVersion") == []
+            # non-generated files in the same directory still participate
+            assert [s["relative_path"] for s in _find_symbols(agent, "/BaseStruct")] == ["base.go"]
+            # the headers of unchanged files are not read again
+            language_server = agent.language_server
+            assert language_server is not None
+            with monkeypatch.context() as m:
+                m.setattr(gopls, "open", lambda *args, **kwargs: pytest.fail("a file's header was read"), raising=False)
+                assert language_server.is_generated_file("version_generated.go")
+                assert not language_server.is_generated_file("base.go")
+            (repo_copy / "base.go").write_text("// Code generated by hand. DO NOT EDIT.\n\n" + (repo_copy / "base.go").read_text())
+            assert language_server.is_generated_file("base.go")
+        finally:
+            if agent.language_server is not None:
+                agent.language_server.stop()
+
+    def test_go_workspace(self, tmp_path: Path) -> None:
+        workspace_root = tmp_path / "workspace"
+        files = {
+            "go.work": "go 1.21\n\nuse (\n\t./app\n\t./greet\n)\n",
+            "greet/go.mod": "module example.com/greet\n\ngo 1.21\n",
+            "greet/greet.go": (
+                'package greet\n\n// Hello returns a greeting.\nfunc Hello(name string) string {\n\treturn "Hello, " + name\n}\n'
+            ),
+            "app/go.mod": "module example.com/app\n\ngo 1.21\n",
+            "app/main.go": (
+                'package main\n\nimport (\n\t"fmt"\n\n\t"example.com/greet"\n)\n\nfunc main() {\n\tfmt.Println(greet.Hello("go"))\n}\n'
+            ),
+        }
+        for relative_path, content in files.items():
+            (workspace_root / relative_path).parent.mkdir(parents=True, exist_ok=True)
+            (workspace_root / relative_path).write_text(content, encoding="utf-8")
+        agent = _create_go_agent(workspace_root)
+        try:
+            assert [s["relative_path"] for s in _find_symbols(agent, "Hello")] == ["greet/greet.go"]
+            # references resolve across the module boundary
+            refs = json.loads(agent.get_tool(FindReferencingSymbolsTool).apply_ex(name_path="Hello", relative_path="greet/greet.go"))
+            assert [(r["relative_path"], r["name_path"]) for r in refs] == [("app/main.go", "main")]
+            # imports of the other workspace module count as internal
+            graph = json.loads(agent.get_tool(InternalImportGraphTool).apply_ex())
+            assert graph["edges"] == [{"from": "example.com/app", "to": "example.com/greet", "in_cycle": False}]
+        finally:
+            if agent.language_server is not None:
+                agent.language_server.stop()
+
+    def test_go_workspace_with_module_outside_project(self, tmp_path: Path) -> None:
+        project_root = tmp_path / "project"
+        files = {
+            "project/go.work": "go 1.21\n\nuse (\n\t./app\n\t../greet\n)\n",
+            "project/app/go.mod": "module example.com/app\n\ngo 1.21\n",
+            "project/app/main.go": (
+                'package main\n\nimport (\n\t"fmt"\n\n\t"example.com/greet"\n)\n\nfunc main() {\n\tfmt.Println(greet.Hello("go"))\n}\n'
+            ),
+            "greet/go.mod": "module example.com/greet\n\ngo 1.21\n",
+            "greet/greet.go": 'package greet\n\nfunc Hello(name string) string {\n\treturn "Hello, " + name\n}\n',
+        }
+        for relative_path, content in files.items():
+            (tmp_path / relative_path).parent.mkdir(parents=True, exist_ok=True)
+            (tmp_path / relative_path).write_text(content, encoding="utf-8")
+        agent = _create_go_agent(project_root)
+        try:
+            language_server = agent.language_server
+            assert language_server is not None
+            # the module outside the project is resolved through the go.work file (the call of greet.Hello in main)
+            definitions = language_server.request_definition("app/main.go", 9, 19)
+            assert [d["absolutePath"] for d in definitions] == [str(tmp_path / "greet" / "greet.go")]
+            # but its symbols are not part of the project, and imports of it are not internal
+            assert _find_symbols(agent, "Hello") == []
+            graph = json.loads(agent.get_tool(InternalImportGraphTool).apply_ex())
+            assert graph["edges"] == []
+        finally:
+            if agent.language_server is not None:
+                agent.language_server.stop()
+
+    def test_language_server_status(self, go_agent: SerenaAgent) -> None:
+        status = json.loads(go_agent.get_tool(LanguageServerStatusTool).apply_ex())
+        assert status["language"] == "go"
+        assert status["running"]
+        assert status["version"].startswith("v")
+        assert status["minimum_version"] == "v0.16.0"
+
+    def test_document_symbols_cache(self, go_agent: SerenaAgent, monkeypatch: pytest.MonkeyPatch) -> None:
+        language_server = go_agent.language_server
+        assert language_server is not None
+        symbols, _roots = language_server.request_document_symbols("child.go")
+        overview = language_server.request_dir_overview(".")
+        # the cached symbols of unchanged files are returned without opening the files in the language server
+        with monkeypatch.context() as m:
+            m.setattr(language_server, "open_file", lambda relative_path: pytest.fail(f"{relative_path} was opened"))
+            assert language_server.request_document_symbols("child.go")[0] is symbols
+            assert language_server.request_dir_overview(".").keys() == overview.keys()
+        # changing the file invalidates its entry
+        with open(os.path.join(go_agent.get_project_root(), "child.go"), "a", encoding="utf-8") as f:
+            f.write("\nfunc Extra() {}\n")
+        assert not language_server.has_up_to_date_document_symbols("child.go")
+        assert "Extra" in [s["name"] for s in language_server.request_document_symbols("child.go")[0]]
+        # when the persisted cache is loaded, the entries of deleted files are dropped
+        language_server.request_document_symbols("labeled.go")
+        language_server.save_cache()
+        os.remove(os.path.join(go_agent.get_project_root(), "labeled.go"))
+        language_server.load_cache()
+        language_server.save_cache()
+        with open(language_server.cache_path, "rb") as f:
+            cached_keys = set(pickle.load(f))
+        assert "child.go-False" in cached_keys and "labeled.go-False" not in cached_keys
+
+    def test_substring_search_with_warm_cache_sends_no_requests(self, go_agent: SerenaAgent, monkeypatch: pytest.MonkeyPatch) -> None:
+        language_server = go_agent.language_server
+        assert language_server is not None
+        symbols = _find_symbols(go_agent, "Exec", substring_matching=True)
+        assert symbols
+
+        class FailingRequests:
+            def __getattr__(self, method: str) -> None:
+                pytest.fail(f"{method} was requested from the language server")
+
+        # the files are unchanged, so their cached symbols are served without requests (and without hashing the contents)
+        with monkeypatch.context() as m:
+            m.setattr(language_server.server, "send", FailingRequests())
+            m.setattr(language_server, "open_file", lambda relative_path: pytest.fail(f"{relative_path} was opened"))
+            m.setattr(solidlsp.ls, "hashlib", types.SimpleNamespace(md5=lambda data: pytest.fail("a file's content was hashed")))
+            assert _find_symbols(go_agent, "Exec", substring_matching=True) == symbols
+
+    def test_reindex(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ReindexTool)
+        stats = json.loads(tool.apply_ex(force=True))
+        assert stats["num_files_total"] > 0
+        assert stats["num_files_indexed"] == stats["num_files_total"]
+        assert stats["failed_files"] == {} and not stats["cancelled"]
+        # unchanged files are not parsed again
+        stats = json.loads(tool.apply_ex())
+        assert (stats["num_files_indexed"], stats["num_files_unchanged"]) == (0, stats["num_files_total"])
+        with open(os.path.join(go_agent.get_project_root(), "child.go"), "a", encoding="utf-8") as f:
+            f.write("\n// trailing comment\n")
+        stats = json.loads(tool.apply_ex())
+        assert stats["num_files_indexed"] == 1
+        assert json.loads(tool.apply_ex(relative_path_or_dir="child.go"))["num_files_total"] == 1
+        # the progress is reported after each file
+        reports: list[tuple[float, float | None, str | None]] = []
+        tool.apply_ex(force=True, progress_reporter=lambda progress, total, message: reports.append((progress, total, message)))
+        assert [(progress, total) for progress, total, _ in reports] == [(i + 1, len(reports)) for i in range(len(reports))]
+        assert reports[-1][2] is not None
+        assert go_agent.get_reindexing_progress() is None
+        assert not go_agent.cancel_reindexing()
+        # a run exceeding its maximum duration is stopped after the current file
+        stats = json.loads(tool.apply_ex(force=True, max_duration_seconds=0))
+        assert stats["cancelled"] and stats["num_files_indexed"] == 1
+
+    def test_symbols_in_range(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(SymbolsInRangeTool)
+        # from the end of Process to the beginning of GetType
+        symbols = json.loads(tool.apply_ex(relative_path="processor.go", start_line=13, end_line=17))
+        assert [(s["name_path"], s["body_location"]) for s in symbols] == [
+            ("ConcreteProcessor/Process", {"start_line": 11, "end_line": 14}),
+            ("ConcreteProcessor/GetType", {"start_line": 17, "end_line": 19}),
+        ]
+        assert symbols[0]["relative_path"] == "processor.go"
+        # a field range includes the enclosing struct
+        symbols = json.loads(tool.apply_ex(relative_path="processor.go", start_line=7, end_line=7))
+        assert [s["name_path"] for s in symbols] == ["ConcreteProcessor", "ConcreteProcessor/data"]
+        assert json.loads(tool.apply_ex(relative_path="processor.go", start_line=15, end_line=15)) == []
+
+    def test_body_hash(self, go_agent: SerenaAgent) -> None:
+        original_hash = _find_symbols(go_agent, "GetValue")[0]["body_hash"]
+        assert original_hash
+        assert _find_symbols(go_agent, "GetValue")[0]["body_hash"] == original_hash
+        # the hash does not require the symbols to be loaded with their bodies
+        language_server = go_agent.language_server
+        assert language_server is not None
+        assert not language_server.has_up_to_date_document_symbols("child.go", include_body=True)
+        assert _find_symbols(go_agent, "GetValue", include_body=True)[0]["body_hash"] == original_hash
+
+        # editing another symbol does not affect the hash
+        go_agent.get_tool(ReplaceSymbolBodyTool).apply_ex(
+            name_path="ChildStruct/GetType",
+            relative_path="child.go",
+            body='func (c *ChildStruct) GetType() string {\n\treturn "child"\n}',
+        )
+        assert _find_symbols(go_agent, "GetValue")[0]["body_hash"] == original_hash
+
+        # editing the symbol itself changes the hash
+        go_agent.get_tool(ReplaceSymbolBodyTool).apply_ex(
+            name_path="ChildStruct/GetValue",
+            relative_path="child.go",
+            body="func (c *ChildStruct) GetValue() int {\n\treturn c.Value + 1\n}",
+        )
+        assert _find_symbols(go_agent, "GetValue")[0]["body_hash"] != original_hash
+
+    def test_symbols_overview_grouped_by_visibility(self, go_agent: SerenaAgent) -> None:
+        overview = json.loads(go_agent.get_tool(GetSymbolsOverviewTool).apply_ex(relative_path="processor.go", group_visibility=True))
+        elements = {e["name_path"]: e for e in overview}
+        assert "ConcreteProcessor/Process" not in elements
+        concrete_processor = elements["ConcreteProcessor"]
+        assert concrete_processor["exported"]["methods"] == ["Process", "GetType", "AddData"]
+        assert concrete_processor["unexported"] == {"fields": ["data"], "methods": []}
+        assert elements["Readable"]["exported"]["methods"] == ["Read"]
+
+    @pytest.mark.parametrize("relative_path", ["base.go", "child.go", "processor.go"])
+    def test_fast_symbols_overview(self, go_agent: SerenaAgent, relative_path: str) -> None:
+        overview_tool = go_agent.get_tool(GetSymbolsOverviewTool)
+        fast_overview = json.loads(overview_tool.apply_ex(relative_path=relative_path, fast=True))
+        assert fast_overview == json.loads(overview_tool.apply_ex(relative_path=relative_path))
+
+    def test_symbols_overview_with_sizes(self, go_agent: SerenaAgent) -> None:
+        overview_tool = go_agent.get_tool(GetSymbolsOverviewTool)
+        overview = json.loads(overview_tool.apply_ex(relative_path="child.go", include_sizes=True))
+        sizes = {e["name_path"]: e["num_lines"] for e in overview}
+        assert sizes == {
+            "ChildStruct": 4,
+            "ChildStruct/Execute": 4,
+            "ChildStruct/Process": 4,
+            "ChildStruct/GetType": 3,
+            "ChildStruct/GetValue": 3,
+        }
+        # the sizes are consistent with the symbols' ranges
+        (execute,) = _find_symbols(go_agent, "ChildStruct/Execute")
+        assert sizes["ChildStruct/Execute"] == execute["body_location"]["end_line"] - execute["body_location"]["start_line"] + 1
+        assert json.loads(overview_tool.apply_ex(relative_path="child.go", include_sizes=True, fast=True)) == overview
+        grouped_overview = json.loads(overview_tool.apply_ex(relative_path="child.go", include_sizes=True, group_visibility=True))
+        assert [(e["name_path"], e["num_lines"]) for e in grouped_overview] == [("ChildStruct", 4)]
+        assert "num_lines" not in json.loads(overview_tool.apply_ex(relative_path="child.go"))[0]
+
+    def test_find_symbol_pagination(self, go_agent: SerenaAgent) -> None:
+        all_symbols = _find_symbols(go_agent, "Process")
+        locations = [(s["relative_path"], s["body_location"]["start_line"]) for s in all_symbols]
+        assert locations == sorted(locations)
+        assert {"ChildStruct/Process", "ConcreteProcessor/Process", "MultipleInterfaces/Process"} <= {s["name_path"] for s in all_symbols}
+
+        assert json.loads(go_agent.get_tool(FindSymbolTool).apply_ex(name_path="Process"))["total_matches"] == len(all_symbols)
+        first_page = json.loads(go_agent.get_tool(FindSymbolTool).apply_ex(name_path="Process", limit=2))
+        assert first_page["total_matches"] == len(all_symbols)
+        assert first_page["symbols"] == all_symbols[:2]
+        second_page = json.loads(go_agent.get_tool(FindSymbolTool).apply_ex(name_path="Process", limit=2, offset=2))
+        assert second_page["total_matches"] == len(all_symbols)
+        assert second_page["symbols"] == all_symbols[2:4]
+
+    def test_find_symbol_within_parent(self, go_agent: SerenaAgent) -> None:
+        parent = _find_symbols(go_agent, "ConcreteProcessor", relative_path="processor.go")[0]
+        assert parent["symbol_id"] == "processor.go:ConcreteProcessor"
+        symbols = _find_symbols(go_agent, "Process", parent_symbol_id=parent["symbol_id"])
+        assert [(s["name_path"], s["symbol_id"]) for s in symbols] == [
+            ("ConcreteProcessor/Process", "processor.go:ConcreteProcessor/Process")
+        ]
+
+        # methods declared in other files of the package are found, too
+        go_agent.get_tool(InsertAfterSymbolTool).apply_ex(
+            name_path="normalizeName",
+            relative_path="markers.go",
+            body="func (b BaseStruct) normalizedName() string {\n\treturn normalizeName(b.Name)\n}",
+        )
+        symbols = _find_symbols(go_agent, "/normalizedName", parent_symbol_id="base.go:BaseStruct")
+        assert [(s["name_path"], s["relative_path"]) for s in symbols] == [("BaseStruct/normalizedName", "markers.go")]
+
+        result = go_agent.get_tool(FindSymbolTool).apply_ex(name_path="GetValue", parent_symbol_id=parent["symbol_id"])
+        assert result.startswith("Error") and "No symbol matching GetValue found below processor.go:ConcreteProcessor" in result
+        result = go_agent.get_tool(FindSymbolTool).apply_ex(name_path="Process", parent_symbol_id="processor.go:Missing")
+        assert result.startswith("Error") and "No symbol with ID" in result
+
+    def test_diff_symbols(self, go_agent: SerenaAgent) -> None:
+        original_content = _read_file(go_agent, "processor.go")
+        add_data = "// AddData adds a data item to be processed.\nfunc (cp *ConcreteProcessor) AddData(item string) {\n\tcp.data = append(cp.data, item)\n}\n"
+        reset = "// Reset discards the collected data items.\nfunc (cp *ConcreteProcessor) Reset() {\n\tcp.data = nil\n}\n"
+        assert add_data in original_content
+        other_content = original_content.replace(add_data, reset).replace('"multiple: processing %v\\n"', '"multiple: %v\\n"')
+
+        diff = json.loads(go_agent.get_tool(DiffSymbolsTool).apply_ex(relative_path="processor.go", other_content=other_content))
+        assert diff["added"] == [{"name_path": "ConcreteProcessor/Reset", "kind": "Method"}]
+        assert diff["removed"] == [{"name_path": "ConcreteProcessor/AddData", "kind": "Method"}]
+        assert diff["modified"] == [{"name_path": "MultipleInterfaces/Process", "kind": "Method"}]
+        # the file itself remains unchanged
+        assert _read_file(go_agent, "processor.go") == original_content
+        assert len(_find_symbols(go_agent, "ConcreteProcessor/AddData")) == 1
+
+    @pytest.mark.parametrize(
+        "type_name, expected_kind",
+        [("Processable", "interface"), ("BaseStruct", "struct"), ("ByteSlice", "slice")],
+    )
+    def test_underlying_kind(self, go_agent: SerenaAgent, type_name: str, expected_kind: str) -> None:
+        symbols = _find_symbols(go_agent, type_name, relative_path="base.go")
+        assert len(symbols) == 1
+        assert symbols[0]["underlying_kind"] == expected_kind
+
+    def test_no_underlying_kind_for_functions(self, go_agent: SerenaAgent) -> None:
+        symbols = _find_symbols(go_agent, "BaseStruct/GetName")
+        assert "underlying_kind" not in symbols[0]
+
+    def test_struct_embedded_fields(self, go_agent: SerenaAgent) -> None:
+        symbol = _find_symbols(go_agent, "ChildStruct", relative_path="child.go")[0]
+        assert symbol["embedded"] == [{"name": "BaseStruct", "type": "BaseStruct", "pointer": False, "exported": True}]
+        assert symbol["fields"] == [{"name": "Value", "type": "int", "exported": True}]
+        symbol = _find_symbols(go_agent, "ConcreteProcessor")[0]
+        assert [e["name"] for e in symbol["embedded"]] == ["BaseStruct"]
+        assert symbol["fields"] == [{"name": "data", "type": "[]string", "exported": False}]
+        assert "fields" not in _find_symbols(go_agent, "Processable")[0]
+
+    def test_find_symbol_flags_deprecated_symbols(self, go_agent: SerenaAgent) -> None:
+        symbol = _find_symbols(go_agent, "BaseStruct/GetName")[0]
+        assert (symbol["deprecated"], symbol["deprecation_message"]) == (True, "use Name directly.")
+        symbol = _find_symbols(go_agent, "BaseStruct/Execute")[0]
+        assert not symbol["deprecated"] and "deprecation_message" not in symbol
+
+    def test_find_symbol_reports_declarations(self, go_agent: SerenaAgent) -> None:
+        symbol = _find_symbols(go_agent, "MultipleInterfaces/Write", include_body=True)[0]
+        assert symbol["declaration"] == "func (mi *MultipleInterfaces) Write(data []byte) error"
+        assert symbol["body"].startswith(symbol["declaration"] + " {")
+        symbol = _find_symbols(go_agent, "Writable/Write")[0]
+        assert symbol["declaration"] == "Write(data []byte) error"
+        assert "declaration" not in _find_symbols(go_agent, "MultipleInterfaces")[0]
+
+    def test_find_symbol_lists_build_variants(self, go_agent: SerenaAgent) -> None:
+        symbol = _find_symbols(go_agent, "Config", relative_path="config_linux.go")[0]
+        assert symbol["build_variants"] == [
+            {"relative_path": "config_linux.go", "line": 5, "build_constraint": "linux"},
+            {"relative_path": "config_windows.go", "line": 5, "build_constraint": "windows"},
+        ]
+        assert "build_variants" not in _find_symbols(go_agent, "LoadConfig")[0]
+
+    def test_find_symbol_read_after_write(self, go_agent: SerenaAgent) -> None:
+        body = "func (c *ChildStruct) Double() int {\n\treturn 2 * c.Value\n}"
+        go_agent.get_tool(InsertAfterSymbolTool).apply_ex(name_path="ChildStruct/GetValue", relative_path="child.go", body=body)
+        # the edited file is parsed locally rather than waiting for the language server
+        symbols = _find_symbols(go_agent, "ChildStruct/Double", relative_path="child.go", include_body=True, read_after_write=True)
+        assert [(s["name_path"], s["symbol_id"], s["body"]) for s in symbols] == [
+            ("ChildStruct/Double", "child.go:ChildStruct/Double", body)
+        ]
+        assert symbols[0]["declaration"] == "func (c *ChildStruct) Double() int"
+        assert [s["relative_path"] for s in _find_symbols(go_agent, "Double", read_after_write=True)] == ["child.go"]
+        # the symbols of the language server, once it has caught up, agree with the local ones
+        assert _find_symbols(go_agent, "ChildStruct/Double", relative_path="child.go", include_body=True) == symbols
+        tool = go_agent.get_tool(FindSymbolTool)
+        result = tool.apply_ex(name_path="Double", parent_symbol_id="child.go:ChildStruct", read_after_write=True)
+        assert result.startswith("Error") and "cannot be combined with parent_symbol_id" in result
+
+    def test_insert_after_symbol_with_doc_comment(self, go_agent: SerenaAgent) -> None:
+        go_agent.get_tool(InsertAfterSymbolTool).apply_ex(
+            name_path="ChildStruct/GetValue",
+            relative_path="child.go",
+            body="func (c *ChildStruct) Double() int {\n\treturn 2 * c.Value\n}",
+            doc_comment="Double returns twice the value.\n\nIt is used in tests.",
+        )
+        content = _read_file(go_agent, "child.go")
+        assert "// Double returns twice the value.\n//\n// It is used in tests.\nfunc (c *ChildStruct) Double() int {" in content
+        _assert_gofmt_clean(go_agent, "child.go")
+
+    def test_insert_before_symbol_with_doc_comment(self, go_agent: SerenaAgent) -> None:
+        go_agent.get_tool(InsertBeforeSymbolTool).apply_ex(
+            name_path="ConcreteProcessor",
+            relative_path="processor.go",
+            body="type ProcessorOption func(*ConcreteProcessor)",
+            doc_comment="// ProcessorOption configures a ConcreteProcessor.",
+        )
+        content = _read_file(go_agent, "processor.go")
+        assert "// ProcessorOption configures a ConcreteProcessor.\ntype ProcessorOption func(*ConcreteProcessor)\n" in content
+        _assert_gofmt_clean(go_agent, "processor.go")
+
+    def test_find_markers(self, go_agent: SerenaAgent) -> None:
+        markers = json.loads(go_agent.get_tool(FindMarkersTool).apply_ex(relative_path="markers.go"))
+        assert [(m["marker"], m["text"], m["in_doc_comment"]) for m in markers] == [
+            ("TODO", "also collapse inner whitespace.", True),
+            ("FIXME", "strings.ToLower is not aware of special casing rules", False),
+        ]
+        assert [m["symbol"]["name_path"] for m in markers] == ["normalizeName", "normalizeName"]
+        assert [m["line"] for m in markers] == [6, 8]
+
+    def test_find_markers_restricted_to_given_markers(self, go_agent: SerenaAgent) -> None:
+        markers = json.loads(go_agent.get_tool(FindMarkersTool).apply_ex(markers=["FIXME"]))
+        assert [(m["relative_path"], m["marker"]) for m in markers] == [("markers.go", "FIXME")]
+
+    def test_referencing_symbols_report_roles(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(FindReferencingSymbolsTool)
+        refs = json.loads(tool.apply_ex(name_path="BaseStruct", relative_path="base.go"))
+        assert sorted(r["relative_path"] for r in refs if r["role"] == "embed") == ["child.go", "processor.go"]
+        refs = json.loads(tool.apply_ex(name_path="Processable/Process", relative_path="base.go"))
+        assert [(r["name_path"], r["role"]) for r in refs if r["relative_path"] == "interfaces.go"] == [("ProcessAll", "call")]
+        refs = json.loads(tool.apply_ex(name_path="Processable", relative_path="base.go"))
+        roles = {(r["relative_path"], r["name_path"]): r["role"] for r in refs}
+        assert roles[("base.go", "Worker")] == "embed"
+        assert roles[("interfaces.go", "ProcessAll")] == "type"
+
+    def test_hover(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(HoverTool)
+        hover_text = tool.apply_ex(relative_path="child.go", line=11, column=22)
+        assert "func (c *ChildStruct) Execute()" in hover_text
+        assert "Execute overrides the Execute method promoted from BaseStruct." in hover_text
+        assert "No hover information available" in tool.apply_ex(relative_path="child.go", line=10, column=0)
+
+
+@pytest.mark.go
+class TestGoTools:
+    def test_zero_value_of_value_type(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(ZeroValueTool).apply_ex(type_name_path="ByteSlice", relative_path="base.go"))
+        assert (result["zero_value"], result["needs_pointer"], result["required_fields"]) == ("ByteSlice{}", False, [])
+
+    def test_zero_value_of_pointer_type(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(ZeroValueTool).apply_ex(type_name_path="ConcreteProcessor", relative_path="processor.go"))
+        assert result["zero_value"] == "&ConcreteProcessor{}"
+        assert result["needs_pointer"]
+        assert sorted(result["pointer_receiver_methods"]) == ["AddData", "GetType", "Process"]
+        assert result["required_fields"] == []
+
+    def test_zero_value_reports_required_fields(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(ZeroValueTool).apply_ex(type_name_path="Registry", relative_path="registry.go"))
+        assert result["zero_value"] == "&Registry{}"
+        assert [(f["name"], f["type"]) for f in result["required_fields"]] == [
+            ("processors", "map[string]Processable"),
+            ("fallback", "Processable"),
+        ]
+
+    def test_package_files(self, go_agent: SerenaAgent) -> None:
+        files = json.loads(go_agent.get_tool(PackageFilesTool).apply_ex(relative_path="base.go"))
+        roles = {f["relative_path"]: f["role"] for f in files}
+        assert roles["base.go"] == roles["child.go"] == roles["processor.go"] == "regular"
+        assert roles["child_test.go"] == "test"
+        assert roles["version_generated.go"] == "generated"
+        assert {f["package"] for f in files} == {"main"}
+
+    def test_detect_embedding_cycle(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(DetectCyclesTool).apply_ex(relative_path="cycles"))
+        assert [c["cycle"] for c in result["embedding_cycles"]] == [["Edge", "Node", "Edge"]]
+        locations = result["embedding_cycles"][0]["locations"]
+        assert [(loc["type"], loc["relative_path"]) for loc in locations] == [("Edge", "cycles/edge.go"), ("Node", "cycles/node.go")]
+        assert result["import_cycles"] == []
+
+    def test_detect_cycles_in_acyclic_package(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(DetectCyclesTool).apply_ex(relative_path="base.go"))
+        assert result == {"embedding_cycles": [], "import_cycles": []}
+
+    def test_internal_import_graph(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(InternalImportGraphTool)
+        result = json.loads(tool.apply_ex(relative_path="layers"))
+        package_dirs = [n["package_dir"] for n in result["nodes"]]
+        assert package_dirs == ["layers/api", "layers/events", "layers/hooks", "layers/service", "layers/store"]
+
+        def short_name(import_path: str) -> str:
+            return import_path.removeprefix("test_repo/layers/")
+
+        edges = [(short_name(e["from"]), short_name(e["to"]), e["in_cycle"]) for e in result["edges"]]
+        assert edges == [
+            ("api", "service", False),
+            ("events", "hooks", True),
+            ("hooks", "events", True),
+            ("hooks", "store", False),
+            ("service", "store", False),
+        ]
+        assert result["cycles"] == [["test_repo/layers/events", "test_repo/layers/hooks", "test_repo/layers/events"]]
+
+    def test_internal_import_graph_in_dot_format(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(InternalImportGraphTool)
+        dot = tool.apply_ex(relative_path="layers/api", output_format="dot", include_external=True)
+        assert dot == 'digraph imports {\n  "test_repo/layers/api";\n  "fmt" [style=dashed];\n  "test_repo/layers/api" -> "fmt";\n}'
+        dot = tool.apply_ex(relative_path="layers", output_format="dot")
+        assert '  "test_repo/layers/events" -> "test_repo/layers/hooks" [color=red];\n' in dot
+        assert '  "test_repo/layers/api" -> "test_repo/layers/service";\n' in dot
+
+    @pytest.mark.parametrize(
+        "line, column, expected_identifier, expected_kind, expected_type",
+        [
+            (11, 6, "cp", "var", "*ConcreteProcessor"),  # receiver of ConcreteProcessor.Process
+            (43, 12, "data", "field", "[]byte"),  # mi.data in MultipleInterfaces.Read
+            (22, 37, "item", "var", "string"),  # parameter of ConcreteProcessor.AddData
+        ],
+    )
+    def test_variable_type(
+        self, go_agent: SerenaAgent, line: int, column: int, expected_identifier: str, expected_kind: str, expected_type: str
+    ) -> None:
+        result = json.loads(go_agent.get_tool(VariableTypeTool).apply_ex(relative_path="processor.go", line=line, column=column))
+        assert (result["identifier"], result["kind"], result["type"]) == (expected_identifier, expected_kind, expected_type)
+
+    def test_variable_type_requires_identifier(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(VariableTypeTool).apply_ex(relative_path="processor.go", line=43, column=2)
+        assert result.startswith("Error") and "not an identifier" in result
+
+    @pytest.mark.parametrize(
+        "relative_path, line, column, expected_target, expected_resolution, expected_shadowed",
+        [
+            ("selectors.go", 5, 3, "ChildStruct/Execute", "override", ["BaseStruct/Execute"]),  # c.Execute()
+            ("selectors.go", 6, 4, "BaseStruct/Execute", "promoted", []),  # cp.Execute()
+            ("selectors.go", 7, 11, "BaseStruct/Name", "promoted", []),  # cp.Name
+            ("child.go", 13, 16, "BaseStruct/Execute", "own", []),  # c.BaseStruct.Execute()
+        ],
+    )
+    def test_resolve_selector(
+        self,
+        go_agent: SerenaAgent,
+        relative_path: str,
+        line: int,
+        column: int,
+        expected_target: str,
+        expected_resolution: str,
+        expected_shadowed: list[str],
+    ) -> None:
+        tool = go_agent.get_tool(ResolveSelectorTool)
+        result = json.loads(tool.apply_ex(relative_path=relative_path, line=line, column=column))
+        assert (result["target"]["name_path"], result["resolution"]) == (expected_target, expected_resolution)
+        assert [s["name_path"] for s in result["shadowed"]] == expected_shadowed
+        assert result["embedding_path"] == (["BaseStruct"] if expected_resolution == "promoted" else [])
+
+    def test_resolve_selector_rejects_qualified_identifier(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(ResolveSelectorTool).apply_ex(relative_path="child.go", line=12, column=6)
+        assert result.startswith("Error") and "qualified identifier" in result
+
+    def test_add_struct_field_with_import(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(AddStructFieldTool).apply_ex(
+            type_name_path="BaseStruct", relative_path="base.go", field_name="Created", field_type="time.Time", tag='json:"created"'
+        )
+        assert result == "OK", result
+        content = _read_file(go_agent, "base.go")
+        assert re.search(r"\tID +int\n\tCreated time\.Time `json:\"created\"`\n}", content)
+        assert '"time"' in content
+        _assert_gofmt_clean(go_agent, "base.go")
+
+    def test_add_struct_field_at_position(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(AddStructFieldTool).apply_ex(
+            type_name_path="BaseStruct", relative_path="base.go", field_name="Enabled", field_type="bool", position=0
+        )
+        assert result == "OK", result
+        assert re.search(r"struct {\n\tEnabled bool\n\tName +string\n", _read_file(go_agent, "base.go"))
+        _assert_gofmt_clean(go_agent, "base.go")
+
+    def test_add_existing_struct_field_fails(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(AddStructFieldTool).apply_ex(
+            type_name_path="BaseStruct", relative_path="base.go", field_name="Name", field_type="string"
+        )
+        assert "already has a field Name" in result
+
+    def test_add_struct_field_restores_file_if_formatting_fails(self, go_agent: SerenaAgent) -> None:
+        original_content = _read_file(go_agent, "base.go")
+        result = go_agent.get_tool(AddStructFieldTool).apply_ex(
+            type_name_path="BaseStruct", relative_path="base.go", field_name="Broken", field_type="map[string"
+        )
+        assert result.startswith("Error"), result
+        assert _read_file(go_agent, "base.go") == original_content
+
+    def test_add_json_tags(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(AddJsonTagsTool).apply_ex(type_name_path="BaseStruct", relative_path="base.go"))
+        assert result == {"tagged": [{"field": "Name", "tag": '`json:"name"`'}, {"field": "ID", "tag": '`json:"id"`'}], "skipped": []}
+        assert 'struct {\n\tName string `json:"name"`\n\tID   int    `json:"id"`\n}' in _read_file(go_agent, "base.go")
+        _assert_gofmt_clean(go_agent, "base.go")
+        # existing json keys are preserved
+        result = json.loads(go_agent.get_tool(AddJsonTagsTool).apply_ex(type_name_path="BaseStruct", relative_path="base.go"))
+        assert result["tagged"] == []
+        assert [s["reason"] for s in result["skipped"]] == ["already tagged", "already tagged"]
+
+    @pytest.mark.parametrize("tag_unexported, expected_data_field", [(False, "\tdata []string\n"), (True, '\tdata []string `json:"-"`\n')])
+    def test_add_json_tags_to_unexported_fields(self, go_agent: SerenaAgent, tag_unexported: bool, expected_data_field: str) -> None:
+        result = json.loads(
+            go_agent.get_tool(AddJsonTagsTool).apply_ex(
+                type_name_path="ConcreteProcessor", relative_path="processor.go", tag_unexported=tag_unexported
+            )
+        )
+        assert {"field": "BaseStruct", "reason": "embedded"} in result["skipped"]
+        assert ({"field": "data", "reason": "unexported"} in result["skipped"]) != tag_unexported
+        assert "\tBaseStruct\n" + expected_data_field in _read_file(go_agent, "processor.go")
+        _assert_gofmt_clean(go_agent, "processor.go")
+
+    def test_remove_struct_field(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(RemoveStructFieldTool).apply_ex(
+            type_name_path="Registry", relative_path="registry.go", field_name="name"
+        )
+        assert result == "OK", result
+        content = _read_file(go_agent, "registry.go")
+        assert "struct {\n\tprocessors map[string]Processable\n\tfallback   Processable\n}" in content
+        _assert_gofmt_clean(go_agent, "registry.go")
+
+    def test_owning_type(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(OwningTypeTool).apply_ex(method_name_path="ChildStruct/GetValue", relative_path="child.go"))
+        assert (result["name_path"], result["relative_path"], result["underlying_kind"]) == ("ChildStruct", "child.go", "struct")
+        assert result["fields"] == [
+            {"name": "BaseStruct", "type": "BaseStruct", "embedded": True},
+            {"name": "Value", "type": "int", "embedded": False},
+        ]
+
+    def test_owning_type_in_other_file(self, go_agent: SerenaAgent) -> None:
+        go_agent.get_tool(InsertAfterSymbolTool).apply_ex(
+            name_path="normalizeName",
+            relative_path="markers.go",
+            body="func (b BaseStruct) normalizedName() string {\n\treturn normalizeName(b.Name)\n}",
+        )
+        result = json.loads(go_agent.get_tool(OwningTypeTool).apply_ex(method_name_path="normalizedName", relative_path="markers.go"))
+        assert (result["name_path"], result["relative_path"]) == ("BaseStruct", "base.go")
+
+    def test_interface_methods(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(InterfaceMethodsTool).apply_ex(interface_name_path="Worker", relative_path="base.go"))
+        assert result["methods"] == [
+            {"name": "Process", "signature": "Process() error", "origin": "from Processable"},
+            {"name": "GetType", "signature": "GetType() string", "origin": "from Processable"},
+            {"name": "Execute", "signature": "Execute()", "origin": "own"},
+        ]
+        assert result["unresolved_embedded"] == []
+
+    def test_interface_methods_deduplicates_identical_signatures(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(
+            go_agent.get_tool(InterfaceMethodsTool).apply_ex(interface_name_path="NamedProcessor", relative_path="interfaces.go")
+        )
+        assert [(m["name"], m["origin"]) for m in result["methods"]] == [
+            ("Process", "from Processable"),
+            ("GetType", "from Processable, from Named"),
+        ]
+
+    def test_check_snippet_satisfies_reports_missing_method(self, go_agent: SerenaAgent) -> None:
+        snippet = "type Draft struct{}\n\nfunc (d Draft) Process() error {\n\treturn nil\n}\n"
+        tool = go_agent.get_tool(CheckSnippetSatisfiesTool)
+        result = json.loads(tool.apply_ex(snippet=snippet, interface_name_path="Processable", relative_path="base.go"))
+        assert not result["satisfied_by_value"] and not result["satisfied_by_pointer"]
+        assert result["missing"] == [{"name": "GetType", "expected": "GetType() string"}]
+
+    def test_check_snippet_satisfies_with_pointer_receivers_and_mismatches(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(CheckSnippetSatisfiesTool)
+        get_type = 'func (d Draft) GetType() string { return "" }\n'
+        snippet = "type Draft struct{}\n\nfunc (d *Draft) Process() error { return nil }\n\n" + get_type
+        result = json.loads(tool.apply_ex(snippet=snippet, interface_name_path="Processable", relative_path="base.go"))
+        assert (result["satisfied_by_value"], result["satisfied_by_pointer"]) == (False, True)
+        assert result["pointer_receiver_methods"] == ["Process"]
+        snippet = "type Draft struct{}\n\nfunc (d Draft) Process() {}\n\n" + get_type
+        result = json.loads(tool.apply_ex(snippet=snippet, interface_name_path="Processable", relative_path="base.go"))
+        assert result["mismatched"] == [{"name": "Process", "expected": "Process() error", "actual": "Process()"}]
+
+    def test_check_snippet_satisfies_reports_compile_errors(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(CheckSnippetSatisfiesTool)
+        snippet = "package other\n\ntype Draft int\n\nfunc (d Draft) Process() error { return errX }\n\n"
+        snippet += 'func (d Draft) GetType() string { return "" }\n'
+        result = json.loads(tool.apply_ex(snippet=snippet, interface_name_path="Processable", relative_path="base.go"))
+        assert result["type_checked"]
+        assert result["compile_errors"] == ["snippet:5:41: undefined: errX"]
+        assert not result["satisfied_by_value"] and not result["satisfied_by_pointer"]
+        assert result["missing"] == [] and result["mismatched"] == []
+        snippet = snippet.replace("errX", "nil")
+        result = json.loads(tool.apply_ex(snippet=snippet, interface_name_path="Processable", relative_path="base.go"))
+        assert (result["satisfied_by_value"], result["satisfied_by_pointer"], result["compile_errors"]) == (True, True, [])
+        assert not os.path.exists(os.path.join(go_agent.get_project_root(), "serena_snippet.go"))
+
+    def test_find_by_doc(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(FindByDocTool).apply_ex(query="Inheritance"))
+        assert [(r["name_path"], r["relative_path"]) for r in result] == [("BaseStruct", "base.go"), ("ChildStruct", "child.go")]
+        assert result[0]["snippet"] == "Other structs reuse it through embedding, Go's alternative to inheritance."
+
+    def test_find_by_doc_options(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(FindByDocTool)
+        assert json.loads(tool.apply_ex(query="Inheritance", case_sensitive=True)) == []
+        result = json.loads(tool.apply_ex(query="embed", relative_path="child.go"))
+        assert [r["name_path"] for r in result] == ["ChildStruct"]
+        assert json.loads(tool.apply_ex(query="embed", relative_path="child.go", whole_word=True)) == []
+
+    def test_missing_docs(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(MissingDocsTool)
+        # the constants are documented by their group's doc comment
+        result = json.loads(tool.apply_ex(relative_path_or_dir="counter.go"))
+        assert result == [
+            {"name_path": "Counter/Increment", "kind": "method", "relative_path": "counter.go", "line": 13, "insert_line": 13}
+        ]
+        result = json.loads(tool.apply_ex(relative_path_or_dir="counter.go", exported_only=False))
+        assert [r["name_path"] for r in result] == ["Counter/Increment", "Counter/reset"]
+        assert json.loads(tool.apply_ex(relative_path_or_dir="child.go")) == []
+
+    def test_godoc_type(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(GodocTool).apply_ex(name_path="BaseStruct", relative_path="base.go"))
+        assert (result["package"], result["kind"]) == ("main", "type")
+        assert result["declaration"] == "type BaseStruct struct {\n\tName string\n\tID   int\n}"
+        assert result["doc"].startswith("BaseStruct holds the state shared by the types in this package.")
+        assert not result["deprecated"]
+        assert result["methods"] == [
+            {
+                "name": "Execute",
+                "declaration": "func (b *BaseStruct) Execute()",
+                "doc": "Execute prints the name and ID of the struct.",
+                "deprecated": False,
+            },
+            {
+                "name": "GetName",
+                "declaration": "func (b *BaseStruct) GetName() string",
+                "doc": "GetName returns the name of the struct.\n\nDeprecated: use Name directly.",
+                "deprecated": True,
+                "deprecation_message": "use Name directly.",
+            },
+        ]
+
+    def test_godoc_method(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(GodocTool).apply_ex(name_path="ChildStruct/GetValue", relative_path="child.go"))
+        assert result["declaration"] == "func (c *ChildStruct) GetValue() int"
+        assert "methods" not in result
+
+    def test_add_interface_method_and_stub(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(
+            go_agent.get_tool(AddInterfaceMethodAndStubTool).apply_ex(
+                interface_name_path="Processable", relative_path="base.go", method_signature="Close() error"
+            )
+        )
+        assert [(s["type"], s["relative_path"], s["receiver"]) for s in result["stubbed"]] == [
+            ("ChildStruct", "child.go", "(c *ChildStruct)"),
+            ("ConcreteProcessor", "processor.go", "(cp *ConcreteProcessor)"),
+            ("MultipleInterfaces", "processor.go", "(mi *MultipleInterfaces)"),
+        ]
+        assert "\tGetType() string\n\tClose() error\n}" in _read_file(go_agent, "base.go")
+        assert "// Close implements Processable.\nfunc (cp *ConcreteProcessor) Close() error {" in _read_file(go_agent, "processor.go")
+        for relative_path in ("base.go", "child.go", "processor.go"):
+            _assert_gofmt_clean(go_agent, relative_path)
+
+    def test_add_existing_interface_method_fails(self, go_agent: SerenaAgent) -> None:
+        result = go_agent.get_tool(AddInterfaceMethodAndStubTool).apply_ex(
+            interface_name_path="Processable", relative_path="base.go", method_signature="GetType() string"
+        )
+        assert "already has a method GetType" in result
+
+    def test_return_flow(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(ReturnFlowTool).apply_ex(name_path="NewProcessor", relative_path="factory.go"))
+        assert result["result_type"] == "Processable"
+        all_types = ["*ChildStruct", "*MultipleInterfaces", "*ConcreteProcessor"]
+        assert result["returned_types"] == all_types
+        assert [(c["caller"], c["arguments"], c["returned_types"]) for c in result["call_sites"]] == [
+            ("NewDefaultProcessor", ['""'], ["*ConcreteProcessor"]),
+            ("RunProcessors", ['"child"'], ["*ChildStruct"]),
+            ("RunProcessors", ["kind"], all_types),
+        ]
+
+    def test_return_flow_of_returned_parameter(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(ReturnFlowTool).apply_ex(name_path="WrapProcessor", relative_path="factory.go"))
+        assert result["returned_types"] == ["indeterminate"]
+        assert [c["returned_types"] for c in result["call_sites"]] == [["*ConcreteProcessor"]]
+
+    def test_single_implementer_interfaces(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(SingleImplementerInterfacesTool)
+        result = json.loads(tool.apply_ex())
+        assert [(i["interface"], i["num_methods"], i["implementer"]["type"]) for i in result["interfaces"]] == [
+            ("Readable", 1, "MultipleInterfaces"),
+            ("Writable", 1, "MultipleInterfaces"),
+        ]
+        assert result["num_interfaces_checked"] == 6
+        result = json.loads(tool.apply_ex(relative_path="base.go"))
+        assert (result["interfaces"], result["num_interfaces_checked"]) == ([], 2)
+
+    def test_interface_audit(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(InterfaceAuditTool).apply_ex(relative_path_or_dir="base.go"))
+        assert [(i["interface"], i["num_methods"], i["num_implementers"]) for i in result] == [("Processable", 2, 3), ("Worker", 3, 2)]
+        worker = result[1]
+        assert (worker["methods"], worker["embeds"], worker["complete"]) == (["Execute"], ["Processable"], True)
+        assert [t["type"] for t in worker["implementers"]] == ["ChildStruct", "ConcreteProcessor"]
+        # the package main cannot be imported by other packages
+        assert not any(i["referenced_outside_package"] for i in result)
+
+    def test_unused_interfaces(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(UnusedInterfacesTool)
+        result = json.loads(tool.apply_ex())
+        # Processable is used as a parameter and result type; Named is embedded only in the unused NamedProcessor
+        interfaces = {i["interface"]: i for i in result["interfaces"]}
+        assert list(interfaces) == ["Worker", "Named", "NamedProcessor", "Readable", "Writable"]
+        assert interfaces["Readable"]["implementers"] == [{"type": "MultipleInterfaces", "relative_path": "processor.go"}]
+        assert interfaces["Readable"]["status"] == "never_consumed"
+        assert interfaces["Named"]["embedded_in"] == ["NamedProcessor"]
+        assert result["num_interfaces_checked"] == 6
+        result = json.loads(tool.apply_ex(relative_path_or_dir="base.go"))
+        assert ([i["interface"] for i in result["interfaces"]], result["num_interfaces_checked"]) == (["Worker"], 2)
+
+    def test_find_shadowing(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(FindShadowingTool).apply_ex(relative_path="shadowing.go"))
+        assert [(s["identifier"], s["line"], s["function"]) for s in result] == [("normalizeName", 5, "Summarize"), ("fmt", 6, "Summarize")]
+        assert result[0]["shadowed"] == {"kind": "func", "relative_path": "markers.go", "line": 7}
+        assert result[1]["shadowed"] == {"kind": "import", "relative_path": "shadowing.go", "line": 2, "import_path": "fmt"}
+        assert json.loads(go_agent.get_tool(FindShadowingTool).apply_ex(relative_path="processor.go")) == []
+
+    def test_transform_references(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(TransformReferencesTool)
+        original_contents = {p: _read_file(go_agent, p) for p in ("child.go", "selectors.go")}
+        template = 'fmt.Println("executing", {receiver}.Name)\n{expr}'
+        result = json.loads(tool.apply_ex(name_path="BaseStruct/Execute", relative_path="base.go", template=template, dry_run=True))
+        assert [(r["relative_path"], r["expression"]) for r in result["references"]] == [
+            ("child.go", "c.BaseStruct.Execute()"),
+            ("selectors.go", "cp.Execute()"),
+        ]
+        assert result["references"][1]["replacement"] == 'fmt.Println("executing", cp.Name)\ncp.Execute()'
+        assert '+\tfmt.Println("executing", cp.Name)\n \tcp.Execute()\n' in result["diff"]
+        assert (result["compile_errors"], result["applied"]) == ([], False)
+        assert {p: _read_file(go_agent, p) for p in original_contents} == original_contents
+        # Execute has no result, so the transformed code does not compile and the changes are rolled back
+        result = json.loads(tool.apply_ex(name_path="BaseStruct/Execute", relative_path="base.go", template="_ = {expr}"))
+        assert not result["applied"]
+        assert any("used as value" in error for error in result["compile_errors"])
+        assert {p: _read_file(go_agent, p) for p in original_contents} == original_contents
+        result = json.loads(tool.apply_ex(name_path="BaseStruct/Execute", relative_path="base.go", template=template))
+        assert result["applied"], result
+        content = _read_file(go_agent, "selectors.go")
+        assert '\tfmt.Println("executing", cp.Name)\n\tcp.Execute()\n' in content
+        assert '"fmt"' in content
+        _assert_gofmt_clean(go_agent, "selectors.go")
+
+    def test_replace_embedding(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ReplaceEmbeddingTool)
+        original_content = _read_file(go_agent, "processor.go")
+        result = json.loads(
+            tool.apply_ex(type_name_path="ConcreteProcessor", relative_path="processor.go", old_base="BaseStruct", new_base="LabeledBase")
+        )
+        assert "-\tBaseStruct\n+\tLabeledBase\n" in result["diff"]
+        assert result["missing_members"] == [
+            {"name": "GetName", "kind": "method", "owner": "BaseStruct"},
+            {"name": "ID", "kind": "field", "owner": "BaseStruct"},
+        ]
+        assert (result["compile_errors"], result["applied"]) == ([], False)
+        assert _read_file(go_agent, "processor.go") == original_content
+        # the child accesses the embedded field by its name, which changes with the type
+        original_content = _read_file(go_agent, "child.go")
+        result = json.loads(
+            tool.apply_ex(
+                type_name_path="ChildStruct", relative_path="child.go", old_base="BaseStruct", new_base="LabeledBase", commit=True
+            )
+        )
+        assert not result["applied"]
+        assert any("c.BaseStruct undefined" in error for error in result["compile_errors"])
+        assert _read_file(go_agent, "child.go") == original_content
+        result = json.loads(
+            tool.apply_ex(
+                type_name_path="ConcreteProcessor", relative_path="processor.go", old_base="BaseStruct", new_base="LabeledBase", commit=True
+            )
+        )
+        assert result["applied"]
+        assert "type ConcreteProcessor struct {\n\tLabeledBase\n\tdata []string\n}" in _read_file(go_agent, "processor.go")
+
+    def test_assignable_types(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(AssignableTypesTool).apply_ex(interface_name_path="Processable", relative_path="base.go"))
+        assert [(t["type"], t["assignable"]) for t in result["concrete_types"]] == [
+            ("ChildStruct", "*ChildStruct"),
+            ("ConcreteProcessor", "*ConcreteProcessor"),
+            ("MultipleInterfaces", "*MultipleInterfaces"),
+        ]
+        interfaces = [(t["type"], t["relative_path"]) for t in result["interfaces"]]
+        assert interfaces == [("Worker", "base.go"), ("NamedProcessor", "interfaces.go")]
+
+    def test_field_method_targets(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(FieldMethodTargetsTool)
+        # h.processor.Process()
+        result = json.loads(tool.apply_ex(relative_path="holder.go", line=21, column=24))
+        assert (result["field"], result["field_type"], result["method"]) == ("Holder.processor", "Processable", "Process")
+        assert result["assignments"] == [
+            {"relative_path": "holder.go", "line": 11, "expression": "&ChildStruct{}", "types": ["*ChildStruct"]}
+        ]
+        assert result["targets"] == [{"type": "*ChildStruct", "name_path": "ChildStruct/Process", "relative_path": "child.go", "line": 17}]
+        assert not result["indeterminate"]
+        # h.fallback.Process(), where the fallback is set to a parameter
+        result = json.loads(tool.apply_ex(relative_path="holder.go", line=24, column=20))
+        assert (result["targets"], result["indeterminate"]) == ([], True)
+
+    def test_find_implementations(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(FindImplementationsTool)
+        result = json.loads(tool.apply_ex(name_path="Worker", relative_path="base.go"))
+        # ConcreteProcessor implements Worker through the Execute method promoted from BaseStruct
+        assert [(s["name_path"], s["symbol_id"]) for s in result if s["kind"] == "Struct"] == [
+            ("ChildStruct", "child.go:ChildStruct"),
+            ("ConcreteProcessor", "processor.go:ConcreteProcessor"),
+        ]
+        result = json.loads(tool.apply_ex(name_path="Processable/GetType", relative_path="base.go"))
+        assert {"ChildStruct/GetType", "ConcreteProcessor/GetType", "MultipleInterfaces/GetType"} <= {s["name_path"] for s in result}
+        # for a concrete type, the implemented interfaces are reported
+        result = json.loads(tool.apply_ex(name_path="ConcreteProcessor", relative_path="processor.go"))
+        assert {"Processable", "Worker"} <= {s["name_path"] for s in result}
+        result = tool.apply_ex(name_path="Missing", relative_path="base.go")
+        assert result.startswith("Error") and "No symbol matching Missing found in base.go" in result
+
+    def test_find_callers(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(FindCallersTool)
+        result = json.loads(tool.apply_ex(name_path="ChildStruct/Process", relative_path="child.go"))
+        callers = {(c["symbol_id"], c["depth"], c["via"]): c["call_lines"] for c in result}
+        assert callers[("child_test.go:TestProcess", 1, "child.go:ChildStruct/Process")] == [15]
+        # ExecuteAll calls the promoted method directly and via ChildStruct/Execute, but it is only reported at depth 1
+        result = json.loads(tool.apply_ex(name_path="BaseStruct/Execute", relative_path="base.go", max_depth=3))
+        assert [(c["symbol_id"], c["depth"]) for c in result] == [("child.go:ChildStruct/Execute", 1), ("selectors.go:ExecuteAll", 1)]
+        assert result[1]["call_lines"] == [6]
+        result = tool.apply_ex(name_path="BaseStruct", relative_path="base.go")
+        assert result.startswith("Error") and "not a function or method" in result
+
+    def test_find_callees(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(FindCalleesTool)
+        # the call of fmt.Printf is outside the project
+        result = json.loads(tool.apply_ex(name_path="ChildStruct/Execute", relative_path="child.go", max_depth=2))
+        assert [(c["symbol_id"], c["depth"], c["via"], c["call_lines"]) for c in result] == [
+            ("base.go:BaseStruct/Execute", 1, "child.go:ChildStruct/Execute", [13])
+        ]
+        result = json.loads(tool.apply_ex(name_path="ExecuteAll", relative_path="selectors.go"))
+        # the processor's call resolves to the promoted method
+        assert [(c["symbol_id"], c["call_lines"]) for c in result] == [
+            ("base.go:BaseStruct/Execute", [6]),
+            ("child.go:ChildStruct/Execute", [5]),
+        ]
+
+    def test_rename_symbol(self, go_agent: SerenaAgent, monkeypatch: pytest.MonkeyPatch) -> None:
+        tool = go_agent.get_tool(RenameSymbolTool)
+        language_server = go_agent.language_server
+        assert language_server is not None
+        child_source = _read_file(go_agent, "child.go")
+        # a dry run applies the edits in memory only
+        with monkeypatch.context() as m:
+            m.setattr(go_agent, "mark_file_modified", lambda relative_path: pytest.fail(f"{relative_path} was marked as modified"))
+            m.setattr(language_server.server.notify, "did_change_text_document", lambda params: pytest.fail("a buffer was changed"))
+            result = json.loads(tool.apply_ex(name_path="BaseStruct", relative_path="base.go", new_name="BaseRecord", dry_run=True))
+        assert not result["applied"]
+        assert {"base.go", "child.go", "processor.go"} <= {c["relative_path"] for c in result["changes"]}
+        assert {"relative_path": "child.go", "line": 13, "column": 3} in result["changes"]
+        assert "-\tc.BaseStruct.Execute()\n+\tc.BaseRecord.Execute()\n" in result["diff"]
+        assert _read_file(go_agent, "child.go") == child_source
+        result = json.loads(tool.apply_ex(name_path="BaseStruct", relative_path="base.go", new_name="BaseRecord"))
+        assert result["applied"]
+        assert "type BaseRecord struct {" in _read_file(go_agent, "base.go")
+        assert "\tBaseRecord\n\tValue int\n" in _read_file(go_agent, "child.go")
+        assert [s["relative_path"] for s in _find_symbols(go_agent, "BaseRecord/Execute")] == ["base.go"]
+        # conflicting renames are rejected without changing any file
+        child_source = _read_file(go_agent, "child.go")
+        result = tool.apply_ex(name_path="ChildStruct/GetValue", relative_path="child.go", new_name="Process")
+        assert result.startswith("Error")
+        assert _read_file(go_agent, "child.go") == child_source
+
+    def test_find_all(self, go_agent: SerenaAgent) -> None:
+        result = json.loads(go_agent.get_tool(FindAllTool).apply_ex(name_path="Processable", relative_path="base.go"))
+        assert (result["kind"], result["definitions"]) == ("type", [{"relative_path": "base.go", "line": 24}])
+        assert [(t["type"], t["relative_path"]) for t in result["implementations"]] == [
+            ("ChildStruct", "child.go"),
+            ("ConcreteProcessor", "processor.go"),
+            ("MultipleInterfaces", "processor.go"),
+        ]
+        usages = {(u["referencing_symbol"], u["role"]) for u in result["usages"]}
+        assert {("Worker", "embed"), ("NewProcessor", "type"), ("Registry/Register", "type")} <= usages
+        # the buckets of a concrete type contain no implementations
+        result = json.loads(go_agent.get_tool(FindAllTool).apply_ex(name_path="ChildStruct", relative_path="child.go"))
+        assert result["implementations"] == [] and result["usages"]
+
+    def test_replace_block(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ReplaceBlockTool)
+        original_content = _read_file(go_agent, "commands.go")
+        body = 'if len(args) == 0 {\n\treturn "", nil\n}\nreturn strings.ToLower(strings.Join(args, " ")), nil'
+        assert tool.apply_ex(name_path="RunCommand", relative_path="commands.go", block_selector='case "shout"', body=body) == "OK"
+        content = _read_file(go_agent, "commands.go")
+        expected_clause = (
+            '\tcase "upper", "shout":\n\t\tif len(args) == 0 {\n\t\t\treturn "", nil\n\t\t}\n'
+            '\t\treturn strings.ToLower(strings.Join(args, " ")), nil\n\tcase "count":\n'
+        )
+        assert expected_clause in content
+        # everything outside of the clause is unchanged
+        assert content.startswith(original_content[: original_content.index('\tcase "upper"')])
+        assert content.endswith(original_content[original_content.index('\tcase "count"') :])
+        _assert_gofmt_clean(go_agent, "commands.go")
+
+    def test_replace_block_with_ambiguous_selector_fails(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ReplaceBlockTool)
+        result = tool.apply_ex(name_path="RunCommand", relative_path="commands.go", block_selector="if@3", body="return")
+        assert "No block matching 'if@3' found" in result
+        assert "if@14" in result
+
+    def test_generate_mock(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(GenerateMockTool)
+        result = json.loads(
+            tool.apply_ex(interface_name_path="Processable", relative_path="base.go", target_relative_path="child_test.go")
+        )
+        assert result == {"mock": "MockProcessable", "relative_path": "child_test.go", "hooks": ["ProcessFunc", "GetTypeFunc"]}
+        content = _read_file(go_agent, "child_test.go")
+        fields = "\tProcessFunc func() error\n\tGetTypeFunc func() string\n\tCalls       []string\n"
+        assert "type MockProcessable struct {\n" + fields + "}" in content
+        assert "var _ Processable = (*MockProcessable)(nil)" in content
+        assert (
+            "func (m *MockProcessable) GetType() string {\n"
+            '\tm.Calls = append(m.Calls, "GetType")\n'
+            "\tif m.GetTypeFunc != nil {\n\t\treturn m.GetTypeFunc()\n\t}\n"
+            '\treturn ""\n}'
+        ) in content
+        _assert_gofmt_clean(go_agent, "child_test.go")
+        result = tool.apply_ex(interface_name_path="Processable", relative_path="base.go", mock_name="MockProcessable")
+        assert result.startswith("Error") and "already declares a type MockProcessable" in result
+
+    def test_generate_stringer(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(GenerateStringerTool)
+        method = 'func (b *BaseStruct) String() string {\n\treturn fmt.Sprintf("BaseStruct{Name: %q, ID: %v}", b.Name, b.ID)\n}'
+        result = json.loads(tool.apply_ex(type_name_path="BaseStruct", relative_path="base.go"))
+        assert (result["method"], result["fields"], result["status"]) == (method, ["Name", "ID"], "added")
+        assert "\treturn b.Name\n}\n\n// String formats the exported fields of BaseStruct.\n" + method in _read_file(go_agent, "base.go")
+        _assert_gofmt_clean(go_agent, "base.go")
+        assert json.loads(tool.apply_ex(type_name_path="BaseStruct", relative_path="base.go"))["status"] == "up_to_date"
+        # after adding a field, the update is offered and applied only on request
+        content = _read_file(go_agent, "base.go")
+        with open(os.path.join(go_agent.get_project_root(), "base.go"), "w", encoding="utf-8") as f:
+            f.write(content.replace("\tID   int\n", "\tID   int\n\tTags []string\n"))
+        result = json.loads(tool.apply_ex(type_name_path="BaseStruct", relative_path="base.go"))
+        assert result["status"] == "outdated"
+        assert '+\treturn fmt.Sprintf("BaseStruct{Name: %q, ID: %v, Tags: %v}", b.Name, b.ID, b.Tags)' in result["diff"]
+        assert method in _read_file(go_agent, "base.go")
+        result = json.loads(tool.apply_ex(type_name_path="BaseStruct", relative_path="base.go", update=True))
+        assert result["status"] == "updated" and result["method"] in _read_file(go_agent, "base.go")
+        # promoted fields follow the type's own fields
+        result = json.loads(tool.apply_ex(type_name_path="ChildStruct", relative_path="child.go", include_promoted=True))
+        assert (result["fields"], result["relative_path"]) == (["Value", "Name", "ID", "Tags"], "child.go")
+        # without existing methods, a pointer receiver is used and fmt is imported
+        with open(os.path.join(go_agent.get_project_root(), "point.go"), "w", encoding="utf-8") as f:
+            f.write("package main\n\n// Point is a point in the plane.\ntype Point struct {\n\tX, Y int\n}\n")
+        result = json.loads(tool.apply_ex(type_name_path="Point", relative_path="point.go"))
+        assert result["method"] == 'func (p *Point) String() string {\n\treturn fmt.Sprintf("Point{X: %v, Y: %v}", p.X, p.Y)\n}'
+        assert 'import "fmt"' in _read_file(go_agent, "point.go")
+        _assert_gofmt_clean(go_agent, "point.go")
+
+    def test_control_flow_features(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ControlFlowFeaturesTool)
+        result = json.loads(tool.apply_ex(name_path="ProcessConcurrently", relative_path="workers.go"))
+        assert [(f["kind"], f["line"], f["text"], f["in_loop"], f["in_function_literal"]) for f in result["features"]] == [
+            ("go", 14, "go func(i int, item Processable) {", True, False),
+            ("defer", 15, "defer wg.Done()", False, True),
+            ("defer", 16, "defer func() {", False, True),
+            ("recover", 17, "recover()", False, True),
+        ]
+        assert result["warnings"] == []
+        result = json.loads(tool.apply_ex(name_path="LockAll", relative_path="workers.go"))
+        features = [(f["kind"], f["line"], f["column"], f["in_loop"]) for f in result["features"]]
+        assert features == [("panic", 31, 2, False), ("defer", 35, 2, True)]
+        assert len(result["warnings"]) == 1 and result["warnings"][0].startswith("line 35: `defer mu.Unlock()` is deferred within a loop")
+
+    def test_type_view(self, go_agent: SerenaAgent) -> None:
+        describe = "func (b BaseStruct) Describe() string {\n\treturn b.Name\n}\n"
+        with open(os.path.join(go_agent.get_project_root(), "describe.go"), "w", encoding="utf-8") as f:
+            f.write("package main\n\n" + describe)
+        tool = go_agent.get_tool(TypeViewTool)
+        result = json.loads(tool.apply_ex(type_name_path="BaseStruct", relative_path=""))
+        assert [(f["relative_path"], [d["name_path"] for d in f["declarations"]]) for f in result["files"]] == [
+            ("base.go", ["BaseStruct", "BaseStruct/Execute", "BaseStruct/GetName"]),
+            ("describe.go", ["BaseStruct/Describe"]),
+        ]
+        assert result["files"][1]["declarations"][0]["text"] == "func (b BaseStruct) Describe() string"
+        result = json.loads(tool.apply_ex(type_name_path="BaseStruct", relative_path="base.go", include_body=True))
+        assert result["files"][1]["declarations"][0]["text"] == describe.rstrip()
+
+        result = json.loads(tool.apply_ex(type_name_path="ChildStruct", relative_path="child.go"))
+        assert result["kind"] == "struct"
+        assert [(m["name"], m["kind"], m["target"], m["embedding_path"]) for m in result["promoted"]] == [
+            ("GetName", "method", "BaseStruct/GetName", ["BaseStruct"]),
+            ("Describe", "method", "BaseStruct/Describe", ["BaseStruct"]),
+            ("Name", "field", "BaseStruct/Name", ["BaseStruct"]),
+            ("ID", "field", "BaseStruct/ID", ["BaseStruct"]),
+        ]
+        assert [(i["interface"], i["by_value"]) for i in result["satisfied_interfaces"]] == [
+            ("Processable", False),
+            ("Worker", False),
+            ("Named", False),
+            ("NamedProcessor", False),
+        ]
+
+    def test_wrap_errors(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(WrapErrorsTool)
+        result = json.loads(tool.apply_ex(name_path="LoadConfig", relative_path="config.go", context_template="loading config"))
+        assert result == {"num_wrapped": 1, "lines": [12]}
+        content = _read_file(go_agent, "config.go")
+        assert '\t"errors"\n\t"fmt"\n' in content
+        assert '\t\treturn nil, fmt.Errorf("loading config: %w", err)\n' in content
+        assert '\t\treturn nil, errors.New("empty configuration")\n' in content
+        _assert_gofmt_clean(go_agent, "config.go")
+        # the returns are wrapped already
+        result = json.loads(tool.apply_ex(name_path="LoadConfig", relative_path="config.go", context_template="loading config"))
+        assert result == {"num_wrapped": 0, "lines": []}
+        assert _read_file(go_agent, "config.go") == content
+
+    def test_receiverless_candidates(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ReceiverlessCandidatesTool)
+        candidate = {
+            "name_path": "Formatter/Title",
+            "relative_path": "formatter.go",
+            "line": 15,
+            "receiver_type": "*Formatter",
+            "suggested_signature": "func Title(words ...string) string",
+            "interfaces": [],
+        }
+        assert json.loads(tool.apply_ex()) == [candidate]
+        assert json.loads(tool.apply_ex(relative_path="base.go")) == []
+        # a method required by an interface is reported along with the interface
+        with open(os.path.join(go_agent.get_project_root(), "titler.go"), "w", encoding="utf-8") as f:
+            f.write("package main\n\ntype Titler interface {\n\tTitle(words ...string) string\n}\n")
+        result = json.loads(tool.apply_ex(relative_path="formatter.go"))
+        assert result == [{**candidate, "interfaces": [{"interface": "Titler", "relative_path": "titler.go"}]}]
+
+    def test_dispatch_table(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(DispatchTableTool)
+        result = json.loads(tool.apply_ex(interface_method_path="Processable/Process", relative_path="base.go"))
+        assert result["origin"] == "own"
+        assert [(entry["type"], entry["resolution"], entry["target"]) for entry in result["implementations"]] == [
+            ("ChildStruct", "own", {"name_path": "ChildStruct/Process", "relative_path": "child.go", "line": 17}),
+            ("ConcreteProcessor", "own", {"name_path": "ConcreteProcessor/Process", "relative_path": "processor.go", "line": 11}),
+            ("MultipleInterfaces", "own", {"name_path": "MultipleInterfaces/Process", "relative_path": "processor.go", "line": 53}),
+        ]
+        # the implementation of ConcreteProcessor is promoted from its embedded BaseStruct
+        result = json.loads(tool.apply_ex(interface_method_path="Worker/Execute", relative_path="base.go"))
+        assert result["implementations"] == [
+            {
+                "type": "ChildStruct",
+                "relative_path": "child.go",
+                "resolution": "override",
+                "embedding_path": [],
+                "target": {"name_path": "ChildStruct/Execute", "relative_path": "child.go", "line": 11},
+            },
+            {
+                "type": "ConcreteProcessor",
+                "relative_path": "processor.go",
+                "resolution": "promoted",
+                "embedding_path": ["BaseStruct"],
+                "target": {"name_path": "BaseStruct/Execute", "relative_path": "base.go", "line": 12},
+            },
+        ]
+        # methods obtained from embedded interfaces report their origin
+        result = json.loads(tool.apply_ex(interface_method_path="Worker/GetType", relative_path="base.go"))
+        assert result["origin"] == "Processable"
+
+    def test_related_tests(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(RelatedTestsTool)
+        result = json.loads(tool.apply_ex(name_path="ChildStruct/Process", relative_path="child.go"))
+        assert result["conventional_test_file"] == "child_test.go"
+        assert result["tests"] == [
+            {
+                "name": "TestProcess",
+                "relative_path": "child_test.go",
+                "line": 13,
+                "num_references": 1,
+                "named_after_symbol": True,
+                "score": 4,
+            }
+        ]
+        result = json.loads(tool.apply_ex(name_path="ChildStruct/GetValue", relative_path="child.go"))
+        assert [t["name"] for t in result["tests"]] == ["TestChildStructGetValue"]
+        result = json.loads(tool.apply_ex(name_path="NewProcessor", relative_path="factory.go"))
+        assert (result["conventional_test_file"], result["tests"]) == (None, [])
+
+    def test_removal_impact(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(RemovalImpactTool)
+        result = json.loads(tool.apply_ex(interface_method_path="Processable/GetType", relative_path="base.go"))
+        assert result["affected_interfaces"] == ["Worker"]
+        implementations = result["implementations"]
+        assert [entry["type"] for entry in implementations] == ["ChildStruct", "ConcreteProcessor", "MultipleInterfaces"]
+        # GetType remains required by Named (and thus by NamedProcessor)
+        assert all(entry["still_required_by"] == ["Named", "NamedProcessor"] for entry in implementations)
+        assert not any(entry["possibly_unused"] for entry in implementations)
+        assert implementations[0]["target"] == {"name_path": "ChildStruct/GetType", "relative_path": "child.go", "resolution": "own"}
+        result = json.loads(tool.apply_ex(interface_method_path="Processable/Process", relative_path="base.go"))
+        assert result["affected_interfaces"] == ["Worker", "NamedProcessor"]
+        assert all(entry["possibly_unused"] for entry in result["implementations"])
+        result = tool.apply_ex(interface_method_path="Worker/GetType", relative_path="base.go")
+        assert result.startswith("Error") and "analyze Processable/GetType instead" in result
+
+    def test_minimal_interface(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(MinimalInterfaceTool)
+        result = json.loads(tool.apply_ex(name_path="RunProcessor", relative_path="consumers.go", param_index=0))
+        assert result["parameter"] == {"name": "p", "type": "*ConcreteProcessor"}
+        assert result["used_methods"] == [{"name": "Process", "signature": "() error"}]
+        assert [i["interface"] for i in result["existing_interfaces"]] == ["Processable", "NamedProcessor", "Worker"]
+        assert result["suggestion"] == "Processable"
+        # no existing interface contains both Read and Write, so one is synthesized
+        result = json.loads(tool.apply_ex(name_path="Rewrite", relative_path="consumers.go", param_index=1))
+        assert result["existing_interfaces"] == []
+        assert result["suggestion"] == "interface {\n\tRead() ([]byte, error)\n\tWrite(data []byte) error\n}"
+        result = tool.apply_ex(name_path="Rewrite", relative_path="consumers.go", param_index=0)
+        assert result.startswith("Error") and "not declared in the package" in result
+
+    def test_suggest_deduplication(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(SuggestDeduplicationTool)
+        result = json.loads(tool.apply_ex())
+        assert result["applied"] is None
+        # methods with single-statement bodies, such as GetType, are not considered
+        (process_group,) = result["groups"]
+        assert [m["name_path"] for m in process_group["methods"]] == [
+            "ChildStruct/Process",
+            "ConcreteProcessor/Process",
+            "MultipleInterfaces/Process",
+        ]
+        helper = process_group["helper"]
+        assert (helper["name"], helper["relative_path"]) == ("process", "child.go")
+        assert helper["text"].endswith("\nfunc process(arg1 string, arg2 any) error {\n\tfmt.Printf(arg1, arg2)\n\treturn nil\n}")
+        assert [e["new_body"] for e in process_group["edits"]] == [
+            '{\n\treturn process("child: processing %v\\n", c.Value)\n}',
+            '{\n\treturn process("concrete: processing %v\\n", len(cp.data))\n}',
+            '{\n\treturn process("multiple: processing %v\\n", len(mi.data))\n}',
+        ]
+        assert len(process_group["warnings"]) == 1
+        # nothing is changed unless a group is applied
+        assert "func process(" not in _read_file(go_agent, "child.go")
+
+        assert json.loads(tool.apply_ex(apply_group=0))["applied"] == 0
+        assert "func process(arg1 string, arg2 any) error {" in _read_file(go_agent, "child.go")
+        assert 'return process("concrete: processing %v\\n", len(cp.data))' in _read_file(go_agent, "processor.go")
+        for relative_path in ("child.go", "processor.go"):
+            _assert_gofmt_clean(go_agent, relative_path)
+        # the methods now delegate to the helper and are thus no longer reported
+        assert json.loads(tool.apply_ex())["groups"] == []
+        result = tool.apply_ex(apply_group=0)
+        assert result.startswith("Error") and "There is no group with index 0" in result
+
+    def test_api_compatibility(self, go_agent: SerenaAgent) -> None:
+        tool = go_agent.get_tool(ApiCompatibilityTool)
+        content = _read_file(go_agent, "base.go")
+        get_name = (
+            "// GetName returns the name of the struct.\n//\n// Deprecated: use Name directly.\n"
+            "func (b *BaseStruct) GetName() string {\n\treturn b.Name\n}\n"
+        )
+        assert get_name in content
+        result = json.loads(tool.apply_ex(relative_path="base.go", new_content=content.replace(get_name, "")))
+        assert not result["is_compatible"]
+        assert result["breaking"] == [
+            {"change": "removed", "name_path": "BaseStruct/GetName", "old": "func (b *BaseStruct) GetName() string"}
+        ]
+
+        new_content = content.replace("\tGetType() string\n", "\tGetType() string\n\tClose() error\n").replace(
+            "\tID   int\n", "\tID   int64\n\tTags []string\n"
+        )
+        new_content += "\nfunc NewBaseStruct() *BaseStruct { return nil }\n"
+        result = json.loads(tool.apply_ex(relative_path="base.go", new_content=new_content))
+        assert [(c["change"], c["name_path"]) for c in result["breaking"]] == [
+            ("field_type_changed", "BaseStruct/ID"),
+            ("interface_method_added", "Processable/Close"),
+        ]
+        assert [(c["change"], c["name_path"]) for c in result["compatible"]] == [
+            ("field_added", "BaseStruct/Tags"),
+            ("added", "NewBaseStruct"),
+        ]
+        assert json.loads(tool.apply_ex(relative_path="base.go", new_content=content)) == {
+            "is_compatible": True,
+            "breaking": [],
+            "compatible": [],
+        }
+
+    def test_edit_transaction_restores_files_on_failure(self, go_agent: SerenaAgent) -> None:
+        code_editor = go_agent.get_tool(AddInterfaceMethodAndStubTool).create_language_server_code_editor()
+        original_contents = {p: _read_file(go_agent, p) for p in ("base.go", "child.go")}
+        with pytest.raises(RuntimeError):
+            with code_editor.edit_transaction():
+                for relative_path in original_contents:
+                    code_editor.replace_text(relative_path, PositionInFile(line=0, col=0), PositionInFile(line=0, col=0), "// edited\n")
+                raise RuntimeError("failure after editing")
+        assert {p: _read_file(go_agent, p) for p in original_contents} == original_contents
+
+    def test_edit_transaction_preview(self, go_agent: SerenaAgent) -> None:
+        code_editor = go_agent.get_tool(AddInterfaceMethodAndStubTool).create_language_server_code_editor()
+        original_contents = {p: _read_file(go_agent, p) for p in ("base.go", "child.go")}
+        with pytest.raises(RuntimeError):
+            with code_editor.edit_transaction() as transaction:
+                assert transaction.preview() == {}
+                # rename BaseStruct, which also changes the call of its Execute method in ChildStruct/Execute
+                for relative_path, content in original_contents.items():
+                    end = PositionInFile(line=content.count("\n"), col=0)
+                    code_editor.replace_text(relative_path, PositionInFile(line=0, col=0), end, content.replace("BaseStruct", "BaseRecord"))
+                preview = {p: diff.to_dict() for p, diff in transaction.preview().items()}
+                # the pending edits are not written to disk before the transaction is completed
+                assert {p: _read_file(go_agent, p) for p in original_contents} == original_contents
+                raise RuntimeError("discarding the transaction after reviewing the preview")
+        assert {p: _read_file(go_agent, p) for p in original_contents} == original_contents
+
+        def get_name_paths(relative_path: str, change: str) -> list[str]:
+            return [entry["name_path"] for entry in preview[relative_path][change]]
+
+        assert list(preview) == ["base.go", "child.go"]
+        base_struct_symbols = ["BaseStruct", "BaseStruct/Name", "BaseStruct/ID", "BaseStruct/Execute", "BaseStruct/GetName"]
+        assert get_name_paths("base.go", "removed") == base_struct_symbols
+        assert get_name_paths("base.go", "added") == [p.replace("BaseStruct", "BaseRecord") for p in base_struct_symbols]
+        assert get_name_paths("base.go", "modified") == []
+        assert get_name_paths("child.go", "removed") == ["ChildStruct/BaseStruct"]
+        assert get_name_paths("child.go", "added") == ["ChildStruct/BaseRecord"]
+        assert get_name_paths("child.go", "modified") == ["ChildStruct", "ChildStruct/Execute"]
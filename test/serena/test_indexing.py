@@ -0,0 +1,84 @@
+from typing import cast
+
+from serena.indexing import IndexingProgress, SymbolIndexer
+from solidlsp import SolidLanguageServer
+
+
+class FakeLanguageServer:
+    """
+    Mimics the document symbols cache of a language server, where each file has a content version
+    """
+
+    def __init__(self, file_versions: dict[str, int], failing_files: tuple[str, ...] = ()):
+        self.file_versions = file_versions
+        self.failing_files = failing_files
+        self.cached_versions: dict[tuple[str, bool], int] = {}
+        self.requested_files: list[str] = []
+        self.num_saves = 0
+
+    def has_up_to_date_document_symbols(self, relative_file_path: str, include_body: bool = False) -> bool:
+        return self.cached_versions.get((relative_file_path, include_body)) == self.file_versions[relative_file_path]
+
+    def request_document_symbols(self, relative_file_path: str, include_body: bool = False) -> tuple[list, list]:
+        if relative_file_path in self.failing_files:
+            raise RuntimeError(f"cannot parse {relative_file_path}")
+        if not include_body:
+            self.requested_files.append(relative_file_path)
+        self.cached_versions[(relative_file_path, include_body)] = self.file_versions[relative_file_path]
+        return [], []
+
+    def save_cache(self) -> None:
+        self.num_saves += 1
+
+
+def _create_indexer(language_server: FakeLanguageServer) -> SymbolIndexer:
+    return SymbolIndexer(cast(SolidLanguageServer, language_server), cache_save_interval=2)
+
+
+class TestSymbolIndexer:
+    FILES = ["a.go", "b.go", "c.go"]
+
+    def test_reports_progress(self) -> None:
+        language_server = FakeLanguageServer({f: 1 for f in self.FILES})
+        events: list[IndexingProgress] = []
+        stats = _create_indexer(language_server).index(self.FILES, progress_callback=events.append)
+        assert [(e.num_files_processed, e.num_files_total, e.current_file) for e in events] == [
+            (1, 3, "a.go"),
+            (2, 3, "b.go"),
+            (3, 3, "c.go"),
+        ]
+        assert (stats.num_files_total, stats.num_files_indexed, stats.num_files_unchanged) == (3, 3, 0)
+        assert not stats.cancelled
+        # saved once after the second file and once at the end
+        assert language_server.num_saves == 2
+
+    def test_reparses_only_changed_files(self) -> None:
+        language_server = FakeLanguageServer({f: 1 for f in self.FILES})
+        _create_indexer(language_server).index(self.FILES)
+        language_server.requested_files.clear()
+        language_server.file_versions["b.go"] = 2
+        stats = _create_indexer(language_server).index(self.FILES)
+        assert language_server.requested_files == ["b.go"]
+        assert (stats.num_files_indexed, stats.num_files_unchanged) == (1, 2)
+        # a forced run reparses all files
+        stats = _create_indexer(language_server).index(self.FILES, force=True)
+        assert stats.num_files_indexed == 3
+
+    def test_cancel(self) -> None:
+        language_server = FakeLanguageServer({f: 1 for f in self.FILES})
+        indexer = _create_indexer(language_server)
+
+        def cancel_after_first_file(progress: IndexingProgress) -> None:
+            if progress.num_files_processed == 1:
+                indexer.cancel()
+
+        stats = indexer.index(self.FILES, progress_callback=cancel_after_first_file)
+        assert stats.cancelled
+        assert stats.num_files_processed == 1
+        assert language_server.requested_files == ["a.go"]
+
+    def test_failed_files(self) -> None:
+        language_server = FakeLanguageServer({f: 1 for f in self.FILES}, failing_files=("b.go",))
+        stats = _create_indexer(language_server).index(self.FILES)
+        assert stats.failed_files == {"b.go": "cannot parse b.go"}
+        assert (stats.num_files_indexed, stats.num_files_processed) == (2, 3)
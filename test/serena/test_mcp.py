@@ -1,11 +1,16 @@
 """Tests for the mcp.py module in serena."""
 
+import asyncio
+import inspect
+
 import pytest
 from mcp.server.fastmcp.tools.base import Tool as MCPTool
 
 from serena.agent import Tool, ToolRegistry
 from serena.config.context_mode import SerenaAgentContext
 from serena.mcp import SerenaMCPFactory
+from serena.tools import SUCCESS_RESULT_SCHEMA, FindSymbolTool
+from serena.tools.tools_base import TOOLS_MANIFEST_SCHEMA_VERSION
 
 make_tool = SerenaMCPFactory.make_mcp_tool
 
@@ -81,6 +86,54 @@ def test_make_tool_execution() -> None:
     assert result == "Hello Alice, you are 30 years old!"
 
 
+class ProgressReportingTool(BaseMockTool):
+    """A mock Tool class reporting its progress."""
+
+    reports_progress = True
+
+    def apply(self, num_items: int) -> str:
+        """Processes items.
+
+        :param num_items: The number of items to process
+        :return: A summary
+        """
+        for i in range(num_items):
+            self.report_progress(i + 1, total=num_items, message=f"item {i}")
+        return f"Processed {num_items} items"
+
+    def apply_ex(self, log_call: bool = True, catch_exceptions: bool = True, progress_reporter=None, **kwargs) -> str:  # type: ignore
+        """Mock implementation of apply_ex."""
+        self._progress_reporter = progress_reporter
+        return self.apply(**kwargs)
+
+
+def test_make_tool_reporting_progress() -> None:
+    """Test that the progress reports of a tool are sent via the MCP context."""
+
+    class MockContext:
+        def __init__(self) -> None:
+            self.reports: list[tuple[float, float | None, str | None]] = []
+
+        async def report_progress(self, progress: float, total: float | None = None, message: str | None = None) -> None:
+            self.reports.append((progress, total, message))
+
+    mcp_tool = make_tool(ProgressReportingTool())
+    assert mcp_tool.is_async
+    assert mcp_tool.context_kwarg is not None
+    context = MockContext()
+
+    async def execute() -> str:
+        result = await mcp_tool.fn(**{mcp_tool.context_kwarg: context}, num_items=2)
+        # let the scheduled reports complete
+        await asyncio.sleep(0)
+        return result
+
+    assert asyncio.run(execute()) == "Processed 2 items"
+    assert context.reports == [(1, 2, "item 0"), (2, 2, "item 1")]
+    # tools not reporting progress are executed synchronously, without the context
+    assert not make_tool(BasicTool()).is_async
+
+
 def test_make_tool_no_params() -> None:
     """Test make_tool with a function that has no parameters."""
 
@@ -298,3 +351,63 @@ def test_make_tool_all_tools(tool_class) -> None:
 
     # The description should be a string (either from docstring or default)
     assert isinstance(mcp_tool.description, str)
+
+
+def test_output_schema_defaults_to_return_description() -> None:
+    """Test that tools without an explicit output schema are described by their return docstring."""
+    assert BasicTool.get_output_json_schema_from_cls() == {"type": "string", "description": "A greeting message"}
+
+
+class TestToolsManifest:
+    def test_manifest_structure(self) -> None:
+        manifest = ToolRegistry().create_tools_manifest(["find_symbol"])
+        assert manifest["schema_version"] == TOOLS_MANIFEST_SCHEMA_VERSION
+        tools = {tool["name"]: tool for tool in manifest["tools"]}
+        # only symbolic and editing tools are described
+        assert {"find_symbol", "get_symbols_overview", "find_referencing_symbols", "replace_symbol_body", "replace_regex"} <= set(tools)
+        assert "read_file" not in tools
+        assert "get_current_config" not in tools
+
+        find_symbol = tools["find_symbol"]
+        assert find_symbol["active"]
+        assert not find_symbol["can_edit"]
+        assert find_symbol["output_format"] == "json"
+        assert "name_path" in find_symbol["input_schema"]["required"]
+        assert find_symbol["input_schema"]["properties"]["depth"]["description"]
+        assert "underlying_kind" in find_symbol["output_schema"]["properties"]["symbols"]["items"]["properties"]
+
+        overview_item_properties = tools["get_symbols_overview"]["output_schema"]["items"]["properties"]
+        assert {"exported", "unexported"} <= set(overview_item_properties)
+        stub_properties = tools["add_interface_method_and_stub"]["output_schema"]["properties"]
+        assert "receiver" in stub_properties["stubbed"]["items"]["properties"]
+
+        replace_symbol_body = tools["replace_symbol_body"]
+        assert not replace_symbol_body["active"]
+        assert replace_symbol_body["can_edit"]
+        assert replace_symbol_body["output_format"] == "text"
+        assert replace_symbol_body["output_schema"] == SUCCESS_RESULT_SCHEMA
+
+    def test_manifest_version(self) -> None:
+        registry = ToolRegistry()
+        manifest = registry.create_tools_manifest([])
+        # the version depends on the tools' descriptions only, not on which tools are active
+        assert registry.create_tools_manifest(registry.get_tool_names())["version"] == manifest["version"]
+        original_docstring = FindSymbolTool.apply.__doc__
+        try:
+            FindSymbolTool.apply.__doc__ = original_docstring.replace(":param depth:", ":param depth: (changed)")  # type: ignore
+            assert registry.create_tools_manifest([])["version"] != manifest["version"]
+        finally:
+            FindSymbolTool.apply.__doc__ = original_docstring
+
+    def test_json_results_are_described_by_output_schemas(self) -> None:
+        registry = ToolRegistry()
+        # create_text_file merely encodes its success message as a JSON string
+        plain_message_tools = {"create_text_file"}
+        undescribed_tools = [
+            tool["name"]
+            for tool in registry.create_tools_manifest([])["tools"]
+            if tool["output_format"] == "text"
+            and tool["name"] not in plain_message_tools
+            and "json.dumps(" in inspect.getsource(registry.get_tool_class_by_name(tool["name"]).apply)
+        ]
+        assert undescribed_tools == []
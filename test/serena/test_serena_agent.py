@@ -88,7 +88,7 @@ class TestSerenaAgent:
         find_symbol_tool = agent.get_tool(FindSymbolTool)
         result = find_symbol_tool.apply_ex(name_path=symbol_name)
 
-        symbols = json.loads(result)
+        symbols = json.loads(result)["symbols"]
         assert any(
             symbol_name in s["name_path"] and expected_kind.lower() in s["kind"].lower() and expected_file in s["relative_path"]
             for s in symbols
@@ -141,7 +141,7 @@ class TestSerenaAgent:
         result = find_symbol_tool.apply_ex(name_path=symbol_name, relative_path=def_file)
 
         time.sleep(1)
-        symbols = json.loads(result)
+        symbols = json.loads(result)["symbols"]
         # Find the definition
         def_symbol = symbols[0]
 
@@ -242,7 +242,7 @@ class TestSerenaAgent:
             substring_matching=substring_matching,
         )
 
-        symbols = json.loads(result)
+        symbols = json.loads(result)["symbols"]
         assert any(
             expected_symbol_name == s["name_path"].split("/")[-1]
             and expected_kind.lower() in s["kind"].lower()
@@ -282,5 +282,5 @@ class TestSerenaAgent:
             substring_matching=True,
         )
 
-        symbols = json.loads(result)
+        symbols = json.loads(result)["symbols"]
         assert not symbols, f"Expected to find no symbols for {name_path}. Symbols found: {symbols}"
@@ -1,5 +1,6 @@
 import pytest
 
+from solidlsp.ls_types import SymbolKind
 from src.serena.symbol import LanguageServerSymbol
 
 
@@ -160,3 +161,65 @@ class TestSymbolNameMatching:
         result = LanguageServerSymbol.match_name_path(name_path_pattern, symbol_name_path_parts, is_substring_match)
         error_msg = self._create_assertion_error_message(name_path_pattern, symbol_name_path_parts, is_substring_match, expected, result)
         assert result == expected, error_msg
+
+    @pytest.mark.parametrize(
+        "name_path_pattern, symbol_name_path_parts, separator, expected",
+        [
+            pytest.param("Container[T]/Add", ["Container[T]", "Add"], "/", True, id="generic receiver"),
+            pytest.param("Container/Add", ["Container[T]", "Add"], "/", True, id="generic receiver without type parameters"),
+            pytest.param("Container[T].Add", ["Container[T]", "Add"], ".", True, id="generic receiver, dot separator"),
+            pytest.param("/Container[T].Add", ["Container[T]", "Add"], ".", False, id="slash is no separator with dot separator"),
+            pytest.param("Map[K, pkg.V].Get", ["Map[K, pkg.V]", "Get"], ".", True, id="dot within brackets is not split"),
+            pytest.param(r"weird\.name.method", ["weird.name", "method"], ".", True, id="escaped separator"),
+            pytest.param("ConcreteProcessor.Process", ["ConcreteProcessor", "Process"], "/", False, id="dot is literal with slash"),
+        ],
+    )
+    def test_match_name_path_with_separator(self, name_path_pattern, symbol_name_path_parts, separator, expected):
+        """Tests matching with a custom separator as well as bracket groups and escapes."""
+        result = LanguageServerSymbol.match_name_path(name_path_pattern, symbol_name_path_parts, False, separator=separator)
+        assert result == expected
+
+    def test_match_malformed_name_path(self):
+        with pytest.raises(ValueError):
+            LanguageServerSymbol.match_name_path("Container[T/Add", ["Container[T]", "Add"], False)
+
+
+def _create_symbol(name: str, kind: SymbolKind, body: str | None = None) -> LanguageServerSymbol:
+    symbol_root: dict = {"name": name, "kind": kind, "children": []}
+    if body is not None:
+        symbol_root["body"] = body
+    return LanguageServerSymbol(symbol_root)  # type: ignore
+
+
+class TestSymbolProperties:
+    def test_go_method_name_path_parts(self) -> None:
+        assert _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method).get_name_path_parts() == ["ChildStruct", "GetValue"]
+        assert _create_symbol("(ChildStruct).GetValue", SymbolKind.Method).get_name_path_parts() == ["ChildStruct", "GetValue"]
+        assert _create_symbol("GetValue", SymbolKind.Method).get_name_path_parts() == ["GetValue"]
+        assert _create_symbol("(x).y", SymbolKind.Function).get_name_path_parts() == ["(x).y"]
+        assert _create_symbol("(*Container[T]).Add", SymbolKind.Method).get_name_path_parts() == ["Container[T]", "Add"]
+        assert _create_symbol("(*Container[T]).Add", SymbolKind.Method).get_name_path() == "Container[T]/Add"
+
+    def test_body_hash(self) -> None:
+        body = "func (c *ChildStruct) GetValue() int {\n\treturn c.Value\n}"
+        symbol = _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method, body)
+        assert symbol.get_body_hash() == _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method, body).get_body_hash()
+        assert symbol.get_body_hash() != _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method, body + " ").get_body_hash()
+        assert _create_symbol("GetValue", SymbolKind.Method).get_body_hash() is None
+        assert symbol.to_dict(include_body_hash=True)["body_hash"] == symbol.get_body_hash()
+        assert "body_hash" not in symbol.to_dict()
+
+    def test_body_hash_from_file_content(self) -> None:
+        body = "func (c *ChildStruct) GetValue() int {\n\treturn c.Value\n}"
+        file_content = "package main\n\n" + body + "\n"
+        symbol = _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method)
+        symbol.symbol_root["location"] = {"range": {"start": {"line": 2, "character": 0}, "end": {"line": 4, "character": 1}}}
+        assert symbol.get_body_hash() is None
+        assert symbol.get_body_hash(file_content) == _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method, body).get_body_hash()
+        assert symbol.to_dict(include_body_hash=True, file_content=file_content)["body_hash"] == symbol.get_body_hash(file_content)
+
+    def test_symbol_id(self) -> None:
+        symbol = _create_symbol("(*ChildStruct).GetValue", SymbolKind.Method)
+        assert symbol.get_symbol_id() is None
+        symbol.symbol_root["location"] = {"relativePath": "child.go"}  # type: ignore
+        assert symbol.get_symbol_id() == "child.go:ChildStruct/GetValue"
@@ -483,3 +483,19 @@ def test_nix_symbol_replacement_no_double_semicolon(snapshot):
         NIX_ATTR_REPLACEMENT,
     )
     test_case.run_test(content_after_ground_truth=snapshot)
+
+
+def test_add_doc_comment_uses_line_comments_of_language():
+    assert CodeEditor._add_doc_comment("func Double(x int) int {\n", "Double doubles x.", "calc.go") == (
+        "// Double doubles x.\nfunc Double(x int) int {\n"
+    )
+    assert CodeEditor._add_doc_comment("\n    def double(self, x):\n", "Doubles x.\n\n# Used in tests.", "pkg/calc.py") == (
+        "\n    # Doubles x.\n    #\n    # Used in tests.\n    def double(self, x):\n"
+    )
+    assert CodeEditor._add_doc_comment("def double(x)\n", "Doubles x.", "calc.rb") == "# Doubles x.\ndef double(x)\n"
+    assert CodeEditor._add_doc_comment("local function double(x)\n", "Doubles x.", "calc.lua") == (
+        "-- Doubles x.\nlocal function double(x)\n"
+    )
+    assert CodeEditor._add_doc_comment("x = 1\n", "", "notes.txt") == "x = 1\n"
+    with pytest.raises(ValueError):
+        CodeEditor._add_doc_comment("x = 1\n", "Sets x.", "notes.txt")
@@ -0,0 +1,939 @@
+import pytest
+
+from serena.util.go_source import (
+    GoControlFlowFeatureKind,
+    GoDeclarationKind,
+    GoReferenceRole,
+    GoTokenKind,
+    GoUnderlyingKind,
+    add_struct_tag_key,
+    classify_reference,
+    classify_type_expression,
+    count_body_statements,
+    find_assignments,
+    find_blocks,
+    find_common_body,
+    find_control_flow_features,
+    find_field_assignments,
+    find_local_declarations,
+    find_reference_expression,
+    find_return_statements,
+    find_unwrapped_error_returns,
+    find_variable_uses,
+    get_block_replacement,
+    get_body_similarity,
+    get_deprecation_message,
+    get_embedded_type_replacement,
+    get_error_wrapping_edit,
+    get_file_name_build_constraint,
+    get_import_insertion,
+    get_import_removal,
+    get_interface_method_insertion,
+    get_parameter_and_result_types,
+    get_parameter_names,
+    get_reference_transformation_edit,
+    get_struct_field_insertion,
+    get_struct_field_removal,
+    get_struct_tag_value,
+    get_struct_tags_edit,
+    get_zero_value_literal,
+    instantiate_reference_template,
+    normalize_method_signature,
+    parse_go_file,
+    parse_go_work_use_directives,
+    parse_interface_elements,
+    parse_object_signature,
+    parse_struct_fields,
+    references_receiver,
+    select_block,
+    split_expression_list,
+    to_json_field_name,
+    tokenize,
+)
+
+GO_SOURCE = """package sample
+
+import (
+	"fmt"
+	alias "net/http"
+)
+
+// Container holds items.
+//
+// It is generic.
+type Container[T any] struct {
+	items []T
+}
+
+// Add adds an item.
+func (c *Container[T]) Add(item T) {
+	c.items = append(c.items, item) // trailing comment with a { brace
+}
+
+type (
+	// Alias is an alias.
+	Alias = int
+	Array [5]int
+)
+
+//go:generate stringer -type=Color
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
+
+var a, b int = 1, 2
+
+var text = `raw
+string with } brace`
+
+func Helper() interface{} {
+	fmt.Println("}")
+	return nil
+}
+"""
+
+
+class TestGoSourceParsing:
+    def test_tokenize_skips_comments_and_literals(self) -> None:
+        tokens = tokenize('x := "a // b" // comment\n/* block */ y', include_comments=False)
+        assert [t.text for t in tokens] == ["x", ":=", '"a // b"', "y"]
+        assert tokens[-1].line == 1
+
+    def test_get_tokens_slices_by_offset(self) -> None:
+        go_file = parse_go_file(GO_SOURCE)
+        assert [t.text for t in go_file.get_tokens()] == [t.text for t in tokenize(GO_SOURCE)]
+        helper = go_file.find_declaration("Helper")
+        assert helper is not None and helper.body_start is not None
+        body_tokens = go_file.get_tokens(helper.body_start, helper.end)
+        assert body_tokens == [t for t in tokenize(GO_SOURCE) if helper.body_start <= t.start < helper.end]
+        assert body_tokens[0].text == "{" and body_tokens[-1].text == "}"
+        comments = [t for t in go_file.get_tokens(include_comments=True) if t.kind == GoTokenKind.COMMENT]
+        assert comments == [t for t in tokenize(GO_SOURCE, include_comments=True) if t.kind == GoTokenKind.COMMENT]
+
+    def test_package_and_imports(self) -> None:
+        go_file = parse_go_file(GO_SOURCE)
+        assert go_file.package_name == "sample"
+        assert [(i.alias, i.path, i.get_package_name()) for i in go_file.imports] == [
+            (None, "fmt", "fmt"),
+            ("alias", "net/http", "alias"),
+        ]
+
+    def test_declarations(self) -> None:
+        go_file = parse_go_file(GO_SOURCE)
+        names = [(d.kind, d.name) for d in go_file.declarations]
+        assert names == [
+            (GoDeclarationKind.TYPE, "Container"),
+            (GoDeclarationKind.METHOD, "Add"),
+            (GoDeclarationKind.TYPE, "Alias"),
+            (GoDeclarationKind.TYPE, "Array"),
+            (GoDeclarationKind.TYPE, "Color"),
+            (GoDeclarationKind.CONSTANT, "Red"),
+            (GoDeclarationKind.CONSTANT, "Green"),
+            (GoDeclarationKind.VARIABLE, "a"),
+            (GoDeclarationKind.VARIABLE, "b"),
+            (GoDeclarationKind.VARIABLE, "text"),
+            (GoDeclarationKind.FUNCTION, "Helper"),
+        ]
+
+    def test_type_declarations(self) -> None:
+        go_file = parse_go_file(GO_SOURCE)
+        container = go_file.find_declaration("Container")
+        assert container is not None
+        assert container.type_params == "[T any]"
+        assert container.doc == "Container holds items.\n\nIt is generic."
+        alias = go_file.find_declaration("Alias")
+        assert alias is not None and alias.is_alias and alias.in_group and alias.doc == "Alias is an alias."
+        array = go_file.find_declaration("Array")
+        assert array is not None and array.type_params is None and array.type_expr == "[5]int"
+        color = go_file.find_declaration("Color")
+        assert color is not None and color.doc is None
+
+    def test_group_doc(self) -> None:
+        go_file = parse_go_file("package p\n\n// Limits.\nconst (\n\t// Min is low.\n\tMin = 0\n\tMax = 1\n)\n\nvar (\n\tA = 1\n)\n")
+        assert [(d.name, d.doc, d.group_doc) for d in go_file.declarations] == [
+            ("Min", "Min is low.", "Limits."),
+            ("Max", None, "Limits."),
+            ("A", None, None),
+        ]
+
+    @pytest.mark.parametrize(
+        "doc, expected_message",
+        [
+            ("GetName returns the name.\n\nDeprecated: use Name directly.", "use Name directly."),
+            ("Old does things.\n\nDeprecated: use New instead,\nwhich is faster.\n\nMore text.", "use New instead, which is faster."),
+            ("Deprecated:", ""),
+            ("Mentions Deprecated: within a sentence.", None),
+            ("See the Deprecated: section.\n\n Deprecated: indented", None),
+            (None, None),
+        ],
+    )
+    def test_deprecation_message(self, doc: str | None, expected_message: str | None) -> None:
+        assert get_deprecation_message(doc) == expected_message
+
+    def test_deprecated_declaration(self) -> None:
+        go_file = parse_go_file("package p\n\n// Old is old.\n//\n// Deprecated: use New.\nfunc Old() {}\n\n// New is new.\nfunc New() {}")
+        assert [(d.name, d.deprecation_message) for d in go_file.declarations] == [("Old", "use New."), ("New", None)]
+
+    def test_function_declarations(self) -> None:
+        go_file = parse_go_file(GO_SOURCE)
+        add = go_file.find_declaration("Add", receiver_type="Container")
+        assert add is not None
+        assert (add.receiver_name, add.receiver_is_pointer) == ("c", True)
+        assert go_file.get_signature_text(add) == "func (c *Container[T]) Add(item T)"
+        assert go_file.get_declaration_text(add).endswith("// trailing comment with a { brace\n}")
+        helper = go_file.find_declaration("Helper")
+        assert helper is not None
+        assert go_file.get_signature_text(helper) == "func Helper() interface{}"
+        assert go_file.get_declaration_text(helper).endswith("return nil\n}")
+        assert go_file.get_line_and_column(helper.name_start) == (38, 5)
+        assert go_file.get_receiver_type_text(add) == "Container[T]"
+        assert go_file.get_receiver_type_text(helper) is None
+        unnamed_receiver_file = parse_go_file("package p\n\nfunc (Pair[K, V]) Nop() {}\n")
+        assert unnamed_receiver_file.get_receiver_type_text(unnamed_receiver_file.declarations[0]) == "Pair[K, V]"
+
+    @pytest.mark.parametrize(
+        "type_expr, expected_kind",
+        [
+            ("struct { X int }", GoUnderlyingKind.STRUCT),
+            ("interface{}", GoUnderlyingKind.INTERFACE),
+            ("any", GoUnderlyingKind.INTERFACE),
+            ("map[string]int", GoUnderlyingKind.MAP),
+            ("[]byte", GoUnderlyingKind.SLICE),
+            ("[4]byte", GoUnderlyingKind.ARRAY),
+            ("func(int) error", GoUnderlyingKind.FUNC),
+            ("<-chan int", GoUnderlyingKind.CHAN),
+            ("*BaseStruct", GoUnderlyingKind.POINTER),
+            ("uint64", GoUnderlyingKind.BASIC),
+            ("BaseStruct", GoUnderlyingKind.NAMED),
+            ("time.Duration", GoUnderlyingKind.NAMED),
+        ],
+    )
+    def test_classify_type_expression(self, type_expr: str, expected_kind: GoUnderlyingKind) -> None:
+        assert classify_type_expression(type_expr) == expected_kind
+
+    def test_parse_struct_fields(self) -> None:
+        fields = parse_struct_fields('struct {\n\tBaseStruct\n\t*pkg.Other\n\ta, B int `json:"b"`\n\tbuf [4]byte // comment\n}')
+        assert [(f.name, f.type_expr, f.embedded) for f in fields] == [
+            ("BaseStruct", "BaseStruct", True),
+            ("Other", "*pkg.Other", True),
+            ("a", "int", False),
+            ("B", "int", False),
+            ("buf", "[4]byte", False),
+        ]
+        assert fields[1].is_pointer and not fields[2].is_exported
+        assert fields[3].tag == '`json:"b"`'
+
+    def test_parse_interface_elements(self) -> None:
+        type_expr = "interface {\n\tProcessable\n\tio.Reader\n\tWrite(data  []byte) error // c\n\t~int | ~string\n}"
+        elements = parse_interface_elements(type_expr)
+        assert [(e.text, e.method_name) for e in elements] == [
+            ("Processable", None),
+            ("io.Reader", None),
+            ("Write(data []byte) error", "Write"),
+            ("~int | ~string", None),
+        ]
+        assert elements[2].get_signature() == "(data []byte) error"
+        assert [e.text for e in parse_interface_elements("interface{ A(); B() int }")] == ["A()", "B() int"]
+
+    @pytest.mark.parametrize(
+        "signature, expected",
+        [
+            ("() error", "() error"),
+            ("(data []byte) (n int, err error)", "([]byte) (int, error)"),
+            ("(a, b int, opts ...string) (err error)", "(int, int, ...string) error"),
+            ("(pkg.Item, func(int) error) map[string]int", "(pkg.Item, func(int) error) map[string]int"),
+        ],
+    )
+    def test_normalize_method_signature(self, signature: str, expected: str) -> None:
+        assert normalize_method_signature(signature) == expected
+
+    def test_get_parameter_and_result_types(self) -> None:
+        assert get_parameter_and_result_types("(name string, p Processable)") == (["string", "Processable"], [])
+        assert get_parameter_and_result_types("() *Registry") == ([], ["*Registry"])
+
+    def test_get_parameter_names(self) -> None:
+        assert get_parameter_names("(a, b int, c string) error") == ["a", "b", "c"]
+        assert get_parameter_names("(int, List[int])") == [None, None]
+        assert split_expression_list('"x", f(1, 2), T{A: 1, B: 2}') == ['"x"', "f(1, 2)", "T{A: 1, B: 2}"]
+
+    @pytest.mark.parametrize(
+        "underlying_kind, type_expr, expected_literal",
+        [
+            (GoUnderlyingKind.STRUCT, "struct{}", "T{}"),
+            (GoUnderlyingKind.MAP, "map[string]int", "T{}"),
+            (GoUnderlyingKind.BASIC, "string", 'T("")'),
+            (GoUnderlyingKind.BASIC, "bool", "T(false)"),
+            (GoUnderlyingKind.BASIC, "float64", "T(0)"),
+            (GoUnderlyingKind.FUNC, "func()", "T(nil)"),
+        ],
+    )
+    def test_get_zero_value_literal(self, underlying_kind: GoUnderlyingKind, type_expr: str, expected_literal: str) -> None:
+        assert get_zero_value_literal("T", underlying_kind, type_expr) == expected_literal
+
+    @pytest.mark.parametrize(
+        "source, expected",
+        [
+            ("// Code generated by stringer. DO NOT EDIT.\n\npackage p\n", True),
+            ("// Copyright 2024\n\n// Code generated from api.proto. DO NOT EDIT.\npackage p\n", True),
+            ("// Code generated by hand; edit freely.\npackage p\n", False),
+            ("package p\n\n// Code generated by stringer. DO NOT EDIT.\n", False),
+        ],
+    )
+    def test_is_generated(self, source: str, expected: bool) -> None:
+        assert parse_go_file(source).is_generated() == expected
+
+    @pytest.mark.parametrize(
+        "source, expected",
+        [
+            ("//go:build linux && !cgo\n\npackage p\n", "linux && !cgo"),
+            ("// Copyright 2024\n\n//go:build windows\n\n// Package p does things.\npackage p\n", "windows"),
+            ("package p\n", None),
+            ("package p\n\n//go:build linux\n", None),
+        ],
+    )
+    def test_get_build_constraint(self, source: str, expected: str | None) -> None:
+        assert parse_go_file(source).get_build_constraint() == expected
+
+    @pytest.mark.parametrize(
+        "file_name, expected",
+        [
+            ("config_linux.go", "linux"),
+            ("config_windows_amd64.go", "windows && amd64"),
+            ("config_arm64_test.go", "arm64"),
+            ("config.go", None),
+            ("linux.go", None),
+            ("config_loader.go", None),
+        ],
+    )
+    def test_get_file_name_build_constraint(self, file_name: str, expected: str | None) -> None:
+        assert get_file_name_build_constraint(file_name) == expected
+
+    @pytest.mark.parametrize(
+        "signature, expected",
+        [
+            ("var cp *ConcreteProcessor", ("var", "cp", "*ConcreteProcessor")),
+            ("field data []byte // size=24 (0x18)", ("field", "data", "[]byte")),
+            ("const Red Color = 0", ("const", "Red", "Color")),
+            ("func (cp *ConcreteProcessor) Process() error", ("func", "Process", "func() error")),
+            ("type BaseStruct struct {\n\tName string\n}", ("type", "BaseStruct", "BaseStruct")),
+            ('package fmt ("fmt")', ("package", "fmt", None)),
+        ],
+    )
+    def test_parse_object_signature(self, signature: str, expected: tuple[str, str, str | None]) -> None:
+        parsed = parse_object_signature(signature)
+        assert parsed is not None
+        assert (parsed.kind, parsed.name, parsed.type_expr) == expected
+
+
+STRUCT_SOURCE = """package sample
+
+type Sample struct {
+\tA int // a
+\t// B and C are documented together.
+\tB, C string `json:"b"`
+}
+
+type Empty struct{}
+"""
+
+
+def _apply_edit(source: str, start: int, end: int, new_text: str) -> str:
+    return source[:start] + new_text + source[end:]
+
+
+class TestGoStructEditing:
+    @pytest.mark.parametrize(
+        "type_name, position, expected_body",
+        [
+            ("Sample", -1, ' {\n\tA int // a\n\t// B and C are documented together.\n\tB, C string `json:"b"`\n\tD bool\n}'),
+            ("Sample", 1, ' {\n\tA int // a\n\tD bool\n\t// B and C are documented together.\n\tB, C string `json:"b"`\n}'),
+            ("Empty", -1, "{\n\tD bool\n}"),
+        ],
+    )
+    def test_struct_field_insertion(self, type_name: str, position: int, expected_body: str) -> None:
+        go_file = parse_go_file(STRUCT_SOURCE)
+        declaration = go_file.find_declaration(type_name)
+        assert declaration is not None
+        edit = get_struct_field_insertion(go_file, declaration, "D bool", position)
+        assert f"type {type_name} struct{expected_body}" in _apply_edit(STRUCT_SOURCE, edit.start, edit.end, edit.new_text)
+
+    @pytest.mark.parametrize(
+        "field_name, expected_body",
+        [
+            ("A", ' {\n\t// B and C are documented together.\n\tB, C string `json:"b"`\n}'),
+            ("B", ' {\n\tA int // a\n\t// B and C are documented together.\n\tC string `json:"b"`\n}'),
+        ],
+    )
+    def test_struct_field_removal(self, field_name: str, expected_body: str) -> None:
+        go_file = parse_go_file(STRUCT_SOURCE)
+        declaration = go_file.find_declaration("Sample")
+        assert declaration is not None
+        edit = get_struct_field_removal(go_file, declaration, field_name)
+        assert f"type Sample struct{expected_body}" in _apply_edit(STRUCT_SOURCE, edit.start, edit.end, edit.new_text)
+
+    @pytest.mark.parametrize(
+        "new_type, expected_body",
+        [
+            ("Other", ' {\n\tOther `json:"base"`\n\t*pkg.Ptr\n}'),
+            ("other.Base", ' {\n\tother.Base `json:"base"`\n\t*pkg.Ptr\n}'),
+        ],
+    )
+    def test_embedded_type_replacement(self, new_type: str, expected_body: str) -> None:
+        source = 'package p\n\ntype S struct {\n\tBase `json:"base"`\n\t*pkg.Ptr\n}\n'
+        go_file = parse_go_file(source)
+        declaration = go_file.find_declaration("S")
+        assert declaration is not None
+        edit = get_embedded_type_replacement(go_file, declaration, "Base", new_type)
+        assert f"type S struct{expected_body}" in _apply_edit(source, edit.start, edit.end, edit.new_text)
+        # the pointer of a pointer embedding is kept
+        edit = get_embedded_type_replacement(go_file, declaration, "Ptr", "Other")
+        assert "\t*Other\n" in _apply_edit(source, edit.start, edit.end, edit.new_text)
+        with pytest.raises(ValueError, match="does not embed Missing"):
+            get_embedded_type_replacement(go_file, declaration, "Missing", "Other")
+
+    @pytest.mark.parametrize(
+        "source, expected",
+        [
+            ("type I interface {\n\tA()\n}\n", "type I interface {\n\tA()\n\tClose() error\n}\n"),
+            ("type I interface{ A() }\n", "type I interface{\n\tA()\n\tClose() error\n}\n"),
+        ],
+    )
+    def test_interface_method_insertion(self, source: str, expected: str) -> None:
+        go_file = parse_go_file(source)
+        declaration = go_file.find_declaration("I")
+        assert declaration is not None
+        edit = get_interface_method_insertion(go_file, declaration, "Close() error")
+        assert _apply_edit(source, edit.start, edit.end, edit.new_text) == expected
+
+    def test_removal_of_missing_field_fails(self) -> None:
+        go_file = parse_go_file(STRUCT_SOURCE)
+        declaration = go_file.find_declaration("Sample")
+        assert declaration is not None
+        with pytest.raises(ValueError, match="has no field X"):
+            get_struct_field_removal(go_file, declaration, "X")
+
+
+class TestGoStructTags:
+    @pytest.mark.parametrize(
+        "field_name, style, expected",
+        [
+            ("Name", "snake", "name"),
+            ("UserID", "snake", "user_id"),
+            ("UserID", "camel", "userID"),
+            ("HTTPServer", "snake", "http_server"),
+            ("HTTPServer", "camel", "httpServer"),
+            ("Base64Data", "snake", "base64_data"),
+        ],
+    )
+    def test_to_json_field_name(self, field_name: str, style: str, expected: str) -> None:
+        assert to_json_field_name(field_name, style) == expected
+
+    def test_to_json_field_name_with_unknown_style_fails(self) -> None:
+        with pytest.raises(ValueError, match="kebab"):
+            to_json_field_name("Name", "kebab")
+
+    @pytest.mark.parametrize(
+        "tag, expected",
+        [
+            (None, '`json:"b"`'),
+            ('`db:"b_col"`', '`db:"b_col" json:"b"`'),
+            ('"db:\\"b_col\\""', '"db:\\"b_col\\" json:\\"b\\""'),
+        ],
+    )
+    def test_add_struct_tag_key(self, tag: str | None, expected: str) -> None:
+        assert add_struct_tag_key(tag, "json", "b") == expected
+        assert get_struct_tag_value(expected, "json") == "b"
+
+    def test_get_struct_tag_value(self) -> None:
+        assert get_struct_tag_value('`json:"b,omitempty" db:"b_col"`', "db") == "b_col"
+        assert get_struct_tag_value('`json:"b"`', "db") is None
+        assert get_struct_tag_value(None, "json") is None
+
+    def test_struct_tags_edit(self) -> None:
+        go_file = parse_go_file(STRUCT_SOURCE)
+        declaration = go_file.find_declaration("Sample")
+        assert declaration is not None
+        edit = get_struct_tags_edit(go_file, declaration, {"A": '`json:"a"`', "B": '`json:"bc"`', "C": '`json:"bc"`'})
+        edited = _apply_edit(STRUCT_SOURCE, edit.start, edit.end, edit.new_text)
+        assert '\tA int `json:"a"` // a\n\t// B and C are documented together.\n\tB, C string `json:"bc"`\n}' in edited
+        with pytest.raises(ValueError, match="declared together"):
+            get_struct_tags_edit(go_file, declaration, {"B": '`json:"b"`'})
+
+
+REFERENCE_SOURCE = """package sample
+
+type Sample struct {
+\tBase
+\t*pkg.Other `json:"other"`
+\tbase Base
+}
+
+func NewBase(p Processable) *Base {
+\tb := Base{Name: "x"}
+\tb.Name, n = "y", 1
+\tb.Process()
+\t_ = ByteSlice(data)
+\treturn &b
+}
+"""
+
+
+class TestGoReferenceClassification:
+    @pytest.mark.parametrize(
+        "identifier, occurrence, refers_to_type, expected_role",
+        [
+            ("Base", 0, True, GoReferenceRole.EMBED),
+            ("Other", 0, True, GoReferenceRole.EMBED),
+            ("Base", 1, True, GoReferenceRole.TYPE),
+            ("Processable", 0, True, GoReferenceRole.TYPE),
+            ("Base", 2, True, GoReferenceRole.TYPE),
+            ("Base", 3, True, GoReferenceRole.COMPOSITE_LITERAL),
+            ("Name", 0, False, GoReferenceRole.COMPOSITE_LITERAL_FIELD),
+            ("Name", 1, False, GoReferenceRole.ASSIGNMENT),
+            ("Process", 0, False, GoReferenceRole.CALL),
+            ("ByteSlice", 0, True, GoReferenceRole.CONVERSION),
+            ("b", 3, False, GoReferenceRole.READ),
+        ],
+    )
+    def test_classify_reference(self, identifier: str, occurrence: int, refers_to_type: bool, expected_role: GoReferenceRole) -> None:
+        tokens = tokenize(REFERENCE_SOURCE)
+        index = [i for i, t in enumerate(tokens) if t.text == identifier][occurrence]
+        assert classify_reference(tokens, index, refers_to_type) == expected_role
+
+
+FACTORY_SOURCE = """package sample
+
+func New(kind string, n int) (Processable, error) {
+	create := func() Processable { return nil }
+	switch kind {
+	case "a", "b":
+		return &A{}, nil
+	case "c":
+		c := &C{}
+		if n > 0 {
+			c, err := build(n)
+			return c, err
+		}
+		return c, nil
+	default:
+		return create(), nil
+	}
+}
+"""
+
+
+class TestGoDataflow:
+    def test_find_return_statements(self) -> None:
+        go_file = parse_go_file(FACTORY_SOURCE)
+        statements = find_return_statements(go_file, go_file.declarations[0])
+        assert [s.results for s in statements] == [["&A{}", "nil"], ["c", "err"], ["c", "nil"], ["create()", "nil"]]
+        assert [(s.case_values, s.is_nested_in_clause) for s in statements] == [
+            (['"a"', '"b"'], False),
+            (['"c"'], True),
+            (['"c"'], False),
+            ([], False),
+        ]
+        switch = statements[0].switch
+        assert switch is not None and all(s.switch is switch for s in statements)
+        assert (switch.subject, switch.case_values) == ("kind", ['"a"', '"b"', '"c"'])
+
+    def test_find_assignments(self) -> None:
+        go_file = parse_go_file(FACTORY_SOURCE)
+        function = go_file.declarations[0]
+        assert find_assignments(go_file, function, "c", len(FACTORY_SOURCE)) == [("&C{}", 0), ("build(n)", 0)]
+        assert find_assignments(go_file, function, "err", len(FACTORY_SOURCE)) == [("build(n)", 1)]
+        assert find_assignments(go_file, function, "c", FACTORY_SOURCE.index("if n > 0")) == [("&C{}", 0)]
+
+    def test_find_field_assignments(self) -> None:
+        source = (
+            "package p\n\ntype H struct {\n\tname string\n\tp    P\n}\n\n"
+            'func NewH() H {\n\treturn H{name: "x", p: &A{}}\n}\n\n'
+            'func NewH2() *H {\n\th := &H{"y", NewB()}\n\th.p = &C{Value: 1}\n\tif h.p == nil {\n\t}\n\treturn h\n}\n'
+        )
+        assignments = find_field_assignments(parse_go_file(source), "H", "p", 1)
+        assert [expression for expression, _ in assignments] == ["&A{}", "NewB()", "&C{Value: 1}"]
+        assert all(source.startswith(expression, offset) for expression, offset in assignments)
+
+
+CONCURRENCY_SOURCE = """package sample
+
+func Run(items []Item, t *Tracker) {
+	var handle func(Item)
+	if handle == nil {
+		t.recover()
+	}
+	for _, item := range items {
+		defer item.Close()
+		go func(item Item) {
+			defer func() { recover() }()
+			for {
+				panic(item)
+			}
+		}(item)
+	}
+}
+"""
+
+
+class TestGoControlFlowFeatures:
+    def test_find_control_flow_features(self) -> None:
+        go_file = parse_go_file(CONCURRENCY_SOURCE)
+        features = find_control_flow_features(go_file, go_file.declarations[0])
+        assert [(f.kind, f.text, f.in_loop, f.in_function_literal) for f in features] == [
+            (GoControlFlowFeatureKind.DEFER, "defer item.Close()", True, False),
+            (GoControlFlowFeatureKind.GO, "go func(item Item) {", True, False),
+            (GoControlFlowFeatureKind.DEFER, "defer func() { recover() }()", False, True),
+            (GoControlFlowFeatureKind.RECOVER, "recover()", False, True),
+            (GoControlFlowFeatureKind.PANIC, "panic(item)", True, True),
+        ]
+        assert go_file.get_line_and_column(features[0].start) == (8, 2)
+
+    def test_find_control_flow_features_without_body(self) -> None:
+        go_file = parse_go_file("package sample\n\nfunc external()\n")
+        assert find_control_flow_features(go_file, go_file.declarations[0]) == []
+
+
+ERROR_SOURCE = """package sample
+
+func Load(path string) (*Config, error) {
+	data, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	check := func() error { return err }
+	if err := check(); err != nil {
+		return nil, fmt.Errorf("checking: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrEmpty
+	}
+	return parse(data)
+}
+
+func Name() string {
+	return ""
+}
+"""
+
+
+class TestGoErrorWrapping:
+    def test_find_unwrapped_error_returns(self) -> None:
+        go_file = parse_go_file(ERROR_SOURCE)
+        errors = find_unwrapped_error_returns(go_file, go_file.declarations[0])
+        assert [(e.text, go_file.get_line_and_column(e.start)) for e in errors] == [("err", (5, 14)), ("ErrEmpty", (12, 14))]
+        with pytest.raises(ValueError, match="not of type error"):
+            find_unwrapped_error_returns(go_file, go_file.declarations[1])
+
+    def test_error_wrapping_edit(self) -> None:
+        go_file = parse_go_file(ERROR_SOURCE)
+        function = go_file.declarations[0]
+        errors = find_unwrapped_error_returns(go_file, function)
+        edit = get_error_wrapping_edit(go_file, function, errors, '"loading: %w"', "fmt")
+        assert (edit.start, edit.end) == (function.start, function.end)
+        assert '\t\treturn nil, fmt.Errorf("loading: %w", err)\n' in edit.new_text
+        assert '\t\treturn nil, fmt.Errorf("loading: %w", ErrEmpty)\n' in edit.new_text
+        assert "func() error { return err }" in edit.new_text
+        assert 'fmt.Errorf("checking: %w", err)' in edit.new_text
+
+
+RECEIVER_SOURCE = """package sample
+
+func (s *Server) Addr() string { return s.host }
+
+func (s *Server) Name() string { return other.s }
+
+func (s Server) Shadowed() { s := 1; _ = s }
+
+func (Server) Unnamed() {}
+
+func (_ *Server) Blank() {}
+"""
+
+
+class TestGoReceiverReferences:
+    def test_references_receiver(self) -> None:
+        go_file = parse_go_file(RECEIVER_SOURCE)
+        assert [(d.name, references_receiver(go_file, d)) for d in go_file.declarations] == [
+            ("Addr", True),
+            ("Name", False),
+            ("Shadowed", True),
+            ("Unnamed", False),
+            ("Blank", False),
+        ]
+
+
+VARIABLE_USE_SOURCE = """package sample
+
+func Run(p *Processor, other *Processor) {
+	p.Process()
+	f := p.Close
+	_ = p.name + other.p
+	log(p)
+	_ = p.(io.Reader)
+}
+"""
+
+
+class TestGoVariableUses:
+    def test_find_variable_uses(self) -> None:
+        go_file = parse_go_file(VARIABLE_USE_SOURCE)
+        uses = find_variable_uses(go_file, go_file.declarations[0], "p")
+        assert [(u.token.line, u.selector, u.is_call) for u in uses] == [
+            (3, "Process", True),
+            (4, "Close", False),
+            (5, "name", False),
+            (6, None, False),
+            (7, None, False),
+        ]
+
+
+LOCAL_DECLARATION_SOURCE = """package sample
+
+func (s *S) Apply(a, b int, _ string) (n int, err error) {
+	x, y := a, b
+	var (
+		u, v = 1, 2
+		w    string
+	)
+	const limit = 3
+	type pair struct{ first int }
+	for _, item := range items {
+		log := func(fmt string) {}
+		log(item)
+	}
+	return x + y, nil
+}
+"""
+
+
+class TestGoLocalDeclarations:
+    def test_find_local_declarations(self) -> None:
+        go_file = parse_go_file(LOCAL_DECLARATION_SOURCE)
+        declarations = find_local_declarations(go_file, go_file.declarations[0])
+        assert [d.text for d in declarations] == ["s", "a", "b", "n", "err", "x", "y", "u", "v", "w", "limit", "pair", "item", "log", "fmt"]
+        assert [d.line for d in declarations if d.text in ("s", "w", "fmt")] == [2, 6, 11]
+
+
+REFERENCE_EXPRESSION_SOURCE = """package sample
+
+func Run(c *Child) {
+	c.BaseStruct.Execute()
+	name := load(1)[0].Format(c.Name, 2)
+	Execute()
+	defer (c).Execute()
+	Log(Execute)
+}
+"""
+
+
+class TestGoReferenceExpressions:
+    @pytest.mark.parametrize(
+        "occurrence, expected_expression, expected_receiver, expected_arguments",
+        [
+            ("Execute()\n\tname", "c.BaseStruct.Execute()", "c.BaseStruct", ""),
+            ("Format", "load(1)[0].Format(c.Name, 2)", "load(1)[0]", "c.Name, 2"),
+            ("Name, 2", "c.Name", "c", None),
+            ("Execute()\n\tdefer", "Execute()", None, ""),
+            ("Execute()\n\tLog", "(c).Execute()", "(c)", ""),
+            ("Execute)", "Execute", None, None),
+        ],
+    )
+    def test_find_reference_expression(
+        self, occurrence: str, expected_expression: str, expected_receiver: str | None, expected_arguments: str | None
+    ) -> None:
+        go_file = parse_go_file(REFERENCE_EXPRESSION_SOURCE)
+        expression = find_reference_expression(go_file, REFERENCE_EXPRESSION_SOURCE.index(occurrence))
+        assert expression is not None
+        assert go_file.get_text(expression.start, expression.end) == expected_expression
+        assert (expression.receiver, expression.arguments) == (expected_receiver, expected_arguments)
+
+    def test_reference_transformation_edit(self) -> None:
+        go_file = parse_go_file(REFERENCE_EXPRESSION_SOURCE)
+        expressions = []
+        for occurrence in ("Execute()\n\tname", "Execute()\n\tLog"):
+            expression = find_reference_expression(go_file, REFERENCE_EXPRESSION_SOURCE.index(occurrence))
+            assert expression is not None
+            expressions.append(expression)
+        edit = get_reference_transformation_edit(go_file, expressions, "logErrors({receiver}.{name}({args}))")
+        edited = _apply_edit(REFERENCE_EXPRESSION_SOURCE, edit.start, edit.end, edit.new_text)
+        assert "\tlogErrors(c.BaseStruct.Execute())\n" in edited
+        assert "\tdefer logErrors((c).Execute())\n" in edited
+
+    def test_reference_transformation_requires_placeholder_values(self) -> None:
+        go_file = parse_go_file(REFERENCE_EXPRESSION_SOURCE)
+        expression = find_reference_expression(go_file, REFERENCE_EXPRESSION_SOURCE.index("Execute)"))
+        assert expression is not None
+        assert instantiate_reference_template(go_file, expression, "wrap({name})") == "wrap(Execute)"
+        with pytest.raises(ValueError, match="uses {args}"):
+            instantiate_reference_template(go_file, expression, "{name}({args})")
+
+
+class TestGoImportEditing:
+    @pytest.mark.parametrize(
+        "imports, expected_imports",
+        [
+            ('\n\nimport (\n\t"os" // files\n)', '\n\nimport (\n\t"os" // files\n\t"fmt"\n)'),
+            ('\n\nimport "os"', '\n\nimport (\n\t"os"\n\t"fmt"\n)'),
+            ("", '\n\nimport "fmt"'),
+        ],
+    )
+    def test_import_insertion(self, imports: str, expected_imports: str) -> None:
+        source = f"package sample{imports}\n\nfunc f() {{}}\n"
+        edited = get_import_insertion(parse_go_file(source), "fmt").apply(source)
+        assert edited == f"package sample{expected_imports}\n\nfunc f() {{}}\n"
+
+    def test_import_removal(self) -> None:
+        source = 'package sample\n\nimport (\n\t"os"\n\tstr "strings" // strings\n)\n\nimport "io"\n\nimport ("fmt"; "log")\n'
+        go_file = parse_go_file(source)
+        edited = {i.path: get_import_removal(go_file, i).apply(source) for i in go_file.imports}
+        assert edited["os"] == source.replace('\t"os"\n', "")
+        assert edited["strings"] == source.replace('\tstr "strings" // strings\n', "")
+        assert edited["io"] == source.replace('import "io"\n', "")
+        assert edited["fmt"] == source.replace('"fmt";', "")
+        assert edited["log"] == source.replace('"log"', "")
+
+
+DUPLICATION_SOURCE = """package sample
+
+func (a *A) Score(x int) int {
+	if x > 0 {
+		return weigh(x, a.base+1, []int{1, 2})
+	}
+	return 0
+}
+
+func (b *B) Score(x int) int {
+	// the same structure, but with different arguments
+	if x > 0 {
+		return weigh(x, adjust(b.base), []int{1, 2, 3})
+	}
+	return 1
+}
+
+func (a *A) Reset() {
+	a.base = 0
+	log("reset")
+}
+
+func (b *B) Reset() {
+	b.base = 0
+	log("reset")
+}
+"""
+
+
+class TestGoCommonBody:
+    def test_body_similarity(self) -> None:
+        go_file = parse_go_file(DUPLICATION_SOURCE)
+        score_a, score_b, reset_a, reset_b = go_file.declarations
+        # receiver references are equal regardless of the receivers' names, comments are ignored
+        assert get_body_similarity(go_file, reset_a, go_file, reset_b) == 1.0
+        assert 0.7 < get_body_similarity(go_file, score_a, go_file, score_b) < 1.0
+        assert get_body_similarity(go_file, score_a, go_file, reset_a) < 0.5
+
+    def test_count_body_statements(self) -> None:
+        go_file = parse_go_file(DUPLICATION_SOURCE)
+        assert [count_body_statements(go_file, d) for d in go_file.declarations] == [2, 2, 2, 2]
+        go_file = parse_go_file("package sample\n\nfunc f() { a(); b() }\n\nfunc g() {}\n")
+        assert [count_body_statements(go_file, d) for d in go_file.declarations] == [2, 0]
+
+    def test_find_common_body(self) -> None:
+        go_file = parse_go_file(DUPLICATION_SOURCE)
+        common_body = find_common_body([(go_file, d) for d in go_file.declarations[:2]])
+        assert common_body is not None
+        assert common_body.hole_texts == [["a.base+1", "[]int{1, 2}", "0"], ["adjust(b.base)", "[]int{1, 2, 3}", "1"]]
+        assert common_body.identifiers == {"x", "weigh"}
+        assert common_body.instantiate(["p", "q", "r"]) == "{\n\tif x > 0 {\n\t\treturn weigh(x, p, q)\n\t}\n\treturn r\n}"
+
+    def test_receiver_statements_have_no_common_body(self) -> None:
+        # the receivers are referenced outside of call arguments and returned expressions
+        go_file = parse_go_file(DUPLICATION_SOURCE)
+        assert find_common_body([(go_file, d) for d in go_file.declarations[2:]]) is None
+
+
+BLOCK_SOURCE = """package sample
+
+func Run(name string) string {
+	switch name {
+	case "a": // the first command
+		return "A"
+	// b is deprecated
+	case "b", "c":
+		if name == "b" {
+			return "B"
+		}
+		return "C"
+	}
+	return ""
+}
+"""
+
+
+class TestGoBlocks:
+    def test_find_blocks(self) -> None:
+        go_file = parse_go_file(BLOCK_SOURCE)
+        blocks = find_blocks(go_file, go_file.declarations[0])
+        assert [(b.get_selector(), b.get_label()) for b in blocks] == [
+            ("switch@3", "switch@3"),
+            ("case@4", 'case "a"'),
+            ("case@7", 'case "b", "c"'),
+            ("if@8", "if@8"),
+        ]
+        # comments in the line of the label and before the next clause are not part of a clause's content
+        assert BLOCK_SOURCE[blocks[1].body_start : blocks[1].body_end] == '\n\t\treturn "A"'
+        assert BLOCK_SOURCE[blocks[3].body_start : blocks[3].body_end] == '\n\t\t\treturn "B"\n\t\t'
+
+    @pytest.mark.parametrize(
+        "selector, expected_selector",
+        [('case "c"', "case@7"), ('case  "b" , "c"', "case@7"), ("if@8", "if@8"), ("case @ 4", "case@4")],
+    )
+    def test_select_block(self, selector: str, expected_selector: str) -> None:
+        go_file = parse_go_file(BLOCK_SOURCE)
+        blocks = find_blocks(go_file, go_file.declarations[0])
+        assert select_block(blocks, selector).get_selector() == expected_selector
+
+    @pytest.mark.parametrize("selector", ['case "d"', "default", "if@9", "while@3", "case"])
+    def test_select_missing_block_fails(self, selector: str) -> None:
+        go_file = parse_go_file(BLOCK_SOURCE)
+        with pytest.raises(ValueError):
+            select_block(find_blocks(go_file, go_file.declarations[0]), selector)
+
+    def test_block_replacement(self) -> None:
+        go_file = parse_go_file(BLOCK_SOURCE)
+        blocks = find_blocks(go_file, go_file.declarations[0])
+        edit = get_block_replacement(go_file, blocks[1], 'log("a")\nreturn "AA"\n')
+        edited = BLOCK_SOURCE[: edit.start] + edit.new_text + BLOCK_SOURCE[edit.end :]
+        assert '\tcase "a": // the first command\n\t\tlog("a")\n\t\treturn "AA"\n\t// b is deprecated\n' in edited
+        edit = get_block_replacement(go_file, blocks[3], "\treturn strings.ToUpper(name)")
+        edited = BLOCK_SOURCE[: edit.start] + edit.new_text + BLOCK_SOURCE[edit.end :]
+        assert '\t\tif name == "b" {\n\t\t\treturn strings.ToUpper(name)\n\t\t}\n' in edited
+
+    @pytest.mark.parametrize("content", ["return }", "if x {", 'return "x"\ncase "d":'])
+    def test_malformed_block_replacement_fails(self, content: str) -> None:
+        go_file = parse_go_file(BLOCK_SOURCE)
+        blocks = find_blocks(go_file, go_file.declarations[0])
+        with pytest.raises(ValueError):
+            get_block_replacement(go_file, blocks[1], content)
+
+
+class TestGoWorkFiles:
+    def test_use_directives(self) -> None:
+        source = (
+            "go 1.22\n\n"
+            "toolchain go1.22.3\n\n"
+            "use ./tools // the linters\n"
+            "use (\n"
+            "\t./api\n"
+            "\t// the storage layer\n"
+            '\t"./store"\n'
+            ")\n"
+            "use (../shared)\n\n"
+            "replace example.com/lib => ./lib\n"
+        )
+        assert parse_go_work_use_directives(source) == ["./tools", "./api", "./store", "../shared"]
+
+    def test_no_use_directives(self) -> None:
+        assert parse_go_work_use_directives("go 1.22\n\nreplace example.com/user => ../user\n") == []
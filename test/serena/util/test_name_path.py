@@ -0,0 +1,90 @@
+import pytest
+
+from serena.util.name_path import (
+    NamePath,
+    format_name_path,
+    match_name_path_part,
+    parse_name_path,
+    strip_type_parameters,
+)
+
+
+class TestNamePathParsing:
+    @pytest.mark.parametrize(
+        "name_path, separator, expected",
+        [
+            pytest.param("foo", "/", NamePath(["foo"], False), id="simple"),
+            pytest.param("/foo/bar/", "/", NamePath(["foo", "bar"], True), id="absolute with trailing separator"),
+            pytest.param("Container[T]/Add", "/", NamePath(["Container[T]", "Add"], False), id="generic receiver"),
+            pytest.param("Container[T].Add", ".", NamePath(["Container[T]", "Add"], False), id="generic receiver, dot separator"),
+            pytest.param("Map[K, pkg.V].Get", ".", NamePath(["Map[K, pkg.V]", "Get"], False), id="dot within brackets"),
+            pytest.param("Tree[Node[T]]/Walk", "/", NamePath(["Tree[Node[T]]", "Walk"], False), id="nested brackets"),
+            pytest.param(r"weird\.name.method", ".", NamePath(["weird.name", "method"], False), id="escaped separator"),
+            pytest.param(r"a\\/b", "/", NamePath(["a\\", "b"], False), id="escaped backslash"),
+            pytest.param(r"arr\[/x", "/", NamePath(["arr[", "x"], False), id="escaped bracket"),
+            pytest.param(".Type.Method", ".", NamePath(["Type", "Method"], True), id="absolute, dot separator"),
+            pytest.param("pkg/Type.Method", "/", NamePath(["pkg", "Type.Method"], False), id="dot is not special with slash separator"),
+        ],
+    )
+    def test_parse(self, name_path: str, separator: str, expected: NamePath) -> None:
+        assert parse_name_path(name_path, separator=separator) == expected
+
+    @pytest.mark.parametrize(
+        "name_path, separator",
+        [
+            pytest.param("a//b", "/", id="empty segment"),
+            pytest.param("/", "/", id="no segments"),
+            pytest.param("Container[T/Add", "/", id="unclosed bracket"),
+            pytest.param("Container]/Add", "/", id="unopened bracket"),
+            pytest.param("foo\\", "/", id="dangling escape"),
+            pytest.param("foo", "ab", id="separator too long"),
+            pytest.param("foo", "[", id="reserved separator"),
+            pytest.param("foo", "x", id="alphanumeric separator"),
+        ],
+    )
+    def test_parse_invalid(self, name_path: str, separator: str) -> None:
+        with pytest.raises(ValueError):
+            parse_name_path(name_path, separator=separator)
+
+    @pytest.mark.parametrize(
+        "parts, separator",
+        [
+            (["Container[T]", "Add"], "/"),
+            (["Map[K, pkg.V]", "Get"], "."),
+            (["weird.name", "method"], "."),
+            (["a/b", "c\\d"], "/"),
+            (["arr[", "x]"], "/"),
+        ],
+    )
+    def test_format_round_trip(self, parts: list[str], separator: str) -> None:
+        formatted = format_name_path(parts, separator=separator)
+        assert parse_name_path(formatted, separator=separator).parts == parts
+        assert parse_name_path(format_name_path(parts, separator=separator, is_absolute=True), separator=separator).is_absolute
+
+    def test_format_leaves_plain_names_unchanged(self) -> None:
+        assert format_name_path(["Container[T]", "Add"]) == "Container[T]/Add"
+        assert format_name_path(["Type", "Method"], separator=".") == "Type.Method"
+
+
+class TestNamePathPartMatching:
+    def test_strip_type_parameters(self) -> None:
+        assert strip_type_parameters("Container[T]") == "Container"
+        assert strip_type_parameters("Pair[K, V]") == "Pair"
+        assert strip_type_parameters("Container") == "Container"
+        assert strip_type_parameters("operator[]") == "operator[]"
+
+    @pytest.mark.parametrize(
+        "pattern, name, substring_matching, expected",
+        [
+            ("Container[T]", "Container[T]", False, True),
+            ("Container", "Container[T]", False, True),
+            ("Container[T]", "Container", False, True),
+            ("Pair[K,V]", "Pair[K, V]", False, True),
+            ("Container[U]", "Container[T]", False, False),
+            ("Contain", "Container[T]", True, True),
+            ("Contain", "Container[T]", False, False),
+            ("operator[]", "operator[]", False, True),
+        ],
+    )
+    def test_match_part(self, pattern: str, name: str, substring_matching: bool, expected: bool) -> None:
+        assert match_name_path_part(pattern, name, substring_matching=substring_matching) == expected
@@ -3,6 +3,7 @@ import os
 import pytest
 
 from solidlsp import SolidLanguageServer
+from solidlsp.language_servers.gopls import Gopls
 from solidlsp.ls_config import Language
 from solidlsp.ls_utils import SymbolUtils
 
@@ -31,3 +32,60 @@ class TestGoLanguageServer:
         assert any(
             "main.go" in ref.get("relativePath", "") for ref in refs
         ), "main.go should reference Helper (tried all positions in selectionRange)"
+
+    @pytest.mark.parametrize("language_server", [Language.GO], indirect=True)
+    def test_request_implementation(self, language_server: SolidLanguageServer) -> None:
+        file_path = os.path.join("base.go")
+        symbols, _roots = language_server.request_document_symbols(file_path)
+        worker_symbol = next(sym for sym in symbols if sym.get("name") == "Worker")
+        sel_start = worker_symbol["selectionRange"]["start"]
+        implementations = language_server.request_implementation(file_path, sel_start["line"], sel_start["character"])
+        assert {"child.go", "processor.go"} <= {impl["relativePath"] for impl in implementations}
+
+    @pytest.mark.parametrize("language_server", [Language.GO], indirect=True)
+    def test_request_call_hierarchy(self, language_server: SolidLanguageServer) -> None:
+        # `func ExecuteAll(` is in line 5 of selectors.go
+        items = language_server.request_call_hierarchy_items("selectors.go", 4, 5)
+        assert [item["name"] for item in items] == ["ExecuteAll"]
+        callees = language_server.request_outgoing_calls(items[0])
+        assert sorted(call["to"]["name"] for call in callees) == ["Execute", "Execute"]
+        assert language_server.request_incoming_calls(items[0]) == []
+
+    @pytest.mark.parametrize("language_server", [Language.GO], indirect=True)
+    def test_apply_text_edits_at_same_position(self, language_server: SolidLanguageServer) -> None:
+        start = {"line": 0, "character": 0}
+        edits = [
+            {"range": {"start": start, "end": start}, "newText": "// first\n"},
+            {"range": {"start": start, "end": start}, "newText": "// second\n"},
+        ]
+        with language_server.open_file("main.go") as file_buffer:
+            original_contents = file_buffer.contents
+            language_server.apply_text_edits("main.go", edits)  # type: ignore
+            # the texts of insertions at the same position appear in the order of the edits
+            assert file_buffer.contents == "// first\n// second\n" + original_contents
+            language_server.delete_text_between_positions("main.go", start, {"line": 2, "character": 0})  # type: ignore
+            assert file_buffer.contents == original_contents
+
+    @pytest.mark.parametrize("language_server", [Language.GO], indirect=True)
+    def test_server_version(self, language_server: SolidLanguageServer) -> None:
+        version = language_server.get_server_version()
+        assert version is not None
+        assert language_server.get_minimum_server_version() == Gopls.MINIMUM_GOPLS_VERSION
+
+
+@pytest.mark.go
+class TestGoplsVersionCheck:
+    def test_check_gopls_version(self) -> None:
+        output = "golang.org/x/tools/gopls v0.16.2\n    golang.org/x/tools/gopls@v0.16.2 h1:K1z03MlikHfaMTtG01cUeL5FAOTJnITuNe0TWOcg8tM=\n"
+        assert Gopls._check_gopls_version(output, "v0.16.0") == "v0.16.2"
+        assert Gopls._check_gopls_version(output, "v0.16.2") == "v0.16.2"
+        with pytest.raises(RuntimeError, match=r"Found gopls v0.16.2, but at least gopls v0.17.0 is required"):
+            Gopls._check_gopls_version(output, "v0.17.0")
+        with pytest.raises(RuntimeError):
+            Gopls._check_gopls_version("golang.org/x/tools/gopls v0.9.5", "v0.16.0")
+
+    def test_check_unknown_gopls_version(self) -> None:
+        # the versions of development builds cannot be determined, so the check is skipped
+        assert Gopls._check_gopls_version("golang.org/x/tools/gopls (devel)\n", "v0.16.0") == "golang.org/x/tools/gopls (devel)"
+        with pytest.raises(ValueError):
+            Gopls._check_gopls_version("golang.org/x/tools/gopls v0.16.2", "latest")